@@ -41,7 +41,9 @@ func NewApp(configuration config.Configuration, controllers ...Controller) *echo
 			"sentry-trace",
 			"Authorization",
 		},
-		ExposeHeaders:    nil,
+		ExposeHeaders: []string{
+			"X-Next-Cursor",
+		},
 		MaxAge:           0,
 		AllowCredentials: true,
 	}))
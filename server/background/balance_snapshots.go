@@ -0,0 +1,369 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RecordBalanceSnapshots   = "RecordBalanceSnapshots"
+	BackfillBalanceSnapshots = "BackfillBalanceSnapshots"
+)
+
+var (
+	_ ScheduledJobHandler = &RecordBalanceSnapshotsHandler{}
+	_ Job                 = &RecordBalanceSnapshotsJob{}
+	_ JobHandler          = &BackfillBalanceSnapshotsHandler{}
+	_ Job                 = &BackfillBalanceSnapshotsJob{}
+)
+
+type (
+	RecordBalanceSnapshotsHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		repo         repository.JobRepository
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	RecordBalanceSnapshotsArguments struct {
+		AccountId uint64 `json:"accountId"`
+	}
+
+	RecordBalanceSnapshotsJob struct {
+		args  RecordBalanceSnapshotsArguments
+		log   *logrus.Entry
+		repo  repository.BaseRepository
+		clock clock.Clock
+	}
+
+	BackfillBalanceSnapshotsHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	BackfillBalanceSnapshotsArguments struct {
+		AccountId uint64 `json:"accountId"`
+		// BankAccountId can be left blank to backfill every bank account in the account.
+		BankAccountId uint64    `json:"bankAccountId"`
+		Start         time.Time `json:"start"`
+	}
+
+	BackfillBalanceSnapshotsJob struct {
+		args  BackfillBalanceSnapshotsArguments
+		log   *logrus.Entry
+		repo  repository.BaseRepository
+		clock clock.Clock
+	}
+)
+
+func TriggerBackfillBalanceSnapshots(ctx context.Context, backgroundJobs JobController, arguments BackfillBalanceSnapshotsArguments) error {
+	return backgroundJobs.TriggerJob(ctx, BackfillBalanceSnapshots, arguments)
+}
+
+func NewRecordBalanceSnapshotsHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+) *RecordBalanceSnapshotsHandler {
+	return &RecordBalanceSnapshotsHandler{
+		log:          log,
+		db:           db,
+		repo:         repository.NewJobRepository(db, clock),
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (r RecordBalanceSnapshotsHandler) QueueName() string {
+	return RecordBalanceSnapshots
+}
+
+func (r *RecordBalanceSnapshotsHandler) HandleConsumeJob(ctx context.Context, data []byte) error {
+	var args RecordBalanceSnapshotsArguments
+	if err := errors.Wrap(r.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Record Balance Snapshots job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return r.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		repo := repository.NewRepositoryFromSession(r.clock, 0, args.AccountId, txn)
+		job, err := NewRecordBalanceSnapshotsJob(
+			r.log.WithContext(span.Context()),
+			repo,
+			args,
+			r.clock,
+		)
+		if err != nil {
+			return err
+		}
+		return job.Run(span.Context())
+	})
+}
+
+func (r RecordBalanceSnapshotsHandler) DefaultSchedule() string {
+	// Run once a day at 11:30 PM, so that the snapshot is as close to the end of the day as possible for most accounts.
+	return "0 30 23 * * *"
+}
+
+func (r *RecordBalanceSnapshotsHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := r.log.WithContext(ctx)
+
+	log.Info("retrieving accounts to record balance snapshots for")
+	accounts, err := r.repo.GetAccountsWithActiveBankAccounts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve accounts with active bank accounts")
+	}
+
+	if len(accounts) == 0 {
+		crumbs.Debug(ctx, "No accounts have active bank accounts.", nil)
+		log.Info("no accounts have active bank accounts")
+		return nil
+	}
+
+	log.WithField("count", len(accounts)).Info("found accounts to record balance snapshots for")
+
+	for _, item := range accounts {
+		itemLog := log.WithField("accountId", item.AccountId)
+		itemLog.Trace("enqueuing account to record balance snapshots")
+		err = enqueuer.EnqueueJob(ctx, r.QueueName(), RecordBalanceSnapshotsArguments{
+			AccountId: item.AccountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to record balance snapshots")
+			crumbs.Warn(ctx, "Failed to enqueue job to record balance snapshots", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+	}
+
+	return nil
+}
+
+func NewRecordBalanceSnapshotsJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	args RecordBalanceSnapshotsArguments,
+	clock clock.Clock,
+) (*RecordBalanceSnapshotsJob, error) {
+	return &RecordBalanceSnapshotsJob{
+		args:  args,
+		log:   log,
+		repo:  repo,
+		clock: clock,
+	}, nil
+}
+
+func (r *RecordBalanceSnapshotsJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := r.log.WithContext(span.Context())
+
+	account, err := r.repo.GetAccount(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account to record balance snapshots")
+		return err
+	}
+
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Error("failed to parse account timezone")
+		return err
+	}
+
+	bankAccounts, err := r.repo.GetBankAccounts(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve bank accounts to record balance snapshots")
+		return err
+	}
+
+	today := util.Midnight(r.clock.Now(), timezone)
+	snapshots := make([]models.BalanceSnapshot, 0, len(bankAccounts))
+	for _, bankAccount := range bankAccounts {
+		if bankAccount.Status != models.ActiveBankAccountStatus {
+			continue
+		}
+
+		balances, err := r.repo.GetBalances(span.Context(), bankAccount.BankAccountId)
+		if err != nil {
+			log.WithError(err).WithField("bankAccountId", bankAccount.BankAccountId).Error("failed to retrieve balances")
+			return err
+		}
+
+		snapshots = append(snapshots, models.BalanceSnapshot{
+			BankAccountId: bankAccount.BankAccountId,
+			Date:          today,
+			Current:       balances.Current,
+			Available:     balances.Available,
+			Free:          balances.Free,
+			Expenses:      balances.Expenses,
+			Goals:         balances.Goals,
+		})
+	}
+
+	log.WithField("count", len(snapshots)).Debug("recording balance snapshots")
+
+	return errors.Wrap(r.repo.UpsertBalanceSnapshots(span.Context(), snapshots), "failed to record balance snapshots")
+}
+
+func NewBackfillBalanceSnapshotsHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+) *BackfillBalanceSnapshotsHandler {
+	return &BackfillBalanceSnapshotsHandler{
+		log:          log,
+		db:           db,
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (b BackfillBalanceSnapshotsHandler) QueueName() string {
+	return BackfillBalanceSnapshots
+}
+
+func (b *BackfillBalanceSnapshotsHandler) HandleConsumeJob(ctx context.Context, data []byte) error {
+	var args BackfillBalanceSnapshotsArguments
+	if err := errors.Wrap(b.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Backfill Balance Snapshots job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return b.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		repo := repository.NewRepositoryFromSession(b.clock, 0, args.AccountId, txn)
+		job, err := NewBackfillBalanceSnapshotsJob(
+			b.log.WithContext(span.Context()),
+			repo,
+			args,
+			b.clock,
+		)
+		if err != nil {
+			return err
+		}
+		return job.Run(span.Context())
+	})
+}
+
+func NewBackfillBalanceSnapshotsJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	args BackfillBalanceSnapshotsArguments,
+	clock clock.Clock,
+) (*BackfillBalanceSnapshotsJob, error) {
+	return &BackfillBalanceSnapshotsJob{
+		args:  args,
+		log:   log,
+		repo:  repo,
+		clock: clock,
+	}, nil
+}
+
+// Run reconstructs the balance of each bank account for every day since the start date that does not already have a
+// snapshot. Days that already have a snapshot are left alone, so the backfill can be run more than once.
+func (b *BackfillBalanceSnapshotsJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := b.log.WithContext(span.Context())
+
+	account, err := b.repo.GetAccount(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account to backfill balance snapshots")
+		return err
+	}
+
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Error("failed to parse account timezone")
+		return err
+	}
+
+	var bankAccounts []models.BankAccount
+	if b.args.BankAccountId != 0 {
+		bankAccount, err := b.repo.GetBankAccount(span.Context(), b.args.BankAccountId)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve bank account to backfill balance snapshots")
+			return err
+		}
+		bankAccounts = append(bankAccounts, *bankAccount)
+	} else {
+		bankAccounts, err = b.repo.GetBankAccounts(span.Context())
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve bank accounts to backfill balance snapshots")
+			return err
+		}
+	}
+
+	now := b.clock.Now()
+	for _, bankAccount := range bankAccounts {
+		bankLog := log.WithField("bankAccountId", bankAccount.BankAccountId)
+
+		balances, err := b.repo.GetBalances(span.Context(), bankAccount.BankAccountId)
+		if err != nil {
+			bankLog.WithError(err).Error("failed to retrieve balances")
+			return err
+		}
+
+		transactions, err := b.repo.GetTransactionsByDateRange(
+			span.Context(),
+			bankAccount.BankAccountId,
+			util.Midnight(b.args.Start, timezone),
+			now,
+		)
+		if err != nil {
+			bankLog.WithError(err).Error("failed to retrieve transaction history")
+			return err
+		}
+
+		snapshots := models.EstimateBalanceSnapshots(
+			bankAccount,
+			balances.Expenses,
+			balances.Goals,
+			transactions,
+			b.args.Start,
+			now,
+			timezone,
+		)
+		count, err := b.repo.BackfillBalanceSnapshots(span.Context(), snapshots)
+		if err != nil {
+			bankLog.WithError(err).Error("failed to backfill balance snapshots")
+			return err
+		}
+
+		bankLog.WithFields(logrus.Fields{
+			"estimated": len(snapshots),
+			"stored":    count,
+		}).Info("backfilled balance snapshots")
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package background
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordBalanceSnapshotsJob_Run(t *testing.T) {
+	t.Run("records today's balances", func(t *testing.T) {
+		clock := clock.NewMock()
+		clock.Set(time.Date(2023, 11, 3, 12, 0, 0, 0, time.UTC))
+		log, hook := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t, testutils.IsolatedDatabase)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+
+		handler := NewRecordBalanceSnapshotsHandler(log, db, clock)
+		argsEncoded, err := DefaultJobMarshaller(RecordBalanceSnapshotsArguments{
+			AccountId: user.AccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		// Running the job twice in one day should replace the first snapshot rather than fail.
+		for i := 0; i < 2; i++ {
+			err = handler.HandleConsumeJob(context.Background(), argsEncoded)
+			assert.NoError(t, err, "should run job successfully")
+		}
+		testutils.MustHaveLogMessage(t, hook, "recording balance snapshots")
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		today := util.Midnight(clock.Now(), timezone)
+		snapshots, err := repo.GetBalanceSnapshots(context.Background(), bankAccount.BankAccountId, today, today.AddDate(0, 0, 1))
+		require.NoError(t, err, "must be able to retrieve snapshots")
+		require.Len(t, snapshots, 1, "should have a single snapshot for today")
+		assert.Equal(t, bankAccount.CurrentBalance, snapshots[0].Current)
+		assert.Equal(t, bankAccount.AvailableBalance, snapshots[0].Available)
+		assert.False(t, snapshots[0].IsEstimated)
+	})
+}
+
+func TestBackfillBalanceSnapshotsJob_Run(t *testing.T) {
+	t.Run("does not replace recorded snapshots", func(t *testing.T) {
+		clock := clock.NewMock()
+		clock.Set(time.Date(2023, 11, 3, 12, 0, 0, 0, time.UTC))
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t, testutils.IsolatedDatabase)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fixtures.GivenIHaveNTransactions(t, clock, bankAccount, 5)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		yesterday := util.Midnight(clock.Now(), timezone).AddDate(0, 0, -1)
+		require.NoError(t, repo.UpsertBalanceSnapshots(context.Background(), []models.BalanceSnapshot{
+			{
+				BankAccountId: bankAccount.BankAccountId,
+				Date:          yesterday,
+				Current:       123,
+			},
+		}), "must be able to record a snapshot")
+
+		handler := NewBackfillBalanceSnapshotsHandler(log, db, clock)
+		argsEncoded, err := DefaultJobMarshaller(BackfillBalanceSnapshotsArguments{
+			AccountId: user.AccountId,
+			Start:     clock.Now().AddDate(0, 0, -30),
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), argsEncoded)
+		assert.NoError(t, err, "should run job successfully")
+
+		snapshots, err := repo.GetBalanceSnapshots(context.Background(), bankAccount.BankAccountId, yesterday.AddDate(0, 0, -30), clock.Now())
+		require.NoError(t, err, "must be able to retrieve snapshots")
+		assert.Len(t, snapshots, 30, "should have a snapshot for every day before today")
+		last := snapshots[len(snapshots)-1]
+		assert.Equal(t, yesterday, last.Date.In(timezone))
+		assert.EqualValues(t, 123, last.Current, "recorded snapshot should not be replaced")
+		assert.False(t, last.IsEstimated)
+		assert.True(t, snapshots[0].IsEstimated)
+	})
+}
@@ -0,0 +1,193 @@
+package background
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DetectRecurringTransactions = "DetectRecurringTransactions"
+)
+
+var (
+	_ ScheduledJobHandler = &DetectRecurringTransactionsHandler{}
+	_ Job                 = &DetectRecurringTransactionsJob{}
+)
+
+type (
+	DetectRecurringTransactionsHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		repo         repository.JobRepository
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	DetectRecurringTransactionsArguments struct {
+		AccountId     uint64 `json:"accountId"`
+		BankAccountId uint64 `json:"bankAccountId"`
+	}
+
+	DetectRecurringTransactionsJob struct {
+		args  DetectRecurringTransactionsArguments
+		log   *logrus.Entry
+		repo  repository.BaseRepository
+		clock clock.Clock
+	}
+)
+
+func TriggerDetectRecurringTransactions(ctx context.Context, backgroundJobs JobController, arguments DetectRecurringTransactionsArguments) error {
+	return backgroundJobs.TriggerJob(ctx, DetectRecurringTransactions, arguments)
+}
+
+func NewDetectRecurringTransactionsHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+) *DetectRecurringTransactionsHandler {
+	return &DetectRecurringTransactionsHandler{
+		log:          log,
+		db:           db,
+		repo:         repository.NewJobRepository(db, clock),
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (d DetectRecurringTransactionsHandler) QueueName() string {
+	return DetectRecurringTransactions
+}
+
+func (d *DetectRecurringTransactionsHandler) HandleConsumeJob(ctx context.Context, data []byte) error {
+	var args DetectRecurringTransactionsArguments
+	if err := errors.Wrap(d.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Detect Recurring Transactions job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return d.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		repo := repository.NewRepositoryFromSession(d.clock, 0, args.AccountId, txn)
+		job, err := NewDetectRecurringTransactionsJob(
+			d.log.WithContext(span.Context()),
+			repo,
+			args,
+			d.clock,
+		)
+		if err != nil {
+			return err
+		}
+		return job.Run(span.Context())
+	})
+}
+
+func (d DetectRecurringTransactionsHandler) DefaultSchedule() string {
+	// Run once a day at 3 AM.
+	return "0 0 3 * * *"
+}
+
+func (d *DetectRecurringTransactionsHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := d.log.WithContext(ctx)
+
+	log.Info("retrieving bank accounts with recent transactions")
+	bankAccounts, err := d.repo.GetBankAccountsWithRecentTransactions(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve bank accounts with recent transactions")
+	}
+
+	if len(bankAccounts) == 0 {
+		crumbs.Debug(ctx, "No bank accounts had recent transactions.", nil)
+		log.Info("no bank accounts have recent transactions")
+		return nil
+	}
+
+	log.WithField("count", len(bankAccounts)).Info("found bank accounts with recent transactions")
+
+	for _, item := range bankAccounts {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId":     item.AccountId,
+			"bankAccountId": item.BankAccountId,
+		})
+		itemLog.Trace("enqueuing bank account to detect recurring transactions")
+		err = enqueuer.EnqueueJob(ctx, d.QueueName(), DetectRecurringTransactionsArguments{
+			AccountId:     item.AccountId,
+			BankAccountId: item.BankAccountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to detect recurring transactions")
+			crumbs.Warn(ctx, "Failed to enqueue job to detect recurring transactions", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+	}
+
+	return nil
+}
+
+func NewDetectRecurringTransactionsJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	args DetectRecurringTransactionsArguments,
+	clock clock.Clock,
+) (*DetectRecurringTransactionsJob, error) {
+	return &DetectRecurringTransactionsJob{
+		args:  args,
+		log:   log,
+		repo:  repo,
+		clock: clock,
+	}, nil
+}
+
+func (d *DetectRecurringTransactionsJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := d.log.WithContext(span.Context()).WithField("bankAccountId", d.args.BankAccountId)
+
+	account, err := d.repo.GetAccount(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account to detect recurring transactions")
+		return err
+	}
+
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Error("failed to parse account timezone")
+		return err
+	}
+
+	now := d.clock.Now()
+	transactions, err := d.repo.GetTransactionsByDateRange(
+		span.Context(),
+		d.args.BankAccountId,
+		now.Add(-models.RecurringTransactionLookback),
+		now,
+	)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve transaction history")
+		return err
+	}
+
+	suggestions := models.DetectRecurringTransactions(transactions, timezone, now)
+	log.WithField("count", len(suggestions)).Debug("detected recurring transactions")
+
+	return errors.Wrap(
+		d.repo.ReplaceSpendingSuggestions(span.Context(), d.args.BankAccountId, suggestions),
+		"failed to store spending suggestions",
+	)
+}
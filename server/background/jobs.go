@@ -10,6 +10,7 @@ import (
 	"github.com/monetr/monetr/server/platypus"
 	"github.com/monetr/monetr/server/pubsub"
 	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/storage"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -52,6 +53,7 @@ func NewBackgroundJobs(
 	publisher pubsub.Publisher,
 	plaidPlatypus platypus.Platypus,
 	plaidSecrets secrets.PlaidSecretsProvider,
+	fileStorage storage.Storage,
 ) (*BackgroundJobs, error) {
 	var enqueuer JobEnqueuer
 	var processor JobProcessor
@@ -72,12 +74,16 @@ func NewBackgroundJobs(
 	}
 
 	jobs := []JobHandler{
+		NewBackfillBalanceSnapshotsHandler(log, db, clock),
 		NewDeactivateLinksHandler(log, db, clock, configuration, plaidSecrets, plaidPlatypus),
+		NewDetectRecurringTransactionsHandler(log, db, clock),
 		NewProcessFundingScheduleHandler(log, db, clock),
 		NewProcessSpendingHandler(log, db, clock),
 		NewPullTransactionsHandler(log, db, clock, plaidSecrets, plaidPlatypus, publisher),
+		NewRecordBalanceSnapshotsHandler(log, db, clock),
 		NewRemoveLinkHandler(log, db, clock, publisher),
-		NewRemoveTransactionsHandler(log, db, clock),
+		NewRemoveTransactionsHandler(log, db, clock, fileStorage),
+		NewSeedCategoriesHandler(log, db, clock),
 		NewSyncPlaidHandler(log, db, clock, plaidSecrets, plaidPlatypus, publisher),
 	}
 
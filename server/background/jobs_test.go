@@ -26,7 +26,7 @@ func TestNewBackgroundJobs(t *testing.T) {
 		var jobs *BackgroundJobs
 		var err error
 		assert.Panics(t, func() {
-			jobs, err = NewBackgroundJobs(ctx, log, clock, configuration, nil, nil, nil, nil, nil)
+			jobs, err = NewBackgroundJobs(ctx, log, clock, configuration, nil, nil, nil, nil, nil, nil)
 		}, "must panic if rabbitmq is specified")
 
 		assert.Nil(t, jobs, "object returned should be nil")
@@ -45,7 +45,7 @@ func TestNewBackgroundJobs(t *testing.T) {
 			},
 		}
 
-		jobs, err := NewBackgroundJobs(ctx, log, clock, configuration, nil, nil, nil, nil, nil)
+		jobs, err := NewBackgroundJobs(ctx, log, clock, configuration, nil, nil, nil, nil, nil, nil)
 		assert.Nil(t, jobs, "object returned should be nil")
 		assert.EqualError(t, err, "invalid background job engine specified")
 	})
@@ -164,6 +164,8 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 	}
 
 	expensesToUpdate := make([]models.Spending, 0)
+	// The funding event that was recorded for each funding schedule that was processed.
+	fundingEvents := map[uint64]uint64{}
 
 	initialBalances, err := p.repo.GetBalances(ctx, p.args.BankAccountId)
 	if err != nil {
@@ -205,6 +207,17 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 			}
 		}
 
+		// Keep track of the occurrences before the funding schedule is processed so that the funding event can be
+		// reverted later.
+		event := models.FundingEvent{
+			BankAccountId:                  p.args.BankAccountId,
+			FundingScheduleId:              fundingScheduleId,
+			PreviousLastOccurrence:         fundingSchedule.LastOccurrence,
+			PreviousNextOccurrence:         fundingSchedule.NextOccurrence,
+			PreviousNextOccurrenceOriginal: fundingSchedule.NextOccurrenceOriginal,
+			Contributions:                  make([]models.FundingEventContribution, 0),
+		}
+
 		if !fundingSchedule.CalculateNextOccurrence(span.Context(), p.clock.Now(), timezone) {
 			crumbs.IndicateBug(span.Context(), "bug: funding schedule for processing occurs in the future", map[string]interface{}{
 				"nextOccurrence": fundingSchedule.NextOccurrence,
@@ -225,6 +238,7 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 			return err
 		}
 
+		funded := make([]models.Spending, 0, len(expenses))
 		switch len(expenses) {
 		case 0:
 			crumbs.Debug(span.Context(), "There are no spending objects associated with this funding schedule", map[string]interface{}{
@@ -264,6 +278,10 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 				//  enough money in their account at the time of this running that this will accurately reflect a real
 				//  allocated balance. This can be impacted though by a delay in a deposit showing in Plaid and thus us
 				//  over-allocating temporarily until the deposit shows properly in Plaid.
+				event.Contributions = append(event.Contributions, models.FundingEventContribution{
+					SpendingId: spending.SpendingId,
+					Amount:     spending.NextContributionAmount,
+				})
 				spending.CurrentAmount += spending.NextContributionAmount
 				if err = (&spending).CalculateNextContribution(
 					span.Context(),
@@ -279,9 +297,17 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 					return err
 				}
 
-				expensesToUpdate = append(expensesToUpdate, spending)
+				funded = append(funded, spending)
 			}
 		}
+
+		if err = p.repo.CreateFundingEvent(span.Context(), &event); err != nil {
+			fundingLog.WithError(err).Error("failed to record funding event")
+			return err
+		}
+
+		fundingEvents[fundingScheduleId] = event.FundingEventId
+		expensesToUpdate = append(expensesToUpdate, funded...)
 	}
 
 	if len(expensesToUpdate) == 0 {
@@ -296,9 +322,31 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 		"count": len(expensesToUpdate),
 	})
 
-	if err = p.repo.UpdateSpending(span.Context(), p.args.BankAccountId, expensesToUpdate); err != nil {
-		log.WithError(err).Error("failed to update spending")
-		return err
+	// Spending objects are updated per funding schedule so that their ledger entries reference the funding event that
+	// contributed to them.
+	for _, fundingScheduleId := range p.args.FundingScheduleIds {
+		funded := make([]models.Spending, 0, len(expensesToUpdate))
+		for _, spending := range expensesToUpdate {
+			if spending.FundingScheduleId == fundingScheduleId {
+				funded = append(funded, spending)
+			}
+		}
+
+		fundingEventId, ok := fundingEvents[fundingScheduleId]
+		if !ok || len(funded) == 0 {
+			continue
+		}
+
+		if err = p.repo.UpdateSpending(
+			span.Context(),
+			p.args.BankAccountId,
+			funded,
+			models.SpendingLedgerReasonFunding,
+			&fundingEventId,
+		); err != nil {
+			log.WithError(err).Error("failed to update spending")
+			return err
+		}
 	}
 
 	updatedBalances, err := p.repo.GetBalances(ctx, p.args.BankAccountId)
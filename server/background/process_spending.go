@@ -2,6 +2,7 @@ package background
 
 import (
 	"context"
+	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/getsentry/sentry-go"
@@ -164,6 +165,12 @@ func (p *ProcessSpendingJob) Run(ctx context.Context) error {
 		return err
 	}
 
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Error("failed to parse account's timezone")
+		return err
+	}
+
 	now := p.clock.Now()
 	allSpending, err := p.repo.GetSpending(span.Context(), p.args.BankAccountId)
 	if err != nil {
@@ -171,8 +178,21 @@ func (p *ProcessSpendingJob) Run(ctx context.Context) error {
 		return err
 	}
 
+	spent, err := p.getSpentInEndingPeriods(span.Context(), allSpending, now, timezone)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve how much was spent from recurring expenses")
+		return err
+	}
+
+	spendingById := make(map[uint64]models.Spending, len(allSpending))
+	for _, spending := range allSpending {
+		spendingById[spending.SpendingId] = spending
+	}
+
 	fundingSchedules := map[uint64]*models.FundingSchedule{}
 
+	// The amount of money left over from expenses that is being swept to each goal or overflow spending object.
+	swept := map[uint64]int64{}
 	spendingToUpdate := make([]models.Spending, 0, len(allSpending))
 	for i := range allSpending {
 		// Avoid funky pointer issues with arrays and for loops.
@@ -194,6 +214,10 @@ func (p *ProcessSpendingJob) Run(ctx context.Context) error {
 			fundingSchedules[spending.FundingScheduleId] = fundingSchedule
 		}
 
+		if amount, ok := spent[spending.SpendingId]; ok {
+			p.applyRolloverPolicy(log, &spending, amount, spendingById, swept)
+		}
+
 		if err = spending.CalculateNextContribution(
 			span.Context(),
 			account.Timezone,
@@ -207,6 +231,46 @@ func (p *ProcessSpendingJob) Run(ctx context.Context) error {
 		spendingToUpdate = append(spendingToUpdate, spending)
 	}
 
+	// Add the money that was swept to the spending objects it was swept to. They might already be updated if they were
+	// stale themselves.
+	for targetId, amount := range swept {
+		index := -1
+		for i := range spendingToUpdate {
+			if spendingToUpdate[i].SpendingId == targetId {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			spendingToUpdate = append(spendingToUpdate, spendingById[targetId])
+			index = len(spendingToUpdate) - 1
+		}
+
+		target := &spendingToUpdate[index]
+		target.CurrentAmount += amount
+
+		fundingSchedule, ok := fundingSchedules[target.FundingScheduleId]
+		if !ok {
+			fundingSchedule, err = p.repo.GetFundingSchedule(span.Context(), target.BankAccountId, target.FundingScheduleId)
+			if err != nil {
+				log.WithError(err).Error("failed to retrieve funding schedule for spending object being swept to")
+				return err
+			}
+
+			fundingSchedules[target.FundingScheduleId] = fundingSchedule
+		}
+
+		if err = target.CalculateNextContribution(
+			span.Context(),
+			account.Timezone,
+			fundingSchedule,
+			now,
+		); err != nil {
+			log.WithError(err).Error("failed to calculate next contribution for spending object being swept to")
+			return err
+		}
+	}
+
 	if len(spendingToUpdate) == 0 {
 		log.Info("no stale spending object were updated")
 		return nil
@@ -214,5 +278,106 @@ func (p *ProcessSpendingJob) Run(ctx context.Context) error {
 
 	log.WithField("count", len(spendingToUpdate)).Info("updating stale spending objects")
 
-	return errors.Wrap(p.repo.UpdateSpending(span.Context(), p.args.BankAccountId, spendingToUpdate), "failed to update stale spending")
+	return errors.Wrap(p.repo.UpdateSpending(
+		span.Context(),
+		p.args.BankAccountId,
+		spendingToUpdate,
+		models.SpendingLedgerReasonRecurrence,
+		nil,
+	), "failed to update stale spending")
+}
+
+// getSpentInEndingPeriods returns how much was spent from each stale expense during the period that ends at its next
+// recurrence, keyed by the spending Id. Only expenses whose rollover policy needs to know how much was spent are
+// included.
+func (p *ProcessSpendingJob) getSpentInEndingPeriods(
+	ctx context.Context,
+	allSpending []models.Spending,
+	now time.Time,
+	timezone *time.Location,
+) (map[uint64]int64, error) {
+	periods := map[uint64][2]time.Time{}
+	spendingIds := make([]uint64, 0)
+	var start, end time.Time
+	for _, spending := range allSpending {
+		if !spending.GetIsStale(now) || spending.GetIsPaused() {
+			continue
+		}
+
+		if spending.GetRolloverPolicy() == models.SpendingRolloverPolicyRollover {
+			continue
+		}
+
+		// The period that is ending is the one that contains the instant before the next recurrence.
+		items := spending.GetBudgetPeriods(1, spending.NextRecurrence.Add(-time.Nanosecond), timezone)
+		if len(items) == 0 {
+			continue
+		}
+
+		period := items[0]
+		periods[spending.SpendingId] = period
+		spendingIds = append(spendingIds, spending.SpendingId)
+		if start.IsZero() || period[0].Before(start) {
+			start = period[0]
+		}
+		if period[1].After(end) {
+			end = period[1]
+		}
+	}
+
+	spent := make(map[uint64]int64, len(spendingIds))
+	if len(spendingIds) == 0 {
+		return spent, nil
+	}
+
+	actuals, err := p.repo.GetSpendingActuals(ctx, p.args.BankAccountId, spendingIds, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spendingId := range spendingIds {
+		spent[spendingId] = 0
+	}
+	for _, actual := range actuals {
+		period, ok := periods[actual.SpendingId]
+		if !ok || actual.Date.Before(period[0]) || !actual.Date.Before(period[1]) {
+			continue
+		}
+
+		spent[actual.SpendingId] += actual.Amount
+	}
+
+	return spent, nil
+}
+
+// applyRolloverPolicy applies the rollover policy of the expense for the period that is ending. Money that is swept is
+// added to swept for the spending object it is being swept to. If that spending object no longer exists then the money
+// is kept by the expense instead.
+func (p *ProcessSpendingJob) applyRolloverPolicy(
+	log *logrus.Entry,
+	spending *models.Spending,
+	spent int64,
+	spendingById map[uint64]models.Spending,
+	swept map[uint64]int64,
+) {
+	policy := spending.GetRolloverPolicy()
+	if policy == models.SpendingRolloverPolicySweep {
+		if spending.SweepSpendingId == nil {
+			log.WithField("spendingId", spending.SpendingId).Warn("expense sweeps leftover money but does not have a spending object to sweep to, it will be kept")
+			return
+		}
+
+		if _, ok := spendingById[*spending.SweepSpendingId]; !ok {
+			log.WithFields(logrus.Fields{
+				"spendingId":      spending.SpendingId,
+				"sweepSpendingId": *spending.SweepSpendingId,
+			}).Warn("spending object to sweep leftover money to does not exist, it will be kept")
+			return
+		}
+	}
+
+	surplus := spending.ApplyRolloverPolicy(spent)
+	if surplus > 0 && policy == models.SpendingRolloverPolicySweep {
+		swept[*spending.SweepSpendingId] += surplus
+	}
 }
@@ -388,10 +388,30 @@ func (p *PullTransactionsJob) Run(ctx context.Context) error {
 		for i, j := 0, len(transactionsToInsert)-1; i < j; i, j = i+1, j-1 {
 			transactionsToInsert[i], transactionsToInsert[j] = transactionsToInsert[j], transactionsToInsert[i]
 		}
+		if err = p.repo.AssignCategories(span.Context(), transactionsToInsert); err != nil {
+			log.WithError(err).Error("failed to assign categories to new transactions")
+			return err
+		}
+
+		if err = p.repo.ApplyTransactionRules(span.Context(), transactionsToInsert); err != nil {
+			log.WithError(err).Error("failed to apply transaction rules to new transactions")
+			return err
+		}
+
 		if err = p.repo.InsertTransactions(span.Context(), transactionsToInsert); err != nil {
 			log.WithError(err).Error("failed to insert new transactions")
 			return err
 		}
+
+		if err = p.repo.DeductTransactionSpending(span.Context(), transactionsToInsert); err != nil {
+			log.WithError(err).Error("failed to deduct spending for new transactions")
+			return err
+		}
+
+		if _, err = p.repo.DetectTransfers(span.Context(), transactionsToInsert); err != nil {
+			log.WithError(err).Error("failed to detect transfers in new transactions")
+			return err
+		}
 	}
 
 	if len(transactionsToInsert)+len(transactionsToUpdate) > 0 {
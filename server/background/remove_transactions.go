@@ -8,7 +8,9 @@ import (
 	"github.com/go-pg/pg/v10"
 	"github.com/monetr/monetr/server/crumbs"
 	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
 	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/storage"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -28,6 +30,7 @@ type (
 		db           *pg.DB
 		unmarshaller JobUnmarshaller
 		clock        clock.Clock
+		fileStorage  storage.Storage
 	}
 
 	RemoveTransactionsArguments struct {
@@ -41,6 +44,9 @@ type (
 		log   *logrus.Entry
 		repo  repository.BaseRepository
 		clock clock.Clock
+		// removedFiles are the files that were attached to the removed transactions. They are only removed from storage
+		// once the job's changes have been committed.
+		removedFiles []models.File
 	}
 )
 
@@ -52,12 +58,14 @@ func NewRemoveTransactionsHandler(
 	log *logrus.Entry,
 	db *pg.DB,
 	clock clock.Clock,
+	fileStorage storage.Storage,
 ) *RemoveTransactionsHandler {
 	return &RemoveTransactionsHandler{
 		log:          log,
 		db:           db,
 		unmarshaller: DefaultJobUnmarshaller,
 		clock:        clock,
+		fileStorage:  fileStorage,
 	}
 }
 
@@ -80,17 +88,49 @@ func (r *RemoveTransactionsHandler) HandleConsumeJob(ctx context.Context, data [
 		"plaidTransactionIds": args.PlaidTransactionIds,
 	})
 
-	return r.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+	var job *RemoveTransactionsJob
+	err := r.db.RunInTransaction(ctx, func(txn *pg.Tx) (err error) {
 		span := sentry.StartSpan(ctx, "db.transaction")
 		defer span.Finish()
 
 		repo := repository.NewRepositoryFromSession(r.clock, 0, args.AccountId, txn)
-		job, err := NewRemoveTransactionsJob(r.log.WithContext(ctx), repo, r.clock, args)
+		job, err = NewRemoveTransactionsJob(r.log.WithContext(ctx), repo, r.clock, args)
 		if err != nil {
 			return err
 		}
 		return job.Run(span.Context())
 	})
+	if err != nil {
+		return err
+	}
+
+	r.removeFiles(ctx, job.removedFiles)
+
+	return nil
+}
+
+// removeFiles deletes the files that were attached to removed transactions from storage. The records of these files
+// have already been removed, so a failure here only leaves an orphaned file behind and does not fail the job.
+func (r *RemoveTransactionsHandler) removeFiles(ctx context.Context, files []models.File) {
+	if len(files) == 0 {
+		return
+	}
+
+	log := r.log.WithContext(ctx)
+	if r.fileStorage == nil {
+		log.WithField("count", len(files)).Warn("file storage is not configured, attachments of removed transactions cannot be removed")
+		return
+	}
+
+	for _, file := range files {
+		if err := r.fileStorage.Remove(ctx, file.ObjectUri); err != nil {
+			log.WithError(err).WithField("fileId", file.FileId).Warn("failed to remove attachment of removed transaction from storage")
+			crumbs.Warn(ctx, "Failed to remove attachment of removed transaction from storage", "storage", map[string]interface{}{
+				"fileId": file.FileId,
+				"error":  err,
+			})
+		}
+	}
 }
 
 func NewRemoveTransactionsJob(
@@ -150,7 +190,7 @@ func (r *RemoveTransactionsJob) Run(ctx context.Context) error {
 	}
 
 	for _, existingTransaction := range transactions {
-		if existingTransaction.SpendingId == nil {
+		if existingTransaction.SpendingId == nil && len(existingTransaction.Splits) == 0 {
 			continue
 		}
 
@@ -158,12 +198,13 @@ func (r *RemoveTransactionsJob) Run(ctx context.Context) error {
 		// maintain our balances correctly.
 		updatedTransaction := existingTransaction
 		updatedTransaction.SpendingId = nil
+		updatedTransaction.Splits = []models.TransactionSplit{}
 
 		// This is a simple sanity check, working with objects in slices and for loops can be goofy, or my
 		// understanding of the way objects works with how they are referenced in memory is poor. This is to make
 		// sure im not doing it wrong though. I'm worried that making a "copy" of the object and then modifying the
 		// copy will modify the original as well.
-		if existingTransaction.SpendingId == nil {
+		if existingTransaction.SpendingId == nil && len(existingTransaction.Splits) == 0 {
 			sentry.CaptureMessage("original transaction modified")
 			panic("original transaction modified")
 		}
@@ -187,6 +228,17 @@ func (r *RemoveTransactionsJob) Run(ctx context.Context) error {
 		}
 	}
 
+	transactionIds := make([]uint64, len(transactions))
+	for i, transaction := range transactions {
+		transactionIds[i] = transaction.TransactionId
+	}
+
+	r.removedFiles, err = r.repo.DeleteTransactionAttachments(span.Context(), transactionIds)
+	if err != nil {
+		log.WithError(err).Error("failed to remove attachments of removed transactions")
+		return err
+	}
+
 	log.Debugf("successfully removed %d transaction(s)", len(transactions))
 
 	link.LastSuccessfulUpdate = myownsanity.TimeP(r.clock.Now().UTC())
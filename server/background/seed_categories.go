@@ -0,0 +1,126 @@
+package background
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SeedCategories = "SeedCategories"
+)
+
+var (
+	_ ScheduledJobHandler = &SeedCategoriesHandler{}
+)
+
+type (
+	// SeedCategoriesHandler backfills the default categories for accounts that existed before categories were added.
+	// Accounts are otherwise seeded when their transactions are first synced or their categories are first listed;
+	// this makes sure that every account has been seeded regardless of whether that ever happens. Once an account is
+	// seeded it is never enqueued again.
+	SeedCategoriesHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		repo         repository.JobRepository
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	SeedCategoriesArguments struct {
+		AccountId uint64 `json:"accountId"`
+	}
+)
+
+func NewSeedCategoriesHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+) *SeedCategoriesHandler {
+	return &SeedCategoriesHandler{
+		log:          log,
+		db:           db,
+		repo:         repository.NewJobRepository(db, clock),
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (s SeedCategoriesHandler) QueueName() string {
+	return SeedCategories
+}
+
+func (s *SeedCategoriesHandler) HandleConsumeJob(ctx context.Context, data []byte) error {
+	var args SeedCategoriesArguments
+	if err := errors.Wrap(s.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Seed Categories job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return s.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		repo := repository.NewRepositoryFromSession(s.clock, 0, args.AccountId, txn)
+		if err := repo.EnsureDefaultCategories(span.Context()); err != nil {
+			s.log.WithContext(span.Context()).
+				WithError(err).
+				WithField("accountId", args.AccountId).
+				Error("failed to seed default categories")
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (s SeedCategoriesHandler) DefaultSchedule() string {
+	// Run once an hour, accounts that have already been seeded are not enqueued so this is a no-op once every account
+	// has categories.
+	return "0 15 * * * *"
+}
+
+func (s *SeedCategoriesHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := s.log.WithContext(ctx)
+
+	log.Info("retrieving accounts to seed default categories for")
+	accounts, err := s.repo.GetAccountsWithoutCategories(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve accounts without categories")
+	}
+
+	if len(accounts) == 0 {
+		crumbs.Debug(ctx, "No accounts need default categories seeded.", nil)
+		log.Debug("no accounts need default categories seeded")
+		return nil
+	}
+
+	log.WithField("count", len(accounts)).Info("found accounts to seed default categories for")
+
+	for _, item := range accounts {
+		itemLog := log.WithField("accountId", item.AccountId)
+		itemLog.Trace("enqueuing account to seed default categories")
+		err = enqueuer.EnqueueJob(ctx, s.QueueName(), SeedCategoriesArguments{
+			AccountId: item.AccountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to seed default categories")
+			crumbs.Warn(ctx, "Failed to enqueue job to seed default categories", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+	}
+
+	return nil
+}
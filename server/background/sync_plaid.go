@@ -477,10 +477,30 @@ func (s *SyncPlaidJob) Run(ctx context.Context) error {
 			crumbs.Debug(span.Context(), "Creating transactions.", map[string]interface{}{
 				"count": len(transactionsToInsert),
 			})
+			if err = s.repo.AssignCategories(span.Context(), transactionsToInsert); err != nil {
+				log.WithError(err).Error("failed to assign categories to new transactions")
+				return err
+			}
+
+			if err = s.repo.ApplyTransactionRules(span.Context(), transactionsToInsert); err != nil {
+				log.WithError(err).Error("failed to apply transaction rules to new transactions")
+				return err
+			}
+
 			if err = s.repo.InsertTransactions(span.Context(), transactionsToInsert); err != nil {
 				log.WithError(err).Error("failed to insert new transactions")
 				return err
 			}
+
+			if err = s.repo.DeductTransactionSpending(span.Context(), transactionsToInsert); err != nil {
+				log.WithError(err).Error("failed to deduct spending for new transactions")
+				return err
+			}
+
+			if _, err = s.repo.DetectTransfers(span.Context(), transactionsToInsert); err != nil {
+				log.WithError(err).Error("failed to detect transfers in new transactions")
+				return err
+			}
 		}
 
 		if len(transactionsToInsert)+len(transactionsToUpdate) > 0 {
@@ -583,7 +603,7 @@ func (s *SyncPlaidJob) Run(ctx context.Context) error {
 			}
 
 			for _, existingTransaction := range transactions {
-				if existingTransaction.SpendingId == nil {
+				if existingTransaction.SpendingId == nil && len(existingTransaction.Splits) == 0 {
 					continue
 				}
 
@@ -591,12 +611,13 @@ func (s *SyncPlaidJob) Run(ctx context.Context) error {
 				// maintain our balances correctly.
 				updatedTransaction := existingTransaction
 				updatedTransaction.SpendingId = nil
+				updatedTransaction.Splits = []models.TransactionSplit{}
 
 				// This is a simple sanity check, working with objects in slices and for loops can be goofy, or my
 				// understanding of the way objects works with how they are referenced in memory is poor. This is to make
 				// sure im not doing it wrong though. I'm worried that making a "copy" of the object and then modifying the
 				// copy will modify the original as well.
-				if existingTransaction.SpendingId == nil {
+				if existingTransaction.SpendingId == nil && len(existingTransaction.Splits) == 0 {
 					sentry.CaptureMessage("original transaction modified")
 					panic("original transaction modified")
 				}
@@ -14,6 +14,7 @@ import (
 	"github.com/monetr/monetr/server/models"
 	"github.com/monetr/monetr/server/platypus"
 	"github.com/monetr/monetr/server/pubsub"
+	"github.com/monetr/monetr/server/repository"
 	"github.com/monetr/monetr/server/secrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -178,4 +179,118 @@ func TestSyncPlaidJob_Run(t *testing.T) {
 		count = fixtures.CountAllTransactions(t, user.AccountId)
 		assert.EqualValues(t, 2, count, "should have a total of two transactions including the deleted one")
 	})
+
+	t.Run("deleted split transaction", func(t *testing.T) {
+		clock := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		publisher := pubsub.NewPostgresPubSub(log, db)
+		provider := secrets.NewPostgresPlaidSecretsProvider(log, db, nil)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		plaidLink := fixtures.GivenIHaveAPlaidLink(t, clock, user)
+
+		accessToken := gofakeit.UUID()
+		require.NoError(t, provider.UpdateAccessTokenForPlaidLinkId(context.Background(), plaidLink.AccountId, plaidLink.PlaidLink.ItemId, accessToken))
+
+		plaidBankAccount := fixtures.GivenIHaveABankAccount(t, clock, &plaidLink, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, clock, &plaidBankAccount, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		firstExpense := fixtures.GivenIHaveAnExpense(t, clock, fundingSchedule, 10000)
+		secondExpense := fixtures.GivenIHaveAnExpense(t, clock, fundingSchedule, 10000)
+		transaction := fixtures.GivenIHaveATransaction(t, clock, plaidBankAccount)
+
+		// Split the transaction across both of the expenses.
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		{
+			existing := transaction
+			updated := transaction
+			updated.Splits = []models.TransactionSplit{
+				{SpendingId: firstExpense.SpendingId, Amount: transaction.Amount / 2},
+				{SpendingId: secondExpense.SpendingId, Amount: transaction.Amount - (transaction.Amount / 2)},
+			}
+			_, err := repo.ProcessTransactionSpentFrom(context.Background(), plaidBankAccount.BankAccountId, &updated, &existing)
+			require.NoError(t, err, "must be able to split the transaction")
+			require.NoError(t, repo.UpdateTransaction(context.Background(), plaidBankAccount.BankAccountId, &updated), "must update transaction")
+
+			first, err := repo.GetSpendingById(context.Background(), plaidBankAccount.BankAccountId, firstExpense.SpendingId)
+			require.NoError(t, err, "must retrieve the first expense")
+			require.EqualValues(t, 10000-(transaction.Amount/2), first.CurrentAmount, "split amount must be deducted from the first expense")
+		}
+
+		plaidPlatypus := mockgen.NewMockPlatypus(ctrl)
+		plaidClient := mockgen.NewMockClient(ctrl)
+		plaidPlatypus.EXPECT().
+			NewClient(
+				gomock.Any(),
+				gomock.AssignableToTypeOf(new(models.Link)),
+				gomock.Eq(accessToken),
+				gomock.Eq(plaidLink.PlaidLink.ItemId),
+			).
+			Return(plaidClient, nil).
+			AnyTimes()
+
+		plaidClient.EXPECT().
+			GetAccounts(
+				gomock.Any(),
+			).
+			Return([]platypus.BankAccount{
+				platypus.PlaidBankAccount{
+					AccountId: plaidBankAccount.PlaidAccountId,
+					Balances: platypus.PlaidBankAccountBalances{
+						Available: 100,
+						Current:   100,
+					},
+					Mask:         plaidBankAccount.Mask,
+					Name:         plaidBankAccount.Name,
+					OfficialName: plaidBankAccount.PlaidOfficialName,
+					Type:         "depository",
+					SubType:      "checking",
+				},
+			}, nil).
+			AnyTimes()
+
+		plaidClient.EXPECT().
+			Sync(
+				gomock.Any(),
+				gomock.Nil(),
+			).
+			Return(&platypus.SyncResult{
+				NextCursor: gofakeit.UUID(),
+				HasMore:    false,
+				New:        []platypus.Transaction{},
+				Updated:    []platypus.Transaction{},
+				Deleted: []string{
+					transaction.PlaidTransactionId,
+				},
+			}, nil)
+
+		handler := NewSyncPlaidHandler(log, db, clock, provider, plaidPlatypus, publisher)
+
+		{ // Plaid removes the split transaction.
+			args := SyncPlaidArguments{
+				AccountId: user.AccountId,
+				LinkId:    plaidLink.LinkId,
+				Trigger:   "webhook",
+			}
+			argsEncoded, err := DefaultJobMarshaller(args)
+			assert.NoError(t, err, "must be able to marshal arguments")
+
+			err = handler.HandleConsumeJob(context.Background(), argsEncoded)
+			assert.NoError(t, err, "must process job successfully")
+		}
+
+		count := fixtures.CountNonDeletedTransactions(t, user.AccountId)
+		assert.EqualValues(t, 0, count, "the split transaction should have been removed")
+
+		first, err := repo.GetSpendingById(context.Background(), plaidBankAccount.BankAccountId, firstExpense.SpendingId)
+		require.NoError(t, err, "must retrieve the first expense")
+		assert.EqualValues(t, 10000, first.CurrentAmount, "the first split should be returned to the expense")
+
+		second, err := repo.GetSpendingById(context.Background(), plaidBankAccount.BankAccountId, secondExpense.SpendingId)
+		require.NoError(t, err, "must retrieve the second expense")
+		assert.EqualValues(t, 10000, second.CurrentAmount, "the second split should be returned to the expense")
+	})
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/monetr/monetr/server/platypus"
 	"github.com/monetr/monetr/server/pubsub"
 	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/storage"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
@@ -27,14 +28,15 @@ type SynchronousJobRunner struct {
 	marshal JobMarshaller
 }
 
-// NewSynchronousJobRunner will create a job runner for the current test. It does need to be provided the Platypus and
-// PlaidSecretsProvider interfaces. But it will derive other requirements automatically, such as logs and the current
-// database connection from the test context.
+// NewSynchronousJobRunner will create a job runner for the current test. It does need to be provided the Platypus,
+// PlaidSecretsProvider and file Storage interfaces. But it will derive other requirements automatically, such as logs
+// and the current database connection from the test context.
 func NewSynchronousJobRunner(
 	t *testing.T,
 	clock clock.Clock,
 	plaidPlatypus platypus.Platypus,
 	plaidSecrets secrets.PlaidSecretsProvider,
+	fileStorage storage.Storage,
 ) *SynchronousJobRunner {
 	if t == nil {
 		panic("must be run within a test")
@@ -51,10 +53,12 @@ func NewSynchronousJobRunner(
 	publisher := pubsub.NewPostgresPubSub(log, db)
 
 	jobs := []JobHandler{
+		NewBackfillBalanceSnapshotsHandler(log, db, clock),
+		NewDetectRecurringTransactionsHandler(log, db, clock),
 		NewProcessFundingScheduleHandler(log, db, clock),
 		NewPullTransactionsHandler(log, db, clock, plaidSecrets, plaidPlatypus, publisher),
 		NewRemoveLinkHandler(log, db, clock, publisher),
-		NewRemoveTransactionsHandler(log, db, clock),
+		NewRemoveTransactionsHandler(log, db, clock, fileStorage),
 	}
 	for i := range jobs {
 		runner.jobs[jobs[i].QueueName()] = jobs[i]
@@ -7,10 +7,15 @@ import (
 )
 
 type MonetrClient interface {
-	GetTransactions(ctx context.Context, bankAccountId uint64, count, offset int64) ([]models.Transaction, error)
+	// GetTransactions returns a page of transactions for the specified bank account, newest first. The cursor should
+	// be blank for the first page, the returned cursor can then be used to retrieve the next page. When there are no
+	// more pages the returned cursor will be blank.
+	GetTransactions(ctx context.Context, bankAccountId uint64, count int64, cursor string) ([]models.Transaction, string, error)
 	GetSpending(ctx context.Context, bankAccountId uint64) ([]models.Spending, error)
 	GetFundingSchedules(ctx context.Context, bankAccountId uint64) ([]models.FundingSchedule, error)
 	GetBankAccounts(ctx context.Context) ([]models.BankAccount, error)
 	GetLinks(ctx context.Context) ([]models.Link, error)
+	GetCategories(ctx context.Context) ([]models.Category, error)
+	GetCategoryMappings(ctx context.Context) ([]models.CategoryMapping, error)
 	GetMe(ctx context.Context) (*models.User, error)
 }
@@ -36,20 +36,23 @@ func NewMonetrHTTPClient(log *logrus.Entry, endpoint, token string) MonetrClient
 	}
 }
 
-func (m *monetrHttpClient) GetTransactions(ctx context.Context, bankAccountId uint64, count, offset int64) ([]models.Transaction, error) {
-	result := make([]models.Transaction, 0)
-	if err := m.request(ctx, fmt.Sprintf("/api/bank_accounts/%d/transactions", bankAccountId), url.Values{
-		"count": []string{
+func (m *monetrHttpClient) GetTransactions(ctx context.Context, bankAccountId uint64, count int64, cursor string) ([]models.Transaction, string, error) {
+	query := url.Values{
+		"limit": []string{
 			strconv.FormatInt(count, 10),
 		},
-		"offset": []string{
-			strconv.FormatInt(offset, 10),
-		},
-	}, &result); err != nil {
-		return nil, errors.Wrap(err, "failed to retrieve transactions")
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
 	}
 
-	return result, nil
+	result := make([]models.Transaction, 0)
+	header, err := m.requestWithHeader(ctx, fmt.Sprintf("/api/bank_accounts/%d/transactions", bankAccountId), query, &result)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to retrieve transactions")
+	}
+
+	return result, header.Get("X-Next-Cursor"), nil
 }
 
 func (m *monetrHttpClient) GetSpending(ctx context.Context, bankAccountId uint64) ([]models.Spending, error) {
@@ -88,6 +91,24 @@ func (m *monetrHttpClient) GetLinks(ctx context.Context) ([]models.Link, error)
 	return result, nil
 }
 
+func (m *monetrHttpClient) GetCategories(ctx context.Context) ([]models.Category, error) {
+	result := make([]models.Category, 0)
+	if err := m.request(ctx, "/api/categories", nil, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve categories")
+	}
+
+	return result, nil
+}
+
+func (m *monetrHttpClient) GetCategoryMappings(ctx context.Context) ([]models.CategoryMapping, error) {
+	result := make([]models.CategoryMapping, 0)
+	if err := m.request(ctx, "/api/categories/mappings", nil, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve category mappings")
+	}
+
+	return result, nil
+}
+
 func (m *monetrHttpClient) GetMe(ctx context.Context) (*models.User, error) {
 	var result struct {
 		User *models.User `json:"user"`
@@ -100,9 +121,16 @@ func (m *monetrHttpClient) GetMe(ctx context.Context) (*models.User, error) {
 }
 
 func (m *monetrHttpClient) request(ctx context.Context, path string, query url.Values, result interface{}) error {
+	_, err := m.requestWithHeader(ctx, path, query, result)
+	return err
+}
+
+// requestWithHeader performs the same request as request, but also returns the headers of the response. This is used
+// for endpoints that return pagination details in the response headers.
+func (m *monetrHttpClient) requestWithHeader(ctx context.Context, path string, query url.Values, result interface{}) (http.Header, error) {
 	uri, err := url.Parse(m.endpoint)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse monetr endpoint")
+		return nil, errors.Wrap(err, "failed to parse monetr endpoint")
 	}
 	uri.Path = path
 	if query != nil {
@@ -117,7 +145,7 @@ func (m *monetrHttpClient) request(ctx context.Context, path string, query url.V
 
 	request, err := http.NewRequestWithContext(ctx, "GET", uri.String(), nil)
 	if err != nil {
-		return errors.Wrap(err, "failed to create monetr request")
+		return nil, errors.Wrap(err, "failed to create monetr request")
 	}
 	request.AddCookie(&http.Cookie{
 		Name:     "M-Token",
@@ -132,7 +160,7 @@ func (m *monetrHttpClient) request(ctx context.Context, path string, query url.V
 	start := time.Now()
 	response, err := m.client.Do(request)
 	if err != nil {
-		return errors.Wrap(err, "failed to make request to monetr")
+		return nil, errors.Wrap(err, "failed to make request to monetr")
 	}
 	end := time.Since(start)
 	defer response.Body.Close()
@@ -144,15 +172,15 @@ func (m *monetrHttpClient) request(ctx context.Context, path string, query url.V
 			Error string `json:"error"`
 		}
 		if err = json.NewDecoder(response.Body).Decode(&responseError); err != nil {
-			return errors.Wrap(err, "failed to decode error response body")
+			return nil, errors.Wrap(err, "failed to decode error response body")
 		}
 
-		return errors.Errorf("request failure [%d]: %s", response.StatusCode, responseError.Error)
+		return nil, errors.Errorf("request failure [%d]: %s", response.StatusCode, responseError.Error)
 	}
 
 	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
-		return errors.Wrap(err, "failed to decode response body")
+		return nil, errors.Wrap(err, "failed to decode response body")
 	}
 
-	return nil
+	return response.Header, nil
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/cache"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/logging"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newBackfillBalanceSnapshotsCommand(parent *cobra.Command) {
+	var accountId uint64
+	var bankAccountId uint64
+	var since time.Duration
+	var dryRun bool
+	var local bool
+
+	command := &cobra.Command{
+		Use:   "backfill-balances",
+		Short: "Reconstruct past daily balance snapshots from transaction history.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if accountId == 0 {
+				return errors.New("--account must be specified")
+			}
+
+			clock := clock.New()
+			configuration := config.LoadConfiguration()
+			log := logging.NewLoggerWithConfig(configuration.Logging)
+
+			db, err := getDatabase(log, configuration, nil)
+			if err != nil {
+				log.WithError(err).Fatalf("failed to initialize database")
+				return errors.Wrap(err, "failed to initialize database")
+			}
+
+			jobArgs := background.BackfillBalanceSnapshotsArguments{
+				AccountId:     accountId,
+				BankAccountId: bankAccountId,
+				Start:         clock.Now().Add(-since),
+			}
+
+			if local || dryRun {
+				log.Info("running locally")
+
+				txn, err := db.BeginContext(cmd.Context())
+				if err != nil {
+					log.WithError(err).Fatalf("failed to begin transaction to backfill balances")
+					return err
+				}
+
+				repo := repository.NewRepositoryFromSession(clock, 0, accountId, txn)
+				job, err := background.NewBackfillBalanceSnapshotsJob(log, repo, jobArgs, clock)
+				if err != nil {
+					_ = txn.RollbackContext(cmd.Context())
+					return err
+				}
+
+				if err := job.Run(cmd.Context()); err != nil {
+					log.WithError(err).Fatalf("failed to backfill balances")
+					_ = txn.RollbackContext(cmd.Context())
+					return err
+				}
+
+				if dryRun {
+					log.Info("dry run... rolling changes back")
+					return txn.RollbackContext(cmd.Context())
+				} else {
+					return txn.CommitContext(cmd.Context())
+				}
+			}
+
+			redisController, err := cache.NewRedisCache(log, configuration.Redis)
+			if err != nil {
+				log.WithError(err).Fatalf("failed to create redis cache: %+v", err)
+				return err
+			}
+			defer redisController.Close()
+
+			backgroundJobs, err := background.NewBackgroundJobs(
+				cmd.Context(),
+				log,
+				clock,
+				configuration,
+				db,
+				redisController.Pool(),
+				nil,
+				nil,
+				nil,
+				nil,
+			)
+			if err != nil {
+				return err
+			}
+
+			return background.TriggerBackfillBalanceSnapshots(cmd.Context(), backgroundJobs, jobArgs)
+		},
+	}
+
+	command.PersistentFlags().Uint64VarP(&accountId, "account", "a", 0, "Account ID to backfill balances for. (required)")
+	command.PersistentFlags().Uint64VarP(&bankAccountId, "bank-account", "b", 0, "Bank account ID to backfill balances for. Must belong to the account specified. If omitted then every bank account in the account is backfilled.")
+	command.PersistentFlags().DurationVarP(&since, "since", "s", 365*24*time.Hour, "How far back to backfill balances. Days that already have a balance snapshot are not changed.")
+	command.PersistentFlags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run the backfill, this will log how many snapshots would be stored for each bank account without persisting any changes. [local]")
+	command.PersistentFlags().BoolVar(&local, "local", false, "Run the job locally, this means the job is not dispatched to the external scheduler like RabbitMQ or Redis. This defaults to true when dry running or when the job engine is in-memory.")
+	parent.AddCommand(command)
+}
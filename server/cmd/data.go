@@ -3,11 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/monetr/monetr/server/client"
 	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/formats/journal"
 	"github.com/monetr/monetr/server/logging"
 	"github.com/monetr/monetr/server/models"
 	"github.com/pkg/errors"
@@ -29,12 +32,14 @@ func init() {
 
 	newExportDataCommand(DataCommand)
 	newImportDataCommand(DataCommand)
+	newImportBudgetDataCommand(DataCommand)
 }
 
 func newExportDataCommand(parent *cobra.Command) {
 	var hostname string
 	var token string
 	var output string
+	var format string
 
 	command := &cobra.Command{
 		Use:   "export",
@@ -42,6 +47,19 @@ func newExportDataCommand(parent *cobra.Command) {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log := logging.NewLoggerWithLevel(config.LogLevel)
 
+			// The journal format is parsed up front so that an invalid format fails before anything is retrieved.
+			var journalFormat journal.Format
+			if format != "json" {
+				var err error
+				journalFormat, err = journal.ParseFormat(format)
+				if err != nil {
+					return errors.Wrap(err, "invalid --format, must be one of json, ledger, hledger or beancount")
+				}
+				if !cmd.Flags().Changed("output") {
+					output = "monetr_export." + journalFormat.Extension()
+				}
+			}
+
 			monetrClient := client.NewMonetrHTTPClient(log, hostname, token)
 
 			var err error
@@ -50,6 +68,8 @@ func newExportDataCommand(parent *cobra.Command) {
 			var transactions []models.Transaction
 			var fundingSchedules []models.FundingSchedule
 			var spending []models.Spending
+			var categories []models.Category
+			var categoryMappings []models.CategoryMapping
 
 			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(10*time.Minute))
 			defer cancel()
@@ -74,6 +94,23 @@ func newExportDataCommand(parent *cobra.Command) {
 				log.WithField("count", len(bankAccounts)).Debug("found bank accounts")
 			}
 
+			{ // Categories
+				log.Debug("retrieving categories")
+				categories, err = monetrClient.GetCategories(ctx)
+				if err != nil {
+					log.WithError(err).Fatalf("failed to retrieve categories")
+					return err
+				}
+				log.WithField("count", len(categories)).Debug("found categories")
+
+				categoryMappings, err = monetrClient.GetCategoryMappings(ctx)
+				if err != nil {
+					log.WithError(err).Fatalf("failed to retrieve category mappings")
+					return err
+				}
+				log.WithField("count", len(categoryMappings)).Debug("found category mappings")
+			}
+
 			for _, bankAccount := range bankAccounts {
 				bankLog := log.WithField("bankAccountId", bankAccount.BankAccountId)
 
@@ -102,20 +139,22 @@ func newExportDataCommand(parent *cobra.Command) {
 				{ // Transactions
 					bankLog.Debug("retrieving transactions")
 
-					var offset int64 = 0
+					// Transactions are retrieved using a cursor rather than an offset, that way transactions being
+					// synced while the export is running do not cause any to be skipped or duplicated.
+					cursor := ""
 					total := 0
 					for {
-						items, err := monetrClient.GetTransactions(ctx, bankAccount.BankAccountId, 25, offset)
+						items, next, err := monetrClient.GetTransactions(ctx, bankAccount.BankAccountId, 100, cursor)
 						if err != nil {
 							bankLog.WithError(err).Fatalf("failed to retrieve transactions")
 							return err
 						}
 						transactions = append(transactions, items...)
-						offset += 25
 						total += len(items)
-						if len(items) < 25 {
+						if next == "" {
 							break
 						}
+						cursor = next
 					}
 					bankLog.WithField("count", total).Debug("found transactions")
 				}
@@ -127,13 +166,43 @@ func newExportDataCommand(parent *cobra.Command) {
 				return err
 			}
 
-			dump := map[string]interface{}{
-				"you":              me,
-				"links":            links,
-				"bankAccounts":     bankAccounts,
-				"transactions":     transactions,
-				"spending":         spending,
-				"fundingSchedules": fundingSchedules,
+			if journalFormat != "" {
+				timezone := time.UTC
+				if me.Account != nil {
+					if timezone, err = me.Account.GetTimezone(); err != nil {
+						log.WithError(err).Fatal("failed to parse account timezone")
+						return err
+					}
+				}
+
+				file, err := os.Create(output)
+				if err != nil {
+					return errors.Wrap(err, "failed to create data export")
+				}
+				defer file.Close()
+
+				return journal.Write(file, journalFormat, journal.Data{
+					Links:            links,
+					BankAccounts:     bankAccounts,
+					FundingSchedules: fundingSchedules,
+					Spending:         spending,
+					Transactions:     transactions,
+					Categories:       categories,
+				}, journal.Options{
+					Timezone: timezone,
+					Now:      time.Now(),
+				})
+			}
+
+			dump := dataExport{
+				You:              me,
+				Links:            links,
+				BankAccounts:     bankAccounts,
+				Transactions:     transactions,
+				Spending:         spending,
+				FundingSchedules: fundingSchedules,
+				Categories:       categories,
+				CategoryMappings: categoryMappings,
 			}
 
 			dumpRaw, err := json.Marshal(dump)
@@ -148,7 +217,8 @@ func newExportDataCommand(parent *cobra.Command) {
 
 	command.PersistentFlags().StringVarP(&hostname, "hostname", "H", "https://my.monetr.app", "Specify the hostname (with protocol and port if necessary) of the monetr instance you want to export data from.")
 	command.PersistentFlags().StringVarP(&token, "token", "t", "", "Provide your authentication token in order to retrieve the data via HTTP requests to monetr's API.")
-	command.PersistentFlags().StringVarP(&output, "output", "o", "monetr_export.json", "Specify an output path for the data export, file will be in a JSON format.")
+	command.PersistentFlags().StringVarP(&output, "output", "o", "monetr_export.json", "Specify an output path for the data export. If a journal format is specified then the extension will match that format by default.")
+	command.PersistentFlags().StringVarP(&format, "format", "f", "json", "Specify the format of the data export. Can be json, or a plain-text accounting journal for ledger, hledger or beancount. Journal exports cannot be imported back into monetr.")
 	_ = command.MarkPersistentFlagRequired("token")
 	parent.AddCommand(command)
 }
@@ -161,6 +231,47 @@ func newImportDataCommand(parent *cobra.Command) {
 		Use:   "import",
 		Short: "Import data from your monetr export into your local monetr instance. This requires database access.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			configuration := config.LoadConfiguration()
+			log := logging.NewLoggerWithConfig(configuration.Logging)
+
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				return errors.Wrap(err, "failed to read data export")
+			}
+
+			var export dataExport
+			if err = json.Unmarshal(raw, &export); err != nil {
+				return errors.Wrap(err, "failed to parse data export")
+			}
+
+			db, err := getDatabase(log, configuration, nil)
+			if err != nil {
+				log.WithError(err).Fatalf("failed to initialize database")
+				return errors.Wrap(err, "failed to initialize database")
+			}
+			defer db.Close()
+
+			importer, err := runDataImport(context.Background(), db, clock.New(), export, cmd.OutOrStdout(), dryRun)
+			if err != nil {
+				log.WithError(err).Error("failed to import data, no changes have been made")
+				return err
+			}
+
+			if dryRun {
+				log.WithField("skipped", importer.skipped).Info("dry run complete, changes were rolled back")
+				return nil
+			}
+
+			if importer.alreadyImported {
+				log.Warn("data export was already imported, no changes have been made")
+				return nil
+			}
+
+			log.WithField("skipped", importer.skipped).Info("data import complete")
+			if importer.password != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "\nThe login was created with the temporary password %s, change it after logging in.\n", importer.password)
+			}
+
 			return nil
 		},
 	}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/formats/budgetimport"
+	"github.com/monetr/monetr/server/logging"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newImportBudgetDataCommand(parent *cobra.Command) {
+	var source string
+	var inputs []string
+	var accountId uint64
+	var dryRun bool
+
+	command := &cobra.Command{
+		Use:   "import-budget",
+		Short: "Import a budget exported from YNAB or Actual Budget into an account on your local monetr instance. This requires database access.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			budgetSource, err := budgetimport.ParseSource(source)
+			if err != nil {
+				return err
+			}
+			if accountId == 0 {
+				return errors.New("--account must be specified")
+			}
+			if len(inputs) == 0 {
+				return errors.New("at least one --input must be specified")
+			}
+
+			files := make([]budgetimport.File, 0, len(inputs))
+			for _, input := range inputs {
+				data, err := os.ReadFile(input)
+				if err != nil {
+					return errors.Wrapf(err, "failed to read %s", input)
+				}
+				files = append(files, budgetimport.File{
+					Name: filepath.Base(input),
+					Data: data,
+				})
+			}
+
+			// The export is parsed before connecting to the database so that problems with the files are reported first.
+			budget, err := budgetimport.Parse(budgetSource, files)
+			if err != nil {
+				return errors.Wrap(err, "failed to parse budget export")
+			}
+
+			configuration := config.LoadConfiguration()
+			log := logging.NewLoggerWithConfig(configuration.Logging)
+
+			db, err := getDatabase(log, configuration, nil)
+			if err != nil {
+				log.WithError(err).Fatalf("failed to initialize database")
+				return errors.Wrap(err, "failed to initialize database")
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			txn, err := db.BeginContext(ctx)
+			if err != nil {
+				log.WithError(err).Fatalf("failed to begin transaction for budget import")
+				return err
+			}
+
+			// Everything that is imported is created by the account's first user, the same way it would be if that
+			// user uploaded the export themselves.
+			var user models.User
+			err = txn.ModelContext(ctx, &user).
+				Relation("Account").
+				Where(`"user"."account_id" = ?`, accountId).
+				Order(`user_id ASC`).
+				Limit(1).
+				Select()
+			if err != nil {
+				_ = txn.RollbackContext(ctx)
+				return errors.Wrapf(err, "failed to find a user for account %d", accountId)
+			}
+
+			timezone, err := user.Account.GetTimezone()
+			if err != nil {
+				_ = txn.RollbackContext(ctx)
+				return errors.Wrap(err, "failed to parse account timezone")
+			}
+
+			clock := clock.New()
+			repo := repository.NewRepositoryFromSession(clock, user.UserId, accountId, txn)
+			result, err := budgetimport.Import(ctx, repo, budget, budgetimport.Options{
+				Timezone: timezone,
+				Now:      clock.Now(),
+			})
+			if err != nil {
+				log.WithError(err).Error("failed to import budget, no changes have been made")
+				_ = txn.RollbackContext(ctx)
+				return err
+			}
+
+			output := cmd.OutOrStdout()
+			fmt.Fprintf(output, "+ %-16s %s\n", "link", budgetSource.Name())
+			for _, account := range budget.Accounts {
+				fmt.Fprintf(output, "+ %-16s %s\n", "bank account", account.Name)
+			}
+			fmt.Fprintf(output, "+ %-16s %d\n", "spending", result.Spending)
+			fmt.Fprintf(output, "+ %-16s %d (%d transfers)\n", "transactions", result.Transactions, result.Transfers)
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(output, "! %s\n", warning)
+			}
+
+			if dryRun {
+				log.Info("dry run... rolling changes back")
+				return txn.RollbackContext(ctx)
+			}
+
+			if err = txn.CommitContext(ctx); err != nil {
+				log.WithError(err).Fatalf("failed to commit budget import")
+				return err
+			}
+
+			log.WithField("linkId", result.LinkId).Info("budget import complete")
+
+			return nil
+		},
+	}
+	command.PersistentFlags().StringVarP(&source, "source", "s", "", "The app the budget was exported from, must be ynab or actual. (required)")
+	command.PersistentFlags().StringSliceVarP(&inputs, "input", "i", nil, "The exported files, can be specified more than once. For YNAB this can be the exported zip file, or the register and plan CSV files. For Actual Budget this is the exported transactions CSV file. (required)")
+	command.PersistentFlags().Uint64VarP(&accountId, "account", "a", 0, "Account ID to import the budget into. (required)")
+	command.PersistentFlags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run the budget import, this will print what would be created without changing anything.")
+	_ = command.MarkPersistentFlagRequired("source")
+	_ = command.MarkPersistentFlagRequired("input")
+	_ = command.MarkPersistentFlagRequired("account")
+	parent.AddCommand(command)
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+)
+
+// dataExport is the format of the file written by the data export command and read by the data import command.
+type dataExport struct {
+	You              *models.User             `json:"you"`
+	Links            []models.Link            `json:"links"`
+	BankAccounts     []models.BankAccount     `json:"bankAccounts"`
+	Transactions     []models.Transaction     `json:"transactions"`
+	Spending         []models.Spending        `json:"spending"`
+	FundingSchedules []models.FundingSchedule `json:"fundingSchedules"`
+	Categories       []models.Category        `json:"categories"`
+	CategoryMappings []models.CategoryMapping `json:"categoryMappings"`
+}
+
+// dataImporter recreates the contents of a data export in the database. Every object is created with a new Id, the
+// Ids from the export are only used to connect objects to each other. Everything the importer does is written to the
+// output as a diff, additions are prefixed with a +, objects that already exist with a ~ and objects that are skipped
+// with a !.
+type dataImporter struct {
+	clock  clock.Clock
+	txn    pg.DBI
+	output io.Writer
+	export dataExport
+
+	repo             repository.Repository
+	links            map[uint64]uint64
+	bankAccounts     map[uint64]uint64
+	fundingSchedules map[uint64]uint64
+	spending         map[uint64]uint64
+	transactions     map[uint64]uint64
+	categories       map[uint64]uint64
+	// password is set when a new login is created, it is only known to the importer and must be given to the user.
+	password string
+	skipped  int
+	// alreadyImported is set when the login already has an account with every bank account in the export, in which
+	// case nothing is imported.
+	alreadyImported bool
+}
+
+// runDataImport imports the export inside a single transaction. If the import fails, or if this is a dry run, then the
+// transaction is rolled back and nothing is changed.
+func runDataImport(
+	ctx context.Context,
+	db *pg.DB,
+	clock clock.Clock,
+	export dataExport,
+	output io.Writer,
+	dryRun bool,
+) (*dataImporter, error) {
+	txn, err := db.BeginContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction for data import")
+	}
+
+	importer := &dataImporter{
+		clock:  clock,
+		txn:    txn,
+		output: output,
+		export: export,
+	}
+	if err = importer.Import(ctx); err != nil {
+		_ = txn.RollbackContext(ctx)
+		return importer, err
+	}
+
+	if dryRun {
+		return importer, errors.Wrap(txn.RollbackContext(ctx), "failed to roll back dry run")
+	}
+
+	return importer, errors.Wrap(txn.CommitContext(ctx), "failed to commit data import")
+}
+
+func (d *dataImporter) added(kind string, format string, args ...interface{}) {
+	fmt.Fprintf(d.output, "+ %-16s %s\n", kind, fmt.Sprintf(format, args...))
+}
+
+func (d *dataImporter) existing(kind string, format string, args ...interface{}) {
+	fmt.Fprintf(d.output, "~ %-16s %s\n", kind, fmt.Sprintf(format, args...))
+}
+
+func (d *dataImporter) skip(kind string, format string, args ...interface{}) {
+	d.skipped++
+	fmt.Fprintf(d.output, "! %-16s %s\n", kind, fmt.Sprintf(format, args...))
+}
+
+func (d *dataImporter) Import(ctx context.Context) error {
+	if d.export.You == nil || d.export.You.Login == nil || d.export.You.Account == nil {
+		return errors.New("data export does not include the user's login and account")
+	}
+
+	d.links = map[uint64]uint64{}
+	d.bankAccounts = map[uint64]uint64{}
+	d.fundingSchedules = map[uint64]uint64{}
+	d.spending = map[uint64]uint64{}
+	d.transactions = map[uint64]uint64{}
+	d.categories = map[uint64]uint64{}
+
+	steps := []func(ctx context.Context) error{
+		d.importUser,
+		d.importCategories,
+		d.importLinks,
+		d.importBankAccounts,
+		d.importFundingSchedules,
+		d.importSpending,
+		d.importTransactions,
+	}
+	for _, step := range steps {
+		if err := step(ctx); err != nil {
+			return err
+		}
+
+		if d.alreadyImported {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// importUser creates the login, account and user from the export. If a login with the same email already exists then
+// the imported account is added to that login instead, a login can belong to more than one account.
+func (d *dataImporter) importUser(ctx context.Context) error {
+	unauthenticated := repository.NewUnauthenticatedRepository(d.clock, d.txn)
+	exported := d.export.You
+
+	login, err := unauthenticated.GetLoginForEmail(ctx, exported.Login.Email)
+	switch errors.Cause(err) {
+	case nil:
+		accountId, err := d.findExistingImport(ctx, login.LoginId)
+		if err != nil {
+			return err
+		}
+
+		if accountId != nil {
+			d.alreadyImported = true
+			d.skip("account", "%d (was %d) for %s was already imported, nothing will be imported", *accountId, exported.Account.AccountId, login.Email)
+			return nil
+		}
+
+		d.existing("login", "%s already exists, the imported account will be added to it", login.Email)
+	case pg.ErrNoRows:
+		// The password hash is never included in an export, so the login is created with a random password.
+		d.password, err = generateImportPassword()
+		if err != nil {
+			return err
+		}
+
+		login, err = unauthenticated.CreateLogin(ctx, exported.Login.Email, d.password, exported.Login.FirstName, exported.Login.LastName)
+		if err != nil {
+			return errors.Wrap(err, "failed to create login")
+		}
+
+		if exported.Login.IsEmailVerified {
+			if err = unauthenticated.SetEmailVerified(ctx, login.Email); err != nil {
+				return errors.Wrap(err, "failed to verify login email")
+			}
+		}
+		d.added("login", "%s", login.Email)
+	default:
+		return errors.Wrap(err, "failed to check for an existing login")
+	}
+
+	account := models.Account{
+		Timezone: exported.Account.Timezone,
+	}
+	// The categories are imported from the export, the defaults should not be seeded on top of them. Exports from
+	// before categories existed will not have any, those accounts are seeded like any other.
+	if len(d.export.Categories) > 0 {
+		now := d.clock.Now().UTC()
+		account.CategoriesSeededAt = &now
+	}
+	if err = unauthenticated.CreateAccountV2(ctx, &account); err != nil {
+		return errors.Wrap(err, "failed to create account")
+	}
+	d.added("account", "%d (was %d) in %s", account.AccountId, exported.Account.AccountId, account.Timezone)
+
+	user := models.User{
+		FirstName: exported.FirstName,
+		LastName:  exported.LastName,
+	}
+	if err = unauthenticated.CreateUser(ctx, login.LoginId, account.AccountId, &user); err != nil {
+		return errors.Wrap(err, "failed to create user")
+	}
+	d.added("user", "%d (was %d) %s %s", user.UserId, exported.UserId, user.FirstName, user.LastName)
+
+	d.repo = repository.NewRepositoryFromSession(d.clock, user.UserId, account.AccountId, d.txn)
+
+	return nil
+}
+
+// findExistingImport looks for an account on the existing login that already has every bank account in the export,
+// matched by their name and mask. This is how a data export that has already been imported is detected, since every
+// imported object is given a new Id. If there is no such account then nil is returned.
+func (d *dataImporter) findExistingImport(ctx context.Context, loginId uint64) (*uint64, error) {
+	if len(d.export.BankAccounts) == 0 {
+		return nil, nil
+	}
+
+	existing := make([]models.BankAccount, 0)
+	err := d.txn.ModelContext(ctx, &existing).
+		Join(`INNER JOIN "users" AS "user"`).
+		JoinOn(`"user"."account_id" = "bank_account"."account_id"`).
+		Where(`"user"."login_id" = ?`, loginId).
+		Select(&existing)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve existing bank accounts for login")
+	}
+
+	type bankAccountKey struct {
+		name string
+		mask string
+	}
+	byAccount := map[uint64]map[bankAccountKey]struct{}{}
+	for _, item := range existing {
+		if _, ok := byAccount[item.AccountId]; !ok {
+			byAccount[item.AccountId] = map[bankAccountKey]struct{}{}
+		}
+		byAccount[item.AccountId][bankAccountKey{item.Name, item.Mask}] = struct{}{}
+	}
+
+	for accountId, bankAccounts := range byAccount {
+		found := true
+		for _, item := range d.export.BankAccounts {
+			if _, ok := bankAccounts[bankAccountKey{item.Name, item.Mask}]; !ok {
+				found = false
+				break
+			}
+		}
+
+		if found {
+			return &accountId, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// importLinks creates every link as a manual link, the Plaid credentials for a link are never included in an export.
+func (d *dataImporter) importLinks(ctx context.Context) error {
+	for _, item := range d.export.Links {
+		link := models.Link{
+			LinkType:              models.ManualLinkType,
+			LinkStatus:            models.LinkStatusSetup,
+			InstitutionName:       item.InstitutionName,
+			CustomInstitutionName: item.CustomInstitutionName,
+			Description:           item.Description,
+		}
+		if err := d.repo.CreateLink(ctx, &link); err != nil {
+			return errors.Wrapf(err, "failed to create link %d", item.LinkId)
+		}
+		d.links[item.LinkId] = link.LinkId
+
+		if item.LinkType != models.ManualLinkType {
+			d.added("link", "%d (was %d) %s, converted to a manual link", link.LinkId, item.LinkId, item.InstitutionName)
+		} else {
+			d.added("link", "%d (was %d) %s", link.LinkId, item.LinkId, item.InstitutionName)
+		}
+	}
+
+	return nil
+}
+
+// importCategories creates the categories and then their mappings. A category can only be created once its parent has
+// been, so categories are created in passes until every category whose parent is in the export has been created.
+func (d *dataImporter) importCategories(ctx context.Context) error {
+	pending := d.export.Categories
+	for len(pending) > 0 {
+		remaining := make([]models.Category, 0, len(pending))
+		for _, item := range pending {
+			category := item
+			if item.ParentCategoryId != nil {
+				parentId, ok := d.categories[*item.ParentCategoryId]
+				if !ok {
+					remaining = append(remaining, item)
+					continue
+				}
+				category.ParentCategoryId = &parentId
+			}
+
+			if err := d.repo.CreateCategory(ctx, &category); err != nil {
+				return errors.Wrapf(err, "failed to create category %d", item.CategoryId)
+			}
+			d.categories[item.CategoryId] = category.CategoryId
+			d.added("category", "%d (was %d) %s", category.CategoryId, item.CategoryId, item.Name)
+		}
+
+		if len(remaining) == len(pending) {
+			for _, item := range remaining {
+				d.skip("category", "%d %s, parent category %d was not imported", item.CategoryId, item.Name, *item.ParentCategoryId)
+			}
+			break
+		}
+		pending = remaining
+	}
+
+	for _, item := range d.export.CategoryMappings {
+		// Mappings without a category are kept as they are, they stop the category from being created again.
+		mapping := models.CategoryMapping{
+			PlaidCategory: item.PlaidCategory,
+		}
+		if item.CategoryId != nil {
+			categoryId, ok := d.categories[*item.CategoryId]
+			if !ok {
+				d.skip("category mapping", "%s, category %d was not imported", item.PlaidCategory, *item.CategoryId)
+				continue
+			}
+			mapping.CategoryId = &categoryId
+		}
+
+		if err := d.repo.UpsertCategoryMapping(ctx, &mapping); err != nil {
+			return errors.Wrapf(err, "failed to create category mapping %s", item.PlaidCategory)
+		}
+	}
+	if len(d.export.CategoryMappings) > 0 {
+		d.added("category mappings", "%d mappings", len(d.export.CategoryMappings))
+	}
+
+	return nil
+}
+
+func (d *dataImporter) importBankAccounts(ctx context.Context) error {
+	for _, item := range d.export.BankAccounts {
+		linkId, ok := d.links[item.LinkId]
+		if !ok {
+			d.skip("bank account", "%d %s, link %d is not in the export", item.BankAccountId, item.Name, item.LinkId)
+			continue
+		}
+
+		bankAccount := item
+		bankAccount.LinkId = linkId
+		bankAccount.PlaidAccountId = ""
+		if err := d.repo.CreateBankAccounts(ctx, &bankAccount); err != nil {
+			return errors.Wrapf(err, "failed to create bank account %d", item.BankAccountId)
+		}
+		d.bankAccounts[item.BankAccountId] = bankAccount.BankAccountId
+		d.added("bank account", "%d (was %d) %s", bankAccount.BankAccountId, item.BankAccountId, item.Name)
+	}
+
+	return nil
+}
+
+func (d *dataImporter) importFundingSchedules(ctx context.Context) error {
+	for _, item := range d.export.FundingSchedules {
+		bankAccountId, ok := d.bankAccounts[item.BankAccountId]
+		if !ok {
+			d.skip("funding schedule", "%d %s, bank account %d was not imported", item.FundingScheduleId, item.Name, item.BankAccountId)
+			continue
+		}
+
+		fundingSchedule := item
+		fundingSchedule.FundingScheduleId = 0
+		fundingSchedule.BankAccountId = bankAccountId
+		fundingSchedule.BankAccount = nil
+		if err := d.repo.CreateFundingSchedule(ctx, &fundingSchedule); err != nil {
+			return errors.Wrapf(err, "failed to create funding schedule %d", item.FundingScheduleId)
+		}
+		d.fundingSchedules[item.FundingScheduleId] = fundingSchedule.FundingScheduleId
+		d.added("funding schedule", "%d (was %d) %s", fundingSchedule.FundingScheduleId, item.FundingScheduleId, item.Name)
+	}
+
+	return nil
+}
+
+func (d *dataImporter) importSpending(ctx context.Context) error {
+	for _, item := range d.export.Spending {
+		bankAccountId, ok := d.bankAccounts[item.BankAccountId]
+		if !ok {
+			d.skip("spending", "%d %s, bank account %d was not imported", item.SpendingId, item.Name, item.BankAccountId)
+			continue
+		}
+		fundingScheduleId, ok := d.fundingSchedules[item.FundingScheduleId]
+		if !ok {
+			d.skip("spending", "%d %s, funding schedule %d was not imported", item.SpendingId, item.Name, item.FundingScheduleId)
+			continue
+		}
+
+		spending := item
+		spending.SpendingId = 0
+		spending.BankAccountId = bankAccountId
+		spending.BankAccount = nil
+		spending.FundingScheduleId = fundingScheduleId
+		spending.FundingSchedule = nil
+		// The spending object that is swept to may not have been created yet, it is linked once all of the spending
+		// has been created.
+		spending.SweepSpendingId = nil
+		if err := d.repo.CreateSpending(ctx, &spending); err != nil {
+			return errors.Wrapf(err, "failed to create spending %d", item.SpendingId)
+		}
+		d.spending[item.SpendingId] = spending.SpendingId
+		d.added("spending", "%d (was %d) %s", spending.SpendingId, item.SpendingId, item.Name)
+	}
+
+	for _, item := range d.export.Spending {
+		spendingId, ok := d.spending[item.SpendingId]
+		if !ok || item.SweepSpendingId == nil {
+			continue
+		}
+
+		query := d.txn.ModelContext(ctx, &models.Spending{}).
+			Where(`"spending"."account_id" = ?`, d.repo.AccountId()).
+			Where(`"spending"."spending_id" = ?`, spendingId)
+		if sweepSpendingId, ok := d.spending[*item.SweepSpendingId]; ok {
+			query = query.Set(`"sweep_spending_id" = ?`, sweepSpendingId)
+		} else {
+			d.skip("spending", "%d %s, sweep spending %d was not imported so it will roll over instead", item.SpendingId, item.Name, *item.SweepSpendingId)
+			query = query.Set(`"rollover_policy" = ?`, models.SpendingRolloverPolicyRollover)
+		}
+		if _, err := query.Update(); err != nil {
+			return errors.Wrapf(err, "failed to link sweep spending for spending %d", item.SpendingId)
+		}
+	}
+
+	return nil
+}
+
+// importTransactions creates the transactions along with their splits. Transfers are linked once every transaction has
+// been created, as the other side of a transfer may not have been created yet when its partner is.
+func (d *dataImporter) importTransactions(ctx context.Context) error {
+	counts := map[uint64]int{}
+	// transfers is the created transaction for each exported transaction that was one side of a transfer.
+	type transfer struct {
+		exported models.Transaction
+		created  *models.Transaction
+	}
+	transfers := make([]transfer, 0)
+	for _, item := range d.export.Transactions {
+		bankAccountId, ok := d.bankAccounts[item.BankAccountId]
+		if !ok {
+			d.skip("transaction", "%d %s, bank account %d was not imported", item.TransactionId, item.Name, item.BankAccountId)
+			continue
+		}
+
+		transaction := item
+		transaction.TransactionId = 0
+		transaction.Spending = nil
+		transaction.Splits = nil
+		transaction.TransferTransactionId = nil
+		if item.CategoryId != nil {
+			if categoryId, ok := d.categories[*item.CategoryId]; ok {
+				transaction.CategoryId = &categoryId
+			} else {
+				d.skip("transaction", "%d %s, category %d was not imported so it will not be categorized", item.TransactionId, item.Name, *item.CategoryId)
+				transaction.CategoryId = nil
+			}
+		}
+		if item.SpendingId != nil {
+			if spendingId, ok := d.spending[*item.SpendingId]; ok {
+				transaction.SpendingId = &spendingId
+			} else {
+				d.skip("transaction", "%d %s, spending %d was not imported so it will not be spent from anything", item.TransactionId, item.Name, *item.SpendingId)
+				transaction.SpendingId = nil
+				transaction.SpendingAmount = nil
+			}
+		}
+		if err := d.repo.CreateTransaction(ctx, bankAccountId, &transaction); err != nil {
+			return errors.Wrapf(err, "failed to create transaction %d", item.TransactionId)
+		}
+		d.transactions[item.TransactionId] = transaction.TransactionId
+		counts[item.BankAccountId]++
+
+		if len(item.Splits) > 0 {
+			splits := make([]models.TransactionSplit, 0, len(item.Splits))
+			for _, split := range item.Splits {
+				spendingId, ok := d.spending[split.SpendingId]
+				if !ok {
+					d.skip("split", "%d on transaction %d, spending %d was not imported", split.TransactionSplitId, item.TransactionId, split.SpendingId)
+					continue
+				}
+				splits = append(splits, models.TransactionSplit{
+					AccountId:      d.repo.AccountId(),
+					BankAccountId:  bankAccountId,
+					TransactionId:  transaction.TransactionId,
+					SpendingId:     spendingId,
+					Amount:         split.Amount,
+					SpendingAmount: split.SpendingAmount,
+					CreatedAt:      split.CreatedAt,
+				})
+			}
+			if len(splits) > 0 {
+				if _, err := d.txn.ModelContext(ctx, &splits).Insert(&splits); err != nil {
+					return errors.Wrapf(err, "failed to create splits for transaction %d", item.TransactionId)
+				}
+			}
+		}
+
+		if item.TransferTransactionId != nil {
+			created := transaction
+			transfers = append(transfers, transfer{
+				exported: item,
+				created:  &created,
+			})
+		}
+	}
+
+	updates := make([]*models.Transaction, 0, len(transfers))
+	for _, item := range transfers {
+		partnerId, ok := d.transactions[*item.exported.TransferTransactionId]
+		if !ok {
+			d.skip("transfer", "transaction %d, the other side of the transfer %d was not imported", item.exported.TransactionId, *item.exported.TransferTransactionId)
+			continue
+		}
+		item.created.TransferTransactionId = &partnerId
+		item.created.TransferStatus = item.exported.TransferStatus
+		updates = append(updates, item.created)
+	}
+	if len(updates) > 0 {
+		if err := d.repo.UpdateTransactions(ctx, updates); err != nil {
+			return errors.Wrap(err, "failed to link transfers")
+		}
+	}
+
+	for _, bankAccount := range d.export.BankAccounts {
+		if count := counts[bankAccount.BankAccountId]; count > 0 {
+			d.added("transactions", "%d in %s", count, bankAccount.Name)
+		}
+	}
+	if len(updates) > 0 {
+		d.added("transfers", "%d transactions linked", len(updates))
+	}
+
+	return nil
+}
+
+func generateImportPassword() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", errors.Wrap(err, "failed to generate password")
+	}
+
+	return hex.EncodeToString(data), nil
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// givenIHaveADataExport builds a data export the same way the export command does, by encoding it as JSON. The Ids in
+// the export are intentionally small so they would collide with real objects if they were not remapped.
+func givenIHaveADataExport(t *testing.T, clock clock.Clock) dataExport {
+	now := clock.Now().UTC()
+	rule := testutils.RuleToSet(t, time.UTC, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", now)
+	matched := models.TransferStatusConfirmed
+
+	export := dataExport{
+		You: &models.User{
+			UserId:    1,
+			LoginId:   1,
+			AccountId: 1,
+			FirstName: "Elliot",
+			LastName:  "Alderson",
+			Login: &models.Login{
+				LoginId:         1,
+				Email:           testutils.GetUniqueEmail(t),
+				FirstName:       "Elliot",
+				LastName:        "Alderson",
+				IsEmailVerified: true,
+			},
+			Account: &models.Account{
+				AccountId: 1,
+				Timezone:  "UTC",
+			},
+		},
+		Links: []models.Link{
+			{
+				LinkId:          1,
+				LinkType:        models.PlaidLinkType,
+				InstitutionName: "U.S. Bank",
+			},
+		},
+		BankAccounts: []models.BankAccount{
+			{
+				BankAccountId:  1,
+				LinkId:         1,
+				Mask:           "1234",
+				Name:           "Checking",
+				CurrentBalance: 100000,
+				Type:           models.DepositoryBankAccountType,
+				SubType:        models.CheckingBankAccountSubType,
+				LastUpdated:    now,
+			},
+			{
+				BankAccountId:  2,
+				LinkId:         1,
+				Mask:           "5678",
+				Name:           "Savings",
+				CurrentBalance: 500000,
+				Type:           models.DepositoryBankAccountType,
+				SubType:        models.SavingsBankAccountSubType,
+				LastUpdated:    now,
+			},
+			{ // The link for this bank account is not part of the export.
+				BankAccountId: 3,
+				LinkId:        2,
+				Mask:          "0000",
+				Name:          "Orphaned",
+				LastUpdated:   now,
+			},
+		},
+		FundingSchedules: []models.FundingSchedule{
+			{
+				FundingScheduleId:      1,
+				BankAccountId:          1,
+				Name:                   "Payday",
+				RuleSet:                rule,
+				NextOccurrence:         now.AddDate(0, 0, 7),
+				NextOccurrenceOriginal: now.AddDate(0, 0, 7),
+			},
+		},
+		Spending: []models.Spending{
+			{
+				SpendingId:        1,
+				BankAccountId:     1,
+				FundingScheduleId: 1,
+				SpendingType:      models.SpendingTypeExpense,
+				Name:              "Groceries",
+				TargetAmount:      10000,
+				CurrentAmount:     5000,
+				RuleSet:           rule,
+				NextRecurrence:    now.AddDate(0, 1, 0),
+			},
+			{
+				SpendingId:        2,
+				BankAccountId:     1,
+				FundingScheduleId: 1,
+				SpendingType:      models.SpendingTypeGoal,
+				Name:              "Vacation",
+				TargetAmount:      100000,
+				CurrentAmount:     2000,
+				NextRecurrence:    now.AddDate(1, 0, 0),
+			},
+			{ // The funding schedule for this spending was not exported.
+				SpendingId:        3,
+				BankAccountId:     1,
+				FundingScheduleId: 2,
+				SpendingType:      models.SpendingTypeExpense,
+				Name:              "Streaming",
+				TargetAmount:      1500,
+				RuleSet:           rule,
+				NextRecurrence:    now.AddDate(0, 1, 0),
+			},
+		},
+		Transactions: []models.Transaction{
+			{
+				TransactionId:  1,
+				BankAccountId:  1,
+				Amount:         1500,
+				SpendingId:     myownsanity.Uint64P(1),
+				SpendingAmount: myownsanity.Int64P(1500),
+				Date:           now,
+				Name:           "Grocery Store",
+				OriginalName:   "GROCERY STORE",
+				Currency:       "USD",
+				CreatedAt:      now,
+			},
+			{
+				TransactionId: 2,
+				BankAccountId: 1,
+				Amount:        3000,
+				Date:          now,
+				Name:          "Big Box Store",
+				OriginalName:  "BIG BOX STORE",
+				Currency:      "USD",
+				CreatedAt:     now,
+				Splits: []models.TransactionSplit{
+					{
+						TransactionSplitId: 1,
+						TransactionId:      2,
+						SpendingId:         1,
+						Amount:             1000,
+						SpendingAmount:     1000,
+						CreatedAt:          now,
+					},
+					{
+						TransactionSplitId: 2,
+						TransactionId:      2,
+						SpendingId:         2,
+						Amount:             2000,
+						SpendingAmount:     2000,
+						CreatedAt:          now,
+					},
+				},
+			},
+			{ // Both sides of a transfer from checking to savings.
+				TransactionId:         3,
+				BankAccountId:         1,
+				Amount:                10000,
+				Date:                  now,
+				Name:                  "Transfer to Savings",
+				OriginalName:          "TRANSFER TO SAVINGS",
+				Currency:              "USD",
+				CreatedAt:             now,
+				TransferTransactionId: myownsanity.Uint64P(4),
+				TransferStatus:        &matched,
+			},
+			{
+				TransactionId:         4,
+				BankAccountId:         2,
+				Amount:                -10000,
+				Date:                  now,
+				Name:                  "Transfer from Checking",
+				OriginalName:          "TRANSFER FROM CHECKING",
+				Currency:              "USD",
+				CreatedAt:             now,
+				TransferTransactionId: myownsanity.Uint64P(3),
+				TransferStatus:        &matched,
+			},
+			{ // The spending object for this transaction was not imported.
+				TransactionId:  5,
+				BankAccountId:  1,
+				Amount:         1500,
+				SpendingId:     myownsanity.Uint64P(3),
+				SpendingAmount: myownsanity.Int64P(1500),
+				Date:           now,
+				Name:           "Streaming Service",
+				OriginalName:   "STREAMING SERVICE",
+				Currency:       "USD",
+				CreatedAt:      now,
+			},
+			{ // The bank account for this transaction was not imported.
+				TransactionId: 6,
+				BankAccountId: 3,
+				Amount:        100,
+				Date:          now,
+				Name:          "Coffee",
+				OriginalName:  "COFFEE",
+				Currency:      "USD",
+				CreatedAt:     now,
+			},
+		},
+	}
+
+	raw, err := json.Marshal(export)
+	require.NoError(t, err, "must be able to encode data export")
+	var result dataExport
+	require.NoError(t, json.Unmarshal(raw, &result), "must be able to decode data export")
+
+	return result
+}
+
+func TestDataImport(t *testing.T) {
+	t.Run("import everything", func(t *testing.T) {
+		clock := clock.NewMock()
+		db := testutils.GetPgDatabase(t)
+		export := givenIHaveADataExport(t, clock)
+
+		var output bytes.Buffer
+		importer, err := runDataImport(context.Background(), db, clock, export, &output, false)
+		require.NoError(t, err, "must import data export")
+		assert.NotEmpty(t, importer.password, "a password should be generated for the new login")
+		assert.False(t, importer.alreadyImported)
+		assert.EqualValues(t, 4, importer.skipped, "orphaned objects should be skipped")
+
+		assert.Contains(t, output.String(), "+ login")
+		assert.Contains(t, output.String(), "converted to a manual link")
+		assert.Contains(t, output.String(), "! bank account     3 Orphaned, link 2 is not in the export")
+		assert.Contains(t, output.String(), "! spending         3 Streaming, funding schedule 2 was not imported")
+		assert.Contains(t, output.String(), "! transaction      5 Streaming Service, spending 3 was not imported")
+		assert.Contains(t, output.String(), "! transaction      6 Coffee, bank account 3 was not imported")
+		assert.Contains(t, output.String(), "+ transfers        2 transactions linked")
+
+		require.Len(t, importer.bankAccounts, 2, "two bank accounts should be imported")
+		require.Len(t, importer.spending, 2, "two spending objects should be imported")
+		require.Len(t, importer.transactions, 5, "five transactions should be imported")
+		for exported, created := range importer.transactions {
+			assert.NotEqual(t, exported, created, "transaction Ids must be remapped")
+		}
+
+		repo := repository.NewRepositoryFromSession(clock, 0, importer.repo.AccountId(), db)
+		checkingId := importer.bankAccounts[1]
+		groceriesId := importer.spending[1]
+		vacationId := importer.spending[2]
+
+		{ // Spent from a single spending object.
+			transaction, err := repo.GetTransaction(context.Background(), checkingId, importer.transactions[1])
+			require.NoError(t, err, "must retrieve imported transaction")
+			require.NotNil(t, transaction.SpendingId)
+			assert.Equal(t, groceriesId, *transaction.SpendingId, "spending Id must be remapped")
+		}
+
+		{ // Split transaction.
+			transactions, err := repo.GetTransactionsByIds(context.Background(), checkingId, []uint64{importer.transactions[2]})
+			require.NoError(t, err, "must retrieve imported transaction")
+			transaction := transactions[importer.transactions[2]]
+			require.Len(t, transaction.Splits, 2, "splits must be imported")
+			spendingIds := []uint64{transaction.Splits[0].SpendingId, transaction.Splits[1].SpendingId}
+			assert.ElementsMatch(t, []uint64{groceriesId, vacationId}, spendingIds, "split spending Ids must be remapped")
+		}
+
+		{ // Transfers are linked to each other.
+			outflow, err := repo.GetTransaction(context.Background(), checkingId, importer.transactions[3])
+			require.NoError(t, err, "must retrieve imported transaction")
+			require.NotNil(t, outflow.TransferTransactionId)
+			assert.Equal(t, importer.transactions[4], *outflow.TransferTransactionId)
+
+			inflow, err := repo.GetTransaction(context.Background(), importer.bankAccounts[2], importer.transactions[4])
+			require.NoError(t, err, "must retrieve imported transaction")
+			require.NotNil(t, inflow.TransferTransactionId)
+			assert.Equal(t, importer.transactions[3], *inflow.TransferTransactionId)
+		}
+
+		{ // The transaction for spending that was skipped is not spent from anything.
+			transaction, err := repo.GetTransaction(context.Background(), checkingId, importer.transactions[5])
+			require.NoError(t, err, "must retrieve imported transaction")
+			assert.Nil(t, transaction.SpendingId)
+			assert.Nil(t, transaction.SpendingAmount)
+		}
+	})
+
+	t.Run("categorized transactions", func(t *testing.T) {
+		clock := clock.NewMock()
+		db := testutils.GetPgDatabase(t)
+		export := givenIHaveADataExport(t, clock)
+		now := clock.Now().UTC()
+		// The child is listed before its parent, it can only be created once the parent has been.
+		export.Categories = []models.Category{
+			{
+				CategoryId:       2,
+				ParentCategoryId: myownsanity.Uint64P(1),
+				Name:             "Supermarkets and Groceries",
+				CreatedAt:        now,
+				UpdatedAt:        now,
+			},
+			{
+				CategoryId: 1,
+				Name:       "Shops",
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			},
+		}
+		export.CategoryMappings = []models.CategoryMapping{
+			{
+				PlaidCategory: "Shops > Supermarkets and Groceries",
+				CategoryId:    myownsanity.Uint64P(2),
+				CreatedAt:     now,
+			},
+		}
+		export.Transactions[0].CategoryId = myownsanity.Uint64P(2)
+		// The category for this transaction is not part of the export.
+		export.Transactions[1].CategoryId = myownsanity.Uint64P(3)
+
+		var output bytes.Buffer
+		importer, err := runDataImport(context.Background(), db, clock, export, &output, false)
+		require.NoError(t, err, "must import data export with categories")
+		assert.EqualValues(t, 5, importer.skipped)
+		assert.Contains(t, output.String(), "! transaction      2 Big Box Store, category 3 was not imported")
+		require.Len(t, importer.categories, 2, "both categories should be imported")
+
+		repo := repository.NewRepositoryFromSession(clock, 0, importer.repo.AccountId(), db)
+		checkingId := importer.bankAccounts[1]
+		groceriesId := importer.categories[2]
+
+		{ // The category Id must be remapped to the imported category.
+			transaction, err := repo.GetTransaction(context.Background(), checkingId, importer.transactions[1])
+			require.NoError(t, err, "must retrieve imported transaction")
+			require.NotNil(t, transaction.CategoryId)
+			assert.Equal(t, groceriesId, *transaction.CategoryId)
+		}
+
+		{
+			transaction, err := repo.GetTransaction(context.Background(), checkingId, importer.transactions[2])
+			require.NoError(t, err, "must retrieve imported transaction")
+			assert.Nil(t, transaction.CategoryId, "transaction with a missing category should not be categorized")
+		}
+
+		{ // The hierarchy and mappings are kept.
+			category, err := repo.GetCategory(context.Background(), groceriesId)
+			require.NoError(t, err, "must retrieve imported category")
+			require.NotNil(t, category.ParentCategoryId)
+			assert.Equal(t, importer.categories[1], *category.ParentCategoryId)
+
+			mappings, err := repo.GetCategoryMappings(context.Background())
+			require.NoError(t, err, "must retrieve imported category mappings")
+			require.Len(t, mappings, 1)
+			require.NotNil(t, mappings[0].CategoryId)
+			assert.Equal(t, groceriesId, *mappings[0].CategoryId)
+		}
+
+		{ // The defaults are not seeded on top of the imported categories.
+			require.NoError(t, repo.EnsureDefaultCategories(context.Background()), "must ensure default categories")
+			categories, err := repo.GetCategories(context.Background())
+			require.NoError(t, err, "must retrieve categories")
+			assert.Len(t, categories, 2, "only the imported categories should exist")
+		}
+	})
+
+	t.Run("sweep spending", func(t *testing.T) {
+		clock := clock.NewMock()
+		db := testutils.GetPgDatabase(t)
+		export := givenIHaveADataExport(t, clock)
+		now := clock.Now().UTC()
+		rule := testutils.RuleToSet(t, time.UTC, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", now)
+		// Groceries sweeps to the vacation goal, which is created after it.
+		export.Spending[0].RolloverPolicy = models.SpendingRolloverPolicySweep
+		export.Spending[0].SweepSpendingId = myownsanity.Uint64P(2)
+		export.Spending = append(export.Spending, models.Spending{
+			// This sweeps to spending that was not imported.
+			SpendingId:        4,
+			BankAccountId:     1,
+			FundingScheduleId: 1,
+			SpendingType:      models.SpendingTypeExpense,
+			Name:              "Gas",
+			TargetAmount:      5000,
+			RuleSet:           rule,
+			NextRecurrence:    now.AddDate(0, 1, 0),
+			RolloverPolicy:    models.SpendingRolloverPolicySweep,
+			SweepSpendingId:   myownsanity.Uint64P(3),
+		})
+
+		var output bytes.Buffer
+		importer, err := runDataImport(context.Background(), db, clock, export, &output, false)
+		require.NoError(t, err, "must import data export with sweep spending")
+		assert.EqualValues(t, 5, importer.skipped)
+		assert.Contains(t, output.String(), "! spending         4 Gas, sweep spending 3 was not imported so it will roll over instead")
+
+		repo := repository.NewRepositoryFromSession(clock, 0, importer.repo.AccountId(), db)
+		checkingId := importer.bankAccounts[1]
+
+		{
+			groceries, err := repo.GetSpendingById(context.Background(), checkingId, importer.spending[1])
+			require.NoError(t, err, "must retrieve imported spending")
+			assert.Equal(t, models.SpendingRolloverPolicySweep, groceries.RolloverPolicy)
+			require.NotNil(t, groceries.SweepSpendingId)
+			assert.Equal(t, importer.spending[2], *groceries.SweepSpendingId, "sweep spending Id must be remapped")
+		}
+
+		{
+			gas, err := repo.GetSpendingById(context.Background(), checkingId, importer.spending[4])
+			require.NoError(t, err, "must retrieve imported spending")
+			assert.Equal(t, models.SpendingRolloverPolicyRollover, gas.RolloverPolicy, "missing sweep target should fall back to rolling over")
+			assert.Nil(t, gas.SweepSpendingId)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		clock := clock.NewMock()
+		db := testutils.GetPgDatabase(t)
+		export := givenIHaveADataExport(t, clock)
+
+		var output bytes.Buffer
+		importer, err := runDataImport(context.Background(), db, clock, export, &output, true)
+		require.NoError(t, err, "must dry run data import")
+		assert.EqualValues(t, 4, importer.skipped)
+		assert.Contains(t, output.String(), "+ login")
+		assert.Contains(t, output.String(), "+ transactions     4 in Checking")
+
+		_, err = repository.NewUnauthenticatedRepository(clock, db).GetLoginForEmail(context.Background(), export.You.Login.Email)
+		assert.Equal(t, pg.ErrNoRows, errors.Cause(err), "login should not exist after a dry run")
+	})
+
+	t.Run("import the same export twice", func(t *testing.T) {
+		clock := clock.NewMock()
+		db := testutils.GetPgDatabase(t)
+		export := givenIHaveADataExport(t, clock)
+
+		first, err := runDataImport(context.Background(), db, clock, export, &bytes.Buffer{}, false)
+		require.NoError(t, err, "must import data export")
+
+		var output bytes.Buffer
+		second, err := runDataImport(context.Background(), db, clock, export, &output, false)
+		require.NoError(t, err, "importing an export twice should not fail")
+		assert.True(t, second.alreadyImported, "the second import should detect the first")
+		assert.Empty(t, second.password, "no login should be created")
+		assert.Contains(t, output.String(), "was already imported, nothing will be imported")
+		assert.NotContains(t, output.String(), "+ ", "nothing should be added")
+
+		login, err := repository.NewUnauthenticatedRepository(clock, db).GetLoginForEmail(context.Background(), export.You.Login.Email)
+		require.NoError(t, err, "must retrieve login")
+		count, err := db.Model(&models.User{}).Where(`"user"."login_id" = ?`, login.LoginId).Count()
+		require.NoError(t, err, "must count users for login")
+		assert.EqualValues(t, 1, count, "only the first import should create an account")
+		assert.NotZero(t, first.repo.AccountId())
+	})
+}
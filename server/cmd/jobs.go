@@ -23,6 +23,7 @@ func init() {
 
 	JobCommand.AddCommand(RunJobCommand)
 	newCleanupJobsCommand(RunJobCommand)
+	newBackfillBalanceSnapshotsCommand(RunJobCommand)
 	RunJobCommand.AddCommand(RunPullTransactionsCommand)
 
 	RunPullTransactionsCommand.PersistentFlags().BoolVar(&AllFlag, "all", false, "Pull transactions for all accounts. This job should not be run locally unless you are debugging as it may take a very long time. Will ignore 'account' and 'link' flags.")
@@ -80,6 +81,7 @@ var (
 				nil,
 				nil,
 				nil,
+				nil,
 			)
 			if err != nil {
 				return err
@@ -213,6 +215,7 @@ var (
 				nil,
 				nil,
 				nil,
+				nil,
 			)
 			if err != nil {
 				return err
@@ -195,6 +195,12 @@ func RunServer() error {
 		return err
 	}
 
+	fileStorage, err := getStorage(log, configuration)
+	if err != nil {
+		log.WithError(err).Fatal("failed to initialize file storage")
+		return err
+	}
+
 	plaidSecrets := secrets.NewPostgresPlaidSecretsProvider(log, db, kms)
 	plaidClient := platypus.NewPlaid(log, plaidSecrets, repository.NewPlaidRepository(db), configuration.Plaid)
 
@@ -216,6 +222,7 @@ func RunServer() error {
 		pubsub.NewPostgresPubSub(log, db),
 		plaidClient,
 		plaidSecrets,
+		fileStorage,
 	)
 	if err != nil {
 		log.WithError(err).Fatalf("failed to setup background job proceessor")
@@ -245,6 +252,7 @@ func RunServer() error {
 		basicPaywall,
 		email,
 		clientTokens,
+		fileStorage,
 		clock,
 	)...)
 
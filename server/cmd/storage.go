@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/storage"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
+)
+
+func getStorage(log *logrus.Entry, configuration config.Configuration) (storage.Storage, error) {
+	if !configuration.Storage.Enabled {
+		log.Trace("file storage is not enabled, uploaded files will not be retained")
+		return nil, nil
+	}
+
+	log.Trace("setting up file storage interface")
+
+	switch configuration.Storage.Provider {
+	case config.StorageProviderFilesystem:
+		storageConfig := configuration.Storage.Filesystem
+		basePath, err := util.ExpandHomePath(storageConfig.BasePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to expand filesystem storage base path")
+		}
+
+		log.WithFields(logrus.Fields{
+			"basePath": basePath,
+		}).Trace("using filesystem storage")
+
+		return storage.NewFilesystemStorage(log, basePath)
+	case config.StorageProviderS3:
+		storageConfig := configuration.Storage.S3
+		if storageConfig.Bucket == "" {
+			return nil, errors.New("s3 storage requires a bucket")
+		}
+
+		log.WithFields(logrus.Fields{
+			"bucket": storageConfig.Bucket,
+		}).Trace("using S3 storage")
+
+		options := session.Options{
+			Config: aws.Config{
+				Region:           aws.String(storageConfig.Region),
+				S3ForcePathStyle: aws.Bool(storageConfig.ForcePathStyle),
+			},
+		}
+
+		// If credentials are not provided explicitly then the default AWS credential chain is used.
+		if storageConfig.AccessKey != "" || storageConfig.SecretKey != "" {
+			options.Config.Credentials = credentials.NewStaticCredentialsFromCreds(credentials.Value{
+				AccessKeyID:     storageConfig.AccessKey,
+				SecretAccessKey: storageConfig.SecretKey,
+			})
+		}
+
+		if storageConfig.Endpoint != nil && *storageConfig.Endpoint != "" {
+			options.Config.Endpoint = storageConfig.Endpoint
+		}
+
+		awsSession, err := session.NewSessionWithOptions(options)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create aws session")
+		}
+
+		return storage.NewS3StorageBackend(log, storageConfig.Bucket, s3.New(awsSession)), nil
+	case config.StorageProviderGCS:
+		storageConfig := configuration.Storage.GCS
+		if storageConfig.Bucket == "" {
+			return nil, errors.New("gcs storage requires a bucket")
+		}
+
+		log.WithFields(logrus.Fields{
+			"bucket": storageConfig.Bucket,
+		}).Trace("using Google Cloud Storage")
+
+		options := make([]option.ClientOption, 0, 1)
+		if storageConfig.CredentialsFile != nil && *storageConfig.CredentialsFile != "" {
+			options = append(options, option.WithCredentialsFile(*storageConfig.CredentialsFile))
+		}
+
+		client, err := gcs.NewClient(context.Background(), options...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create google cloud storage client")
+		}
+
+		return storage.NewGCSStorageBackend(log, storageConfig.Bucket, client), nil
+	default:
+		return nil, errors.Errorf("invalid storage provider: %s", configuration.Storage.Provider)
+	}
+}
@@ -51,6 +51,7 @@ type Configuration struct {
 	Redis               Redis          `yaml:"redis"`
 	Sentry              Sentry         `yaml:"sentry"`
 	Server              Server         `yaml:"server"`
+	Storage             Storage        `yaml:"storage"`
 	Stripe              Stripe         `yaml:"stripe"`
 	Security            Security       `yaml:"security"`
 }
@@ -142,6 +143,61 @@ type Security struct {
 	PrivateKey string `yaml:"privateKey"`
 }
 
+type StorageProvider string
+
+const (
+	StorageProviderFilesystem StorageProvider = "filesystem"
+	StorageProviderS3         StorageProvider = "s3"
+	StorageProviderGCS        StorageProvider = "gcs"
+)
+
+// Storage specifies where files uploaded to monetr (like transaction exports from a bank) are kept. If storage is not
+// enabled then uploaded files are still processed, but are not retained.
+type Storage struct {
+	Enabled bool `yaml:"enabled"`
+	// Provider selects which of the storage backends below is used. Only one provider can be used at a time, and files
+	// stored with one provider cannot be read if the provider is changed.
+	Provider StorageProvider `yaml:"provider"`
+	// Filesystem stores files in a directory on the same machine as monetr. This is ideal for self-hosted deployments
+	// with a single instance, but cannot be used if multiple instances of monetr are deployed.
+	Filesystem FilesystemStorage `yaml:"filesystem"`
+	S3         S3Storage         `yaml:"s3"`
+	GCS        GCSStorage        `yaml:"gcs"`
+	// Attachments limits the files that can be attached to transactions, like photos or PDFs of receipts.
+	Attachments AttachmentStorage `yaml:"attachments"`
+}
+
+type AttachmentStorage struct {
+	// MaxSize is the largest file (in bytes) that can be attached to a transaction.
+	MaxSize int64 `yaml:"maxSize"`
+	// AllowedContentTypes are the media types of files that can be attached to a transaction.
+	AllowedContentTypes []string `yaml:"allowedContentTypes"`
+}
+
+type FilesystemStorage struct {
+	// BasePath is the directory that files will be written to. It will be created if it does not exist.
+	BasePath string `yaml:"basePath"`
+}
+
+type S3Storage struct {
+	Bucket    string `yaml:"bucket"`
+	Region    string `yaml:"region"`
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+	// Endpoint can be specified to use an S3 compatible service other than AWS, like MinIO.
+	Endpoint *string `yaml:"endpoint"`
+	// ForcePathStyle will put the bucket name in the path of requests rather than the domain name. This is typically
+	// required by S3 compatible services.
+	ForcePathStyle bool `yaml:"forcePathStyle"`
+}
+
+type GCSStorage struct {
+	Bucket string `yaml:"bucket"`
+	// CredentialsFile is the path to a service account's JSON credentials file. If it is not specified then the
+	// application default credentials are used.
+	CredentialsFile *string `yaml:"credentialsFile"`
+}
+
 type PostgreSQL struct {
 	Address            string `yaml:"address"`
 	Port               int    `yaml:"port"`
@@ -524,6 +580,17 @@ func setupDefaults(v *viper.Viper) {
 	v.SetDefault("Server.ListenAddress", "0.0.0.0")
 	v.SetDefault("Server.StatsPort", 9000)
 	v.SetDefault("Server.UICacheHours", 12)
+	v.SetDefault("Storage.Enabled", false)
+	v.SetDefault("Storage.Provider", StorageProviderFilesystem)
+	v.SetDefault("Storage.Filesystem.BasePath", "/etc/monetr/storage")
+	v.SetDefault("Storage.Attachments.MaxSize", 10*1024*1024)
+	v.SetDefault("Storage.Attachments.AllowedContentTypes", []string{
+		"application/pdf",
+		"image/heic",
+		"image/jpeg",
+		"image/png",
+		"image/webp",
+	})
 	v.SetDefault("Stripe.FreeTrialDays", 30)
 	v.SetDefault("UIDomainName", "0.0.0.0:4000")
 }
@@ -589,6 +656,15 @@ func setupEnv(v *viper.Viper) {
 	_ = v.BindEnv("Sentry.ExternalDSN", "MONETR_SENTRY_EXTERNAL_DSN")
 	_ = v.BindEnv("Sentry.SampleRate", "MONETR_SENTRY_SAMPLE_RATE")
 	_ = v.BindEnv("Sentry.TraceSampleRate", "MONETR_SENTRY_TRACE_SAMPLE_RATE")
+	_ = v.BindEnv("Storage.Enabled", "MONETR_STORAGE_ENABLED")
+	_ = v.BindEnv("Storage.Provider", "MONETR_STORAGE_PROVIDER")
+	_ = v.BindEnv("Storage.Filesystem.BasePath", "MONETR_STORAGE_FILESYSTEM_BASE_PATH")
+	_ = v.BindEnv("Storage.S3.Bucket", "MONETR_STORAGE_S3_BUCKET")
+	_ = v.BindEnv("Storage.S3.Region", "MONETR_STORAGE_S3_REGION")
+	_ = v.BindEnv("Storage.S3.Endpoint", "MONETR_STORAGE_S3_ENDPOINT")
+	_ = v.BindEnv("Storage.S3.ForcePathStyle", "MONETR_STORAGE_S3_FORCE_PATH_STYLE")
+	_ = v.BindEnv("Storage.GCS.Bucket", "MONETR_STORAGE_GCS_BUCKET")
+	_ = v.BindEnv("Storage.Attachments.MaxSize", "MONETR_STORAGE_ATTACHMENTS_MAX_SIZE")
 	_ = v.BindEnv("Stripe.Enabled", "MONETR_STRIPE_ENABLED")
 	_ = v.BindEnv("Stripe.APIKey", "MONETR_STRIPE_API_KEY")
 	_ = v.BindEnv("Stripe.PublicKey", "MONETR_STRIPE_PUBLIC_KEY")
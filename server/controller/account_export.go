@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/formats/journal"
+	"github.com/monetr/monetr/server/repository"
+)
+
+// journalExportPageSize is how many transactions are retrieved at a time when building a journal export.
+const journalExportPageSize = 500
+
+// Export Journal
+// @Summary Export Journal
+// @ID export-journal
+// @tags Account
+// @description Download everything in the account as a plain-text accounting journal for Ledger, hledger or Beancount.
+// @description Each bank account becomes an account in the journal, and each spending object becomes a sub-account of
+// @description the bank account it belongs to. Funding is recorded as postings from the bank account's Available
+// @description sub-account into each spending sub-account, so that the balances in the journal match monetr's.
+// @Security ApiKeyAuth
+// @Produce plain
+// @Param format query string true "The format of the journal, one of ledger, hledger or beancount."
+// @Router /account/export [get]
+// @Success 200
+// @Failure 400 {object} ApiError The format provided is not valid.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getAccountExport(ctx echo.Context) error {
+	format, err := journal.ParseFormat(ctx.QueryParam("format"))
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	var data journal.Data
+
+	data.Links, err = repo.GetLinks(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve links")
+	}
+
+	data.BankAccounts, err = repo.GetBankAccounts(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve bank accounts")
+	}
+
+	data.Categories, err = repo.GetCategories(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve categories")
+	}
+
+	for _, bankAccount := range data.BankAccounts {
+		fundingSchedules, err := repo.GetFundingSchedules(c.getContext(ctx), bankAccount.BankAccountId)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve funding schedules")
+		}
+		data.FundingSchedules = append(data.FundingSchedules, fundingSchedules...)
+
+		spending, err := repo.GetSpending(c.getContext(ctx), bankAccount.BankAccountId)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve spending")
+		}
+		data.Spending = append(data.Spending, spending...)
+
+		for offset := 0; ; offset += journalExportPageSize {
+			transactions, err := repo.GetTransactions(
+				c.getContext(ctx),
+				bankAccount.BankAccountId,
+				journalExportPageSize,
+				offset,
+				repository.TransactionFilters{},
+			)
+			if err != nil {
+				return c.wrapPgError(ctx, err, "failed to retrieve transactions")
+			}
+			data.Transactions = append(data.Transactions, transactions...)
+			if len(transactions) < journalExportPageSize {
+				break
+			}
+		}
+	}
+
+	// The journal is written to a buffer first so that if something goes wrong the client still gets an error.
+	buffer := bytes.NewBuffer(nil)
+	if err = journal.Write(buffer, format, data, journal.Options{
+		Timezone: c.mustGetTimezone(ctx),
+		Now:      c.clock.Now(),
+	}); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to write journal")
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": "monetr." + format.Extension(),
+	}))
+
+	return ctx.Blob(http.StatusOK, format.ContentType(), buffer.Bytes())
+}
@@ -0,0 +1,48 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAccountExport(t *testing.T) {
+	t.Run("beancount", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bankAccount, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 20000)
+		fixtures.GivenIHaveNTransactions(t, app.Clock, bankAccount, 3)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/account/export").
+			WithQuery("format", "beancount").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.Header("Content-Disposition").IsEqual(`attachment; filename=monetr.beancount`)
+		body := response.Body().Raw()
+		assert.Contains(t, body, `option "operating_currency" "USD"`)
+		assert.Contains(t, body, ":Available\n", "bank account should have an available sub-account")
+		assert.Contains(t, body, ":Expenses:", "expense should be a sub-account of the bank account")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.GET("/api/account/export").
+			WithQuery("format", "gnucash").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual(`invalid journal format "gnucash", must be one of ledger, hledger or beancount`)
+	})
+}
@@ -0,0 +1,69 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalanceHistory(t *testing.T) {
+	t.Run("daily history", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		timezone, err := user.Account.GetTimezone()
+		require.NoError(t, err, "must be able to parse the account timezone")
+		today := util.Midnight(app.Clock.Now(), timezone)
+		repo := repository.NewRepositoryFromSession(app.Clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+		require.NoError(t, repo.UpsertBalanceSnapshots(context.Background(), []models.BalanceSnapshot{
+			{
+				BankAccountId: bank.BankAccountId,
+				Date:          today.AddDate(0, 0, -1),
+				Current:       1000,
+			},
+			{
+				BankAccountId: bank.BankAccountId,
+				Date:          today,
+				Current:       2000,
+			},
+		}), "must be able to record snapshots")
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/balances/history").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(2)
+		response.JSON().Path("$[0].current").Number().IsEqual(1000)
+		response.JSON().Path("$[1].current").Number().IsEqual(2000)
+		response.JSON().Path("$[1].isEstimated").Boolean().IsFalse()
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/balances/history").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("interval", "hourly").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual(`invalid interval "hourly", must be one of daily, weekly or monthly`)
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/util"
 )
 
 // List All Bank Accounts
@@ -75,6 +76,97 @@ func (c *Controller) getBalances(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, balances)
 }
 
+// Get Bank Account Balance History
+// @Summary Get Bank Account Balance History
+// @id get-bank-account-balance-history
+// @tags Bank Accounts
+// @description Get the history of the specified bank account's balances from the snapshots that are recorded every
+// @description night. Daily history has the balances as of the end of each day. Weekly and monthly history has the
+// @description balances as of the end of each week or month, dated at the start of the week or month. Weeks start on
+// @description Sunday. Days without a snapshot are omitted. Snapshots that were reconstructed from transaction history
+// @description rather than recorded are marked as estimated.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param start query string false "The first day to include (YYYY-MM-DD). Defaults to 30 days, 12 weeks or 12 months before the end date depending on the interval."
+// @Param end query string false "The last day to include (YYYY-MM-DD). Defaults to today."
+// @Param interval query string false "One of daily, weekly or monthly. Defaults to daily."
+// @Router /bank_accounts/{bankAccountId}/balances/history [get]
+// @Success 200 {array} models.BalanceSnapshot
+// @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getBalanceHistory(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	interval, start, end, err := c.parseBalanceHistoryRange(ctx, timezone)
+	if err != nil {
+		return err
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if _, err = repo.GetBankAccount(c.getContext(ctx), bankAccountId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve bank account")
+	}
+
+	snapshots, err := repo.GetBalanceSnapshots(c.getContext(ctx), bankAccountId, start, end)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve balance history")
+	}
+
+	return ctx.JSON(http.StatusOK, models.SummarizeBalanceSnapshots(snapshots, interval, timezone))
+}
+
+// parseBalanceHistoryRange reads the interval, start and end query parameters that are shared by the history
+// endpoints. The end date is inclusive, so the returned end is midnight of the following day. If the parameters are
+// not valid then a bad request error is returned that should be returned by the caller as is.
+func (c *Controller) parseBalanceHistoryRange(
+	ctx echo.Context,
+	timezone *time.Location,
+) (interval models.BalanceHistoryInterval, start, end time.Time, err error) {
+	interval = models.DailyBalanceHistoryInterval
+	if value := strings.TrimSpace(ctx.QueryParam("interval")); value != "" {
+		interval, err = models.ParseBalanceHistoryInterval(value)
+		if err != nil {
+			return interval, start, end, c.badRequest(ctx, "%s", err.Error())
+		}
+	}
+
+	end = util.Midnight(c.clock.Now(), timezone).AddDate(0, 0, 1)
+	if value := strings.TrimSpace(ctx.QueryParam("end")); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, timezone)
+		if err != nil {
+			return interval, start, end, c.badRequest(ctx, "invalid end date, must be in the format YYYY-MM-DD")
+		}
+		end = date.AddDate(0, 0, 1)
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("start")); value != "" {
+		start, err = time.ParseInLocation("2006-01-02", value, timezone)
+		if err != nil {
+			return interval, start, end, c.badRequest(ctx, "invalid start date, must be in the format YYYY-MM-DD")
+		}
+	} else {
+		switch interval {
+		case models.WeeklyBalanceHistoryInterval:
+			start = end.AddDate(0, 0, -7*12)
+		case models.MonthlyBalanceHistoryInterval:
+			start = end.AddDate(0, -12, 0)
+		default:
+			start = end.AddDate(0, 0, -30)
+		}
+	}
+	if !start.Before(end) {
+		return interval, start, end, c.badRequest(ctx, "start date must be before the end date")
+	}
+
+	return interval, start, end, nil
+}
+
 // Create Bank Account
 // @Summary Create Bank Account
 // @ID create-bank-account
@@ -101,12 +193,17 @@ func (c *Controller) postBankAccounts(ctx echo.Context) error {
 	bankAccount.BankAccountId = 0
 	bankAccount.Name = strings.TrimSpace(bankAccount.Name)
 	bankAccount.Mask = strings.TrimSpace(bankAccount.Mask)
+	bankAccount.Currency = strings.ToUpper(strings.TrimSpace(bankAccount.Currency))
 	bankAccount.LastUpdated = time.Now().UTC()
 
 	if bankAccount.Name == "" {
 		return c.badRequest(ctx, "bank account must have a name")
 	}
 
+	if bankAccount.Currency != "" && len(bankAccount.Currency) != 3 {
+		return c.badRequest(ctx, "currency must be a three letter ISO 4217 currency code")
+	}
+
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
 	// Bank accounts can only be created this way when they are associated with a link that allows manual
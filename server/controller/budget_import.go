@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/formats/budgetimport"
+)
+
+// Import Budget
+// @Summary Import Budget
+// @ID import-budget
+// @tags Account
+// @description Import a budget that was exported from YNAB or Actual Budget. Files should be provided as a multipart
+// @description form under the `data` field, and the app they were exported from under the `source` field. For YNAB
+// @description this can be the exported zip file or the register and plan CSV files from inside it, for Actual Budget
+// @description it is the exported transactions CSV file. A new manual link is created with a bank account for each
+// @description account in the budget. Categories are created as expenses and goals on the account that most of the
+// @description budget's spending came from, with whatever was available in each category carried over.
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param source formData string true "The app the budget was exported from, one of ynab or actual."
+// @Router /import/budget [post]
+// @Success 200 {object} budgetimport.Result
+// @Failure 400 {object} ApiError Invalid source, no files provided or the files could not be read.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postImportBudget(ctx echo.Context) error {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return c.badRequest(ctx, "request must be a multipart form")
+	}
+
+	source, err := budgetimport.ParseSource(ctx.FormValue("source"))
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	headers := form.File["data"]
+	if len(headers) == 0 {
+		return c.badRequest(ctx, "must provide at least one file to import")
+	}
+
+	files := make([]budgetimport.File, 0, len(headers))
+	for _, header := range headers {
+		if header.Size > maxUploadSize {
+			return c.badRequest(ctx, "%s is too large, must be less than %d bytes", header.Filename, maxUploadSize)
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read uploaded file")
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read uploaded file")
+		}
+
+		files = append(files, budgetimport.File{
+			Name: header.Filename,
+			Data: data,
+		})
+	}
+
+	budget, err := budgetimport.Parse(source, files)
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	result, err := budgetimport.Import(c.getContext(ctx), repo, budget, budgetimport.Options{
+		Timezone: c.mustGetTimezone(ctx),
+		Now:      c.clock.Now(),
+	})
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to import budget")
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
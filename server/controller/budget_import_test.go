@@ -0,0 +1,74 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+const sampleActualBudgetExport = `Account,Date,Payee,Notes,Category,Amount,Split_Amount,Cleared
+Checking,2023-09-01,Starting Balance,,Starting Balances,2500.00,0,Reconciled
+Checking,2023-09-02,Grocery Store,,Groceries,-84.12,0,Cleared
+Checking,2023-09-15,Employer,Paycheck,Income,1800.00,0,Cleared
+Checking,2023-10-05,Savings,,,-200.00,0,Cleared
+Savings,2023-10-05,Checking,,,200.00,0,Cleared
+`
+
+func TestPostImportBudget(t *testing.T) {
+	t.Run("actual budget", func(t *testing.T) {
+		_, e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.POST("/api/import/budget").
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFormField("source", "actual").
+			WithFileBytes("data", "transactions.csv", []byte(sampleActualBudgetExport)).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.linkId").Number().Gt(0)
+		response.JSON().Path("$.bankAccounts").Number().IsEqual(2)
+		response.JSON().Path("$.spending").Number().IsEqual(1)
+		response.JSON().Path("$.transactions").Number().IsEqual(4)
+		response.JSON().Path("$.transfers").Number().IsEqual(1)
+
+		{ // The imported accounts should be under a manual link named after the source.
+			response := e.GET("/api/links").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$[0].institutionName").String().IsEqual("Actual Budget")
+		}
+	})
+
+	t.Run("invalid source", func(t *testing.T) {
+		_, e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.POST("/api/import/budget").
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFormField("source", "mint").
+			WithFileBytes("data", "transactions.csv", []byte(sampleActualBudgetExport)).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual(`invalid budget source "mint", must be one of ynab or actual`)
+	})
+
+	t.Run("wrong export", func(t *testing.T) {
+		_, e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.POST("/api/import/budget").
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFormField("source", "ynab").
+			WithFileBytes("data", "transactions.csv", []byte(sampleActualBudgetExport)).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("export does not contain a YNAB register")
+	})
+}
@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+)
+
+// List Categories
+// @Summary List Categories
+// @id list-categories
+// @tags Categories
+// @description List the categories for the current account, sorted by name. Categories form a tree, top level
+// @description categories do not have a parent. If the account does not have any categories yet then the default
+// @description categories are created first, and existing transactions are assigned a category based on their Plaid
+// @description categories.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /categories [get]
+// @Success 200 {array} models.Category
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getCategories(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err := repo.EnsureDefaultCategories(c.getContext(ctx)); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create default categories")
+	}
+
+	categories, err := repo.GetCategories(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve categories")
+	}
+
+	return ctx.JSON(http.StatusOK, categories)
+}
+
+// Create Category
+// @Summary Create Category
+// @id create-category
+// @tags Categories
+// @description Create a category. Category names must be unique among categories with the same parent.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param category body models.Category true "Category"
+// @Router /categories [post]
+// @Success 200 {object} models.Category
+// @Failure 400 {object} ApiError Invalid category.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postCategories(ctx echo.Context) error {
+	var category models.Category
+	if err := ctx.Bind(&category); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err := c.validateCategory(ctx, repo, &category); err != nil {
+		return err
+	}
+
+	if err := repo.CreateCategory(c.getContext(ctx), &category); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create category")
+	}
+
+	return ctx.JSON(http.StatusOK, category)
+}
+
+// Update Category
+// @Summary Update Category
+// @id update-category
+// @tags Categories
+// @description Rename a category or move it beneath a different parent. A category cannot be moved beneath itself or
+// @description any of its children.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param categoryId path int true "Category ID"
+// @Param category body models.Category true "Category"
+// @Router /categories/{categoryId} [put]
+// @Success 200 {object} models.Category
+// @Failure 400 {object} ApiError Invalid category.
+// @Failure 404 {object} ApiError The category does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) putCategories(ctx echo.Context) error {
+	categoryId, err := strconv.ParseUint(ctx.Param("categoryId"), 10, 64)
+	if err != nil || categoryId == 0 {
+		return c.badRequest(ctx, "must specify a valid category Id")
+	}
+
+	var category models.Category
+	if err = ctx.Bind(&category); err != nil {
+		return c.invalidJson(ctx)
+	}
+	category.CategoryId = categoryId
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err = c.validateCategory(ctx, repo, &category); err != nil {
+		return err
+	}
+
+	if err = repo.UpdateCategory(c.getContext(ctx), &category); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update category")
+	}
+
+	return ctx.JSON(http.StatusOK, category)
+}
+
+// Delete Category
+// @Summary Delete Category
+// @id delete-category
+// @tags Categories
+// @description Remove a category. Its children, transactions and mappings are moved to its parent. If the category is
+// @description a top level category then its children become top level categories, and its transactions and mappings
+// @description are left without a category.
+// @Security ApiKeyAuth
+// @Param categoryId path int true "Category ID"
+// @Router /categories/{categoryId} [delete]
+// @Success 200
+// @Failure 400 {object} ApiError Invalid category Id.
+// @Failure 404 {object} ApiError The category does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) deleteCategories(ctx echo.Context) error {
+	categoryId, err := strconv.ParseUint(ctx.Param("categoryId"), 10, 64)
+	if err != nil || categoryId == 0 {
+		return c.badRequest(ctx, "must specify a valid category Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err = repo.DeleteCategory(c.getContext(ctx), categoryId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to delete category")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// List Category Mappings
+// @Summary List Category Mappings
+// @id list-category-mappings
+// @tags Categories
+// @description List the mappings from Plaid categories to the account's categories. Plaid categories are written as
+// @description their levels joined by ` > `, for example `Food and Drink > Restaurants`. New transactions are assigned
+// @description the category of the most specific mapping for their Plaid category.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /categories/mappings [get]
+// @Success 200 {array} models.CategoryMapping
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getCategoryMappings(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	mappings, err := repo.GetCategoryMappings(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve category mappings")
+	}
+
+	return ctx.JSON(http.StatusOK, mappings)
+}
+
+// Update Category Mapping
+// @Summary Update Category Mapping
+// @id update-category-mapping
+// @tags Categories
+// @description Map a Plaid category to one of the account's categories, replacing the existing mapping for that Plaid
+// @description category if there is one. Transactions that have already been categorized are not changed.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param mapping body models.CategoryMapping true "Category Mapping"
+// @Router /categories/mappings [put]
+// @Success 200 {object} models.CategoryMapping
+// @Failure 400 {object} ApiError Invalid category mapping.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) putCategoryMapping(ctx echo.Context) error {
+	var mapping models.CategoryMapping
+	if err := ctx.Bind(&mapping); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	mapping.PlaidCategory = models.CategoryPathKey(strings.Split(mapping.PlaidCategory, strings.TrimSpace(models.CategoryPathSeparator)))
+	if mapping.PlaidCategory == "" {
+		return c.badRequest(ctx, "plaid category is required")
+	}
+
+	if mapping.CategoryId == nil || *mapping.CategoryId == 0 {
+		return c.badRequest(ctx, "category is required")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if _, err := repo.GetCategory(c.getContext(ctx), *mapping.CategoryId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve category")
+	}
+
+	if err := repo.UpsertCategoryMapping(c.getContext(ctx), &mapping); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update category mapping")
+	}
+
+	return ctx.JSON(http.StatusOK, mapping)
+}
+
+// Delete Category Mapping
+// @Summary Delete Category Mapping
+// @id delete-category-mapping
+// @tags Categories
+// @description Remove the mapping for a Plaid category. New transactions with that Plaid category will be assigned the
+// @description category of a less specific mapping instead, or a new category will be created for them.
+// @Security ApiKeyAuth
+// @Param plaidCategory query string true "Plaid category"
+// @Router /categories/mappings [delete]
+// @Success 200
+// @Failure 400 {object} ApiError Plaid category was not provided.
+// @Failure 404 {object} ApiError The category mapping does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) deleteCategoryMapping(ctx echo.Context) error {
+	plaidCategory := models.CategoryPathKey(strings.Split(ctx.QueryParam("plaidCategory"), strings.TrimSpace(models.CategoryPathSeparator)))
+	if plaidCategory == "" {
+		return c.badRequest(ctx, "plaid category is required")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err := repo.DeleteCategoryMapping(c.getContext(ctx), plaidCategory); err != nil {
+		return c.wrapPgError(ctx, err, "failed to delete category mapping")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (c *Controller) validateCategory(ctx echo.Context, repo repository.BaseRepository, category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return c.badRequest(ctx, "category must have a name")
+	}
+
+	if category.ParentCategoryId != nil && *category.ParentCategoryId == 0 {
+		category.ParentCategoryId = nil
+	}
+
+	if category.ParentCategoryId == nil {
+		return nil
+	}
+
+	categories, err := repo.GetCategories(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve categories")
+	}
+
+	if err = models.ValidateCategoryParent(categories, category.CategoryId, category.ParentCategoryId); err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	return nil
+}
+
+// validateTransactionCategory checks the category provided for a transaction. A category of zero will remove the
+// category from the transaction.
+func (c *Controller) validateTransactionCategory(ctx echo.Context, repo repository.BaseRepository, categoryId *uint64) (*uint64, error) {
+	if categoryId == nil || *categoryId == 0 {
+		return nil, nil
+	}
+
+	if _, err := repo.GetCategory(c.getContext(ctx), *categoryId); err != nil {
+		return nil, c.wrapPgError(ctx, err, "failed to retrieve category")
+	}
+
+	return categoryId, nil
+}
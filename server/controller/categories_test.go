@@ -0,0 +1,293 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategories(t *testing.T) {
+	t.Run("create and delete", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Default categories are created the first time categories are listed.
+			response := e.GET("/api/categories").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().NotEmpty()
+		}
+
+		var parentId, childId int64
+		{
+			response := e.POST("/api/categories").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name": "  Pets  ",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.name").String().IsEqual("Pets")
+			response.JSON().Path("$.parentCategoryId").IsNull()
+			parentId = int64(response.JSON().Path("$.categoryId").Number().Gt(0).Raw())
+		}
+
+		{
+			response := e.POST("/api/categories").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":             "Vet",
+					"parentCategoryId": parentId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.parentCategoryId").Number().IsEqual(parentId)
+			childId = int64(response.JSON().Path("$.categoryId").Number().Gt(0).Raw())
+		}
+
+		{ // A category cannot be moved beneath its own child.
+			response := e.PUT("/api/categories/{categoryId}").
+				WithPath("categoryId", parentId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":             "Pets",
+					"parentCategoryId": childId,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("category cannot be moved beneath itself")
+		}
+
+		{
+			response := e.DELETE("/api/categories/{categoryId}").
+				WithPath("categoryId", parentId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{ // The child of a deleted top level category becomes a top level category.
+			response := e.GET("/api/categories").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			var categories []models.Category
+			response.JSON().Decode(&categories)
+			found := false
+			for _, category := range categories {
+				if int64(category.CategoryId) == childId {
+					found = true
+					assert.Nil(t, category.ParentCategoryId)
+				}
+			}
+			assert.True(t, found, "child category should still exist")
+		}
+	})
+
+	t.Run("requires a name", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/categories").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": " ",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("category must have a name")
+	})
+
+	t.Run("defaults are not seeded again", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var categories []models.Category
+		{
+			response := e.GET("/api/categories").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Decode(&categories)
+			assert.NotEmpty(t, categories, "default categories should be seeded")
+		}
+
+		for _, category := range categories {
+			response := e.DELETE("/api/categories/{categoryId}").
+				WithPath("categoryId", category.CategoryId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{ // Removing every category should not cause the defaults to be created again.
+			response := e.GET("/api/categories").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+	})
+}
+
+func TestCategoryMappings(t *testing.T) {
+	t.Run("map a plaid category", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var categoryId int64
+		{
+			response := e.POST("/api/categories").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name": "Coffee",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			categoryId = int64(response.JSON().Path("$.categoryId").Number().Raw())
+		}
+
+		{
+			response := e.PUT("/api/categories/mappings").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"plaidCategory": "Food and Drink>Restaurants > Coffee Shop",
+					"categoryId":    categoryId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.plaidCategory").String().IsEqual("Food and Drink > Restaurants > Coffee Shop")
+		}
+
+		{
+			response := e.GET("/api/categories/mappings").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			var mappings []models.CategoryMapping
+			response.JSON().Decode(&mappings)
+			found := false
+			for _, mapping := range mappings {
+				if mapping.PlaidCategory == "Food and Drink > Restaurants > Coffee Shop" {
+					found = true
+					if assert.NotNil(t, mapping.CategoryId) {
+						assert.EqualValues(t, categoryId, *mapping.CategoryId)
+					}
+				}
+			}
+			assert.True(t, found, "mapping should be listed")
+		}
+
+		{
+			response := e.DELETE("/api/categories/mappings").
+				WithQuery("plaidCategory", "Food and Drink > Restaurants > Coffee Shop").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+	})
+
+	t.Run("category must exist", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/categories/mappings").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"plaidCategory": "Food and Drink",
+				"categoryId":    9999,
+			}).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	})
+}
+
+func TestPutTransactionsCategory(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	var categoryId int64
+	{
+		response := e.POST("/api/categories").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": "Groceries",
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		categoryId = int64(response.JSON().Path("$.categoryId").Number().Raw())
+	}
+
+	var transaction models.Transaction
+	{
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":       "Grocery Store",
+				"amount":     2500,
+				"date":       app.Clock.Now(),
+				"categoryId": categoryId,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transaction.categoryId").Number().IsEqual(categoryId)
+		response.JSON().Path("$.transaction").Decode(&transaction)
+	}
+
+	{ // Leaving the category out of an update keeps the existing category.
+		transaction.CategoryId = nil
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(transaction).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transaction.categoryId").Number().IsEqual(categoryId)
+	}
+
+	{ // A category of zero removes it.
+		zero := uint64(0)
+		transaction.CategoryId = &zero
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(transaction).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transaction.categoryId").IsNull()
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/monetr/monetr/server/pubsub"
 	"github.com/monetr/monetr/server/secrets"
 	"github.com/monetr/monetr/server/security"
+	"github.com/monetr/monetr/server/storage"
 	"github.com/monetr/monetr/server/stripe_helper"
 	"github.com/monetr/monetr/server/util"
 	"github.com/pkg/errors"
@@ -48,6 +49,7 @@ type Controller struct {
 	stripeWebhooks           billing.StripeWebhookHandler
 	email                    communication.EmailCommunication
 	clientTokens             security.ClientTokens
+	fileStorage              storage.Storage
 	clock                    clock.Clock
 }
 
@@ -64,6 +66,7 @@ func NewController(
 	basicPaywall billing.BasicPayWall,
 	email communication.EmailCommunication,
 	clientTokens security.ClientTokens,
+	fileStorage storage.Storage,
 	clock clock.Clock,
 ) *Controller {
 	var recaptcha captcha.Verification
@@ -105,6 +108,7 @@ func NewController(
 		stripeWebhooks:           billing.NewStripeWebhookHandler(log, accountsRepo, basicBilling, pubSub),
 		email:                    email,
 		clientTokens:             clientTokens,
+		fileStorage:              fileStorage,
 		clock:                    clock,
 	}
 }
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/formats/csvimport"
+	"github.com/monetr/monetr/server/models"
+)
+
+// Get CSV Mapping
+// @Summary Get CSV Mapping
+// @ID get-csv-mapping
+// @tags Transactions
+// @description Retrieve the column mapping that is used to import CSV files for the specified manual bank account.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Router /bank_accounts/{bankAccountId}/upload/csv/mapping [get]
+// @Success 200 {object} models.CSVMapping
+// @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID.
+// @Failure 404 {object} ApiError The bank account does not have a CSV mapping.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getCSVMapping(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	mapping, err := repo.GetCSVMapping(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve csv mapping")
+	}
+
+	return ctx.JSON(http.StatusOK, mapping)
+}
+
+// Update CSV Mapping
+// @Summary Update CSV Mapping
+// @ID update-csv-mapping
+// @tags Transactions
+// @description Create or replace the column mapping that is used to import CSV files for the specified manual bank
+// @description account. Column references are zero based indexes.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param mapping body models.CSVMapping true "CSV Mapping"
+// @Router /bank_accounts/{bankAccountId}/upload/csv/mapping [put]
+// @Success 200 {object} models.CSVMapping
+// @Failure 400 {object} ApiError Invalid mapping or non-manual link.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) putCSVMapping(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	var mapping models.CSVMapping
+	if err := ctx.Bind(&mapping); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	mapping.CSVMappingId = 0
+	mapping.BankAccountId = bankAccountId
+	if err = c.validateCSVMapping(&mapping); err != nil {
+		return c.badRequest(ctx, "invalid csv mapping: %s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	ok, err := repo.GetLinkIsManualByBankAccountId(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to verify bank account link type")
+	}
+
+	if !ok {
+		return c.badRequest(ctx, "Cannot import transactions for non-manual link.")
+	}
+
+	if err = repo.UpsertCSVMapping(c.getContext(ctx), &mapping); err != nil {
+		return c.wrapPgError(ctx, err, "failed to save csv mapping")
+	}
+
+	return ctx.JSON(http.StatusOK, mapping)
+}
+
+// Preview CSV Upload
+// @Summary Preview CSV Upload
+// @ID preview-csv-upload
+// @tags Transactions
+// @description Parse the first few rows of a CSV file without creating any transactions. The file should be provided
+// @description as a multipart form under the `data` field. A mapping can be provided as JSON under the `mapping` field
+// @description to try it before it is saved, otherwise the bank account's saved mapping is used. Amounts in the
+// @description result use monetr's sign convention, positive amounts are debits.
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param limit query int false "The number of rows to parse, default is 10. Max is 100."
+// @Router /bank_accounts/{bankAccountId}/upload/csv/preview [post]
+// @Success 200 {object} csvimport.Result
+// @Failure 400 {object} ApiError Invalid file or mapping.
+// @Failure 404 {object} ApiError No mapping was provided and the bank account does not have one saved.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postCSVPreview(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 10)
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	header, err := ctx.FormFile("data")
+	if err != nil {
+		return c.badRequest(ctx, "must provide a file to preview")
+	}
+
+	if header.Size > maxUploadSize {
+		return c.badRequest(ctx, "file is too large, must be less than %d bytes", maxUploadSize)
+	}
+
+	var mapping *models.CSVMapping
+	if rawMapping := strings.TrimSpace(ctx.FormValue("mapping")); rawMapping != "" {
+		mapping = &models.CSVMapping{}
+		if err = json.Unmarshal([]byte(rawMapping), mapping); err != nil {
+			return c.badRequest(ctx, "mapping must be valid JSON")
+		}
+
+		if err = c.validateCSVMapping(mapping); err != nil {
+			return c.badRequest(ctx, "invalid csv mapping: %s", err.Error())
+		}
+	} else {
+		repo := c.mustGetAuthenticatedRepository(ctx)
+		mapping, err = repo.GetCSVMapping(c.getContext(ctx), bankAccountId)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve csv mapping")
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read uploaded file")
+	}
+	defer file.Close()
+
+	result, err := csvimport.Parse(file, *mapping, limit)
+	if err != nil {
+		return c.badRequest(ctx, "failed to parse file: %s", err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// validateCSVMapping will fill in any defaults on the provided mapping and then make sure that it can actually be used
+// to read a file.
+func (c *Controller) validateCSVMapping(mapping *models.CSVMapping) error {
+	mapping.Normalize()
+	if err := mapping.Validate(); err != nil {
+		return err
+	}
+
+	_, err := csvimport.DateLayout(mapping.DateFormat)
+	return err
+}
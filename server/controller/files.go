@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+)
+
+// List Files
+// @Summary List Files
+// @ID list-files
+// @tags Files
+// @description List the files that have been uploaded for the specified bank account, newest first.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param limit query int false "Specifies the number of files to return in the result, default is 25. Max is 100."
+// @Param offset query int false "The number of files to skip before returning any."
+// @Param cursor query string false "Return the page of files after this cursor, taken from the `X-Next-Cursor` header of the previous page. Cannot be used with offset."
+// @Router /bank_accounts/{bankAccountId}/files [get]
+// @Success 200 {array} models.File
+// @Header 200 {string} X-Next-Cursor "The cursor for the next page, only present if there are more files."
+// @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getFiles(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	offset := urlParamIntDefault(ctx, "offset", 0)
+
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	if offset < 0 {
+		return c.badRequest(ctx, "offset cannot be less than 0")
+	}
+
+	after, err := c.parseCursor(ctx)
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	// Retrieve one extra file to know whether there is another page.
+	files, err := repo.GetFiles(c.getContext(ctx), bankAccountId, limit+1, offset, after)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve files")
+	}
+
+	if len(files) > limit {
+		files = files[:limit]
+		last := files[limit-1]
+		setNextCursor(ctx, repository.NewCursor(last.CreatedAt, last.FileId))
+	}
+
+	return ctx.JSON(http.StatusOK, files)
+}
+
+// Download File
+// @Summary Download File
+// @ID download-file
+// @tags Files
+// @description Download the original contents of a file that was uploaded for the specified bank account.
+// @Security ApiKeyAuth
+// @Produce octet-stream
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param fileId path int true "File ID"
+// @Router /bank_accounts/{bankAccountId}/files/{fileId}/download [get]
+// @Success 200
+// @Failure 400 {object} ApiError Invalid Bank Account ID or File ID.
+// @Failure 404 {object} ApiError The file does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getFileDownload(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	fileId, err := strconv.ParseUint(ctx.Param("fileId"), 10, 64)
+	if err != nil || fileId == 0 {
+		return c.badRequest(ctx, "must specify a valid file Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	file, err := repo.GetFile(c.getContext(ctx), bankAccountId, fileId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve file")
+	}
+
+	return c.streamFile(ctx, file)
+}
+
+// streamFile responds with the contents of the provided file from file storage as a download. The content type of a
+// file is whatever it was uploaded with, so browsers are told not to sniff it and the file is always served as an
+// attachment. That way an uploaded HTML or SVG file cannot be rendered in monetr's origin.
+func (c *Controller) streamFile(ctx echo.Context, file *models.File) error {
+	if c.fileStorage == nil {
+		return c.notFound(ctx, "file storage is not configured")
+	}
+
+	reader, err := c.fileStorage.Read(c.getContext(ctx), file.ObjectUri)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read file")
+	}
+	defer reader.Close()
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": file.Name,
+	}))
+	ctx.Response().Header().Set(echo.HeaderContentLength, strconv.FormatUint(file.Size, 10))
+	ctx.Response().Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+
+	return ctx.Stream(http.StatusOK, file.ContentType, reader)
+}
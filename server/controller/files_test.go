@@ -0,0 +1,77 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestGetFiles(t *testing.T) {
+	t.Run("upload and download", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var fileId uint64
+		{ // Uploading a file should retain a copy of it.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.ofx", []byte(sampleOFXUpload)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			fileId = uint64(response.JSON().Path("$[0].fileId").Number().Gt(0).Raw())
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/files").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].fileId").Number().IsEqual(fileId)
+			response.JSON().Path("$[0].name").String().IsEqual("statement.ofx")
+			response.JSON().Path("$[0].contentType").String().IsEqual("application/x-ofx")
+			response.JSON().Path("$[0].size").Number().IsEqual(len(sampleOFXUpload))
+			response.JSON().Object().NotContainsKey("objectUri")
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/files/{fileId}/download").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("fileId", fileId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.Header("Content-Type").IsEqual("application/x-ofx")
+			response.Header("Content-Disposition").IsEqual(`attachment; filename=statement.ofx`)
+			response.Header("X-Content-Type-Options").IsEqual("nosniff")
+			response.Body().IsEqual(sampleOFXUpload)
+		}
+	})
+
+	t.Run("file does not exist", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/files/{fileId}/download").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("fileId", 1234).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	})
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+)
+
+// List Funding Events
+// @Summary List Funding Events
+// @id list-funding-events
+// @tags Funding Schedules
+// @description List the times the funding schedule was processed and what it contributed to each spending object, most
+// @description recent first.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param fundingScheduleId path int true "Funding Schedule ID"
+// @Param limit query int false "Specifies the number of funding events to return in the result, default is 25. Max is 100."
+// @Param offset query int false "The number of funding events to skip before returning any."
+// @Router /bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events [get]
+// @Success 200 {array} models.FundingEvent
+// @Failure 400 {object} ApiError Invalid request.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getFundingEvents(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	fundingScheduleId, err := strconv.ParseUint(ctx.Param("fundingScheduleId"), 10, 64)
+	if err != nil || fundingScheduleId == 0 {
+		return c.badRequest(ctx, "must specify a valid funding schedule Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	offset := urlParamIntDefault(ctx, "offset", 0)
+
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	if offset < 0 {
+		return c.badRequest(ctx, "offset cannot be less than 0")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	events, err := repo.GetFundingEvents(c.getContext(ctx), bankAccountId, fundingScheduleId, limit, offset)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve funding events")
+	}
+
+	return ctx.JSON(http.StatusOK, events)
+}
+
+// Revert Funding Event
+// @Summary Revert Funding Event
+// @id revert-funding-event
+// @tags Funding Schedules
+// @description Revert a funding event that happened by mistake, or before the deposit it was meant for arrived. The
+// @description contributions of the funding event are removed from each spending object, their next contribution
+// @description amounts are restored, and the funding schedule is moved back to the occurrence it was on before. Only
+// @description the most recent funding event of a funding schedule that has not already been reverted can be reverted.
+// @description The funding event cannot be reverted if any of the funds it contributed have already been spent.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param fundingScheduleId path int true "Funding Schedule ID"
+// @Param fundingEventId path int true "Funding Event ID"
+// @Router /bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events/{fundingEventId}/revert [post]
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ApiError The funding event cannot be reverted.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 404 {object} ApiError The funding event does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postRevertFundingEvent(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	fundingScheduleId, err := strconv.ParseUint(ctx.Param("fundingScheduleId"), 10, 64)
+	if err != nil || fundingScheduleId == 0 {
+		return c.badRequest(ctx, "must specify a valid funding schedule Id")
+	}
+
+	fundingEventId, err := strconv.ParseUint(ctx.Param("fundingEventId"), 10, 64)
+	if err != nil || fundingEventId == 0 {
+		return c.badRequest(ctx, "must specify a valid funding event Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	event, err := repo.GetFundingEvent(c.getContext(ctx), bankAccountId, fundingEventId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve funding event")
+	}
+
+	if event.FundingScheduleId != fundingScheduleId {
+		return c.notFound(ctx, "funding event does not exist")
+	}
+
+	if event.RevertedAt != nil {
+		return c.badRequest(ctx, "funding event has already been reverted")
+	}
+
+	// Each funding event restores the occurrences from before it, so reverting anything but the most recent funding
+	// event would leave the funding schedule on the wrong occurrence.
+	latest, err := repo.GetLatestFundingEvent(c.getContext(ctx), bankAccountId, fundingScheduleId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve latest funding event")
+	}
+
+	if latest == nil || latest.FundingEventId != event.FundingEventId {
+		return c.badRequest(ctx, "only the most recent funding event of a funding schedule can be reverted")
+	}
+
+	fundingSchedule, err := repo.GetFundingSchedule(c.getContext(ctx), bankAccountId, fundingScheduleId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve funding schedule")
+	}
+
+	// Spending objects might have been moved to another funding schedule since they were funded, so all of them are
+	// considered rather than just the ones for this funding schedule.
+	spending, err := repo.GetSpending(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve spending")
+	}
+
+	updatedSpending, err := event.Revert(fundingSchedule, spending)
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	if err = repo.UpdateSpending(
+		c.getContext(ctx),
+		bankAccountId,
+		updatedSpending,
+		models.SpendingLedgerReasonFundingReverted,
+		&event.FundingEventId,
+	); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update spending for reverted funding event")
+	}
+
+	if err = repo.RevertFundingEvent(c.getContext(ctx), event, fundingSchedule); err != nil {
+		return c.wrapPgError(ctx, err, "failed to revert funding event")
+	}
+
+	balance, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not get updated balances")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"fundingEvent":    event,
+		"fundingSchedule": fundingSchedule,
+		"spending":        updatedSpending,
+		"balance":         balance,
+	})
+}
@@ -0,0 +1,160 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRevertFundingEvent(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 1000)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	// Pretend that the funding schedule was processed, contributing 400 to the expense.
+	repo := repository.NewRepositoryFromSession(app.Clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+	previousNextOccurrence := fundingSchedule.NextOccurrence.AddDate(0, 0, -15)
+	event := models.FundingEvent{
+		BankAccountId:                  bank.BankAccountId,
+		FundingScheduleId:              fundingSchedule.FundingScheduleId,
+		PreviousLastOccurrence:         nil,
+		PreviousNextOccurrence:         previousNextOccurrence,
+		PreviousNextOccurrenceOriginal: previousNextOccurrence,
+		Contributions: []models.FundingEventContribution{
+			{SpendingId: expense.SpendingId, Amount: 400},
+		},
+	}
+	require.NoError(t, repo.CreateFundingEvent(context.Background(), &event), "must create funding event")
+	fundingSchedule.LastOccurrence = &previousNextOccurrence
+	require.NoError(t, repo.UpdateFundingSchedule(context.Background(), fundingSchedule), "must update funding schedule")
+
+	{
+		response := e.GET("/api/bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("fundingScheduleId", fundingSchedule.FundingScheduleId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].fundingEventId").Number().IsEqual(event.FundingEventId)
+		response.JSON().Path("$[0].contributions[0].amount").Number().IsEqual(400)
+		response.JSON().Path("$[0].revertedAt").IsNull()
+	}
+
+	{
+		response := e.POST("/api/bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events/{fundingEventId}/revert").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("fundingScheduleId", fundingSchedule.FundingScheduleId).
+			WithPath("fundingEventId", event.FundingEventId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.fundingEvent.revertedAt").String().NotEmpty()
+		response.JSON().Path("$.fundingSchedule.lastOccurrence").IsNull()
+		response.JSON().Path("$.fundingSchedule.nextOccurrence").String().AsDateTime().IsEqual(previousNextOccurrence)
+		response.JSON().Path("$.spending[0].spendingId").Number().IsEqual(expense.SpendingId)
+		response.JSON().Path("$.spending[0].currentAmount").Number().IsEqual(600)
+		response.JSON().Path("$.spending[0].nextContributionAmount").Number().IsEqual(400)
+	}
+
+	{ // The ledger records the reverted contribution.
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/ledger").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", expense.SpendingId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$[0].reason").String().IsEqual(string(models.SpendingLedgerReasonFundingReverted))
+		response.JSON().Path("$[0].amount").Number().IsEqual(-400)
+		response.JSON().Path("$[0].referenceId").Number().IsEqual(event.FundingEventId)
+	}
+
+	{ // A funding event cannot be reverted twice.
+		response := e.POST("/api/bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events/{fundingEventId}/revert").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("fundingScheduleId", fundingSchedule.FundingScheduleId).
+			WithPath("fundingEventId", event.FundingEventId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("funding event has already been reverted")
+	}
+}
+
+func TestPostRevertFundingEventAlreadySpent(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 300)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	repo := repository.NewRepositoryFromSession(app.Clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+	first := models.FundingEvent{
+		BankAccountId:                  bank.BankAccountId,
+		FundingScheduleId:              fundingSchedule.FundingScheduleId,
+		PreviousNextOccurrence:         fundingSchedule.NextOccurrence.AddDate(0, 0, -30),
+		PreviousNextOccurrenceOriginal: fundingSchedule.NextOccurrence.AddDate(0, 0, -30),
+	}
+	require.NoError(t, repo.CreateFundingEvent(context.Background(), &first), "must create funding event")
+	second := models.FundingEvent{
+		BankAccountId:                  bank.BankAccountId,
+		FundingScheduleId:              fundingSchedule.FundingScheduleId,
+		PreviousNextOccurrence:         fundingSchedule.NextOccurrence.AddDate(0, 0, -15),
+		PreviousNextOccurrenceOriginal: fundingSchedule.NextOccurrence.AddDate(0, 0, -15),
+		Contributions: []models.FundingEventContribution{
+			{SpendingId: expense.SpendingId, Amount: 400},
+		},
+	}
+	require.NoError(t, repo.CreateFundingEvent(context.Background(), &second), "must create funding event")
+
+	{ // Only the most recent funding event can be reverted.
+		response := e.POST("/api/bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events/{fundingEventId}/revert").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("fundingScheduleId", fundingSchedule.FundingScheduleId).
+			WithPath("fundingEventId", first.FundingEventId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("only the most recent funding event of a funding schedule can be reverted")
+	}
+
+	{ // The expense only has 300 left of the 400 that was contributed.
+		response := e.POST("/api/bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events/{fundingEventId}/revert").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("fundingScheduleId", fundingSchedule.FundingScheduleId).
+			WithPath("fundingEventId", second.FundingEventId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Contains("have already been spent")
+	}
+
+	{ // Funding events must belong to the funding schedule in the path.
+		response := e.POST("/api/bank_accounts/{bankAccountId}/funding_schedules/{fundingScheduleId}/events/{fundingEventId}/revert").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("fundingScheduleId", fundingSchedule.FundingScheduleId+100).
+			WithPath("fundingEventId", second.FundingEventId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	}
+}
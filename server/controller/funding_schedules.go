@@ -8,6 +8,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
 	"github.com/monetr/monetr/server/models"
 	"github.com/monetr/monetr/server/repository"
 	"github.com/pkg/errors"
@@ -242,7 +243,13 @@ func (c *Controller) putFundingSchedules(ctx echo.Context) error {
 				}
 			}
 
-			if err = repo.UpdateSpending(c.getContext(ctx), bankAccountId, spending); err != nil {
+			if err = repo.UpdateSpending(
+				c.getContext(ctx),
+				bankAccountId,
+				spending,
+				models.SpendingLedgerReasonManual,
+				myownsanity.Uint64P(fundingScheduleId),
+			); err != nil {
 				return c.wrapPgError(ctx, err, "failed to update spending objects for updated funding schedule")
 			}
 			updatedSpending = spending
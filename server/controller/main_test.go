@@ -25,6 +25,7 @@ import (
 	"github.com/monetr/monetr/server/config"
 	"github.com/monetr/monetr/server/controller"
 	"github.com/monetr/monetr/server/internal/mock_secrets"
+	"github.com/monetr/monetr/server/internal/mock_storage"
 	"github.com/monetr/monetr/server/internal/mockgen"
 	"github.com/monetr/monetr/server/internal/testutils"
 	"github.com/monetr/monetr/server/platypus"
@@ -87,6 +88,12 @@ func NewTestApplicationConfig(t *testing.T) config.Configuration {
 		Logging: config.Logging{
 			Level: "trace",
 		},
+		Storage: config.Storage{
+			Attachments: config.AttachmentStorage{
+				MaxSize:             1024 * 1024,
+				AllowedContentTypes: []string{"application/pdf", "image/jpeg", "image/png"},
+			},
+		},
 	}
 }
 
@@ -138,12 +145,13 @@ func NewTestApplicationPatched(t *testing.T, configuration config.Configuration,
 		miniRedis.Close()
 	})
 	plaidSecrets := mock_secrets.NewMockPlaidSecrets()
+	fileStorage := mock_storage.NewMockStorage()
 
 	var jobRunner background.JobController
 	if patched.JobController != nil {
 		jobRunner = *patched.JobController
 	} else {
-		jobRunner = background.NewSynchronousJobRunner(t, clock, plaidClient, plaidSecrets)
+		jobRunner = background.NewSynchronousJobRunner(t, clock, plaidClient, plaidSecrets, fileStorage)
 	}
 
 	emailMockController := gomock.NewController(t)
@@ -169,6 +177,7 @@ func NewTestApplicationPatched(t *testing.T, configuration config.Configuration,
 		),
 		email,
 		clientTokens,
+		fileStorage,
 		clock,
 	)
 	app := application.NewApp(configuration, c)
@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/util"
+)
+
+type ManualAssetValueRequest struct {
+	// Date is the day the value is for in the format YYYY-MM-DD, defaults to today.
+	Date string `json:"date"`
+	// Value is the value of the asset in cents.
+	Value int64 `json:"value"`
+}
+
+// List Manual Assets
+// @Summary List Manual Assets
+// @id list-manual-assets
+// @tags Net Worth
+// @description List the manual assets for the current account. Manual assets are things of value that are not held
+// @description at a financial institution, like a house or a car, and are included in the account's net worth.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /manual_assets [get]
+// @Success 200 {array} models.ManualAsset
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getManualAssets(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	assets, err := repo.GetManualAssets(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve manual assets")
+	}
+
+	return ctx.JSON(http.StatusOK, assets)
+}
+
+// Create Manual Asset
+// @Summary Create Manual Asset
+// @id create-manual-asset
+// @tags Net Worth
+// @description Create a manual asset. The current value provided is recorded as the value of the asset for today.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param asset body models.ManualAsset true "Manual Asset"
+// @Router /manual_assets [post]
+// @Success 200 {object} models.ManualAsset
+// @Failure 400 {object} ApiError Invalid manual asset.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postManualAssets(ctx echo.Context) error {
+	var asset models.ManualAsset
+	if err := ctx.Bind(&asset); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if err := c.validateManualAsset(ctx, &asset); err != nil {
+		return err
+	}
+
+	if asset.CurrentValue < 0 {
+		return c.badRequest(ctx, "value of the asset cannot be negative")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	today := util.Midnight(c.clock.Now(), c.mustGetTimezone(ctx))
+	if err := repo.CreateManualAsset(c.getContext(ctx), &asset, today); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create manual asset")
+	}
+
+	return ctx.JSON(http.StatusOK, asset)
+}
+
+// Update Manual Asset
+// @Summary Update Manual Asset
+// @id update-manual-asset
+// @tags Net Worth
+// @description Update the name and type of a manual asset. The value of a manual asset is changed by recording a new
+// @description value, any value provided here is ignored.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param manualAssetId path int true "Manual Asset ID"
+// @Param asset body models.ManualAsset true "Manual Asset"
+// @Router /manual_assets/{manualAssetId} [put]
+// @Success 200 {object} models.ManualAsset
+// @Failure 400 {object} ApiError Invalid manual asset.
+// @Failure 404 {object} ApiError The manual asset does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) putManualAssets(ctx echo.Context) error {
+	manualAssetId, err := strconv.ParseUint(ctx.Param("manualAssetId"), 10, 64)
+	if err != nil || manualAssetId == 0 {
+		return c.badRequest(ctx, "must specify a valid manual asset Id")
+	}
+
+	var asset models.ManualAsset
+	if err = ctx.Bind(&asset); err != nil {
+		return c.invalidJson(ctx)
+	}
+	asset.ManualAssetId = manualAssetId
+
+	if err = c.validateManualAsset(ctx, &asset); err != nil {
+		return err
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err = repo.UpdateManualAsset(c.getContext(ctx), &asset); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update manual asset")
+	}
+
+	return ctx.JSON(http.StatusOK, asset)
+}
+
+// Delete Manual Asset
+// @Summary Delete Manual Asset
+// @id delete-manual-asset
+// @tags Net Worth
+// @description Remove a manual asset along with all of its recorded values. It will no longer be included in the
+// @description account's net worth or net worth history.
+// @Security ApiKeyAuth
+// @Param manualAssetId path int true "Manual Asset ID"
+// @Router /manual_assets/{manualAssetId} [delete]
+// @Success 200
+// @Failure 400 {object} ApiError Invalid manual asset Id.
+// @Failure 404 {object} ApiError The manual asset does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) deleteManualAssets(ctx echo.Context) error {
+	manualAssetId, err := strconv.ParseUint(ctx.Param("manualAssetId"), 10, 64)
+	if err != nil || manualAssetId == 0 {
+		return c.badRequest(ctx, "must specify a valid manual asset Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err = repo.DeleteManualAsset(c.getContext(ctx), manualAssetId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to delete manual asset")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// List Manual Asset Values
+// @Summary List Manual Asset Values
+// @id list-manual-asset-values
+// @tags Net Worth
+// @description List every value that has been recorded for a manual asset, oldest first.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param manualAssetId path int true "Manual Asset ID"
+// @Router /manual_assets/{manualAssetId}/values [get]
+// @Success 200 {array} models.ManualAssetValue
+// @Failure 400 {object} ApiError Invalid manual asset Id.
+// @Failure 404 {object} ApiError The manual asset does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getManualAssetValues(ctx echo.Context) error {
+	manualAssetId, err := strconv.ParseUint(ctx.Param("manualAssetId"), 10, 64)
+	if err != nil || manualAssetId == 0 {
+		return c.badRequest(ctx, "must specify a valid manual asset Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if _, err = repo.GetManualAsset(c.getContext(ctx), manualAssetId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve manual asset")
+	}
+
+	values, err := repo.GetManualAssetValues(c.getContext(ctx), manualAssetId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve manual asset values")
+	}
+
+	return ctx.JSON(http.StatusOK, values)
+}
+
+// Record Manual Asset Value
+// @Summary Record Manual Asset Value
+// @id record-manual-asset-value
+// @tags Net Worth
+// @description Record the value of a manual asset as of a day, replacing any value already recorded for that day. If
+// @description the day is omitted then the value is recorded for today. The current value of the asset is always the
+// @description most recently dated value, so recording a value in the past does not change the current value.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param manualAssetId path int true "Manual Asset ID"
+// @Param value body ManualAssetValueRequest true "Manual Asset Value"
+// @Router /manual_assets/{manualAssetId}/values [post]
+// @Success 200 {object} models.ManualAsset
+// @Failure 400 {object} ApiError Invalid manual asset value.
+// @Failure 404 {object} ApiError The manual asset does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postManualAssetValue(ctx echo.Context) error {
+	manualAssetId, err := strconv.ParseUint(ctx.Param("manualAssetId"), 10, 64)
+	if err != nil || manualAssetId == 0 {
+		return c.badRequest(ctx, "must specify a valid manual asset Id")
+	}
+
+	var request ManualAssetValueRequest
+	if err = ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if request.Value < 0 {
+		return c.badRequest(ctx, "value of the asset cannot be negative")
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	today := util.Midnight(c.clock.Now(), timezone)
+	date := today
+	if value := strings.TrimSpace(request.Date); value != "" {
+		date, err = time.ParseInLocation("2006-01-02", value, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid date, must be in the format YYYY-MM-DD")
+		}
+	}
+
+	if date.After(today) {
+		return c.badRequest(ctx, "cannot record the value of an asset in the future")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	asset, err := repo.GetManualAsset(c.getContext(ctx), manualAssetId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve manual asset")
+	}
+
+	if err = repo.RecordManualAssetValue(c.getContext(ctx), asset, date, request.Value); err != nil {
+		return c.wrapPgError(ctx, err, "failed to record manual asset value")
+	}
+
+	return ctx.JSON(http.StatusOK, asset)
+}
+
+func (c *Controller) validateManualAsset(ctx echo.Context, asset *models.ManualAsset) error {
+	asset.Name = strings.TrimSpace(asset.Name)
+	if asset.Name == "" {
+		return c.badRequest(ctx, "asset must have a name")
+	}
+
+	if asset.AssetType == "" {
+		asset.AssetType = models.OtherManualAssetType
+	}
+
+	assetType, err := models.ParseManualAssetType(string(asset.AssetType))
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+	asset.AssetType = assetType
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+)
+
+// Get Net Worth
+// @Summary Get Net Worth
+// @id get-net-worth
+// @tags Net Worth
+// @description Get the net worth of the current account across every link. The balances of depository, investment
+// @description and other bank accounts, as well as the values of manual assets, are counted as assets. The balances of
+// @description credit and loan bank accounts are counted as liabilities. Bank accounts that are not active are not
+// @description included. The net worth is broken down by institution and by account type, and includes the history of
+// @description the net worth built from the balance snapshots of each bank account and the recorded values of manual
+// @description assets. History is dated at the start of each interval and omits intervals before anything was
+// @description recorded.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param start query string false "The first day of history to include (YYYY-MM-DD). Defaults to 30 days, 12 weeks or 12 months before the end date depending on the interval."
+// @Param end query string false "The last day of history to include (YYYY-MM-DD). Defaults to today."
+// @Param interval query string false "One of daily, weekly or monthly. Defaults to daily."
+// @Router /net_worth [get]
+// @Success 200 {object} models.NetWorth
+// @Failure 400 {object} ApiError Invalid history parameters.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getNetWorth(ctx echo.Context) error {
+	timezone := c.mustGetTimezone(ctx)
+	interval, start, end, err := c.parseBalanceHistoryRange(ctx, timezone)
+	if err != nil {
+		return err
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	links, err := repo.GetLinks(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve links")
+	}
+
+	bankAccounts, err := repo.GetBankAccounts(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve bank accounts")
+	}
+
+	manualAssets, err := repo.GetManualAssets(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve manual assets")
+	}
+
+	// Balances are carried forward until a newer snapshot is recorded, so the latest snapshot of each bank account from
+	// before the start is needed too.
+	snapshots, err := repo.GetLatestBalanceSnapshotsBefore(c.getContext(ctx), start)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve balance history")
+	}
+
+	inRange, err := repo.GetBalanceSnapshotsByDateRange(c.getContext(ctx), start, end)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve balance history")
+	}
+	snapshots = append(snapshots, inRange...)
+
+	// Values are carried forward until a newer value is recorded, so values from before the start are needed too.
+	values, err := repo.GetManualAssetValuesBefore(c.getContext(ctx), end)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve manual asset values")
+	}
+
+	activeBankAccounts := make([]models.BankAccount, 0, len(bankAccounts))
+	for _, bankAccount := range bankAccounts {
+		if bankAccount.Status == models.ActiveBankAccountStatus {
+			activeBankAccounts = append(activeBankAccounts, bankAccount)
+		}
+	}
+
+	result := models.CalculateNetWorth(links, activeBankAccounts, manualAssets)
+	result.History = models.CalculateNetWorthHistory(
+		activeBankAccounts,
+		snapshots,
+		values,
+		interval,
+		start,
+		end,
+		timezone,
+	)
+
+	return ctx.JSON(http.StatusOK, result)
+}
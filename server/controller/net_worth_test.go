@@ -0,0 +1,133 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNetWorth(t *testing.T) {
+	t.Run("includes bank accounts and manual assets", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		checking := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		credit := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.CreditBankAccountType, models.CreditCardBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var manualAssetId uint64
+		{
+			response := e.POST("/api/manual_assets").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":         "House",
+					"assetType":    "Property",
+					"currentValue": 25000000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.assetType").String().IsEqual("property")
+			response.JSON().Path("$.currentValue").Number().IsEqual(25000000)
+			manualAssetId = uint64(response.JSON().Path("$.manualAssetId").Number().Raw())
+		}
+
+		{ // Recording a value in the past should not change the current value.
+			response := e.POST("/api/manual_assets/{manualAssetId}/values").
+				WithPath("manualAssetId", manualAssetId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"date":  app.Clock.Now().AddDate(0, -1, 0).Format("2006-01-02"),
+					"value": 24000000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.currentValue").Number().IsEqual(25000000)
+		}
+
+		{
+			response := e.GET("/api/manual_assets/{manualAssetId}/values").
+				WithPath("manualAssetId", manualAssetId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+			response.JSON().Path("$[0].value").Number().IsEqual(24000000)
+		}
+
+		{
+			response := e.GET("/api/net_worth").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.assets").Number().IsEqual(checking.CurrentBalance + 25000000)
+			response.JSON().Path("$.liabilities").Number().IsEqual(credit.CurrentBalance)
+			response.JSON().Path("$.netWorth").Number().IsEqual(checking.CurrentBalance + 25000000 - credit.CurrentBalance)
+			response.JSON().Path("$.institutions").Array().Length().IsEqual(2)
+			response.JSON().Path("$.accountTypes").Array().Length().IsEqual(3)
+			response.JSON().Path("$.history").Array().NotEmpty()
+		}
+	})
+
+	t.Run("history carries balances from before the start forward", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		checking := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		timezone, err := user.Account.GetTimezone()
+		require.NoError(t, err, "must be able to parse the account timezone")
+		today := util.Midnight(app.Clock.Now(), timezone)
+
+		repo := repository.NewRepositoryFromSession(app.Clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+		require.NoError(t, repo.UpsertBalanceSnapshots(context.Background(), []models.BalanceSnapshot{
+			{
+				BankAccountId: checking.BankAccountId,
+				Date:          today.AddDate(0, 0, -60),
+				Current:       1000,
+			},
+			{
+				BankAccountId: checking.BankAccountId,
+				Date:          today.AddDate(0, 0, -45),
+				Current:       1500,
+			},
+		}), "must be able to record snapshots")
+
+		response := e.GET("/api/net_worth").
+			WithQuery("start", today.AddDate(0, 0, -7).Format("2006-01-02")).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.history").Array().Length().IsEqual(8)
+		response.JSON().Path("$.history[0].assets").Number().IsEqual(1500)
+		response.JSON().Path("$.history[7].assets").Number().IsEqual(1500)
+	})
+
+	t.Run("invalid asset type", func(t *testing.T) {
+		_, e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.POST("/api/manual_assets").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":      "Boat",
+				"assetType": "yacht",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual(`invalid asset type "yacht", must be one of property, vehicle or other`)
+	})
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+)
+
+// NextCursorHeader is set on responses from list endpoints that support cursor pagination when there are more items
+// after the ones returned. The value should be provided as the `cursor` query parameter to retrieve the next page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// parseCursor reads the `cursor` query parameter if one was provided. Cursors and offsets cannot be used together since
+// the offset would be applied after the cursor.
+func (c *Controller) parseCursor(ctx echo.Context) (*repository.Cursor, error) {
+	value := strings.TrimSpace(ctx.QueryParam("cursor"))
+	if value == "" {
+		return nil, nil
+	}
+
+	if urlParamIntDefault(ctx, "offset", 0) != 0 {
+		return nil, errors.New("cursor and offset cannot be used together")
+	}
+
+	return repository.ParseCursor(value)
+}
+
+// setNextCursor will set the next cursor header on the response if the next cursor is not nil. List endpoints request
+// one more item than the limit to determine whether there is another page, that item is not returned, but the cursor is
+// derived from the last item that is returned.
+func setNextCursor(ctx echo.Context, next *repository.Cursor) {
+	if next == nil {
+		return
+	}
+
+	ctx.Response().Header().Set(NextCursorHeader, next.String())
+}
@@ -355,6 +355,7 @@ func (c *Controller) updatePlaidTokenCallback(ctx echo.Context) error {
 				PlaidOfficialName: plaidAccount.GetOfficialName(),
 				Type:              models.BankAccountType(plaidAccount.GetType()),
 				SubType:           models.BankAccountSubType(plaidAccount.GetSubType()),
+				Currency:          plaidAccount.GetBalances().GetIsoCurrencyCode(),
 				LastUpdated:       now,
 			}
 		}
@@ -511,6 +512,7 @@ func (c *Controller) plaidTokenCallback(ctx echo.Context) error {
 			PlaidOfficialName: plaidAccount.GetOfficialName(),
 			Type:              models.BankAccountType(plaidAccount.GetType()),
 			SubType:           models.BankAccountSubType(plaidAccount.GetSubType()),
+			Currency:          plaidAccount.GetBalances().GetIsoCurrencyCode(),
 			LastUpdated:       now,
 		}
 	}
@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+)
+
+type ReconciliationRequest struct {
+	// StatementDate is the last day included in the statement in the format YYYY-MM-DD. It is required.
+	StatementDate string `json:"statementDate"`
+	// StatementBalance is the balance shown on the statement in cents.
+	StatementBalance int64 `json:"statementBalance"`
+	// CreateAdjustment will create a transaction on the statement date for the difference, if there is one. This is
+	// ignored when previewing a reconciliation.
+	CreateAdjustment bool `json:"createAdjustment"`
+}
+
+// List Reconciliations
+// @Summary List Reconciliations
+// @id list-reconciliations
+// @tags Reconciliations
+// @description List the reconciliations of a manual bank account, with the most recent statement date first.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param limit query int false "Maximum number of reconciliations to return, defaults to 25."
+// @Param offset query int false "Number of reconciliations to skip."
+// @Router /bank_accounts/{bankAccountId}/reconciliations [get]
+// @Success 200 {array} models.Reconciliation
+// @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getReconciliations(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	offset := urlParamIntDefault(ctx, "offset", 0)
+
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	if offset < 0 {
+		return c.badRequest(ctx, "offset cannot be less than 0")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	reconciliations, err := repo.GetReconciliations(c.getContext(ctx), bankAccountId, limit, offset)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve reconciliations")
+	}
+
+	return ctx.JSON(http.StatusOK, reconciliations)
+}
+
+// Preview Reconciliation
+// @Summary Preview Reconciliation
+// @id preview-reconciliation
+// @tags Reconciliations
+// @description Calculate the difference between a statement balance and the balance monetr has for a manual bank
+// @description account as of the statement date, without changing anything.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param Reconciliation body ReconciliationRequest true "Statement"
+// @Router /bank_accounts/{bankAccountId}/reconciliations/preview [post]
+// @Success 200 {object} models.Reconciliation
+// @Failure 400 {object} ApiError Invalid statement, or the bank account is not manual.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postReconciliationPreview(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	var request ReconciliationRequest
+	if err = ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	_, reconciliation, err := c.reconcileBankAccount(ctx, repo, bankAccountId, request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, reconciliation)
+}
+
+// Reconcile Bank Account
+// @Summary Reconcile Bank Account
+// @id reconcile-bank-account
+// @tags Reconciliations
+// @description Reconcile a manual bank account against a statement from the bank. The difference between the statement
+// @description balance and the balance monetr has as of the statement date is applied to the current and available
+// @description balances of the bank account, and an adjustment transaction can optionally be created for it.
+// @description Transactions on or before the statement date are locked afterwards, their amount, date and pending state
+// @description can no longer be changed and new transactions cannot be created on or before the statement date. The
+// @description statement date must be after the statement date of the last reconciliation.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param Reconciliation body ReconciliationRequest true "Statement"
+// @Router /bank_accounts/{bankAccountId}/reconciliations [post]
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ApiError Invalid statement, or the bank account is not manual.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postReconciliations(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	var request ReconciliationRequest
+	if err = ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	bankAccount, reconciliation, err := c.reconcileBankAccount(ctx, repo, bankAccountId, request)
+	if err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{}
+	if request.CreateAdjustment && reconciliation.Difference != 0 {
+		adjustment := models.Transaction{
+			BankAccountId: bankAccountId,
+			Amount:        reconciliation.AdjustmentAmount(bankAccount.Type),
+			Date:          reconciliation.StatementDate,
+			Name:          models.ReconciliationAdjustmentName,
+			OriginalName:  models.ReconciliationAdjustmentName,
+			Currency:      bankAccount.GetCurrency(),
+		}
+		if err = repo.CreateTransaction(c.getContext(ctx), bankAccountId, &adjustment); err != nil {
+			return c.wrapPgError(ctx, err, "failed to create adjustment transaction")
+		}
+		reconciliation.AdjustmentTransactionId = &adjustment.TransactionId
+		result["transaction"] = adjustment
+	}
+
+	if err = repo.CreateReconciliation(c.getContext(ctx), reconciliation); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create reconciliation")
+	}
+
+	if reconciliation.Difference != 0 {
+		bankAccount.CurrentBalance += reconciliation.Difference
+		bankAccount.AvailableBalance += reconciliation.Difference
+		if err = repo.UpdateBankAccountBalances(
+			c.getContext(ctx),
+			bankAccountId,
+			bankAccount.CurrentBalance,
+			bankAccount.AvailableBalance,
+		); err != nil {
+			return c.wrapPgError(ctx, err, "failed to update bank account balances")
+		}
+	}
+
+	result["reconciliation"] = reconciliation
+	result["bankAccount"] = bankAccount
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// reconcileBankAccount validates the statement provided for a bank account and calculates the reconciliation for it.
+// Any error returned should be returned by the caller as is.
+func (c *Controller) reconcileBankAccount(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	bankAccountId uint64,
+	request ReconciliationRequest,
+) (*models.BankAccount, *models.Reconciliation, error) {
+	isManual, err := repo.GetLinkIsManualByBankAccountId(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return nil, nil, c.wrapPgError(ctx, err, "failed to validate if link is manual")
+	}
+
+	if !isManual {
+		return nil, nil, c.badRequest(ctx, "cannot reconcile bank accounts for non-manual links")
+	}
+
+	if strings.TrimSpace(request.StatementDate) == "" {
+		return nil, nil, c.badRequest(ctx, "statement date is required")
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	statementDate, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(request.StatementDate), timezone)
+	if err != nil {
+		return nil, nil, c.badRequest(ctx, "invalid statement date, must be in the format YYYY-MM-DD")
+	}
+
+	now := c.clock.Now()
+	if statementDate.After(util.Midnight(now, timezone)) {
+		return nil, nil, c.badRequest(ctx, "statement date cannot be in the future")
+	}
+
+	latest, err := repo.GetLatestReconciliation(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return nil, nil, c.wrapPgError(ctx, err, "failed to retrieve latest reconciliation")
+	}
+
+	if latest != nil && latest.IsLocked(statementDate, timezone) {
+		return nil, nil, c.badRequest(
+			ctx,
+			"statement date must be after the last reconciliation on %s",
+			latest.StatementDate.In(timezone).Format("2006-01-02"),
+		)
+	}
+
+	bankAccount, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return nil, nil, c.wrapPgError(ctx, err, "failed to retrieve bank account")
+	}
+
+	// Only transactions up until now are reflected in the current balance, so anything dated in the future is left out.
+	transactions, err := repo.GetTransactionsByDateRange(
+		c.getContext(ctx),
+		bankAccountId,
+		statementDate.AddDate(0, 0, 1),
+		now,
+	)
+	if err != nil {
+		return nil, nil, c.wrapPgError(ctx, err, "failed to retrieve transactions since the statement date")
+	}
+
+	reconciliation := models.Reconcile(*bankAccount, transactions, statementDate, request.StatementBalance, timezone)
+
+	return bankAccount, &reconciliation, nil
+}
+
+// checkReconciliationLock returns a bad request error if any of the provided dates are on or before the statement date
+// of the last reconciliation of the bank account. Any error returned should be returned by the caller as is.
+func (c *Controller) checkReconciliationLock(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	bankAccountId uint64,
+	dates ...time.Time,
+) error {
+	latest, err := repo.GetLatestReconciliation(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve latest reconciliation")
+	}
+
+	if latest == nil {
+		return nil
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	for _, date := range dates {
+		if latest.IsLocked(date, timezone) {
+			return c.badRequest(
+				ctx,
+				"transactions on or before the last reconciliation on %s cannot be changed",
+				latest.StatementDate.In(timezone).Format("2006-01-02"),
+			)
+		}
+	}
+
+	return nil
+}
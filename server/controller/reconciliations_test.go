@@ -0,0 +1,125 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestPostReconciliations(t *testing.T) {
+	t.Run("reconcile with an adjustment", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		statementDate := app.Clock.Now().AddDate(0, 0, -5)
+		{ // A purchase after the statement date is not included in the statement balance.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(models.Transaction{
+					Name:   "Coffee",
+					Amount: 500,
+					Date:   app.Clock.Now().AddDate(0, 0, -1),
+				}).
+				Expect()
+			response.Status(http.StatusOK)
+		}
+
+		clearedBalance := bank.CurrentBalance + 500
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/reconciliations/preview").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"statementDate":    statementDate.Format("2006-01-02"),
+					"statementBalance": clearedBalance + 1000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.clearedBalance").Number().IsEqual(clearedBalance)
+			response.JSON().Path("$.difference").Number().IsEqual(1000)
+		}
+
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/reconciliations").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"statementDate":    statementDate.Format("2006-01-02"),
+					"statementBalance": clearedBalance + 1000,
+					"createAdjustment": true,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.reconciliation.difference").Number().IsEqual(1000)
+			response.JSON().Path("$.reconciliation.adjustmentTransactionId").Number().Gt(0)
+			response.JSON().Path("$.transaction.amount").Number().IsEqual(-1000)
+			response.JSON().Path("$.transaction.name").String().IsEqual(models.ReconciliationAdjustmentName)
+			response.JSON().Path("$.transaction.currency").String().IsEqual(bank.GetCurrency())
+			response.JSON().Path("$.bankAccount.currentBalance").Number().IsEqual(bank.CurrentBalance + 1000)
+		}
+
+		{ // Transactions can no longer be created on or before the statement date.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(models.Transaction{
+					Name:   "Groceries",
+					Amount: 2500,
+					Date:   statementDate.AddDate(0, 0, -1),
+				}).
+				Expect()
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().HasPrefix("transactions on or before the last reconciliation on")
+		}
+
+		{ // Reconciling again must be for a later statement.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/reconciliations").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"statementDate":    statementDate.AddDate(0, 0, -1).Format("2006-01-02"),
+					"statementBalance": 0,
+				}).
+				Expect()
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().HasPrefix("statement date must be after the last reconciliation on")
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/reconciliations").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+		}
+	})
+
+	t.Run("missing statement date", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/reconciliations").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"statementBalance": 100,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("statement date is required")
+	})
+}
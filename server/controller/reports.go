@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+)
+
+// spendingReportCacheLifetime is how long a spending report is cached for. Reports are not invalidated when
+// transactions change, so this is kept short.
+const spendingReportCacheLifetime = 5 * time.Minute
+
+// Get Spending Report
+// @Summary Get Spending Report
+// @id get-spending-report
+// @tags Reports
+// @description Get the actual income and spending of the current account for a date range, bucketed by week, month or
+// @description year. Within each period the totals are grouped by spending object, category, merchant or bank account.
+// @description Split transactions are reported under each spending object they were split between. Transfers, hidden
+// @description transactions and deleted transactions are not included. All amounts are positive, the net is the income
+// @description minus the expenses. Every period in the range is included even if it has no transactions. Reports are
+// @description cached for up to five minutes.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param groupBy query string false "One of spending, category, merchant or bank_account. Defaults to spending."
+// @Param interval query string false "One of weekly, monthly or yearly. Defaults to monthly."
+// @Param start query string false "The first day to include (YYYY-MM-DD), moved back to the start of its period. Defaults to 12 weeks, 12 months or 3 years before the end date depending on the interval."
+// @Param end query string false "The last day to include (YYYY-MM-DD). Defaults to today."
+// @Param bankAccountId query int false "Only include transactions from this bank account."
+// @Router /reports/spending [get]
+// @Success 200 {object} models.SpendingReport
+// @Failure 400 {object} ApiError Invalid report parameters.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getSpendingReport(ctx echo.Context) error {
+	groupBy := models.SpendingSpendingReportGroupBy
+	if value := strings.TrimSpace(ctx.QueryParam("groupBy")); value != "" {
+		var err error
+		groupBy, err = models.ParseSpendingReportGroupBy(value)
+		if err != nil {
+			return c.badRequest(ctx, "%s", err.Error())
+		}
+	}
+
+	options, err := c.parseSpendingReportOptions(ctx, groupBy)
+	if err != nil {
+		return err
+	}
+
+	report, err := c.getSpendingReportCached(ctx, c.mustGetAuthenticatedRepository(ctx), options)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve spending report")
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// parseSpendingReportOptions reads the interval, date range and bank account of a report from the query parameters.
+// Any error returned should be returned by the caller as is.
+func (c *Controller) parseSpendingReportOptions(
+	ctx echo.Context,
+	groupBy models.SpendingReportGroupBy,
+) (options repository.SpendingReportOptions, err error) {
+	timezone := c.mustGetTimezone(ctx)
+	options.GroupBy = groupBy
+	options.Timezone = timezone
+	options.Interval = models.MonthlySpendingReportInterval
+	if value := strings.TrimSpace(ctx.QueryParam("interval")); value != "" {
+		options.Interval, err = models.ParseSpendingReportInterval(value)
+		if err != nil {
+			return options, c.badRequest(ctx, "%s", err.Error())
+		}
+	}
+
+	options.End = util.Midnight(c.clock.Now(), timezone).AddDate(0, 0, 1)
+	if value := strings.TrimSpace(ctx.QueryParam("end")); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, timezone)
+		if err != nil {
+			return options, c.badRequest(ctx, "invalid end date, must be in the format YYYY-MM-DD")
+		}
+		options.End = date.AddDate(0, 0, 1)
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("start")); value != "" {
+		options.Start, err = time.ParseInLocation("2006-01-02", value, timezone)
+		if err != nil {
+			return options, c.badRequest(ctx, "invalid start date, must be in the format YYYY-MM-DD")
+		}
+	} else {
+		last := options.End.AddDate(0, 0, -1)
+		switch options.Interval {
+		case models.WeeklySpendingReportInterval:
+			options.Start = last.AddDate(0, 0, -7*11)
+		case models.YearlySpendingReportInterval:
+			options.Start = last.AddDate(-2, 0, 0)
+		default:
+			options.Start = last.AddDate(0, -11, 0)
+		}
+	}
+	options.Start = options.Interval.Start(options.Start, timezone)
+	if !options.Start.Before(options.End) {
+		return options, c.badRequest(ctx, "start date must be before the end date")
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("bankAccountId")); value != "" {
+		bankAccountId, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || bankAccountId == 0 {
+			return options, c.badRequest(ctx, "must specify a valid bank account Id")
+		}
+		options.BankAccountId = &bankAccountId
+	}
+
+	return options, nil
+}
+
+// getSpendingReportCached returns the spending report for the provided options from the cache, or builds it and stores
+// it in the cache if it is not there. Failing to read or write the cache is not treated as an error.
+func (c *Controller) getSpendingReportCached(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	options repository.SpendingReportOptions,
+) (*models.SpendingReport, error) {
+	bankAccount := "all"
+	if options.BankAccountId != nil {
+		bankAccount = strconv.FormatUint(*options.BankAccountId, 10)
+	}
+	key := fmt.Sprintf(
+		"reports:spending:%d:%s:%s:%s:%s:%s:%s",
+		c.mustGetAccountId(ctx),
+		options.GroupBy,
+		options.Interval,
+		options.Start.Format("2006-01-02"),
+		options.End.Format("2006-01-02"),
+		options.Timezone.String(),
+		bankAccount,
+	)
+
+	log := c.getLog(ctx).WithField("key", key)
+
+	var cached *models.SpendingReport
+	if err := c.cache.GetEz(c.getContext(ctx), key, &cached); err != nil {
+		log.WithError(err).Warn("failed to retrieve spending report from cache")
+	} else if cached != nil {
+		// Times lose their location when they are cached.
+		cached.Start = cached.Start.In(options.Timezone)
+		cached.End = cached.End.In(options.Timezone)
+		for i := range cached.Periods {
+			cached.Periods[i].Date = cached.Periods[i].Date.In(options.Timezone)
+		}
+		return cached, nil
+	}
+
+	rows, err := repo.GetSpendingReport(c.getContext(ctx), options)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.BuildSpendingReport(
+		rows,
+		options.Interval,
+		options.GroupBy,
+		options.Start,
+		options.End,
+		options.Timezone,
+	)
+
+	if err = c.cache.SetEzTTL(c.getContext(ctx), key, report, spendingReportCacheLifetime); err != nil {
+		log.WithError(err).Warn("failed to store spending report in cache")
+	}
+
+	return &report, nil
+}
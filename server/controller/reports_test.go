@@ -0,0 +1,71 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSpendingReport(t *testing.T) {
+	t.Run("monthly by bank account", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		checking := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		savings := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.SavingsBankAccountSubType)
+		fixtures.GivenIHaveATransfer(t, app.Clock, checking, savings, 2500)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		timezone, err := user.Account.GetTimezone()
+		require.NoError(t, err, "must be able to parse the account timezone")
+		today := util.Midnight(app.Clock.Now(), timezone)
+		repo := repository.NewRepositoryFromSession(app.Clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+		for _, transaction := range []models.Transaction{
+			{Name: "Paycheck", Amount: -100000},
+			{Name: "Groceries", Amount: 4000},
+			{Name: "Coffee", Amount: 500},
+			{Name: "Hidden", Amount: 700, IsHidden: true},
+		} {
+			transaction.BankAccountId = checking.BankAccountId
+			transaction.OriginalName = transaction.Name
+			transaction.Date = today
+			require.NoError(t, repo.CreateTransaction(context.Background(), checking.BankAccountId, &transaction), "must be able to seed transaction")
+		}
+
+		response := e.GET("/api/reports/spending").
+			WithQuery("groupBy", "bank_account").
+			WithQuery("interval", "monthly").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.income").Number().IsEqual(100000)
+		response.JSON().Path("$.expenses").Number().IsEqual(4500)
+		response.JSON().Path("$.net").Number().IsEqual(95500)
+		response.JSON().Path("$.periods").Array().Length().IsEqual(12)
+		response.JSON().Path("$.periods[11].groups").Array().Length().IsEqual(1)
+		response.JSON().Path("$.periods[11].groups[0].id").Number().IsEqual(checking.BankAccountId)
+		response.JSON().Path("$.periods[11].groups[0].transactionCount").Number().IsEqual(3)
+	})
+
+	t.Run("invalid group", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/reports/spending").
+			WithQuery("groupBy", "payee").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual(`invalid group "payee", must be one of spending, category, merchant or bank_account`)
+	})
+}
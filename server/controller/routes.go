@@ -228,6 +228,8 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	billed.POST("/icons/search", c.searchIcon)
 	// Account
 	billed.GET("/account/settings", c.getAccountSettings)
+	billed.GET("/account/export", c.getAccountExport)
+	billed.POST("/import/budget", c.postImportBudget)
 	billed.DELETE("/account", c.deleteAccount)
 	// Links
 	billed.GET("/links", c.getLinks)
@@ -244,16 +246,61 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	billed.GET("/bank_accounts/:bankAccountId", c.getBankAccount)
 	billed.PUT("/bank_accounts/:bankAccountId", c.putBankAccounts)
 	billed.GET("/bank_accounts/:bankAccountId/balances", c.getBalances)
+	billed.GET("/bank_accounts/:bankAccountId/balances/history", c.getBalanceHistory)
 	billed.POST("/bank_accounts", c.postBankAccounts)
 	// Transactions
 	billed.GET("/bank_accounts/:bankAccountId/transactions", c.getTransactions)
+	billed.GET("/bank_accounts/:bankAccountId/transactions/export", c.getTransactionsExport)
 	billed.GET("/bank_accounts/:bankAccountId/transactions/:transactionId", c.getTransactionById)
 	billed.GET("/bank_accounts/:bankAccountId/transactions/spending/:spendingId", c.getTransactionsForSpending)
 	billed.POST("/bank_accounts/:bankAccountId/transactions", c.postTransactions)
+	billed.PUT("/bank_accounts/:bankAccountId/transactions/bulk", c.putTransactionsBulk)
 	billed.PUT("/bank_accounts/:bankAccountId/transactions/:transactionId", c.putTransactions)
 	billed.DELETE("/bank_accounts/:bankAccountId/transactions/:transactionId", c.deleteTransactions)
+	billed.POST("/bank_accounts/:bankAccountId/transactions/:transactionId/transfer/confirm", c.postConfirmTransfer)
+	billed.DELETE("/bank_accounts/:bankAccountId/transactions/:transactionId/transfer", c.deleteTransfer)
+	billed.GET("/bank_accounts/:bankAccountId/transactions/:transactionId/attachments", c.getTransactionAttachments)
+	billed.POST("/bank_accounts/:bankAccountId/transactions/:transactionId/attachments", c.postTransactionAttachments)
+	billed.GET("/bank_accounts/:bankAccountId/transactions/:transactionId/attachments/:fileId", c.getTransactionAttachment)
+	billed.DELETE("/bank_accounts/:bankAccountId/transactions/:transactionId/attachments/:fileId", c.deleteTransactionAttachment)
 	// Uploads
 	billed.POST("/bank_accounts/:bankAccountId/upload/transactions", c.postUploadTransactions)
+	billed.GET("/bank_accounts/:bankAccountId/upload/csv/mapping", c.getCSVMapping)
+	billed.PUT("/bank_accounts/:bankAccountId/upload/csv/mapping", c.putCSVMapping)
+	billed.POST("/bank_accounts/:bankAccountId/upload/csv/preview", c.postCSVPreview)
+	// Reconciliations
+	billed.GET("/bank_accounts/:bankAccountId/reconciliations", c.getReconciliations)
+	billed.POST("/bank_accounts/:bankAccountId/reconciliations", c.postReconciliations)
+	billed.POST("/bank_accounts/:bankAccountId/reconciliations/preview", c.postReconciliationPreview)
+	// Categories
+	billed.GET("/categories", c.getCategories)
+	billed.POST("/categories", c.postCategories)
+	billed.GET("/categories/mappings", c.getCategoryMappings)
+	billed.PUT("/categories/mappings", c.putCategoryMapping)
+	billed.DELETE("/categories/mappings", c.deleteCategoryMapping)
+	billed.PUT("/categories/:categoryId", c.putCategories)
+	billed.DELETE("/categories/:categoryId", c.deleteCategories)
+	// Reports
+	billed.GET("/reports/spending", c.getSpendingReport)
+	billed.GET("/reports/budget", c.getBudgetReport)
+	// Files
+	billed.GET("/bank_accounts/:bankAccountId/files", c.getFiles)
+	billed.GET("/bank_accounts/:bankAccountId/files/:fileId/download", c.getFileDownload)
+	// Transaction rules
+	billed.GET("/transaction_rules", c.getTransactionRules)
+	billed.POST("/transaction_rules", c.postTransactionRules)
+	billed.POST("/transaction_rules/preview", c.postTransactionRulePreview)
+	billed.PUT("/transaction_rules/:transactionRuleId", c.putTransactionRules)
+	billed.DELETE("/transaction_rules/:transactionRuleId", c.deleteTransactionRules)
+	billed.POST("/transaction_rules/:transactionRuleId/apply", c.postTransactionRuleApply)
+	// Net worth
+	billed.GET("/net_worth", c.getNetWorth)
+	billed.GET("/manual_assets", c.getManualAssets)
+	billed.POST("/manual_assets", c.postManualAssets)
+	billed.PUT("/manual_assets/:manualAssetId", c.putManualAssets)
+	billed.DELETE("/manual_assets/:manualAssetId", c.deleteManualAssets)
+	billed.GET("/manual_assets/:manualAssetId/values", c.getManualAssetValues)
+	billed.POST("/manual_assets/:manualAssetId/values", c.postManualAssetValue)
 	// Funding schedules
 	billed.GET("/bank_accounts/:bankAccountId/funding_schedules", c.getFundingSchedules)
 	billed.GET("/bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId", c.getFundingScheduleById)
@@ -261,6 +308,8 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	billed.POST("/bank_accounts/:bankAccountId/funding_schedules", c.postFundingSchedules)
 	billed.PUT("/bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId", c.putFundingSchedules)
 	billed.DELETE("/bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId", c.deleteFundingSchedules)
+	billed.GET("/bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId/events", c.getFundingEvents)
+	billed.POST("/bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId/events/:fundingEventId/revert", c.postRevertFundingEvent)
 	// Spending
 	billed.GET("/bank_accounts/:bankAccountId/spending", c.getSpending)
 	billed.GET("/bank_accounts/:bankAccountId/spending/:spendingId", c.getSpendingById)
@@ -268,6 +317,11 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	billed.POST("/bank_accounts/:bankAccountId/spending/transfer", c.postSpendingTransfer)
 	billed.PUT("/bank_accounts/:bankAccountId/spending/:spendingId", c.putSpending)
 	billed.DELETE("/bank_accounts/:bankAccountId/spending/:spendingId", c.deleteSpending)
+	billed.GET("/bank_accounts/:bankAccountId/spending/:spendingId/budget", c.getSpendingBudget)
+	billed.GET("/bank_accounts/:bankAccountId/spending/:spendingId/ledger", c.getSpendingLedger)
+	billed.GET("/bank_accounts/:bankAccountId/spending/suggestions", c.getSpendingSuggestions)
+	billed.POST("/bank_accounts/:bankAccountId/spending/suggestions/:spendingSuggestionId/accept", c.postAcceptSpendingSuggestion)
+	billed.POST("/bank_accounts/:bankAccountId/spending/suggestions/:spendingSuggestionId/dismiss", c.postDismissSpendingSuggestion)
 	// Forecasting
 	billed.GET("/bank_accounts/:bankAccountId/forecast", c.getForecast)
 	billed.POST("/bank_accounts/:bankAccountId/forecast/spending", c.postForecastNewSpending)
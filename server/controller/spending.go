@@ -8,6 +8,7 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/labstack/echo/v4"
 	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
 )
 
 // List Spending
@@ -95,8 +96,21 @@ func (c *Controller) postSpending(ctx echo.Context) error {
 		return c.invalidJson(ctx)
 	}
 
-	spending.SpendingId = 0 // Make sure we create a new spending.
 	spending.BankAccountId = bankAccountId
+	if err = c.createSpending(ctx, spending); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, spending)
+}
+
+// createSpending validates the provided spending object and then creates it. The spending object must already have
+// its bank account Id set. The returned error is meant to be returned to the client as is.
+func (c *Controller) createSpending(ctx echo.Context, spending *models.Spending) error {
+	requestSpan := c.getSpan(ctx)
+	bankAccountId := spending.BankAccountId
+
+	spending.SpendingId = 0 // Make sure we create a new spending.
 	spending.Name = strings.TrimSpace(spending.Name)
 	spending.Description = strings.TrimSpace(spending.Description)
 
@@ -151,6 +165,13 @@ func (c *Controller) postSpending(ctx echo.Context) error {
 		}
 	}
 
+	// The carried deficit is maintained by the API as the expense recurs.
+	spending.CarriedDeficit = 0
+	if err = c.validateRolloverPolicy(ctx, repo, spending); err != nil {
+		requestSpan.Status = sentry.SpanStatusInvalidArgument
+		return err
+	}
+
 	// Make sure that the next recurrence date is properly in the user's timezone.
 	nextRecurrence, err := c.midnightInLocal(ctx, next)
 	if err != nil {
@@ -176,7 +197,47 @@ func (c *Controller) postSpending(ctx echo.Context) error {
 		return c.wrapPgError(ctx, err, "failed to create spending")
 	}
 
-	return ctx.JSON(http.StatusOK, spending)
+	return nil
+}
+
+// validateRolloverPolicy makes sure the rollover policy of the spending object is valid, and that the spending object
+// it sweeps to exists if it sweeps. Only expenses can have a rollover policy other than the default. Any error returned
+// should be returned to the client as is.
+func (c *Controller) validateRolloverPolicy(ctx echo.Context, repo repository.BaseRepository, spending *models.Spending) error {
+	policy, err := models.ParseSpendingRolloverPolicy(string(spending.RolloverPolicy))
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+	spending.RolloverPolicy = policy
+
+	if spending.SpendingType != models.SpendingTypeExpense && policy != models.SpendingRolloverPolicyRollover {
+		return c.badRequest(ctx, "only expenses can have a rollover policy")
+	}
+
+	if policy != models.SpendingRolloverPolicySweep {
+		spending.SweepSpendingId = nil
+		return nil
+	}
+
+	if spending.SweepSpendingId == nil || *spending.SweepSpendingId == 0 {
+		return c.badRequest(ctx, "must specify a goal or overflow spending object to sweep to")
+	}
+
+	if *spending.SweepSpendingId == spending.SpendingId {
+		return c.badRequest(ctx, "spending cannot sweep to itself")
+	}
+
+	target, err := repo.GetSpendingById(c.getContext(ctx), spending.BankAccountId, *spending.SweepSpendingId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not find spending to sweep to")
+	}
+
+	switch target.SpendingType {
+	case models.SpendingTypeGoal, models.SpendingTypeOverflow:
+		return nil
+	default:
+		return c.badRequest(ctx, "can only sweep to a goal or overflow spending object")
+	}
 }
 
 type SpendingTransfer struct {
@@ -299,7 +360,13 @@ func (c *Controller) postSpendingTransfer(ctx echo.Context) error {
 		spendingToUpdate = append(spendingToUpdate, *toExpense)
 	}
 
-	if err = repo.UpdateSpending(c.getContext(ctx), bankAccountId, spendingToUpdate); err != nil {
+	if err = repo.UpdateSpending(
+		c.getContext(ctx),
+		bankAccountId,
+		spendingToUpdate,
+		models.SpendingLedgerReasonTransfer,
+		nil,
+	); err != nil {
 		return c.wrapPgError(ctx, err, "failed to update spending for transfer")
 	}
 
@@ -369,11 +436,26 @@ func (c *Controller) putSpending(ctx echo.Context) error {
 	updatedSpending.IsBehind = existingSpending.IsBehind
 	updatedSpending.LastRecurrence = existingSpending.LastRecurrence
 	updatedSpending.NextContributionAmount = existingSpending.NextContributionAmount
+	updatedSpending.CarriedDeficit = existingSpending.CarriedDeficit
+
+	// Clients that do not know about rollover policies will not send one, keep the existing policy for them.
+	if updatedSpending.RolloverPolicy == "" {
+		updatedSpending.RolloverPolicy = existingSpending.RolloverPolicy
+		updatedSpending.SweepSpendingId = existingSpending.SweepSpendingId
+	}
 
 	if updatedSpending.SpendingType == models.SpendingTypeGoal {
 		updatedSpending.RuleSet = nil
 	}
 
+	if err = c.validateRolloverPolicy(ctx, repo, updatedSpending); err != nil {
+		return err
+	}
+
+	if updatedSpending.RolloverPolicy != models.SpendingRolloverPolicyCarryDeficit {
+		updatedSpending.CarriedDeficit = 0
+	}
+
 	recalculateSpending := false
 	if updatedSpending.NextRecurrence != existingSpending.NextRecurrence {
 		newNext, err := c.midnightInLocal(ctx, updatedSpending.NextRecurrence)
@@ -395,6 +477,11 @@ func (c *Controller) putSpending(ctx echo.Context) error {
 		recalculateSpending = updatedSpending.RuleSet.String() == existingSpending.RuleSet.String()
 	}
 
+	// A deficit that is no longer carried changes how much needs to be contributed.
+	if updatedSpending.GetCarriedDeficit() != existingSpending.GetCarriedDeficit() {
+		recalculateSpending = true
+	}
+
 	// If the paused status of a spending object changes, recalculate the contributions.
 	if !updatedSpending.IsPaused && existingSpending.IsPaused {
 		recalculateSpending = true
@@ -425,9 +512,13 @@ func (c *Controller) putSpending(ctx echo.Context) error {
 		}
 	}
 
-	if err = repo.UpdateSpending(c.getContext(ctx), bankAccountId, []models.Spending{
-		*updatedSpending,
-	}); err != nil {
+	if err = repo.UpdateSpending(
+		c.getContext(ctx),
+		bankAccountId,
+		[]models.Spending{*updatedSpending},
+		models.SpendingLedgerReasonManual,
+		nil,
+	); err != nil {
 		return c.wrapPgError(ctx, err, "failed to update spending")
 	}
 
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+)
+
+// Get Spending Budget
+// @Summary Get Spending Budget
+// @id get-spending-budget
+// @tags Spending
+// @description Compare how much was budgeted for an expense against how much was actually spent from it, for each of its
+// @description recent recurrence periods. A period starts on one recurrence of the expense and ends on the next, the
+// @description last period is the current one and is not complete. The budgeted amount is the current target amount
+// @description of the expense. Transactions spent from the expense count their full amount, split transactions count
+// @description the amount of the split. Periods are returned oldest first. Goals do not have recurrence periods and
+// @description will always return an empty list.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param spendingId path int true "Spending ID"
+// @Param periods query int false "The number of periods to include, including the current period. Defaults to 6."
+// @Router /bank_accounts/{bankAccountId}/spending/{spendingId}/budget [get]
+// @Success 200 {array} models.SpendingBudgetPeriod
+// @Failure 400 {object} ApiError Invalid request.
+// @Failure 404 {object} ApiError The spending object does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getSpendingBudget(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	spendingId, err := strconv.ParseUint(ctx.Param("spendingId"), 10, 64)
+	if err != nil || spendingId == 0 {
+		return c.badRequest(ctx, "must specify a valid spending Id")
+	}
+
+	count, err := c.parseSpendingBudgetPeriods(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	spending, err := repo.GetSpendingById(c.getContext(ctx), bankAccountId, spendingId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not retrieve spending")
+	}
+
+	budgets, err := c.calculateSpendingBudgets(ctx, repo, bankAccountId, []models.Spending{*spending}, count)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, budgets[spendingId])
+}
+
+// Get Budget Report
+// @Summary Get Budget Report
+// @id get-budget-report
+// @tags Reports
+// @description Summarize budgeted vs actual spending for every expense in the current account. For each expense the
+// @description complete recurrence periods are summarized by the average and maximum amount spent, and the number of
+// @description periods that ended over budget. The current period is included separately. This can be used to tune the
+// @description target amounts of expenses based on what is actually spent. Goals are not included.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param periods query int false "The number of periods to include for each expense, including the current period. Defaults to 6."
+// @Router /reports/budget [get]
+// @Success 200 {array} models.SpendingBudgetSummary
+// @Failure 400 {object} ApiError Invalid request.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getBudgetReport(ctx echo.Context) error {
+	count, err := c.parseSpendingBudgetPeriods(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	bankAccounts, err := repo.GetBankAccounts(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve bank accounts")
+	}
+
+	summaries := make([]models.SpendingBudgetSummary, 0)
+	for _, bankAccount := range bankAccounts {
+		spending, err := repo.GetSpending(c.getContext(ctx), bankAccount.BankAccountId)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve spending")
+		}
+
+		expenses := make([]models.Spending, 0, len(spending))
+		for _, item := range spending {
+			if item.SpendingType == models.SpendingTypeExpense {
+				expenses = append(expenses, item)
+			}
+		}
+
+		budgets, err := c.calculateSpendingBudgets(ctx, repo, bankAccount.BankAccountId, expenses, count)
+		if err != nil {
+			return err
+		}
+
+		for _, expense := range expenses {
+			summaries = append(summaries, models.SummarizeSpendingBudget(expense, budgets[expense.SpendingId]))
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, summaries)
+}
+
+// parseSpendingBudgetPeriods reads the number of periods to include from the query parameters. Any error returned
+// should be returned by the caller as is.
+func (c *Controller) parseSpendingBudgetPeriods(ctx echo.Context) (int, error) {
+	count := urlParamIntDefault(ctx, "periods", 6)
+	if count < 1 {
+		return count, c.badRequest(ctx, "periods must be at least 1")
+	} else if count > 24 {
+		return count, c.badRequest(ctx, "periods cannot be greater than 24")
+	}
+
+	return count, nil
+}
+
+// calculateSpendingBudgets returns the budget vs actual periods for each of the provided spending objects, keyed by
+// their spending Id. The actuals for all of them are retrieved at once. Any error returned should be returned by the
+// caller as is.
+func (c *Controller) calculateSpendingBudgets(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	bankAccountId uint64,
+	spending []models.Spending,
+	count int,
+) (map[uint64][]models.SpendingBudgetPeriod, error) {
+	now := c.clock.Now()
+	timezone := c.mustGetTimezone(ctx)
+	periods := make(map[uint64][][2]time.Time, len(spending))
+	spendingIds := make([]uint64, 0, len(spending))
+	var start, end time.Time
+	for _, item := range spending {
+		itemPeriods := item.GetBudgetPeriods(count, now, timezone)
+		periods[item.SpendingId] = itemPeriods
+		if len(itemPeriods) == 0 {
+			continue
+		}
+
+		spendingIds = append(spendingIds, item.SpendingId)
+		if first := itemPeriods[0][0]; start.IsZero() || first.Before(start) {
+			start = first
+		}
+		if last := itemPeriods[len(itemPeriods)-1][1]; last.After(end) {
+			end = last
+		}
+	}
+
+	actuals, err := repo.GetSpendingActuals(c.getContext(ctx), bankAccountId, spendingIds, start, end)
+	if err != nil {
+		return nil, c.wrapPgError(ctx, err, "failed to retrieve spending actuals")
+	}
+
+	result := make(map[uint64][]models.SpendingBudgetPeriod, len(spending))
+	for _, item := range spending {
+		result[item.SpendingId] = models.CalculateSpendingBudget(item, periods[item.SpendingId], actuals, now)
+	}
+
+	return result, nil
+}
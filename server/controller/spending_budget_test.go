@@ -0,0 +1,71 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestGetSpendingBudget(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 1000)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	{
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(models.Transaction{
+				Name:       "Groceries",
+				Amount:     expense.TargetAmount + 500,
+				Date:       app.Clock.Now(),
+				SpendingId: &expense.SpendingId,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+	}
+
+	{
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/budget").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", expense.SpendingId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].budgeted").Number().IsEqual(expense.TargetAmount)
+		response.JSON().Path("$[0].spent").Number().IsEqual(expense.TargetAmount + 500)
+		response.JSON().Path("$[0].remaining").Number().IsEqual(-500)
+		response.JSON().Path("$[0].isOverBudget").Boolean().IsTrue()
+		response.JSON().Path("$[0].isComplete").Boolean().IsFalse()
+	}
+
+	{
+		response := e.GET("/api/reports/budget").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].spendingId").Number().IsEqual(expense.SpendingId)
+		response.JSON().Path("$[0].completePeriods").Number().IsEqual(0)
+		response.JSON().Path("$[0].current.spent").Number().IsEqual(expense.TargetAmount + 500)
+	}
+
+	{ // Periods are limited.
+		response := e.GET("/api/reports/budget").
+			WithQuery("periods", 25).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("periods cannot be greater than 24")
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/repository"
+)
+
+// Get Spending Ledger
+// @Summary Get Spending Ledger
+// @id get-spending-ledger
+// @tags Spending
+// @description Retrieve the history of changes to the balance of a spending object, newest first. An entry is recorded
+// @description whenever the current or used amount of the spending object changes, along with the reason for the
+// @description change. For funding the reference Id is the funding event, for transactions it is the transaction if
+// @description only a single transaction was changed.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param spendingId path int true "Spending ID"
+// @Param limit query int false "Specifies the number of entries to return in the result, default is 25. Max is 100."
+// @Param offset query int false "The number of entries to skip before returning any."
+// @Param cursor query string false "Return the page of entries after this cursor, taken from the `X-Next-Cursor` header of the previous page. Cannot be used with offset."
+// @Router /bank_accounts/{bankAccountId}/spending/{spendingId}/ledger [get]
+// @Success 200 {array} models.SpendingLedgerEntry
+// @Header 200 {string} X-Next-Cursor "The cursor for the next page, only present if there are more entries."
+// @Failure 400 {object} ApiError Invalid request.
+// @Failure 404 {object} ApiError The spending object does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getSpendingLedger(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	spendingId, err := strconv.ParseUint(ctx.Param("spendingId"), 10, 64)
+	if err != nil || spendingId == 0 {
+		return c.badRequest(ctx, "must specify a valid spending Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	offset := urlParamIntDefault(ctx, "offset", 0)
+
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	if offset < 0 {
+		return c.badRequest(ctx, "offset cannot be less than 0")
+	}
+
+	after, err := c.parseCursor(ctx)
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	exists, err := repo.GetSpendingExists(c.getContext(ctx), bankAccountId, spendingId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to verify spending exists")
+	}
+
+	if !exists {
+		return c.notFound(ctx, "spending does not exist")
+	}
+
+	// Retrieve one extra entry to know whether there is another page.
+	entries, err := repo.GetSpendingLedger(c.getContext(ctx), bankAccountId, spendingId, limit+1, offset, after)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve spending ledger")
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		setNextCursor(ctx, repository.NewCursor(last.CreatedAt, last.SpendingLedgerEntryId))
+	}
+
+	return ctx.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,116 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/controller"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestGetSpendingLedger(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 1000)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	var transactionId uint64
+	{ // Spending from the expense is recorded.
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(models.Transaction{
+				Name:       "Groceries",
+				Amount:     300,
+				Date:       app.Clock.Now(),
+				SpendingId: &expense.SpendingId,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+		transactionId = uint64(response.JSON().Path("$.transaction.transactionId").Number().Raw())
+	}
+
+	{ // Transferring funds back to safe to spend is recorded.
+		response := e.POST("/api/bank_accounts/{bankAccountId}/spending/transfer").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(controller.SpendingTransfer{
+				FromSpendingId: &expense.SpendingId,
+				Amount:         200,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+	}
+
+	{ // Editing the spending without changing its balance does not record anything.
+		expense.Name = "Renamed"
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/spending/{spendingId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", expense.SpendingId).
+			WithCookie(TestCookieName, token).
+			WithJSON(expense).
+			Expect()
+		response.Status(http.StatusOK)
+	}
+
+	{
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/ledger").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", expense.SpendingId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.Header("X-Next-Cursor").IsEmpty()
+		response.JSON().Array().Length().IsEqual(2)
+		response.JSON().Path("$[0].reason").String().IsEqual(string(models.SpendingLedgerReasonTransfer))
+		response.JSON().Path("$[0].amount").Number().IsEqual(-200)
+		response.JSON().Path("$[0].currentAmount").Number().IsEqual(500)
+		response.JSON().Path("$[0].referenceId").IsNull()
+		response.JSON().Path("$[1].reason").String().IsEqual(string(models.SpendingLedgerReasonTransaction))
+		response.JSON().Path("$[1].amount").Number().IsEqual(-300)
+		response.JSON().Path("$[1].currentAmount").Number().IsEqual(700)
+		response.JSON().Path("$[1].referenceId").Number().IsEqual(transactionId)
+	}
+
+	{ // Page through the ledger one entry at a time.
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/ledger").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", expense.SpendingId).
+			WithQuery("limit", 1).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].reason").String().IsEqual(string(models.SpendingLedgerReasonTransfer))
+		cursor := response.Header("X-Next-Cursor").Raw()
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/ledger").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", expense.SpendingId).
+			WithQuery("limit", 1).
+			WithQuery("cursor", cursor).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].reason").String().IsEqual(string(models.SpendingLedgerReasonTransaction))
+	}
+
+	{ // Spending that does not exist.
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/ledger").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", expense.SpendingId+100).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+		response.JSON().Path("$.error").String().IsEqual("spending does not exist")
+	}
+}
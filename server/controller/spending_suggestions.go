@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+)
+
+type AcceptSpendingSuggestionRequest struct {
+	// FundingScheduleId is the funding schedule that will contribute to the new expense. It is required.
+	FundingScheduleId uint64 `json:"fundingScheduleId"`
+	// Name and TargetAmount can be provided to override what was suggested.
+	Name         *string `json:"name"`
+	TargetAmount *int64  `json:"targetAmount"`
+}
+
+// List Spending Suggestions
+// @Summary List Spending Suggestions
+// @ID list-spending-suggestions
+// @tags Spending
+// @description List the expenses that monetr suggests creating for a bank account. Suggestions are generated in the
+// @description background from recurring transactions in the bank account's history.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Router /bank_accounts/{bankAccountId}/spending/suggestions [get]
+// @Success 200 {array} models.SpendingSuggestion
+// @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getSpendingSuggestions(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	suggestions, err := repo.GetSpendingSuggestions(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve spending suggestions")
+	}
+
+	return ctx.JSON(http.StatusOK, suggestions)
+}
+
+// Accept Spending Suggestion
+// @Summary Accept Spending Suggestion
+// @ID accept-spending-suggestion
+// @tags Spending
+// @description Create an expense from a spending suggestion. The expense is validated the same way as any other new
+// @description expense. The name and target amount of the suggestion can be overridden.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param spendingSuggestionId path int true "Spending Suggestion ID"
+// @Param Suggestion body AcceptSpendingSuggestionRequest true "Accept suggestion"
+// @Router /bank_accounts/{bankAccountId}/spending/suggestions/{spendingSuggestionId}/accept [post]
+// @Success 200 {object} models.Spending
+// @Failure 400 {object} ApiError The suggestion has already been accepted or dismissed, or the expense is not valid.
+// @Failure 404 {object} ApiError The suggestion does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postAcceptSpendingSuggestion(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	spendingSuggestionId, err := strconv.ParseUint(ctx.Param("spendingSuggestionId"), 10, 64)
+	if err != nil || spendingSuggestionId == 0 {
+		return c.badRequest(ctx, "must specify a valid spending suggestion Id")
+	}
+
+	var request AcceptSpendingSuggestionRequest
+	if err = ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if request.FundingScheduleId == 0 {
+		return c.badRequest(ctx, "must specify a funding schedule")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	suggestion, err := repo.GetSpendingSuggestion(c.getContext(ctx), bankAccountId, spendingSuggestionId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve spending suggestion")
+	}
+
+	if suggestion.Status != models.SpendingSuggestionStatusPending {
+		return c.badRequest(ctx, "spending suggestion has already been %s", suggestion.Status)
+	}
+
+	spending := &models.Spending{
+		BankAccountId:     bankAccountId,
+		FundingScheduleId: request.FundingScheduleId,
+		SpendingType:      models.SpendingTypeExpense,
+		Name:              suggestion.Name,
+		TargetAmount:      suggestion.TargetAmount,
+		RuleSet:           suggestion.RuleSet,
+		// The suggestion might have been made a while ago, so use the next recurrence from right now.
+		NextRecurrence: suggestion.RuleSet.After(c.clock.Now(), false),
+	}
+	if request.Name != nil {
+		spending.Name = strings.TrimSpace(*request.Name)
+	}
+	if request.TargetAmount != nil {
+		spending.TargetAmount = *request.TargetAmount
+	}
+
+	if err = c.createSpending(ctx, spending); err != nil {
+		return err
+	}
+
+	suggestion.Status = models.SpendingSuggestionStatusAccepted
+	suggestion.SpendingId = &spending.SpendingId
+	if err = repo.UpdateSpendingSuggestion(c.getContext(ctx), suggestion); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update spending suggestion")
+	}
+
+	return ctx.JSON(http.StatusOK, spending)
+}
+
+// Dismiss Spending Suggestion
+// @Summary Dismiss Spending Suggestion
+// @ID dismiss-spending-suggestion
+// @tags Spending
+// @description Dismiss a spending suggestion, it will not be suggested again.
+// @Security ApiKeyAuth
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param spendingSuggestionId path int true "Spending Suggestion ID"
+// @Router /bank_accounts/{bankAccountId}/spending/suggestions/{spendingSuggestionId}/dismiss [post]
+// @Success 200
+// @Failure 400 {object} ApiError The suggestion has already been accepted or dismissed.
+// @Failure 404 {object} ApiError The suggestion does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postDismissSpendingSuggestion(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	spendingSuggestionId, err := strconv.ParseUint(ctx.Param("spendingSuggestionId"), 10, 64)
+	if err != nil || spendingSuggestionId == 0 {
+		return c.badRequest(ctx, "must specify a valid spending suggestion Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	suggestion, err := repo.GetSpendingSuggestion(c.getContext(ctx), bankAccountId, spendingSuggestionId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve spending suggestion")
+	}
+
+	if suggestion.Status != models.SpendingSuggestionStatusPending {
+		return c.badRequest(ctx, "spending suggestion has already been %s", suggestion.Status)
+	}
+
+	suggestion.Status = models.SpendingSuggestionStatusDismissed
+	if err = repo.UpdateSpendingSuggestion(c.getContext(ctx), suggestion); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update spending suggestion")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -0,0 +1,150 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendingSuggestions(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	// Create a few months of a streaming subscription and a gym membership.
+	for _, month := range []time.Month{time.July, time.August, time.September, time.October} {
+		for _, item := range []struct {
+			name   string
+			amount int64
+			day    int
+		}{
+			{"NETFLIX.COM", 1599, 3},
+			{"PLANET FITNESS", 2500, 5},
+		} {
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(models.Transaction{
+					Name:   item.name,
+					Amount: item.amount,
+					Date:   time.Date(2023, month, item.day, 0, 0, 0, 0, time.UTC),
+				}).
+				Expect()
+			response.Status(http.StatusOK)
+		}
+	}
+
+	runner := background.NewSynchronousJobRunner(t, app.Clock, nil, nil, nil)
+	require.NoError(t, background.TriggerDetectRecurringTransactions(
+		context.Background(),
+		runner,
+		background.DetectRecurringTransactionsArguments{
+			AccountId:     bank.AccountId,
+			BankAccountId: bank.BankAccountId,
+		},
+	), "must be able to detect recurring transactions")
+
+	var suggestions []models.SpendingSuggestion
+	{
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/suggestions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(2)
+		response.JSON().Path("$[0].name").String().IsEqual("NETFLIX.COM")
+		response.JSON().Path("$[0].frequency").String().IsEqual(string(models.RecurringFrequencyMonthly))
+		response.JSON().Path("$[0].targetAmount").Number().IsEqual(1599)
+		response.JSON().Decode(&suggestions)
+	}
+
+	t.Run("accept without a funding schedule", func(t *testing.T) {
+		response := e.POST("/api/bank_accounts/{bankAccountId}/spending/suggestions/{spendingSuggestionId}/accept").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingSuggestionId", suggestions[0].SpendingSuggestionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("must specify a funding schedule")
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		response := e.POST("/api/bank_accounts/{bankAccountId}/spending/suggestions/{spendingSuggestionId}/accept").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingSuggestionId", suggestions[0].SpendingSuggestionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"fundingScheduleId": fundingSchedule.FundingScheduleId,
+				"name":              "Netflix",
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.spendingId").Number().Gt(0)
+		response.JSON().Path("$.name").String().IsEqual("Netflix")
+		response.JSON().Path("$.targetAmount").Number().IsEqual(1599)
+		response.JSON().Path("$.spendingType").Number().IsEqual(models.SpendingTypeExpense)
+
+		// Accepted suggestions are no longer listed and cannot be accepted again.
+		response = e.POST("/api/bank_accounts/{bankAccountId}/spending/suggestions/{spendingSuggestionId}/accept").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingSuggestionId", suggestions[0].SpendingSuggestionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"fundingScheduleId": fundingSchedule.FundingScheduleId,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("spending suggestion has already been accepted")
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		response := e.POST("/api/bank_accounts/{bankAccountId}/spending/suggestions/{spendingSuggestionId}/dismiss").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingSuggestionId", suggestions[1].SpendingSuggestionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/spending/suggestions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().IsEmpty()
+	})
+
+	t.Run("dismissed suggestions are not suggested again", func(t *testing.T) {
+		require.NoError(t, background.TriggerDetectRecurringTransactions(
+			context.Background(),
+			runner,
+			background.DetectRecurringTransactionsArguments{
+				AccountId:     bank.AccountId,
+				BankAccountId: bank.BankAccountId,
+			},
+		), "must be able to detect recurring transactions")
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/suggestions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().IsEmpty()
+	})
+}
@@ -527,6 +527,119 @@ func TestPostSpending(t *testing.T) {
 			response.JSON().Path("$.error").String().IsEqual("failed to create spending: a similar object already exists")
 		}
 	})
+
+	t.Run("expense with a rollover policy", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, FifthteenthAndLastDayOfEveryMonth, false)
+		otherExpense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 0)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+		ruleset := testutils.Must(t, models.NewRuleSet, FirstDayOfEveryMonth)
+		nextRecurrence := util.Midnight(ruleset.After(app.Clock.Now(), false), timezone)
+
+		var goalId uint64
+		{ // Create a goal to sweep to
+			response := e.POST("/api/bank_accounts/{bankAccountId}/spending").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":              "Vacation",
+					"fundingScheduleId": fundingSchedule.FundingScheduleId,
+					"targetAmount":      100000,
+					"spendingType":      models.SpendingTypeGoal,
+					"nextRecurrence":    nextRecurrence.AddDate(1, 0, 0),
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.rolloverPolicy").String().IsEqual(string(models.SpendingRolloverPolicyRollover))
+			goalId = uint64(response.JSON().Path("$.spendingId").Number().Raw())
+		}
+
+		{ // Invalid rollover policy
+			response := e.POST("/api/bank_accounts/{bankAccountId}/spending").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":              "Groceries",
+					"ruleset":           FirstDayOfEveryMonth,
+					"fundingScheduleId": fundingSchedule.FundingScheduleId,
+					"targetAmount":      1000,
+					"spendingType":      models.SpendingTypeExpense,
+					"nextRecurrence":    nextRecurrence,
+					"rolloverPolicy":    "bogus",
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual(`invalid rollover policy "bogus", must be one of rollover, release, sweep or carry_deficit`)
+		}
+
+		{ // Sweeping without anything to sweep to
+			response := e.POST("/api/bank_accounts/{bankAccountId}/spending").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":              "Groceries",
+					"ruleset":           FirstDayOfEveryMonth,
+					"fundingScheduleId": fundingSchedule.FundingScheduleId,
+					"targetAmount":      1000,
+					"spendingType":      models.SpendingTypeExpense,
+					"nextRecurrence":    nextRecurrence,
+					"rolloverPolicy":    models.SpendingRolloverPolicySweep,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("must specify a goal or overflow spending object to sweep to")
+		}
+
+		{ // Sweeping to another expense
+			response := e.POST("/api/bank_accounts/{bankAccountId}/spending").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":              "Groceries",
+					"ruleset":           FirstDayOfEveryMonth,
+					"fundingScheduleId": fundingSchedule.FundingScheduleId,
+					"targetAmount":      1000,
+					"spendingType":      models.SpendingTypeExpense,
+					"nextRecurrence":    nextRecurrence,
+					"rolloverPolicy":    models.SpendingRolloverPolicySweep,
+					"sweepSpendingId":   otherExpense.SpendingId,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("can only sweep to a goal or overflow spending object")
+		}
+
+		{ // Sweeping to the goal
+			response := e.POST("/api/bank_accounts/{bankAccountId}/spending").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":              "Groceries",
+					"ruleset":           FirstDayOfEveryMonth,
+					"fundingScheduleId": fundingSchedule.FundingScheduleId,
+					"targetAmount":      1000,
+					"spendingType":      models.SpendingTypeExpense,
+					"nextRecurrence":    nextRecurrence,
+					"rolloverPolicy":    models.SpendingRolloverPolicySweep,
+					"sweepSpendingId":   goalId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.rolloverPolicy").String().IsEqual(string(models.SpendingRolloverPolicySweep))
+			response.JSON().Path("$.sweepSpendingId").Number().IsEqual(goalId)
+			response.JSON().Path("$.carriedDeficit").Number().IsEqual(0)
+		}
+	})
 }
 
 func TestGetSpending(t *testing.T) {
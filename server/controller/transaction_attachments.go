@@ -0,0 +1,306 @@
+package controller
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// List Transaction Attachments
+// @Summary List Transaction Attachments
+// @ID list-transaction-attachments
+// @tags Transactions
+// @description List the files, like photos or PDFs of receipts, that are attached to a transaction.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param transactionId path int true "Transaction ID"
+// @Router /bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments [get]
+// @Success 200 {array} models.File
+// @Failure 400 {object} ApiError Invalid Bank Account ID or Transaction ID.
+// @Failure 404 {object} ApiError The transaction does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getTransactionAttachments(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := strconv.ParseUint(ctx.Param("transactionId"), 10, 64)
+	if err != nil || transactionId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if _, err = repo.GetTransaction(c.getContext(ctx), bankAccountId, transactionId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction")
+	}
+
+	files, err := repo.GetTransactionAttachments(c.getContext(ctx), bankAccountId, transactionId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction attachments")
+	}
+
+	return ctx.JSON(http.StatusOK, files)
+}
+
+// Upload Transaction Attachments
+// @Summary Upload Transaction Attachments
+// @ID upload-transaction-attachments
+// @tags Transactions
+// @description Attach one or more files, like photos or PDFs of receipts, to a transaction. Files should be provided as
+// @description a multipart form under the `data` field. The size and type of files that can be attached are limited by
+// @description the server's configuration. If any of the files cannot be attached then none of them are.
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param transactionId path int true "Transaction ID"
+// @Router /bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments [post]
+// @Success 200 {array} models.File
+// @Failure 400 {object} ApiError No files were provided, or a file is too large or not an allowed type.
+// @Failure 404 {object} ApiError The transaction does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postTransactionAttachments(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := strconv.ParseUint(ctx.Param("transactionId"), 10, 64)
+	if err != nil || transactionId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	if c.fileStorage == nil {
+		return c.badRequest(ctx, "file storage is not configured, attachments cannot be uploaded")
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return c.badRequest(ctx, "request must be a multipart form")
+	}
+
+	headers := form.File["data"]
+	if len(headers) == 0 {
+		return c.badRequest(ctx, "must provide at least one file to attach")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if _, err = repo.GetTransaction(c.getContext(ctx), bankAccountId, transactionId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction")
+	}
+
+	// Validate every file before any of them are stored, that way nothing is left behind in storage if one of the files
+	// is rejected.
+	limits := c.configuration.Storage.Attachments
+	contentTypes := make([]string, len(headers))
+	for i, header := range headers {
+		if header.Size > limits.MaxSize {
+			return c.badRequest(ctx, "%s is too large, must be less than %d bytes", header.Filename, limits.MaxSize)
+		}
+
+		contentTypes[i], err = attachmentContentType(header)
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read uploaded file")
+		}
+
+		if !isAllowedAttachmentType(limits.AllowedContentTypes, contentTypes[i]) {
+			return c.badRequest(ctx, "%s cannot be attached, files of type %s are not allowed", header.Filename, contentTypes[i])
+		}
+	}
+
+	// Files are written to storage before the request's transaction is committed. If any of them cannot be attached
+	// then the records are rolled back, so the files that were already stored need to be removed as well.
+	stored := make([]string, 0, len(headers))
+	files := make([]models.File, 0, len(headers))
+	for i, header := range headers {
+		file, err := c.storeAttachment(ctx, header, contentTypes[i])
+		if err != nil {
+			c.removeStoredFiles(ctx, stored)
+			return err
+		}
+		stored = append(stored, file.ObjectUri)
+
+		file.BankAccountId = bankAccountId
+		if err = repo.CreateFile(c.getContext(ctx), file); err != nil {
+			c.removeStoredFiles(ctx, stored)
+			return c.wrapPgError(ctx, err, "failed to record attachment")
+		}
+
+		if err = repo.CreateTransactionAttachment(c.getContext(ctx), &models.TransactionAttachment{
+			BankAccountId: bankAccountId,
+			TransactionId: transactionId,
+			FileId:        file.FileId,
+		}); err != nil {
+			c.removeStoredFiles(ctx, stored)
+			return c.wrapPgError(ctx, err, "failed to attach file to transaction")
+		}
+
+		files = append(files, *file)
+	}
+
+	return ctx.JSON(http.StatusOK, files)
+}
+
+// Download Transaction Attachment
+// @Summary Download Transaction Attachment
+// @ID download-transaction-attachment
+// @tags Transactions
+// @description Download a file that is attached to a transaction.
+// @Security ApiKeyAuth
+// @Produce octet-stream
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param transactionId path int true "Transaction ID"
+// @Param fileId path int true "File ID"
+// @Router /bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments/{fileId} [get]
+// @Success 200
+// @Failure 400 {object} ApiError Invalid Bank Account ID, Transaction ID or File ID.
+// @Failure 404 {object} ApiError The file is not attached to the transaction.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getTransactionAttachment(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := strconv.ParseUint(ctx.Param("transactionId"), 10, 64)
+	if err != nil || transactionId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	fileId, err := strconv.ParseUint(ctx.Param("fileId"), 10, 64)
+	if err != nil || fileId == 0 {
+		return c.badRequest(ctx, "must specify a valid file Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	file, err := repo.GetTransactionAttachment(c.getContext(ctx), bankAccountId, transactionId, fileId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction attachment")
+	}
+
+	return c.streamFile(ctx, file)
+}
+
+// Delete Transaction Attachment
+// @Summary Delete Transaction Attachment
+// @ID delete-transaction-attachment
+// @tags Transactions
+// @description Remove a file that is attached to a transaction. The file is removed from storage as well.
+// @Security ApiKeyAuth
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param transactionId path int true "Transaction ID"
+// @Param fileId path int true "File ID"
+// @Router /bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments/{fileId} [delete]
+// @Success 200
+// @Failure 400 {object} ApiError Invalid Bank Account ID, Transaction ID or File ID.
+// @Failure 404 {object} ApiError The file is not attached to the transaction.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) deleteTransactionAttachment(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := strconv.ParseUint(ctx.Param("transactionId"), 10, 64)
+	if err != nil || transactionId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	fileId, err := strconv.ParseUint(ctx.Param("fileId"), 10, 64)
+	if err != nil || fileId == 0 {
+		return c.badRequest(ctx, "must specify a valid file Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	file, err := repo.GetTransactionAttachment(c.getContext(ctx), bankAccountId, transactionId, fileId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction attachment")
+	}
+
+	if err = repo.DeleteTransactionAttachment(c.getContext(ctx), bankAccountId, transactionId, fileId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to remove transaction attachment")
+	}
+
+	// The record of the file is already gone, so if the file cannot be removed from storage it is only left orphaned.
+	if c.fileStorage != nil {
+		if err = c.fileStorage.Remove(c.getContext(ctx), file.ObjectUri); err != nil {
+			c.getLog(ctx).WithError(err).WithField("fileId", fileId).Warn("failed to remove attachment from storage")
+		}
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// storeAttachment writes the uploaded file to file storage and returns the file record that should be created for it.
+func (c *Controller) storeAttachment(ctx echo.Context, header *multipart.FileHeader, contentType string) (*models.File, error) {
+	reader, err := header.Open()
+	if err != nil {
+		return nil, c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read uploaded file")
+	}
+	defer reader.Close()
+
+	uri, err := c.fileStorage.Store(c.getContext(ctx), reader, contentType)
+	if err != nil {
+		return nil, c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store attachment")
+	}
+
+	return &models.File{
+		Name:            header.Filename,
+		ContentType:     contentType,
+		Size:            uint64(header.Size),
+		ObjectUri:       uri,
+		CreatedByUserId: c.mustGetUserId(ctx),
+	}, nil
+}
+
+// attachmentContentType returns the media type of the uploaded file. If the client did not provide a useful content
+// type then it is detected from the contents of the file.
+func attachmentContentType(header *multipart.FileHeader) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	if err == nil && mediaType != "" && mediaType != "application/octet-stream" {
+		return strings.ToLower(mediaType), nil
+	}
+
+	reader, err := header.Open()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open uploaded file")
+	}
+	defer reader.Close()
+
+	// http.DetectContentType never looks at more than the first 512 bytes.
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(reader, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", errors.Wrap(err, "failed to read uploaded file")
+	}
+
+	mediaType, _, err = mime.ParseMediaType(http.DetectContentType(buffer[:n]))
+	if err != nil {
+		return "application/octet-stream", nil
+	}
+
+	return mediaType, nil
+}
+
+func isAllowedAttachmentType(allowed []string, contentType string) bool {
+	for _, item := range allowed {
+		if strings.EqualFold(item, contentType) {
+			return true
+		}
+	}
+
+	return false
+}
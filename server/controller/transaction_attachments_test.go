@@ -0,0 +1,125 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+// samplePNGAttachment is just enough of a PNG file for its content type to be detected.
+const samplePNGAttachment = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00"
+
+func TestTransactionAttachments(t *testing.T) {
+	t.Run("upload, download and delete", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		transaction := fixtures.GivenIHaveATransaction(t, app.Clock, bank)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var fileId uint64
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "receipt.png", []byte(samplePNGAttachment)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].name").String().IsEqual("receipt.png")
+			response.JSON().Path("$[0].contentType").String().IsEqual("image/png")
+			fileId = uint64(response.JSON().Path("$[0].fileId").Number().Gt(0).Raw())
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].fileId").Number().IsEqual(fileId)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments/{fileId}").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithPath("fileId", fileId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.Header("Content-Type").IsEqual("image/png")
+			response.Body().IsEqual(samplePNGAttachment)
+		}
+
+		{
+			response := e.DELETE("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments/{fileId}").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithPath("fileId", fileId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments/{fileId}").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithPath("fileId", fileId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusNotFound)
+		}
+	})
+
+	t.Run("content type is not allowed", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		transaction := fixtures.GivenIHaveATransaction(t, app.Clock, bank)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFileBytes("data", "notes.txt", []byte("this is not a receipt")).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("notes.txt cannot be attached, files of type text/plain are not allowed")
+	})
+
+	t.Run("transaction does not exist", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/attachments").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", 1234).
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFileBytes("data", "receipt.png", []byte(samplePNGAttachment)).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	})
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+)
+
+// parseTransactionFilters reads the optional filter query parameters used when listing transactions. An error is
+// returned with a message that can be presented to the client if any of the parameters are invalid.
+func (c *Controller) parseTransactionFilters(ctx echo.Context) (repository.TransactionFilters, error) {
+	var filters repository.TransactionFilters
+	timezone := c.mustGetTimezone(ctx)
+
+	if value := strings.TrimSpace(ctx.QueryParam("startDate")); value != "" {
+		start, _, err := parseFilterDate(value, timezone)
+		if err != nil {
+			return filters, errors.New("startDate must be a valid date")
+		}
+		filters.StartDate = &start
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("endDate")); value != "" {
+		end, dateOnly, err := parseFilterDate(value, timezone)
+		if err != nil {
+			return filters, errors.New("endDate must be a valid date")
+		}
+		// When only a date is provided the end date should include that entire day.
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		} else {
+			end = end.Add(time.Nanosecond)
+		}
+		filters.EndDate = &end
+	}
+
+	if filters.StartDate != nil && filters.EndDate != nil && !filters.StartDate.Before(*filters.EndDate) {
+		return filters, errors.New("startDate must be before endDate")
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("minAmount")); value != "" {
+		amount, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || amount < 0 {
+			return filters, errors.New("minAmount must be a positive number of cents")
+		}
+		filters.MinimumAmount = &amount
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("maxAmount")); value != "" {
+		amount, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || amount < 0 {
+			return filters, errors.New("maxAmount must be a positive number of cents")
+		}
+		filters.MaximumAmount = &amount
+	}
+
+	if filters.MinimumAmount != nil && filters.MaximumAmount != nil && *filters.MinimumAmount > *filters.MaximumAmount {
+		return filters, errors.New("minAmount cannot be greater than maxAmount")
+	}
+
+	switch direction := repository.TransactionDirection(strings.ToLower(strings.TrimSpace(ctx.QueryParam("direction")))); direction {
+	case "":
+	case repository.TransactionDirectionDebit, repository.TransactionDirectionCredit:
+		filters.Direction = direction
+	default:
+		return filters, errors.New("direction must be either debit or credit")
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("pending")); value != "" {
+		pending, err := strconv.ParseBool(value)
+		if err != nil {
+			return filters, errors.New("pending must be either true or false")
+		}
+		filters.IsPending = &pending
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("spendingId")); value != "" {
+		if strings.EqualFold(value, "unassigned") {
+			filters.Unassigned = true
+		} else {
+			spendingId, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || spendingId == 0 {
+				return filters, errors.New("spendingId must be a valid spending Id or unassigned")
+			}
+			filters.SpendingId = &spendingId
+		}
+	}
+
+	if value := strings.TrimSpace(ctx.QueryParam("categoryId")); value != "" {
+		if strings.EqualFold(value, "uncategorized") {
+			filters.Uncategorized = true
+		} else {
+			categoryId, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || categoryId == 0 {
+				return filters, errors.New("categoryId must be a valid category Id or uncategorized")
+			}
+			filters.CategoryId = &categoryId
+		}
+	}
+
+	filters.Search = strings.TrimSpace(ctx.QueryParam("search"))
+
+	return filters, nil
+}
+
+// parseFilterDate accepts either a plain date, which is treated as midnight in the provided timezone, or a full RFC3339
+// timestamp. The boolean returned indicates whether the value was a plain date.
+func parseFilterDate(value string, timezone *time.Location) (time.Time, bool, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, timezone); err == nil {
+		return date, true, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	return date, false, err
+}
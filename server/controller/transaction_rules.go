@@ -0,0 +1,377 @@
+package controller
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+)
+
+const (
+	// transactionRuleBatchSize is the number of transactions that are read at a time when a rule is evaluated against
+	// existing transactions.
+	transactionRuleBatchSize = 250
+	// transactionRulePreviewLimit is the maximum number of matching transactions that are returned by a preview.
+	transactionRulePreviewLimit = 25
+)
+
+// List Transaction Rules
+// @Summary List Transaction Rules
+// @ID list-transaction-rules
+// @tags Transaction Rules
+// @description List the transaction rules for the current account in the order they are evaluated.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /transaction_rules [get]
+// @Success 200 {array} models.TransactionRule
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getTransactionRules(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	rules, err := repo.GetTransactionRules(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction rules")
+	}
+
+	return ctx.JSON(http.StatusOK, rules)
+}
+
+// Create Transaction Rule
+// @Summary Create Transaction Rule
+// @ID create-transaction-rule
+// @tags Transaction Rules
+// @description Create a transaction rule. Rules are evaluated against new transactions as they are synced or
+// @description imported, in order of their priority (ascending). When multiple matching rules specify the same action
+// @description the rule with the lowest priority wins.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param rule body models.TransactionRule true "Transaction Rule"
+// @Router /transaction_rules [post]
+// @Success 200 {object} models.TransactionRule
+// @Failure 400 {object} ApiError Invalid transaction rule.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postTransactionRules(ctx echo.Context) error {
+	var rule models.TransactionRule
+	if err := ctx.Bind(&rule); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err := c.validateTransactionRule(ctx, repo, &rule); err != nil {
+		return err
+	}
+
+	if err := repo.CreateTransactionRule(c.getContext(ctx), &rule); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create transaction rule")
+	}
+
+	return ctx.JSON(http.StatusOK, rule)
+}
+
+// Update Transaction Rule
+// @Summary Update Transaction Rule
+// @ID update-transaction-rule
+// @tags Transaction Rules
+// @description Replace the conditions, actions, priority and name of an existing transaction rule.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param transactionRuleId path int true "Transaction Rule ID"
+// @Param rule body models.TransactionRule true "Transaction Rule"
+// @Router /transaction_rules/{transactionRuleId} [put]
+// @Success 200 {object} models.TransactionRule
+// @Failure 400 {object} ApiError Invalid transaction rule.
+// @Failure 404 {object} ApiError The transaction rule does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) putTransactionRules(ctx echo.Context) error {
+	transactionRuleId, err := strconv.ParseUint(ctx.Param("transactionRuleId"), 10, 64)
+	if err != nil || transactionRuleId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction rule Id")
+	}
+
+	var rule models.TransactionRule
+	if err = ctx.Bind(&rule); err != nil {
+		return c.invalidJson(ctx)
+	}
+	rule.TransactionRuleId = transactionRuleId
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err = c.validateTransactionRule(ctx, repo, &rule); err != nil {
+		return err
+	}
+
+	if err = repo.UpdateTransactionRule(c.getContext(ctx), &rule); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update transaction rule")
+	}
+
+	return ctx.JSON(http.StatusOK, rule)
+}
+
+// Delete Transaction Rule
+// @Summary Delete Transaction Rule
+// @ID delete-transaction-rule
+// @tags Transaction Rules
+// @description Remove a transaction rule. Transactions that the rule was already applied to are not changed.
+// @Security ApiKeyAuth
+// @Param transactionRuleId path int true "Transaction Rule ID"
+// @Router /transaction_rules/{transactionRuleId} [delete]
+// @Success 200
+// @Failure 400 {object} ApiError Invalid transaction rule Id.
+// @Failure 404 {object} ApiError The transaction rule does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) deleteTransactionRules(ctx echo.Context) error {
+	transactionRuleId, err := strconv.ParseUint(ctx.Param("transactionRuleId"), 10, 64)
+	if err != nil || transactionRuleId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction rule Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err = repo.DeleteTransactionRule(c.getContext(ctx), transactionRuleId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to delete transaction rule")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Preview Transaction Rule
+// @Summary Preview Transaction Rule
+// @ID preview-transaction-rule
+// @tags Transaction Rules
+// @description Evaluate a transaction rule against existing transactions without changing anything. The rule does
+// @description not need to be saved. The result includes the number of transactions that match, and the most recent
+// @description matching transactions as they would look with the rule's actions applied.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param rule body models.TransactionRule true "Transaction Rule"
+// @Router /transaction_rules/preview [post]
+// @Success 200 {object} TransactionRulePreview
+// @Failure 400 {object} ApiError Invalid transaction rule.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postTransactionRulePreview(ctx echo.Context) error {
+	var rule models.TransactionRule
+	if err := ctx.Bind(&rule); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err := c.validateTransactionRule(ctx, repo, &rule); err != nil {
+		return err
+	}
+	// Preview the rule as if it were enabled, otherwise its actions would not be applied to the matching transactions.
+	rule.IsEnabled = true
+
+	result := TransactionRulePreview{
+		Transactions: make([]models.Transaction, 0),
+	}
+	err := c.forEachTransactionRuleMatch(ctx, repo, rule, func(transaction models.Transaction) error {
+		result.Matched++
+		if len(result.Transactions) < transactionRulePreviewLimit {
+			if spendingId := models.EvaluateTransactionRules([]models.TransactionRule{rule}, &transaction); spendingId != nil {
+				transaction.SpendingId = spendingId
+			}
+			result.Transactions = append(result.Transactions, transaction)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to preview transaction rule")
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// Apply Transaction Rule
+// @Summary Apply Transaction Rule
+// @ID apply-transaction-rule
+// @tags Transaction Rules
+// @description Apply a saved transaction rule to existing transactions. Transactions that are already spent from
+// @description something are not assigned to the rule's spending object, but the rule's other actions still apply.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param transactionRuleId path int true "Transaction Rule ID"
+// @Router /transaction_rules/{transactionRuleId}/apply [post]
+// @Success 200 {object} TransactionRuleApplyResult
+// @Failure 400 {object} ApiError Invalid transaction rule Id.
+// @Failure 404 {object} ApiError The transaction rule does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postTransactionRuleApply(ctx echo.Context) error {
+	transactionRuleId, err := strconv.ParseUint(ctx.Param("transactionRuleId"), 10, 64)
+	if err != nil || transactionRuleId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction rule Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	rule, err := repo.GetTransactionRule(c.getContext(ctx), transactionRuleId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction rule")
+	}
+	// The rule is being applied explicitly, so it is applied even if it is disabled.
+	rule.IsEnabled = true
+
+	result := TransactionRuleApplyResult{
+		Spending: make([]models.Spending, 0),
+	}
+	updatedSpending := map[uint64]models.Spending{}
+	err = c.forEachTransactionRuleMatch(ctx, repo, *rule, func(existing models.Transaction) error {
+		updated := existing
+		if spendingId := models.EvaluateTransactionRules([]models.TransactionRule{*rule}, &updated); spendingId != nil {
+			updated.SpendingId = spendingId
+			spending, err := repo.ProcessTransactionSpentFrom(c.getContext(ctx), existing.BankAccountId, &updated, &existing)
+			if err != nil {
+				return err
+			}
+
+			for _, item := range spending {
+				updatedSpending[item.SpendingId] = item
+			}
+		}
+
+		if updated.Name == existing.Name &&
+			myownsanity.Uint64PEqual(updated.CategoryId, existing.CategoryId) &&
+			updated.SpendingId == existing.SpendingId {
+			return nil
+		}
+
+		if err := repo.UpdateTransaction(c.getContext(ctx), existing.BankAccountId, &updated); err != nil {
+			return err
+		}
+		result.Updated++
+
+		return nil
+	})
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to apply transaction rule")
+	}
+
+	for _, item := range updatedSpending {
+		result.Spending = append(result.Spending, item)
+	}
+	sort.Slice(result.Spending, func(i, j int) bool {
+		return result.Spending[i].SpendingId < result.Spending[j].SpendingId
+	})
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+type TransactionRulePreview struct {
+	// Matched is the total number of existing transactions that the rule matches.
+	Matched int `json:"matched"`
+	// Transactions are the most recent matching transactions with the rule's actions applied.
+	Transactions []models.Transaction `json:"transactions"`
+}
+
+type TransactionRuleApplyResult struct {
+	// Updated is the number of transactions that were changed by the rule.
+	Updated int `json:"updated"`
+	// Spending contains any spending objects that were updated because transactions were spent from them.
+	Spending []models.Spending `json:"spending"`
+}
+
+// validateTransactionRule normalizes the rule and makes sure it is valid, including that the bank account, spending
+// object and categories it references exist. If the rule is not valid then the error returned should be returned by the handler.
+func (c *Controller) validateTransactionRule(ctx echo.Context, repo repository.Repository, rule *models.TransactionRule) error {
+	rule.Normalize()
+	if err := rule.Validate(); err != nil {
+		return c.badRequest(ctx, "invalid transaction rule: %s", err.Error())
+	}
+
+	if bankAccountId := rule.Conditions.BankAccountId; bankAccountId != nil {
+		if _, err := repo.GetBankAccount(c.getContext(ctx), *bankAccountId); err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve bank account for transaction rule")
+		}
+	}
+
+	if spendingId := rule.Actions.SpendingId; spendingId != nil {
+		ok, err := repo.GetSpendingExists(c.getContext(ctx), *rule.Conditions.BankAccountId, *spendingId)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to verify spending exists for transaction rule")
+		}
+
+		if !ok {
+			return c.badRequest(ctx, "invalid transaction rule: spending object does not exist in the bank account")
+		}
+	}
+
+	for _, categoryId := range []*uint64{rule.Conditions.CategoryId, rule.Actions.CategoryId} {
+		if categoryId == nil {
+			continue
+		}
+
+		if _, err := repo.GetCategory(c.getContext(ctx), *categoryId); err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve category for transaction rule")
+		}
+	}
+
+	return nil
+}
+
+// forEachTransactionRuleMatch pages through the existing transactions that could be matched by the rule, newest first,
+// and calls the provided function for each one that the rule matches. Transactions are read using a cursor so that
+// transactions being updated by the function do not affect which transactions are read next.
+func (c *Controller) forEachTransactionRuleMatch(
+	ctx echo.Context,
+	repo repository.Repository,
+	rule models.TransactionRule,
+	fn func(transaction models.Transaction) error,
+) error {
+	var bankAccountIds []uint64
+	if rule.Conditions.BankAccountId != nil {
+		bankAccountIds = []uint64{*rule.Conditions.BankAccountId}
+	} else {
+		bankAccounts, err := repo.GetBankAccounts(c.getContext(ctx))
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve bank accounts")
+		}
+
+		for _, bankAccount := range bankAccounts {
+			bankAccountIds = append(bankAccountIds, bankAccount.BankAccountId)
+		}
+	}
+
+	for _, bankAccountId := range bankAccountIds {
+		// The amount conditions have the same meaning as the amount filters, so they can be used to narrow down the
+		// transactions that need to be evaluated.
+		filters := repository.TransactionFilters{
+			MinimumAmount: rule.Conditions.MinimumAmount,
+			MaximumAmount: rule.Conditions.MaximumAmount,
+		}
+		for {
+			transactions, err := repo.GetTransactions(c.getContext(ctx), bankAccountId, transactionRuleBatchSize, 0, filters)
+			if err != nil {
+				return err
+			}
+
+			for _, transaction := range transactions {
+				if !rule.Matches(transaction) {
+					continue
+				}
+
+				if err = fn(transaction); err != nil {
+					return err
+				}
+			}
+
+			if len(transactions) < transactionRuleBatchSize {
+				break
+			}
+
+			last := transactions[len(transactions)-1]
+			filters.After = repository.NewCursor(last.Date, last.TransactionId)
+		}
+	}
+
+	return nil
+}
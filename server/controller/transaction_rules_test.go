@@ -0,0 +1,147 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestTransactionRules(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 100000)
+	transaction := fixtures.GivenIHaveATransaction(t, app.Clock, bank)
+	fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 3)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	rule := map[string]interface{}{
+		"name":      "Assign merchant",
+		"priority":  1,
+		"isEnabled": true,
+		"conditions": map[string]interface{}{
+			"merchant":      transaction.MerchantName,
+			"bankAccountId": bank.BankAccountId,
+		},
+		"actions": map[string]interface{}{
+			"spendingId": expense.SpendingId,
+			"name":       "Friendly Name",
+		},
+	}
+
+	t.Run("invalid rule", func(t *testing.T) {
+		response := e.POST("/api/transaction_rules").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": "No conditions",
+				"actions": map[string]interface{}{
+					"name": "Friendly Name",
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("invalid transaction rule: rule must have at least one condition")
+	})
+
+	t.Run("category must exist", func(t *testing.T) {
+		response := e.POST("/api/transaction_rules").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": "Missing category",
+				"conditions": map[string]interface{}{
+					"merchant": transaction.MerchantName,
+				},
+				"actions": map[string]interface{}{
+					"categoryId": 999999,
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	})
+
+	t.Run("preview", func(t *testing.T) {
+		response := e.POST("/api/transaction_rules/preview").
+			WithCookie(TestCookieName, token).
+			WithJSON(rule).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.matched").Number().IsEqual(1)
+		response.JSON().Path("$.transactions[0].transactionId").Number().IsEqual(transaction.TransactionId)
+		response.JSON().Path("$.transactions[0].name").String().IsEqual("Friendly Name")
+		response.JSON().Path("$.transactions[0].spendingId").Number().IsEqual(expense.SpendingId)
+	})
+
+	var transactionRuleId uint64
+	t.Run("create and list", func(t *testing.T) {
+		response := e.POST("/api/transaction_rules").
+			WithCookie(TestCookieName, token).
+			WithJSON(rule).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transactionRuleId").Number().Gt(0)
+		transactionRuleId = uint64(response.JSON().Path("$.transactionRuleId").Number().Raw())
+
+		response = e.GET("/api/transaction_rules").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].conditions.merchant").String().IsEqual(transaction.MerchantName)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		response := e.POST("/api/transaction_rules/{transactionRuleId}/apply").
+			WithPath("transactionRuleId", transactionRuleId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.updated").Number().IsEqual(1)
+		response.JSON().Path("$.spending[0].currentAmount").Number().IsEqual(100000 - transaction.Amount)
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.name").String().IsEqual("Friendly Name")
+		response.JSON().Path("$.originalName").String().IsEqual(transaction.OriginalName)
+		response.JSON().Path("$.spendingId").Number().IsEqual(expense.SpendingId)
+
+		// Applying the rule again should not change anything.
+		response = e.POST("/api/transaction_rules/{transactionRuleId}/apply").
+			WithPath("transactionRuleId", transactionRuleId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.updated").Number().IsEqual(0)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		e.DELETE("/api/transaction_rules/{transactionRuleId}").
+			WithPath("transactionRuleId", transactionRuleId).
+			WithCookie(TestCookieName, token).
+			Expect().
+			Status(http.StatusOK)
+
+		response := e.DELETE("/api/transaction_rules/{transactionRuleId}").
+			WithPath("transactionRuleId", transactionRuleId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+		response.JSON().Path("$.error").String().IsEqual("failed to delete transaction rule: record does not exist")
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/monetr/monetr/server/internal/myownsanity"
 	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,8 +25,19 @@ import (
 // @Param bankAccountId path int true "Bank Account ID"
 // @Param limit query int false "Specifies the number of transactions to return in the result, default is 25. Max is 100."
 // @Param offset query int false "The number of transactions to skip before returning any."
+// @Param cursor query string false "Return the page of transactions after this cursor, taken from the `X-Next-Cursor` header of the previous page. Cannot be used with offset."
+// @Param startDate query string false "Only return transactions on or after this date. Either YYYY-MM-DD (in the user's timezone) or RFC3339."
+// @Param endDate query string false "Only return transactions on or before this date. Either YYYY-MM-DD (in the user's timezone) or RFC3339."
+// @Param minAmount query int false "Only return transactions whose absolute amount in cents is at least this much."
+// @Param maxAmount query int false "Only return transactions whose absolute amount in cents is at most this much."
+// @Param direction query string false "Either `debit` for money leaving the account or `credit` for money entering it."
+// @Param pending query bool false "Only return pending or non-pending transactions."
+// @Param spendingId query string false "Only return transactions spent from this spending object, or `unassigned` for transactions not spent from any."
+// @Param categoryId query string false "Only return transactions in this category or any of its child categories, or `uncategorized` for transactions without a category."
+// @Param search query string false "Only return transactions whose name, merchant or original name contain words starting with each word provided."
 // @Router /bank_accounts/{bankAccountId}/transactions [get]
 // @Success 200 {array} swag.TransactionResponse
+// @Header 200 {string} X-Next-Cursor "The cursor for the next page, only present if there are more transactions."
 // @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID.
 // @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
 // @Failure 500 {object} ApiError Something went wrong on our end.
@@ -53,13 +65,26 @@ func (c *Controller) getTransactions(ctx echo.Context) error {
 	// Only let a maximum of 100 transactions be requested at a time.
 	limit = int(math.Min(100, float64(limit)))
 
+	filters, err := c.parseTransactionFilters(ctx)
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
+	filters.After, err = c.parseCursor(ctx)
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
-	transactions, err := repo.GetTransactions(c.getContext(ctx), bankAccountId, limit, offset)
+	// Retrieve one extra transaction to know whether there is another page.
+	transactions, err := repo.GetTransactions(c.getContext(ctx), bankAccountId, limit+1, offset, filters)
 	if err != nil {
 		return c.wrapPgError(ctx, err, "failed to retrieve transactions")
 	}
 
+	transactions = c.trimTransactionsPage(ctx, transactions, limit)
+
 	// If transactions are null or empty then make sure what we return is an empty array. Otherwise we can accidentally
 	// return null.
 	if len(transactions) == 0 {
@@ -69,6 +94,20 @@ func (c *Controller) getTransactions(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, transactions)
 }
 
+// trimTransactionsPage removes the extra transaction that was retrieved to determine if there is another page, and if
+// there is, sets the cursor for that page on the response.
+func (c *Controller) trimTransactionsPage(ctx echo.Context, transactions []models.Transaction, limit int) []models.Transaction {
+	if len(transactions) <= limit {
+		return transactions
+	}
+
+	transactions = transactions[:limit]
+	last := transactions[limit-1]
+	setNextCursor(ctx, repository.NewCursor(last.Date, last.TransactionId))
+
+	return transactions
+}
+
 // getTransactionById will simply return a single transaction for the given bank and transaction specified.
 // If the transaction does not exist then a 404 not found will be returned via the wrapPgError.
 func (c *Controller) getTransactionById(ctx echo.Context) error {
@@ -103,8 +142,10 @@ func (c *Controller) getTransactionById(ctx echo.Context) error {
 // @Param spendingId path int true "Spending ID"
 // @Param limit query int false "Specifies the number of transactions to return in the result, default is 25. Max is 100."
 // @Param offset query int false "The number of transactions to skip before returning any."
+// @Param cursor query string false "Return the page of transactions after this cursor, taken from the `X-Next-Cursor` header of the previous page. Cannot be used with offset."
 // @Router /bank_accounts/{bankAccountId}/transactions/spending/{spendingId} [get]
 // @Success 200 {array} swag.TransactionResponse
+// @Header 200 {string} X-Next-Cursor "The cursor for the next page, only present if there are more transactions."
 // @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID, Spending ID, Limit or Offset.
 // @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
 // @Failure 404 {object} SpendingNotFoundError Invalid Spending ID provided.
@@ -136,6 +177,11 @@ func (c *Controller) getTransactionsForSpending(ctx echo.Context) error {
 	// Only let a maximum of 100 transactions be requested at a time.
 	limit = int(math.Min(100, float64(limit)))
 
+	after, err := c.parseCursor(ctx)
+	if err != nil {
+		return c.badRequest(ctx, "%s", err.Error())
+	}
+
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
 	ok, err := repo.GetSpendingExists(c.getContext(ctx), bankAccountId, spendingId)
@@ -147,11 +193,13 @@ func (c *Controller) getTransactionsForSpending(ctx echo.Context) error {
 		return c.returnError(ctx, http.StatusNotFound, "spending object does not exist")
 	}
 
-	transactions, err := repo.GetTransactionsForSpending(c.getContext(ctx), bankAccountId, spendingId, limit, offset)
+	transactions, err := repo.GetTransactionsForSpending(c.getContext(ctx), bankAccountId, spendingId, limit+1, offset, after)
 	if err != nil {
 		return c.wrapPgError(ctx, err, "failed to retrieve transactions for spending")
 	}
 
+	transactions = c.trimTransactionsPage(ctx, transactions, limit)
+
 	return ctx.JSON(http.StatusOK, transactions)
 }
 
@@ -190,6 +238,18 @@ func (c *Controller) postTransactions(ctx echo.Context) error {
 		return c.badRequest(ctx, "transaction amount must be greater than 0")
 	}
 
+	if len(transaction.Splits) > 0 {
+		return c.badRequest(ctx, "splits can only be added to an existing transaction")
+	}
+
+	if err = c.checkReconciliationLock(ctx, repo, bankAccountId, transaction.Date); err != nil {
+		return err
+	}
+
+	if transaction.CategoryId, err = c.validateTransactionCategory(ctx, repo, transaction.CategoryId); err != nil {
+		return err
+	}
+
 	var updatedSpending *models.Spending
 	if transaction.SpendingId != nil && *transaction.SpendingId > 0 {
 		updatedSpending, err = repo.GetSpendingById(c.getContext(ctx), bankAccountId, *transaction.SpendingId)
@@ -200,18 +260,25 @@ func (c *Controller) postTransactions(ctx echo.Context) error {
 		if err = repo.AddExpenseToTransaction(c.getContext(ctx), &transaction, updatedSpending); err != nil {
 			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to add expense to transaction")
 		}
-
-		if err = repo.UpdateSpending(c.getContext(ctx), bankAccountId, []models.Spending{
-			*updatedSpending,
-		}); err != nil {
-			return c.wrapPgError(ctx, err, "failed to update spending for transaction")
-		}
 	}
 
 	if err = repo.CreateTransaction(c.getContext(ctx), bankAccountId, &transaction); err != nil {
 		return c.wrapPgError(ctx, err, "could not create transaction")
 	}
 
+	// The spending is updated after the transaction is created so that the ledger entry can reference it.
+	if updatedSpending != nil {
+		if err = repo.UpdateSpending(
+			c.getContext(ctx),
+			bankAccountId,
+			[]models.Spending{*updatedSpending},
+			models.SpendingLedgerReasonTransaction,
+			&transaction.TransactionId,
+		); err != nil {
+			return c.wrapPgError(ctx, err, "failed to update spending for transaction")
+		}
+	}
+
 	returnedObject := map[string]interface{}{
 		"transaction": transaction,
 	}
@@ -229,7 +296,11 @@ func (c *Controller) postTransactions(ctx echo.Context) error {
 // @Summary Update Transaction
 // @ID update-transactions
 // @tags Transactions
-// @description Updates the provided transaction.
+// @description Updates the provided transaction. A transaction can be split across multiple spending objects by
+// @description providing `splits`, the amounts of the splits must add up to the amount of the transaction. If `splits`
+// @description is omitted then the existing splits are kept, an empty array will remove them.
+// @description The amount, date and pending state of a manual transaction cannot be changed once it is on or before the
+// @description statement date of the bank account's last reconciliation.
 // @Security ApiKeyAuth
 // @Accept json
 // @Produce json
@@ -277,8 +348,45 @@ func (c *Controller) putTransactions(ctx echo.Context) error {
 		return c.badRequest(ctx, "cannot specify a spent from on a deposit")
 	}
 
+	// If the splits were not provided at all and the transaction is not being spent from something else then the
+	// existing splits are kept. An empty array of splits will remove them.
+	if transaction.Splits == nil && transaction.SpendingId == nil {
+		transaction.Splits = existingTransaction.Splits
+	}
+
+	if transaction.SpendingId != nil && len(transaction.Splits) > 0 {
+		return c.badRequest(ctx, "cannot specify both a spent from and splits")
+	}
+
+	if err = models.ValidateTransactionSplits(transaction.Amount, transaction.Splits); err != nil {
+		return c.badRequest(ctx, "invalid transaction splits: %s", err.Error())
+	}
+
+	// Transfers can only be changed through the transfer endpoints.
+	transaction.TransferTransactionId = existingTransaction.TransferTransactionId
+	transaction.TransferStatus = existingTransaction.TransferStatus
+	if transaction.IsTransfer() && (transaction.SpendingId != nil || len(transaction.Splits) > 0) {
+		return c.badRequest(ctx, "cannot spend from a transfer, the transfer must be unlinked first")
+	}
+
 	transaction.PlaidTransactionId = existingTransaction.PlaidTransactionId
 
+	// If the category was not provided then the existing category is kept. A category of zero will remove it.
+	if transaction.CategoryId == nil {
+		transaction.CategoryId = existingTransaction.CategoryId
+	} else if transaction.CategoryId, err = c.validateTransactionCategory(ctx, repo, transaction.CategoryId); err != nil {
+		return err
+	}
+
+	// Changing any of these on a manual transaction would change a balance that may have already been reconciled.
+	if isManual && (existingTransaction.Amount != transaction.Amount ||
+		existingTransaction.IsPending != transaction.IsPending ||
+		!existingTransaction.Date.Equal(transaction.Date)) {
+		if err = c.checkReconciliationLock(ctx, repo, bankAccountId, existingTransaction.Date, transaction.Date); err != nil {
+			return err
+		}
+	}
+
 	if !isManual {
 		// Prevent the user from attempting to change a transaction's amount if we are on a plaid link.
 		if existingTransaction.Amount != transaction.Amount {
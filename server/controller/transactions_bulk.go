@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// maxBulkTransactionUpdates is the most transactions that can be updated in a single bulk request.
+const maxBulkTransactionUpdates = 500
+
+type BulkTransactionUpdate struct {
+	TransactionId uint64 `json:"transactionId"`
+	// SpendingId will spend the transaction from the specified spending object. If this is 0 then the transaction will
+	// no longer be spent from anything, and any splits the transaction has will be removed.
+	SpendingId *uint64 `json:"spendingId"`
+	// CustomName will rename the transaction. If this is blank then the transaction's original name is restored.
+	CustomName *string   `json:"customName"`
+	Categories *[]string `json:"categories"`
+	// CategoryId will assign the transaction to the specified category. If this is 0 then the transaction will no longer
+	// have a category.
+	CategoryId *uint64 `json:"categoryId"`
+	IsHidden   *bool   `json:"isHidden"`
+	// IsTransfer can be true to confirm a transaction that was matched as a transfer, or false to unlink it.
+	IsTransfer *bool `json:"isTransfer"`
+}
+
+type BulkTransactionUpdateRequest struct {
+	Transactions []BulkTransactionUpdate `json:"transactions"`
+}
+
+type BulkTransactionUpdateResult struct {
+	TransactionId uint64 `json:"transactionId"`
+	Updated       bool   `json:"updated"`
+	// Error is the reason the transaction was not updated, it is only present when Updated is false.
+	Error       *string             `json:"error,omitempty"`
+	Transaction *models.Transaction `json:"transaction,omitempty"`
+}
+
+// Bulk Update Transactions
+// @Summary Bulk Update Transactions
+// @ID bulk-update-transactions
+// @tags Transactions
+// @description Update many transactions in a bank account at once. Each item can change what the transaction is spent
+// @description from, its name, categories, category, whether it is hidden, and confirm or unlink a transfer. Only the fields that
+// @description are provided on an item are changed. Items that are not valid are not updated and their error is
+// @description included in the results, every other item is still updated. Spending balances are adjusted once for
+// @description all of the updated transactions.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param Updates body BulkTransactionUpdateRequest true "Transaction updates"
+// @Router /bank_accounts/{bankAccountId}/transactions/bulk [put]
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ApiError No updates were provided or too many updates were provided.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) putTransactionsBulk(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	var request BulkTransactionUpdateRequest
+	if err = ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if len(request.Transactions) == 0 {
+		return c.badRequest(ctx, "must specify at least one transaction to update")
+	}
+
+	if len(request.Transactions) > maxBulkTransactionUpdates {
+		return c.badRequest(ctx, "cannot update more than %d transactions at once", maxBulkTransactionUpdates)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	transactionIds := make([]uint64, 0, len(request.Transactions))
+	for _, item := range request.Transactions {
+		transactionIds = append(transactionIds, item.TransactionId)
+	}
+
+	existingTransactions, err := repo.GetTransactionsByIds(c.getContext(ctx), bankAccountId, transactionIds)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transactions for update")
+	}
+
+	allSpending, err := repo.GetSpending(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve spending for update")
+	}
+	spendingIds := make(map[uint64]struct{}, len(allSpending))
+	for _, item := range allSpending {
+		spendingIds[item.SpendingId] = struct{}{}
+	}
+
+	// Categories are only needed if one of the updates is assigning a category.
+	assignsCategory := false
+	for _, item := range request.Transactions {
+		if item.CategoryId != nil && *item.CategoryId != 0 {
+			assignsCategory = true
+			break
+		}
+	}
+
+	categoryIds := map[uint64]struct{}{}
+	if assignsCategory {
+		categories, err := repo.GetCategories(c.getContext(ctx))
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve categories for update")
+		}
+		for _, category := range categories {
+			categoryIds[category.CategoryId] = struct{}{}
+		}
+	}
+
+	results := make([]BulkTransactionUpdateResult, len(request.Transactions))
+	updates := make([]*models.Transaction, 0, len(request.Transactions))
+	existing := make([]models.Transaction, 0, len(request.Transactions))
+	resultIndexes := make([]int, 0, len(request.Transactions))
+	seen := make(map[uint64]struct{}, len(request.Transactions))
+	for i, item := range request.Transactions {
+		results[i].TransactionId = item.TransactionId
+
+		if _, ok := seen[item.TransactionId]; ok {
+			results[i].Error = myownsanity.StringP("transaction is included in the update more than once")
+			continue
+		}
+		seen[item.TransactionId] = struct{}{}
+
+		existingTransaction, ok := existingTransactions[item.TransactionId]
+		if !ok {
+			results[i].Error = myownsanity.StringP("transaction does not exist")
+			continue
+		}
+
+		updated, err := applyBulkTransactionUpdate(existingTransaction, item, spendingIds, categoryIds)
+		if err != nil {
+			results[i].Error = myownsanity.StringP(err.Error())
+			continue
+		}
+
+		updates = append(updates, updated)
+		existing = append(existing, existingTransaction)
+		resultIndexes = append(resultIndexes, i)
+	}
+
+	updatedSpending, err := repo.UpdateTransactionsBulk(c.getContext(ctx), bankAccountId, updates, existing)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to update transactions")
+	}
+
+	for i, update := range updates {
+		result := &results[resultIndexes[i]]
+		result.Updated = true
+		result.Transaction = update
+	}
+
+	balance, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not get updated balances")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"results":  results,
+		"spending": updatedSpending,
+		"balance":  balance,
+	})
+}
+
+// applyBulkTransactionUpdate returns a copy of the existing transaction with the changes from the bulk update item
+// applied. An error is returned if the changes are not valid for the transaction.
+func applyBulkTransactionUpdate(
+	existing models.Transaction,
+	item BulkTransactionUpdate,
+	spendingIds map[uint64]struct{},
+	categoryIds map[uint64]struct{},
+) (*models.Transaction, error) {
+	updated := existing
+
+	if item.IsTransfer != nil {
+		if !existing.IsTransfer() {
+			return nil, errors.New("transaction is not part of a transfer")
+		}
+
+		if *item.IsTransfer {
+			status := models.TransferStatusConfirmed
+			updated.TransferStatus = &status
+		} else {
+			status := models.TransferStatusRejected
+			updated.TransferTransactionId = nil
+			updated.TransferStatus = &status
+		}
+	}
+
+	if item.SpendingId != nil {
+		if *item.SpendingId == 0 {
+			updated.SpendingId = nil
+			updated.Splits = nil
+		} else {
+			if updated.IsAddition() {
+				return nil, errors.New("cannot specify a spent from on a deposit")
+			}
+
+			if updated.IsTransfer() {
+				return nil, errors.New("cannot spend from a transfer, the transfer must be unlinked first")
+			}
+
+			if _, ok := spendingIds[*item.SpendingId]; !ok {
+				return nil, errors.New("spending object does not exist")
+			}
+
+			spendingId := *item.SpendingId
+			updated.SpendingId = &spendingId
+			updated.Splits = nil
+		}
+	}
+
+	if item.CustomName != nil {
+		name := strings.TrimSpace(*item.CustomName)
+		if name == "" {
+			updated.CustomName = nil
+			updated.Name = existing.OriginalName
+		} else {
+			updated.CustomName = &name
+			updated.Name = name
+		}
+	}
+
+	if item.Categories != nil {
+		updated.Categories = *item.Categories
+	}
+
+	if item.CategoryId != nil {
+		if *item.CategoryId == 0 {
+			updated.CategoryId = nil
+		} else {
+			if _, ok := categoryIds[*item.CategoryId]; !ok {
+				return nil, errors.New("category does not exist")
+			}
+
+			categoryId := *item.CategoryId
+			updated.CategoryId = &categoryId
+		}
+	}
+
+	if item.IsHidden != nil {
+		updated.IsHidden = *item.IsHidden
+	}
+
+	return &updated, nil
+}
@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestPutTransactionsBulk(t *testing.T) {
+	t.Run("partial failure", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		checking := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		savings := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.SavingsBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &checking, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 20000)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, checking, 2)
+		outflow, inflow := fixtures.GivenIHaveATransfer(t, app.Clock, checking, savings, 2500)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/bulk").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"transactions": []map[string]interface{}{
+					{
+						"transactionId": transactions[0].TransactionId,
+						"spendingId":    expense.SpendingId,
+						"customName":    "Groceries",
+					},
+					{
+						"transactionId": transactions[1].TransactionId,
+						"spendingId":    expense.SpendingId,
+						"isHidden":      true,
+					},
+					{
+						"transactionId": outflow.TransactionId,
+						"spendingId":    expense.SpendingId,
+					},
+					{
+						"transactionId": 999999,
+						"isHidden":      true,
+					},
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.results").Array().Length().IsEqual(4)
+		response.JSON().Path("$.results[0].updated").Boolean().IsTrue()
+		response.JSON().Path("$.results[0].transaction.name").String().IsEqual("Groceries")
+		response.JSON().Path("$.results[0].transaction.spendingId").Number().IsEqual(expense.SpendingId)
+		response.JSON().Path("$.results[1].updated").Boolean().IsTrue()
+		response.JSON().Path("$.results[1].transaction.isHidden").Boolean().IsTrue()
+		response.JSON().Path("$.results[2].updated").Boolean().IsFalse()
+		response.JSON().Path("$.results[2].error").String().IsEqual("cannot spend from a transfer, the transfer must be unlinked first")
+		response.JSON().Path("$.results[3].updated").Boolean().IsFalse()
+		response.JSON().Path("$.results[3].error").String().IsEqual("transaction does not exist")
+		// Both transactions were spent from the same expense, it should only be returned once.
+		response.JSON().Path("$.spending").Array().Length().IsEqual(1)
+		response.JSON().Path("$.spending[0].currentAmount").Number().IsEqual(
+			20000 - transactions[0].Amount - transactions[1].Amount,
+		)
+
+		{ // Each transaction has its own ledger entry.
+			response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/ledger").
+				WithPath("bankAccountId", checking.BankAccountId).
+				WithPath("spendingId", expense.SpendingId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+			response.JSON().Path("$[0].reason").String().IsEqual(string(models.SpendingLedgerReasonTransaction))
+			response.JSON().Path("$[0].referenceId").Number().IsEqual(transactions[1].TransactionId)
+			response.JSON().Path("$[0].amount").Number().IsEqual(-transactions[1].Amount)
+			response.JSON().Path("$[1].referenceId").Number().IsEqual(transactions[0].TransactionId)
+			response.JSON().Path("$[1].amount").Number().IsEqual(-transactions[0].Amount)
+		}
+
+		// Removing the spending should give the funds back to the expense.
+		response = e.PUT("/api/bank_accounts/{bankAccountId}/transactions/bulk").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"transactions": []map[string]interface{}{
+					{
+						"transactionId": transactions[0].TransactionId,
+						"spendingId":    0,
+						"customName":    "",
+					},
+					{
+						"transactionId": transactions[1].TransactionId,
+						"spendingId":    0,
+					},
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.results[0].transaction.name").String().IsEqual(transactions[0].OriginalName)
+		response.JSON().Path("$.spending[0].currentAmount").Number().IsEqual(20000)
+
+		// Unlinking one side of a transfer unlinks the other side as well.
+		response = e.PUT("/api/bank_accounts/{bankAccountId}/transactions/bulk").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"transactions": []map[string]interface{}{
+					{
+						"transactionId": outflow.TransactionId,
+						"isTransfer":    false,
+					},
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.results[0].updated").Boolean().IsTrue()
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", savings.BankAccountId).
+			WithPath("transactionId", inflow.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transferTransactionId").IsNull()
+		response.JSON().Path("$.transferStatus").String().IsEqual(string(models.TransferStatusRejected))
+	})
+
+	t.Run("no updates", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		checking := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/bulk").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"transactions": []map[string]interface{}{},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("must specify at least one transaction to update")
+	})
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/formats/export"
+	"github.com/monetr/monetr/server/models"
+)
+
+// exportFlushInterval is how many transactions are written to an export before the response is flushed to the client.
+const exportFlushInterval = 100
+
+// Export Transactions
+// @Summary Export Transactions
+// @ID export-transactions
+// @tags Transactions
+// @description Download the transactions in a bank account as a CSV, OFX or newline-delimited JSON file. Transactions are
+// @description streamed in the order they occurred, and include the names of the spending objects they are spent from.
+// @description The start and end dates are both inclusive and are in the account's timezone.
+// @Security ApiKeyAuth
+// @Produce text/csv
+// @Produce application/x-ofx
+// @Produce application/x-ndjson
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param format query string false "The format of the export, one of csv, ofx or ndjson. Defaults to csv."
+// @Param start query string false "Only include transactions on or after this date (YYYY-MM-DD)."
+// @Param end query string false "Only include transactions on or before this date (YYYY-MM-DD)."
+// @Router /bank_accounts/{bankAccountId}/transactions/export [get]
+// @Success 200
+// @Failure 400 {object} ApiError The format or dates provided are not valid.
+// @Failure 404 {object} ApiError The bank account does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getTransactionsExport(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	format := export.CSVFormat
+	if value := strings.TrimSpace(ctx.QueryParam("format")); value != "" {
+		format, err = export.ParseFormat(value)
+		if err != nil {
+			return c.badRequest(ctx, "%s", err.Error())
+		}
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	var start, end *time.Time
+	if value := strings.TrimSpace(ctx.QueryParam("start")); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid start date, must be in the format YYYY-MM-DD")
+		}
+		start = &date
+	}
+	if value := strings.TrimSpace(ctx.QueryParam("end")); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid end date, must be in the format YYYY-MM-DD")
+		}
+		// The end date is inclusive, so include everything up until midnight of the following day.
+		date = date.AddDate(0, 0, 1)
+		end = &date
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return c.badRequest(ctx, "start date must be before the end date")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	bankAccount, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve bank account")
+	}
+
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, format.ContentType())
+	response.Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": "transactions." + format.Extension(),
+	}))
+	response.WriteHeader(http.StatusOK)
+
+	writer, err := export.NewTransactionWriter(format, response, export.Options{
+		BankAccount: *bankAccount,
+		Timezone:    timezone,
+		Start:       start,
+		End:         end,
+		Now:         c.clock.Now(),
+	})
+	if err != nil {
+		c.getLog(ctx).WithError(err).Error("failed to start transaction export")
+		return nil
+	}
+
+	// Once the response has been started there is no way to tell the client that something went wrong other than by
+	// ending the export early, so errors past this point are only logged.
+	count := 0
+	err = repo.StreamTransactions(
+		c.getContext(ctx),
+		bankAccountId,
+		start, end,
+		func(transaction models.Transaction, spendingNames []string) error {
+			if err := writer.Write(transaction, spendingNames); err != nil {
+				return err
+			}
+
+			count++
+			if count%exportFlushInterval == 0 {
+				response.Flush()
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		c.getLog(ctx).WithError(err).Error("failed to export transactions")
+		return nil
+	}
+
+	if err = writer.Close(); err != nil {
+		c.getLog(ctx).WithError(err).Error("failed to finish transaction export")
+	}
+
+	return nil
+}
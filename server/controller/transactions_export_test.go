@@ -0,0 +1,107 @@
+package controller_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTransactionsExport(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bankAccount, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 20000)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bankAccount, 3)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Rename one of the transactions and spend it from the expense.
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/bulk").
+				WithPath("bankAccountId", bankAccount.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"transactions": []map[string]interface{}{
+						{
+							"transactionId": transactions[0].TransactionId,
+							"spendingId":    expense.SpendingId,
+							"customName":    "Renamed Transaction",
+						},
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.results[0].updated").Boolean().IsTrue()
+		}
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/export").
+			WithPath("bankAccountId", bankAccount.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.Header("Content-Type").IsEqual("text/csv")
+		response.Header("Content-Disposition").IsEqual(`attachment; filename=transactions.csv`)
+		body := response.Body().Raw()
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		assert.Len(t, lines, 4, "should have a header and one row per transaction")
+		assert.True(t, strings.HasPrefix(lines[0], "transaction_id,date,name,custom_name"), "first line should be the header")
+		assert.Contains(t, body, "Renamed Transaction")
+		assert.Contains(t, body, expense.Name)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fixtures.GivenIHaveNTransactions(t, app.Clock, bankAccount, 5)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/export").
+			WithPath("bankAccountId", bankAccount.BankAccountId).
+			WithQuery("format", "ndjson").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.Header("Content-Type").IsEqual("application/x-ndjson")
+		lines := strings.Split(strings.TrimSpace(response.Body().Raw()), "\n")
+		assert.Len(t, lines, 5, "should have one line per transaction")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/export").
+			WithPath("bankAccountId", bankAccount.BankAccountId).
+			WithQuery("format", "xlsx").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual(`invalid export format "xlsx", must be one of csv, ofx or ndjson`)
+	})
+
+	t.Run("bank account does not exist", func(t *testing.T) {
+		_, e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/export").
+			WithPath("bankAccountId", 123456).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	})
+}
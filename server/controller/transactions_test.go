@@ -1,7 +1,9 @@
 package controller_test
 
 import (
+	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -86,6 +88,253 @@ func TestGetTransactions(t *testing.T) {
 	})
 }
 
+func TestGetTransactionsFiltered(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAPlaidLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 10)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	t.Run("direction", func(t *testing.T) {
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("direction", "debit").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(10)
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("direction", "credit").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(0)
+	})
+
+	t.Run("amount and spending", func(t *testing.T) {
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("minAmount", 100).
+			WithQuery("maxAmount", 10000).
+			WithQuery("spendingId", "unassigned").
+			WithQuery("pending", false).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(10)
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("minAmount", 10001).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(0)
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		date := transactions[0].Date.Format("2006-01-02")
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("startDate", date).
+			WithQuery("endDate", date).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(10)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("search", strings.ToLower(transactions[0].MerchantName)).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().NotEmpty()
+		response.JSON().Array().Length().Le(10)
+	})
+
+	t.Run("category", func(t *testing.T) {
+		var parentId, childId uint64
+		{
+			response := e.POST("/api/categories").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name": "Shopping",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			parentId = uint64(response.JSON().Path("$.categoryId").Number().Raw())
+		}
+
+		{
+			response := e.POST("/api/categories").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":             "Clothing",
+					"parentCategoryId": parentId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			childId = uint64(response.JSON().Path("$.categoryId").Number().Raw())
+		}
+
+		for i, categoryId := range []uint64{parentId, childId} {
+			transaction := transactions[i]
+			transaction.CategoryId = &categoryId
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				WithJSON(transaction).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{ // Filtering by the parent category includes transactions in its children.
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("categoryId", parentId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("categoryId", childId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].transactionId").Number().IsEqual(transactions[1].TransactionId)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("categoryId", "uncategorized").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(8)
+		}
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("direction", "sideways").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("direction must be either debit or credit")
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("startDate", "2023-10-10").
+			WithQuery("endDate", "2023-10-01").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("startDate must be before endDate")
+	})
+}
+
+func TestGetTransactionsCursor(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAPlaidLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 10)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	t.Run("follow cursors", func(t *testing.T) {
+		seen := map[float64]struct{}{}
+		cursor := ""
+		pages := 0
+		for {
+			request := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("limit", 4).
+				WithCookie(TestCookieName, token)
+			if cursor != "" {
+				request = request.WithQuery("cursor", cursor)
+			}
+			response := request.Expect()
+			response.Status(http.StatusOK)
+			pages++
+
+			for _, item := range response.JSON().Array().Iter() {
+				id := item.Object().Value("transactionId").Number().Raw()
+				_, ok := seen[id]
+				assert.False(t, ok, "transaction should not be returned more than once")
+				seen[id] = struct{}{}
+			}
+
+			cursor = response.Header("X-Next-Cursor").Raw()
+			if cursor == "" {
+				break
+			}
+		}
+
+		assert.Equal(t, 3, pages, "should have three pages of transactions")
+		assert.Len(t, seen, 10, "should have seen every transaction")
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("cursor", "not a cursor!").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("invalid cursor")
+	})
+
+	t.Run("cursor with offset", func(t *testing.T) {
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("limit", 4).
+			WithCookie(TestCookieName, token).
+			Expect()
+		response.Status(http.StatusOK)
+		cursor := response.Header("X-Next-Cursor").Raw()
+		assert.NotEmpty(t, cursor, "should have a cursor for the next page")
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("cursor", cursor).
+			WithQuery("offset", 4).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("cursor and offset cannot be used together")
+	})
+}
+
 func TestPostTransactions(t *testing.T) {
 	t.Run("bad request", func(t *testing.T) {
 		_, e := NewTestApplication(t)
@@ -110,6 +359,104 @@ func TestPostTransactions(t *testing.T) {
 	})
 }
 
+func TestPutTransactionSplits(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	groceries := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 10000)
+	household := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 1000)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	var transaction models.Transaction
+	{ // Create the transaction that will be split.
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(models.Transaction{
+				Name:      "Costco",
+				Amount:    5000,
+				Date:      app.Clock.Now(),
+				IsPending: false,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transaction").Decode(&transaction)
+	}
+
+	t.Run("invalid splits", func(t *testing.T) {
+		update := transaction
+		update.Splits = []models.TransactionSplit{
+			{SpendingId: groceries.SpendingId, Amount: 3000},
+			{SpendingId: household.SpendingId, Amount: 1000},
+		}
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(update).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("invalid transaction splits: split amounts must add up to the transaction amount, expected 5000 but got 4000")
+	})
+
+	t.Run("add splits", func(t *testing.T) {
+		update := transaction
+		update.Splits = []models.TransactionSplit{
+			{SpendingId: groceries.SpendingId, Amount: 3000},
+			{SpendingId: household.SpendingId, Amount: 2000},
+		}
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(update).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transaction.splits").Array().Length().IsEqual(2)
+		response.JSON().Path("$.spending").Array().Length().IsEqual(2)
+		// Groceries has enough to cover its split, household only had 10 dollars.
+		response.JSON().Path("$.spending[0].currentAmount").Number().IsEqual(7000)
+		response.JSON().Path("$.spending[1].currentAmount").Number().IsEqual(0)
+		response.JSON().Path("$.transaction.splits[1].spendingAmount").Number().IsEqual(1000)
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions/spending/{spendingId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", household.SpendingId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].splits").Array().Length().IsEqual(2)
+	})
+
+	t.Run("remove splits", func(t *testing.T) {
+		// Splits are omitted from the JSON when they are empty, so the empty array needs to be added manually.
+		var update map[string]interface{}
+		encoded, err := json.Marshal(transaction)
+		require.NoError(t, err, "must be able to encode transaction")
+		require.NoError(t, json.Unmarshal(encoded, &update), "must be able to decode transaction")
+		update["splits"] = []interface{}{}
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(update).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transaction").Object().NotContainsKey("splits")
+		// Everything that was deducted should have been returned.
+		response.JSON().Path("$.spending[0].currentAmount").Number().IsEqual(10000)
+		response.JSON().Path("$.spending[1].currentAmount").Number().IsEqual(1000)
+	})
+}
+
 func TestPutTransactions(t *testing.T) {
 	t.Run("update transaction name", func(t *testing.T) {
 		app, e := NewTestApplication(t)
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+)
+
+// Confirm Transfer
+// @Summary Confirm Transfer
+// @ID confirm-transfer
+// @tags Transactions
+// @description Confirm that a transaction that was automatically matched as a transfer between two bank accounts is
+// @description actually a transfer. Both sides of the transfer are confirmed.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param transactionId path int true "Transaction ID"
+// @Router /bank_accounts/{bankAccountId}/transactions/{transactionId}/transfer/confirm [post]
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} ApiError The transaction is not part of a transfer.
+// @Failure 404 {object} ApiError The transaction does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) postConfirmTransfer(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := strconv.ParseUint(ctx.Param("transactionId"), 10, 64)
+	if err != nil || transactionId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err = repo.ConfirmTransfer(c.getContext(ctx), bankAccountId, transactionId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrNotATransfer) {
+			return c.badRequest(ctx, "transaction is not part of a transfer")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to confirm transfer")
+	}
+
+	transaction, err := repo.GetTransaction(c.getContext(ctx), bankAccountId, transactionId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve confirmed transfer")
+	}
+
+	return ctx.JSON(http.StatusOK, transaction)
+}
+
+// Unlink Transfer
+// @Summary Unlink Transfer
+// @ID unlink-transfer
+// @tags Transactions
+// @description Remove the link between both sides of a transfer. Neither transaction will be matched as a transfer
+// @description again, and both can then be spent from spending objects like any other transaction.
+// @Security ApiKeyAuth
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param transactionId path int true "Transaction ID"
+// @Router /bank_accounts/{bankAccountId}/transactions/{transactionId}/transfer [delete]
+// @Success 200
+// @Failure 400 {object} ApiError The transaction is not part of a transfer.
+// @Failure 404 {object} ApiError The transaction does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) deleteTransfer(ctx echo.Context) error {
+	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
+	if err != nil || bankAccountId == 0 {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := strconv.ParseUint(ctx.Param("transactionId"), 10, 64)
+	if err != nil || transactionId == 0 {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err = repo.UnlinkTransfer(c.getContext(ctx), bankAccountId, transactionId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrNotATransfer) {
+			return c.badRequest(ctx, "transaction is not part of a transfer")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to unlink transfer")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
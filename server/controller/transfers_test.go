@@ -0,0 +1,100 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestTransfers(t *testing.T) {
+	app, e := NewTestApplication(t)
+	user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+	link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+	checking := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+	savings := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.SavingsBankAccountSubType)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &checking, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+	expense := fixtures.GivenIHaveAnExpense(t, app.Clock, fundingSchedule, 10000)
+	outflow, inflow := fixtures.GivenIHaveATransfer(t, app.Clock, checking, savings, 2500)
+	notATransfer := fixtures.GivenIHaveATransaction(t, app.Clock, checking)
+	token := GivenILogin(t, e, user.Login.Email, password)
+
+	t.Run("transfer is linked", func(t *testing.T) {
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithPath("transactionId", outflow.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transferTransactionId").Number().IsEqual(inflow.TransactionId)
+		response.JSON().Path("$.transferStatus").String().IsEqual(string(models.TransferStatusMatched))
+	})
+
+	t.Run("cannot spend from a transfer", func(t *testing.T) {
+		update := outflow
+		update.SpendingId = &expense.SpendingId
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithPath("transactionId", outflow.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(update).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("cannot spend from a transfer, the transfer must be unlinked first")
+	})
+
+	t.Run("confirm not a transfer", func(t *testing.T) {
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/transfer/confirm").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithPath("transactionId", notATransfer.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("transaction is not part of a transfer")
+	})
+
+	t.Run("confirm transfer", func(t *testing.T) {
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/transfer/confirm").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithPath("transactionId", outflow.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transferStatus").String().IsEqual(string(models.TransferStatusConfirmed))
+
+		// Both sides of the transfer should be confirmed.
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", savings.BankAccountId).
+			WithPath("transactionId", inflow.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transferStatus").String().IsEqual(string(models.TransferStatusConfirmed))
+	})
+
+	t.Run("unlink transfer", func(t *testing.T) {
+		response := e.DELETE("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/transfer").
+			WithPath("bankAccountId", savings.BankAccountId).
+			WithPath("transactionId", inflow.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+
+		response = e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+			WithPath("bankAccountId", checking.BankAccountId).
+			WithPath("transactionId", outflow.TransactionId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transferTransactionId").IsNull()
+		response.JSON().Path("$.transferStatus").String().IsEqual(string(models.TransferStatusRejected))
+	})
+}
@@ -1,11 +1,70 @@
 package controller
 
 import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-pg/pg/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/formats/csvimport"
+	"github.com/monetr/monetr/server/formats/ofx"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
+const (
+	// maxUploadSize is the largest file (in bytes) that can be uploaded to import transactions.
+	maxUploadSize = 10 * 1024 * 1024
+)
+
+// TransactionUploadResult is returned for each file that is provided to the transaction upload endpoint. It describes
+// what happened to the transactions found in that file.
+type TransactionUploadResult struct {
+	Name string `json:"name"`
+	// FileId is the Id of the stored copy of the uploaded file. It is omitted if file storage is not configured.
+	FileId  uint64   `json:"fileId,omitempty"`
+	Created int      `json:"created"`
+	Skipped int      `json:"skipped"`
+	Errored int      `json:"errored"`
+	Errors  []string `json:"errors"`
+}
+
+// uploadedBalance keeps track of the most recent balance seen across all the files in a single upload so that the bank
+// account is only updated once with the newest balance.
+type uploadedBalance struct {
+	current   int64
+	available int64
+	asOf      time.Time
+}
+
+// Upload Transactions
+// @Summary Upload Transactions
+// @ID upload-transactions
+// @tags Transactions
+// @description Import transactions for a manual bank account from one or more OFX, QFX or CSV files. Files should be
+// @description provided as a multipart form under the `data` field. Transactions are de-duplicated using the FITID
+// @description provided by the bank, so the same file can be uploaded more than once safely. If the file includes a
+// @description ledger balance, then the bank account's balances will be updated to match. CSV files are read using the
+// @description bank account's saved CSV mapping, and rows that match a transaction that was created by hand on the same
+// @description day for the same amount are skipped. A copy of each file is retained and can be retrieved later from
+// @description the bank account's files.
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Router /bank_accounts/{bankAccountId}/upload/transactions [post]
+// @Success 200 {array} TransactionUploadResult
+// @Failure 400 {object} ApiError Invalid bank account, non-manual link or no files provided.
+// @Failure 500 {object} ApiError Something went wrong on our end.
 func (c *Controller) postUploadTransactions(ctx echo.Context) error {
 	bankAccountId, err := strconv.ParseUint(ctx.Param("bankAccountId"), 10, 64)
 	if err != nil {
@@ -23,5 +82,521 @@ func (c *Controller) postUploadTransactions(ctx echo.Context) error {
 		return c.badRequest(ctx, "Cannot import transactions for non-manual link.")
 	}
 
-	return nil
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return c.badRequest(ctx, "request must be a multipart form")
+	}
+
+	files := form.File["data"]
+	if len(files) == 0 {
+		return c.badRequest(ctx, "must provide at least one file to import")
+	}
+
+	bankAccount, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve bank account")
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+
+	// Uploaded files are written to storage before the request's transaction is committed. If the request fails then
+	// their records are rolled back, so the files themselves need to be removed too.
+	stored := make([]string, 0, len(files))
+
+	var balance *uploadedBalance
+	results := make([]TransactionUploadResult, 0, len(files))
+	for _, header := range files {
+		result, fileBalance, err := c.importTransactionsFile(ctx, repo, bankAccount, header, timezone, &stored)
+		if err != nil {
+			c.removeStoredFiles(ctx, stored)
+			return err
+		}
+
+		if fileBalance != nil && (balance == nil || !fileBalance.asOf.Before(balance.asOf)) {
+			balance = fileBalance
+		}
+
+		results = append(results, *result)
+	}
+
+	if balance != nil {
+		if err = repo.UpdateBankAccountBalances(
+			c.getContext(ctx),
+			bankAccountId,
+			balance.current,
+			balance.available,
+		); err != nil {
+			c.removeStoredFiles(ctx, stored)
+			return c.wrapPgError(ctx, err, "failed to update bank account balances")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, results)
+}
+
+// importTransactionsFile will parse a single uploaded file and create any transactions from it that do not already
+// exist. Problems with the file itself are reported in the result rather than returned. An error is only returned if
+// the request as a whole should fail, like a database error. If the file is kept in file storage then its URI is added
+// to stored.
+func (c *Controller) importTransactionsFile(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	bankAccount *models.BankAccount,
+	header *multipart.FileHeader,
+	timezone *time.Location,
+	stored *[]string,
+) (*TransactionUploadResult, *uploadedBalance, error) {
+	bankAccountId := bankAccount.BankAccountId
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"bankAccountId": bankAccountId,
+		"file":          header.Filename,
+	})
+
+	result := TransactionUploadResult{
+		Name:   header.Filename,
+		Errors: make([]string, 0),
+	}
+
+	if header.Size > maxUploadSize {
+		result.Errors = append(result.Errors, fmt.Sprintf("file is too large, must be less than %d bytes", maxUploadSize))
+		return &result, nil, nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, nil, c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read uploaded file")
+	}
+	defer file.Close()
+
+	storedFile, err := c.storeUploadedFile(ctx, repo, bankAccountId, header, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if storedFile != nil {
+		*stored = append(*stored, storedFile.ObjectUri)
+		result.FileId = storedFile.FileId
+	}
+
+	var transactionsToInsert []models.Transaction
+	var balance *uploadedBalance
+	if isCSVUpload(header) {
+		transactionsToInsert, err = c.readCSVTransactions(ctx, repo, bankAccount, file, &result, timezone)
+	} else {
+		transactionsToInsert, balance, err = c.readOFXTransactions(ctx, repo, bankAccount, file, &result, timezone)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(transactionsToInsert) > 0 {
+		if err = repo.ApplyTransactionRules(c.getContext(ctx), transactionsToInsert); err != nil {
+			return nil, nil, c.wrapPgError(ctx, err, "failed to apply transaction rules to imported transactions")
+		}
+
+		if err = repo.InsertTransactions(c.getContext(ctx), transactionsToInsert); err != nil {
+			return nil, nil, c.wrapPgError(ctx, err, "failed to create imported transactions")
+		}
+
+		if err = repo.DeductTransactionSpending(c.getContext(ctx), transactionsToInsert); err != nil {
+			return nil, nil, c.wrapPgError(ctx, err, "failed to deduct spending for imported transactions")
+		}
+
+		if _, err = repo.DetectTransfers(c.getContext(ctx), transactionsToInsert); err != nil {
+			return nil, nil, c.wrapPgError(ctx, err, "failed to detect transfers in imported transactions")
+		}
+	}
+	result.Created = len(transactionsToInsert)
+
+	log.WithFields(logrus.Fields{
+		"created": result.Created,
+		"skipped": result.Skipped,
+		"errored": result.Errored,
+	}).Debug("imported transactions from uploaded file")
+
+	return &result, balance, nil
+}
+
+// storeUploadedFile keeps a copy of the uploaded file in file storage and records it so that it can be retrieved
+// later. If file storage is not configured then nothing is stored and nil is returned. The provided file is rewound
+// afterwards so that it can still be read.
+func (c *Controller) storeUploadedFile(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	bankAccountId uint64,
+	header *multipart.FileHeader,
+	file multipart.File,
+) (*models.File, error) {
+	if c.fileStorage == nil {
+		c.getLog(ctx).Debug("file storage is not configured, uploaded file will not be retained")
+		return nil, nil
+	}
+
+	contentType := uploadContentType(header)
+	uri, err := c.fileStorage.Store(c.getContext(ctx), file, contentType)
+	if err != nil {
+		return nil, c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store uploaded file")
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		c.removeStoredFiles(ctx, []string{uri})
+		return nil, c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read uploaded file")
+	}
+
+	storedFile := models.File{
+		BankAccountId:   bankAccountId,
+		Name:            header.Filename,
+		ContentType:     contentType,
+		Size:            uint64(header.Size),
+		ObjectUri:       uri,
+		CreatedByUserId: c.mustGetUserId(ctx),
+	}
+	if err = repo.CreateFile(c.getContext(ctx), &storedFile); err != nil {
+		c.removeStoredFiles(ctx, []string{uri})
+		return nil, c.wrapPgError(ctx, err, "failed to record uploaded file")
+	}
+
+	return &storedFile, nil
+}
+
+// removeStoredFiles removes files that were written to file storage by a request that is failing. The records of the
+// files are rolled back with the request, so the files would be left orphaned otherwise. Failing to remove a file is
+// only logged, as the request has already failed.
+func (c *Controller) removeStoredFiles(ctx echo.Context, uris []string) {
+	if c.fileStorage == nil {
+		return
+	}
+
+	for _, uri := range uris {
+		if err := c.fileStorage.Remove(c.getContext(ctx), uri); err != nil {
+			c.getLog(ctx).WithError(err).WithField("uri", uri).Warn("failed to remove stored file after the request failed")
+		}
+	}
+}
+
+// uploadContentType returns the content type of the uploaded file. Browsers are not consistent about the content type
+// they send for OFX and QFX files, so if a useful one was not provided it is derived from the file extension.
+func uploadContentType(header *multipart.FileHeader) string {
+	mediaType, _, err := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	if err == nil && mediaType != "" && mediaType != "application/octet-stream" {
+		return strings.ToLower(mediaType)
+	}
+
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		return "text/csv"
+	case ".ofx":
+		return "application/x-ofx"
+	case ".qfx":
+		return "application/vnd.intu.qfx"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// isCSVUpload returns true if the uploaded file appears to be a CSV file rather than an OFX or QFX file.
+func isCSVUpload(header *multipart.FileHeader) bool {
+	if strings.EqualFold(filepath.Ext(header.Filename), ".csv") {
+		return true
+	}
+
+	switch uploadContentType(header) {
+	case "text/csv", "application/csv":
+		return true
+	default:
+		return false
+	}
+}
+
+// readOFXTransactions parses an OFX or QFX file and returns the transactions from it that do not already exist, as well
+// as the most recent balance presented in the file.
+func (c *Controller) readOFXTransactions(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	bankAccount *models.BankAccount,
+	file io.Reader,
+	result *TransactionUploadResult,
+	timezone *time.Location,
+) ([]models.Transaction, *uploadedBalance, error) {
+	bankAccountId := bankAccount.BankAccountId
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"bankAccountId": bankAccountId,
+		"file":          result.Name,
+	})
+
+	statements, err := ofx.Parse(file)
+	if err != nil {
+		log.WithError(err).Warn("failed to parse uploaded transactions file")
+		result.Errors = append(result.Errors, errors.Wrap(err, "failed to parse file").Error())
+		return nil, nil, nil
+	}
+
+	transactionsToInsert := make([]models.Transaction, 0)
+	seen := map[string]struct{}{}
+	var balance *uploadedBalance
+	for _, statement := range statements {
+		// Some banks will export every account in a single file. If that is the case then only import the statement
+		// for the account that matches the bank account being imported into.
+		if len(statements) > 1 && bankAccount.Mask != "" && !strings.HasSuffix(statement.AccountId, bankAccount.Mask) {
+			log.WithField("statementAccountId", statement.AccountId).Debug("skipping statement for a different account")
+			continue
+		}
+
+		for _, statementError := range statement.Errors {
+			result.Errored++
+			result.Errors = append(result.Errors, statementError.Error())
+		}
+
+		uploadIdentifiers := make([]string, len(statement.Transactions))
+		for i, item := range statement.Transactions {
+			uploadIdentifiers[i] = item.ID
+		}
+
+		existing, err := repo.GetTransactionsByUploadIdentifier(c.getContext(ctx), bankAccountId, uploadIdentifiers)
+		if err != nil {
+			return nil, nil, c.wrapPgError(ctx, err, "failed to retrieve existing transactions")
+		}
+
+		for _, item := range statement.Transactions {
+			if _, ok := existing[item.ID]; ok {
+				result.Skipped++
+				continue
+			}
+
+			// Some banks will repeat a transaction within the same file, make sure we only create it once.
+			if _, ok := seen[item.ID]; ok {
+				result.Skipped++
+				continue
+			}
+			seen[item.ID] = struct{}{}
+
+			transactionsToInsert = append(transactionsToInsert, ofxTransactionToModel(
+				bankAccount,
+				item,
+				timezone,
+				c.clock.Now(),
+			))
+		}
+
+		if statement.LedgerBalance != nil {
+			asOf := c.clock.Now()
+			if statement.LedgerBalance.Date != nil {
+				asOf = *statement.LedgerBalance.Date
+			}
+
+			statementBalance := uploadedBalance{
+				current:   statement.LedgerBalance.Amount,
+				available: statement.LedgerBalance.Amount,
+				asOf:      asOf,
+			}
+			if statement.AvailableBalance != nil {
+				statementBalance.available = statement.AvailableBalance.Amount
+			}
+
+			if balance == nil || !statementBalance.asOf.Before(balance.asOf) {
+				balance = &statementBalance
+			}
+		}
+	}
+
+	return transactionsToInsert, balance, nil
+}
+
+// readCSVTransactions parses a CSV file using the bank account's saved mapping and returns the transactions from it
+// that do not already exist. CSV files do not include balances so the bank account's balance is not changed.
+func (c *Controller) readCSVTransactions(
+	ctx echo.Context,
+	repo repository.BaseRepository,
+	bankAccount *models.BankAccount,
+	file io.Reader,
+	result *TransactionUploadResult,
+	timezone *time.Location,
+) ([]models.Transaction, error) {
+	bankAccountId := bankAccount.BankAccountId
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"bankAccountId": bankAccountId,
+		"file":          result.Name,
+	})
+
+	mapping, err := repo.GetCSVMapping(c.getContext(ctx), bankAccountId)
+	switch errors.Cause(err) {
+	case nil:
+	case pg.ErrNoRows:
+		result.Errors = append(result.Errors, "bank account does not have a csv mapping, one must be saved before csv files can be imported")
+		return nil, nil
+	default:
+		return nil, c.wrapPgError(ctx, err, "failed to retrieve csv mapping")
+	}
+
+	parsed, err := csvimport.Parse(file, *mapping, 0)
+	if err != nil {
+		log.WithError(err).Warn("failed to parse uploaded transactions file")
+		result.Errors = append(result.Errors, errors.Wrap(err, "failed to parse file").Error())
+		return nil, nil
+	}
+
+	for _, rowError := range parsed.Errors {
+		result.Errored++
+		result.Errors = append(result.Errors, fmt.Sprintf("line %d: %s", rowError.Line, rowError.Error))
+	}
+
+	if len(parsed.Rows) == 0 {
+		return nil, nil
+	}
+
+	uploadIdentifiers := make([]string, len(parsed.Rows))
+	start, end := parsed.Rows[0].Date, parsed.Rows[0].Date
+	for i, row := range parsed.Rows {
+		uploadIdentifiers[i] = row.Identifier
+		if row.Date.Before(start) {
+			start = row.Date
+		}
+		if row.Date.After(end) {
+			end = row.Date
+		}
+	}
+
+	existing, err := repo.GetTransactionsByUploadIdentifier(c.getContext(ctx), bankAccountId, uploadIdentifiers)
+	if err != nil {
+		return nil, c.wrapPgError(ctx, err, "failed to retrieve existing transactions")
+	}
+
+	// Transactions that were created by hand before the file was uploaded do not have an upload identifier. Those are
+	// matched by their date and amount instead, and each one can only be matched by a single row.
+	inRange, err := repo.GetTransactionsByDateRange(
+		c.getContext(ctx),
+		bankAccountId,
+		time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, timezone),
+		time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, timezone),
+	)
+	if err != nil {
+		return nil, c.wrapPgError(ctx, err, "failed to retrieve existing transactions")
+	}
+
+	manual := map[string]int{}
+	for _, item := range inRange {
+		if item.UploadIdentifier != nil {
+			continue
+		}
+		manual[csvMatchKey(item.Date.In(timezone), item.Amount)]++
+	}
+
+	transactionsToInsert := make([]models.Transaction, 0, len(parsed.Rows))
+	seen := map[string]struct{}{}
+	for _, row := range parsed.Rows {
+		if _, ok := existing[row.Identifier]; ok {
+			result.Skipped++
+			continue
+		}
+
+		if _, ok := seen[row.Identifier]; ok {
+			result.Skipped++
+			continue
+		}
+		seen[row.Identifier] = struct{}{}
+
+		key := csvMatchKey(row.Date, row.Amount)
+		if manual[key] > 0 {
+			manual[key]--
+			result.Skipped++
+			continue
+		}
+
+		transactionsToInsert = append(transactionsToInsert, csvRowToModel(
+			bankAccount,
+			row,
+			timezone,
+			c.clock.Now(),
+		))
+	}
+
+	return transactionsToInsert, nil
+}
+
+func csvMatchKey(date time.Time, amount int64) string {
+	return fmt.Sprintf("%s:%d", date.Format("2006-01-02"), amount)
+}
+
+func csvRowToModel(
+	bankAccount *models.BankAccount,
+	row csvimport.Row,
+	timezone *time.Location,
+	now time.Time,
+) models.Transaction {
+	uploadIdentifier := row.Identifier
+	return models.Transaction{
+		BankAccountId:        bankAccount.BankAccountId,
+		UploadIdentifier:     &uploadIdentifier,
+		Amount:               row.Amount,
+		Date:                 time.Date(row.Date.Year(), row.Date.Month(), row.Date.Day(), 0, 0, 0, 0, timezone),
+		Name:                 row.Description,
+		OriginalName:         row.Description,
+		MerchantName:         row.Merchant,
+		OriginalMerchantName: row.Merchant,
+		Currency:             bankAccount.GetCurrency(),
+		IsPending:            false,
+		CreatedAt:            now,
+	}
+}
+
+func ofxTransactionToModel(
+	bankAccount *models.BankAccount,
+	item ofx.Transaction,
+	timezone *time.Location,
+	now time.Time,
+) models.Transaction {
+	name := strings.TrimSpace(item.Name)
+	if name == "" {
+		name = strings.TrimSpace(item.Payee)
+	}
+	if name == "" {
+		name = strings.TrimSpace(item.Memo)
+	}
+	if name == "" {
+		name = item.Type
+	}
+
+	currency := item.Currency
+	if currency == "" {
+		currency = bankAccount.GetCurrency()
+	}
+
+	// OFX files use the time of the day the bank posted the transaction, we only care about the date itself. So take
+	// the date as the bank presented it and make it midnight in the account's timezone.
+	date := time.Date(
+		item.DatePosted.Year(),
+		item.DatePosted.Month(),
+		item.DatePosted.Day(),
+		0, 0, 0, 0,
+		timezone,
+	)
+
+	var authorizedDate *time.Time
+	if item.DateUser != nil {
+		value := time.Date(
+			item.DateUser.Year(),
+			item.DateUser.Month(),
+			item.DateUser.Day(),
+			0, 0, 0, 0,
+			timezone,
+		)
+		authorizedDate = &value
+	}
+
+	uploadIdentifier := item.ID
+	// OFX represents money leaving the account as a negative number, monetr represents it as a positive number. So the
+	// amount needs to be inverted.
+	return models.Transaction{
+		BankAccountId:        bankAccount.BankAccountId,
+		UploadIdentifier:     &uploadIdentifier,
+		Amount:               -item.Amount,
+		Date:                 date,
+		AuthorizedDate:       authorizedDate,
+		Name:                 name,
+		OriginalName:         name,
+		MerchantName:         strings.TrimSpace(item.Payee),
+		OriginalMerchantName: strings.TrimSpace(item.Payee),
+		Currency:             currency,
+		IsPending:            false,
+		CreatedAt:            now,
+	}
 }
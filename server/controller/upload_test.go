@@ -0,0 +1,317 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/models"
+)
+
+const sampleOFXUpload = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<CURDEF>USD
+<BANKACCTFROM>
+<ACCTID>0000111122
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20231002
+<TRNAMT>-12.34
+<FITID>2023100201
+<NAME>WENDYS #1234
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20231005
+<TRNAMT>1500.00
+<FITID>2023100501
+<NAME>PAYROLL
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>2345.67
+<DTASOF>20231015
+</LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const sampleCSVUpload = `Date,Description,Amount
+10/02/2023,WENDYS #1234,-12.34
+10/05/2023,PAYROLL,1500.00
+`
+
+func TestPostUploadTransactions(t *testing.T) {
+	t.Run("ofx file", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Upload the file the first time, both transactions should be created.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.ofx", []byte(sampleOFXUpload)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].name").String().IsEqual("statement.ofx")
+			response.JSON().Path("$[0].created").Number().IsEqual(2)
+			response.JSON().Path("$[0].skipped").Number().IsEqual(0)
+			response.JSON().Path("$[0].errored").Number().IsEqual(0)
+		}
+
+		{ // Uploading the same file again should not create any duplicates.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.ofx", []byte(sampleOFXUpload)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$[0].created").Number().IsEqual(0)
+			response.JSON().Path("$[0].skipped").Number().IsEqual(2)
+		}
+
+		{ // The transactions and the balance should be visible.
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+			response.JSON().Path("$[0].amount").Number().IsEqual(-150000)
+			response.JSON().Path("$[1].amount").Number().IsEqual(1234)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.currentBalance").Number().IsEqual(234567)
+			response.JSON().Path("$.availableBalance").Number().IsEqual(234567)
+		}
+	})
+
+	t.Run("not a manual link", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAPlaidLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFileBytes("data", "statement.ofx", []byte(sampleOFXUpload)).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Cannot import transactions for non-manual link.")
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFileBytes("data", "statement.ofx", []byte("not an ofx file")).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$[0].created").Number().IsEqual(0)
+		response.JSON().Path("$[0].errors").Array().Length().IsEqual(1)
+	})
+
+	t.Run("csv file", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Without a mapping the file cannot be imported.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.csv", []byte(sampleCSVUpload)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$[0].created").Number().IsEqual(0)
+			response.JSON().Path("$[0].errors").Array().Length().IsEqual(1)
+		}
+
+		mapping := map[string]interface{}{
+			"skipRows":          1,
+			"dateColumn":        0,
+			"dateFormat":        "MM/DD/YYYY",
+			"amountColumn":      2,
+			"descriptionColumn": 1,
+		}
+
+		{ // Preview the file with a mapping that has not been saved yet.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/csv/preview").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("limit", 1).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.csv", []byte(sampleCSVUpload)).
+				WithFormField("mapping", `{"skipRows":1,"dateFormat":"MM/DD/YYYY","amountColumn":2,"descriptionColumn":1}`).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.rows").Array().Length().IsEqual(1)
+			response.JSON().Path("$.rows[0].amount").Number().IsEqual(1234)
+			response.JSON().Path("$.rows[0].description").String().IsEqual("WENDYS #1234")
+		}
+
+		{ // Save the mapping.
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/upload/csv/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(mapping).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.csvMappingId").Number().Gt(0)
+			response.JSON().Path("$.delimiter").String().IsEqual(",")
+			response.JSON().Path("$.signConvention").String().IsEqual("negativeDebit")
+		}
+
+		{ // Create the payroll transaction by hand, the upload should not duplicate it.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"bankAccountId": bank.BankAccountId,
+					"name":          "Paycheck",
+					"amount":        -150000,
+					"date":          time.Date(2023, 10, 5, 0, 0, 0, 0, time.UTC),
+					"isPending":     false,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.csv", []byte(sampleCSVUpload)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$[0].created").Number().IsEqual(1)
+			response.JSON().Path("$[0].skipped").Number().IsEqual(1)
+		}
+
+		{ // Uploading the same file again should not create any duplicates.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.csv", []byte(sampleCSVUpload)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$[0].created").Number().IsEqual(0)
+			response.JSON().Path("$[0].skipped").Number().IsEqual(2)
+		}
+	})
+
+	t.Run("csv file uses the bank account currency", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var bankAccountId uint64
+		{ // Create a bank account in euros.
+			response := e.POST("/api/bank_accounts").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"linkId":         link.LinkId,
+					"name":           "Girokonto",
+					"currency":       "eur",
+					"accountType":    models.DepositoryBankAccountType,
+					"accountSubType": models.CheckingBankAccountSubType,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.currency").String().IsEqual("EUR")
+			bankAccountId = uint64(response.JSON().Path("$.bankAccountId").Number().Raw())
+		}
+
+		{ // Save the mapping.
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/upload/csv/mapping").
+				WithPath("bankAccountId", bankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"skipRows":          1,
+					"dateColumn":        0,
+					"dateFormat":        "MM/DD/YYYY",
+					"amountColumn":      2,
+					"descriptionColumn": 1,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/upload/transactions").
+				WithPath("bankAccountId", bankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "statement.csv", []byte(sampleCSVUpload)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$[0].created").Number().IsEqual(2)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$[0].currency").String().IsEqual("EUR")
+			response.JSON().Path("$[1].currency").String().IsEqual("EUR")
+		}
+	})
+}
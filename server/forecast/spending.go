@@ -251,6 +251,19 @@ func (s *spendingInstructionBase) getNextSpendingEventAfter(ctx context.Context,
 	// The amount of funds currently allocated towards this spending item. This is not increased until the next funding
 	// event, or the user transfers funds to this spending item.
 
+	// A deficit carried over from the previous period is only added to the recurrence the spending object is currently
+	// waiting for, later recurrences only need the target amount. Forecasts assume that each recurrence spends exactly
+	// the target amount, so the release and sweep rollover policies never have anything left over to move.
+	var carriedDeficit int64
+	if nextRecurrence.Equal(util.Midnight(s.spending.NextRecurrence, timezone)) {
+		carriedDeficit = s.spending.GetCarriedDeficit()
+	}
+	transactionAmount := s.spending.TargetAmount + carriedDeficit
+	amountBeforeFirst := perSpendingAmount * eventsBeforeFirst
+	if eventsBeforeFirst > 0 {
+		amountBeforeFirst += carriedDeficit
+	}
+
 	event := SpendingEvent{
 		Date:               time.Time{},
 		TransactionAmount:  0,
@@ -270,19 +283,22 @@ func (s *spendingInstructionBase) getNextSpendingEventAfter(ctx context.Context,
 		// We need to subtract the spending that will happen before the next period though.
 		// We have $5 allocated but between now and the next funding we need to spend $5. So we cannot take the $5 we
 		// currently have into account when we calculate how much will be needed for the next funding event.
-		amountAfterCurrentSpending := myownsanity.Max(0, balance-(perSpendingAmount*eventsBeforeFirst))
+		amountAfterCurrentSpending := myownsanity.Max(0, balance-amountBeforeFirst)
 		// The total amount we need is determined by how many times we will need the target amount during the next period
 		// between funding events multiplied by how much each spending event costs.
 		// If the current spending object is over-allocated for this funding period and the next funding period then
 		// this can result in a negative contribution amount. Because we would be subtracting more than the calculated
 		// amount that we need.
 		nextSpendingPeriodTotal := perSpendingAmount * eventsBeforeSecond
+		if eventsBeforeFirst == 0 {
+			nextSpendingPeriodTotal += carriedDeficit
+		}
 		// By taking the min of the amount we will have allocated and the amount needed. We can safely arrive at a 0
 		// contribution amount when we are over-allocated.
 		totalContributionAmount = nextSpendingPeriodTotal - myownsanity.Min(amountAfterCurrentSpending, nextSpendingPeriodTotal)
 	} else {
 		// Otherwise we can simply look at how much we need vs how much we already have.
-		amountNeeded := myownsanity.Max(0, perSpendingAmount-balance)
+		amountNeeded := myownsanity.Max(0, perSpendingAmount+carriedDeficit-balance)
 		// And how many times we will have a funding event before our due date.
 		numberOfContributions := s.funding.GetNumberOfFundingEventsBetween(ctx, input, nextRecurrence, timezone)
 		// Then determine how much we would need at each of those funding events.
@@ -301,18 +317,18 @@ func (s *spendingInstructionBase) getNextSpendingEventAfter(ctx context.Context,
 	case nextRecurrence.Before(fundingFirst.Date):
 		// The next event will be a transaction.
 		event.Date = nextRecurrence
-		event.TransactionAmount = s.spending.TargetAmount
+		event.TransactionAmount = transactionAmount
 		// NOTE At the time of writing this, event.RollingAllocation is not being defined anywhere. But this is
 		// ultimately what the math will end up being once it is defined, and we calculate the effects of a transaction.
-		event.RollingAllocation = event.RollingAllocation - s.spending.TargetAmount
+		event.RollingAllocation = event.RollingAllocation - transactionAmount
 	case nextRecurrence.Equal(fundingFirst.Date):
 		// The next event will be both a contribution and a transaction.
 		event.Date = nextRecurrence
 		event.ContributionAmount = totalContributionAmount
-		event.TransactionAmount = s.spending.TargetAmount
+		event.TransactionAmount = transactionAmount
 		// NOTE At the time of writing this, event.RollingAllocation is not being defined anywhere. But this is
 		// ultimately what the math will end up being once it is defined, and we calculate the effects of a transaction.
-		event.RollingAllocation = (event.RollingAllocation + totalContributionAmount) - s.spending.TargetAmount
+		event.RollingAllocation = (event.RollingAllocation + totalContributionAmount) - transactionAmount
 		event.Funding = []FundingEvent{
 			fundingFirst,
 		}
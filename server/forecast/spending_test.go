@@ -88,6 +88,44 @@ func TestSpendingInstructionBase_GetNextSpendingEventAfter(t *testing.T) {
 		}, events)
 	})
 
+	t.Run("carried deficit", func(t *testing.T) {
+		timezone := testutils.Must(t, time.LoadLocation, "America/Chicago")
+		fundingRule := testutils.NewRuleSet(t, 2022, 1, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
+		spendingRule := testutils.NewRuleSet(t, 2022, 10, 8, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8")
+		now := time.Date(2022, 9, 13, 0, 0, 1, 0, timezone).UTC()
+		log := testutils.GetLog(t)
+		fundingInstructions := NewFundingScheduleFundingInstructions(
+			log,
+			models.FundingSchedule{
+				RuleSet:         fundingRule,
+				ExcludeWeekends: false,
+				NextOccurrence:  time.Date(2022, 9, 15, 0, 0, 0, 0, timezone),
+			},
+		)
+		spendingInstructions := NewSpendingInstructions(
+			log,
+			models.Spending{
+				SpendingType:   models.SpendingTypeExpense,
+				TargetAmount:   5000,
+				CurrentAmount:  0,
+				NextRecurrence: time.Date(2022, 10, 8, 0, 0, 0, 0, timezone),
+				RuleSet:        spendingRule,
+				RolloverPolicy: models.SpendingRolloverPolicyCarryDeficit,
+				CarriedDeficit: 1000,
+			},
+			fundingInstructions,
+		)
+
+		events := spendingInstructions.GetNextNSpendingEventsAfter(context.Background(), 4, now, timezone)
+		assert.Len(t, events, 4)
+		assert.EqualValues(t, 3000, events[0].ContributionAmount, "the deficit should be included in the contributions before the next recurrence")
+		assert.EqualValues(t, 3000, events[1].ContributionAmount)
+		assert.Equal(t, time.Date(2022, 10, 8, 0, 0, 0, 0, timezone), events[2].Date)
+		assert.EqualValues(t, 6000, events[2].TransactionAmount, "the deficit should be spent at the next recurrence")
+		assert.EqualValues(t, 0, events[2].RollingAllocation)
+		assert.EqualValues(t, 2500, events[3].ContributionAmount, "the deficit should not be carried past the next recurrence")
+	})
+
 	t.Run("spent more frequently than funded", func(t *testing.T) {
 		timezone := testutils.Must(t, time.LoadLocation, "America/Chicago")
 		fundingRule := testutils.NewRuleSet(t, 2022, 1, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
@@ -0,0 +1,111 @@
+package budgetimport
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// actualIncomeCategory is the category Actual Budget assigns income to by default, like YNAB's inflow it is not
+// something money is spent from.
+const actualIncomeCategory = "Income"
+
+func isActualTransactions(t *table) bool {
+	return t.has("account", "date", "payee", "notes", "category", "amount")
+}
+
+func parseActual(files []File) (*Budget, error) {
+	var transactions *table
+	for _, file := range files {
+		t, err := readTable(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if isActualTransactions(t) {
+			transactions = t
+			break
+		}
+	}
+
+	if transactions == nil {
+		return nil, errors.New("export does not contain Actual Budget transactions")
+	}
+
+	dates := make([]string, 0, len(transactions.rows))
+	for _, row := range transactions.rows {
+		dates = append(dates, transactions.value(row, "date"))
+	}
+	layout, err := detectDateLayout(dates)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", transactions.name)
+	}
+
+	budget := &Budget{
+		Source: ActualSource,
+	}
+	accounts := map[string]int{}
+	for i, row := range transactions.rows {
+		line := i + 2
+		accountName := transactions.value(row, "account")
+		if accountName == "" {
+			return nil, errors.Errorf("transaction on line %d of %s does not have an account", line, transactions.name)
+		}
+
+		date, err := time.Parse(layout, transactions.value(row, "date"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read date on line %d of %s", line, transactions.name)
+		}
+
+		// Actual Budget uses negative amounts for money leaving the account, which is the opposite of monetr.
+		amount, err := parseAmount(transactions.value(row, "amount"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read amount on line %d of %s", line, transactions.name)
+		}
+
+		index, ok := accounts[accountName]
+		if !ok {
+			index = len(budget.Accounts)
+			accounts[accountName] = index
+			budget.Accounts = append(budget.Accounts, Account{
+				Name: accountName,
+			})
+		}
+		account := &budget.Accounts[index]
+		account.Balance += amount
+
+		payee := transactions.value(row, "payee")
+		if payee == startingBalancePayee {
+			continue
+		}
+
+		transaction := Transaction{
+			Line:     line,
+			Date:     date,
+			Payee:    payee,
+			Memo:     transactions.value(row, "notes"),
+			Category: transactions.value(row, "category"),
+			Amount:   -amount,
+		}
+		if transaction.Category == actualIncomeCategory {
+			transaction.Category = ""
+		}
+		account.Transactions = append(account.Transactions, transaction)
+	}
+
+	// Actual Budget names the payee of a transfer after the account on the other side of it. This can only be checked
+	// once every account is known.
+	for i := range budget.Accounts {
+		for j := range budget.Accounts[i].Transactions {
+			transaction := &budget.Accounts[i].Transactions[j]
+			if _, ok := accounts[transaction.Payee]; ok {
+				transaction.Transfer = true
+				transaction.Category = ""
+			}
+		}
+	}
+
+	budget.Categories = categoriesFromTransactions(budget.Accounts)
+
+	return budget, nil
+}
@@ -0,0 +1,246 @@
+package budgetimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/formats/csvimport"
+	"github.com/pkg/errors"
+)
+
+// Source is the budgeting app that an export came from.
+type Source string
+
+const (
+	YNABSource   Source = "ynab"
+	ActualSource Source = "actual"
+)
+
+// ParseSource returns the source with the provided name, names are not case-sensitive.
+func ParseSource(input string) (Source, error) {
+	switch source := Source(strings.ToLower(strings.TrimSpace(input))); source {
+	case YNABSource, ActualSource:
+		return source, nil
+	default:
+		return "", errors.Errorf("invalid budget source %q, must be one of ynab or actual", input)
+	}
+}
+
+// Name is how the source is presented to the user, it is also used as the institution name of the imported link.
+func (s Source) Name() string {
+	switch s {
+	case YNABSource:
+		return "YNAB"
+	case ActualSource:
+		return "Actual Budget"
+	default:
+		return string(s)
+	}
+}
+
+// Budget is everything read from an export in a form that is independent of the app it came from.
+type Budget struct {
+	Source     Source
+	Accounts   []Account
+	Categories []Category
+}
+
+type Account struct {
+	Name string
+	// Balance is the account's balance in cents, derived from all of the account's transactions. Money in the account
+	// is positive.
+	Balance      int64
+	Transactions []Transaction
+}
+
+type Transaction struct {
+	// Line is the line in the file that the transaction was read from.
+	Line  int
+	Date  time.Time
+	Payee string
+	Memo  string
+	// CategoryGroup and Category are blank for transactions that are not categorized, like income and transfers.
+	CategoryGroup string
+	Category      string
+	// Amount is in cents and uses monetr's sign convention, positive values are money leaving the account.
+	Amount   int64
+	Transfer bool
+}
+
+type Category struct {
+	Group string
+	Name  string
+	// Available is how much the category had left to spend as of the most recent month in the export.
+	Available int64
+	// Assigned is how much was budgeted to the category in the most recent month in the export.
+	Assigned int64
+}
+
+// File is a single file from an export.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Parse reads the provided export files. Zip archives are expanded, and each CSV file is identified by its header row,
+// so the files can be provided in any order and with any name.
+//
+// YNAB exports contain a register of every transaction and a plan with what was assigned to each category per month,
+// the plan is optional. Actual Budget can export the transactions of all accounts as a single CSV file.
+func Parse(source Source, files []File) (*Budget, error) {
+	expanded, err := expandArchives(files)
+	if err != nil {
+		return nil, err
+	}
+
+	switch source {
+	case YNABSource:
+		return parseYNAB(expanded)
+	case ActualSource:
+		return parseActual(expanded)
+	default:
+		return nil, errors.Errorf("invalid budget source %q", source)
+	}
+}
+
+func expandArchives(files []File) ([]File, error) {
+	result := make([]File, 0, len(files))
+	for _, file := range files {
+		if !strings.EqualFold(filepath.Ext(file.Name), ".zip") {
+			result = append(result, file)
+			continue
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(file.Data), int64(len(file.Data)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", file.Name)
+		}
+
+		for _, item := range archive.File {
+			if item.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(item.Name), ".csv") {
+				continue
+			}
+
+			reader, err := item.Open()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s from %s", item.Name, file.Name)
+			}
+			data, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s from %s", item.Name, file.Name)
+			}
+
+			result = append(result, File{
+				Name: item.Name,
+				Data: data,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// table is a CSV file where the columns are looked up by the names in the header row.
+type table struct {
+	name    string
+	columns map[string]int
+	rows    [][]string
+}
+
+func readTable(file File) (*table, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(file.Data, []byte("\xEF\xBB\xBF"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", file.Name)
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf("%s is empty", file.Name)
+	}
+
+	result := &table{
+		name:    file.Name,
+		columns: map[string]int{},
+		rows:    records[1:],
+	}
+	for i, column := range records[0] {
+		result.columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	return result, nil
+}
+
+// has returns true if the table has all of the specified columns.
+func (t *table) has(columns ...string) bool {
+	for _, column := range columns {
+		if _, ok := t.columns[column]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// value returns the specified column of a row, or a blank string if the row does not have that column.
+func (t *table) value(row []string, column string) string {
+	index, ok := t.columns[column]
+	if !ok || index >= len(row) {
+		return ""
+	}
+
+	return strings.TrimSpace(row[index])
+}
+
+// dateLayouts are the date formats that budgeting apps will export. Formats where the day and month could be confused
+// are tried with the month first, a layout is only used if every date in the file can be read with it.
+var dateLayouts = []string{
+	"01/02/2006",
+	"02/01/2006",
+	"2006-01-02",
+	"2006/01/02",
+	"02.01.2006",
+	"01.02.2006",
+}
+
+// detectDateLayout returns the first layout that every one of the provided dates can be read with.
+func detectDateLayout(dates []string) (string, error) {
+	for _, layout := range dateLayouts {
+		valid := true
+		for _, date := range dates {
+			if _, err := time.Parse(layout, date); err != nil {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			return layout, nil
+		}
+	}
+
+	return "", errors.New("dates are not in a recognized format")
+}
+
+// parseAmount reads an amount that may use either a period or a comma as its decimal separator. The separator is the
+// last period or comma if it is followed by one or two digits, otherwise the amount is treated as whole units.
+func parseAmount(input string) (int64, error) {
+	digits := strings.TrimRightFunc(strings.TrimSpace(input), func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != ','
+	})
+	separator := "."
+	if index := strings.LastIndexAny(digits, ".,"); index >= 0 && len(digits)-index-1 <= 2 {
+		separator = digits[index : index+1]
+	} else if index >= 0 {
+		// The last separator is a thousands separator, so there is no decimal separator in the value.
+		separator = "#"
+	}
+
+	return csvimport.ParseAmount(input, separator)
+}
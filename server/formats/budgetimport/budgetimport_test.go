@@ -0,0 +1,210 @@
+package budgetimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ynabRegister = "\xEF\xBB\xBF" + `"Account","Flag","Date","Payee","Category Group/Category","Category Group","Category","Memo","Outflow","Inflow","Cleared"
+"Checking","","09/01/2023","Starting Balance","Inflow: Ready to Assign","Inflow","Ready to Assign","",$0.00,"$2,500.00","Reconciled"
+"Checking","","09/02/2023","Grocery Store","Everyday Expenses: Groceries","Everyday Expenses","Groceries","",$84.12,$0.00,"Cleared"
+"Checking","","09/15/2023","Employer","Inflow: Ready to Assign","Inflow","Ready to Assign","Paycheck",$0.00,"$1,800.00","Cleared"
+"Checking","","10/03/2023","Grocery Store","Everyday Expenses: Groceries","Everyday Expenses","Groceries","",$120.50,$0.00,"Cleared"
+"Checking","","10/05/2023","Transfer : Savings","","","","",$200.00,$0.00,"Cleared"
+"Checking","","10/06/2023","Travel Agent","Savings Goals: Vacation","Savings Goals","Vacation","Deposit",$50.00,$0.00,"Cleared"
+"Savings","","09/01/2023","Starting Balance","Inflow: Ready to Assign","Inflow","Ready to Assign","",$0.00,"$1,000.00","Reconciled"
+"Savings","","10/05/2023","Transfer : Checking","","","","",$0.00,$200.00,"Cleared"
+"Visa","","09/01/2023","Starting Balance","","","","",$300.00,$0.00,"Reconciled"
+"Visa","","10/07/2023","Gas Station","Everyday Expenses: Fuel","Everyday Expenses","Fuel","",$45.00,$0.00,"Uncleared"
+`
+
+const ynabPlan = `"Month","Category Group/Category","Category Group","Category","Budgeted","Activity","Available"
+"Sep 2023","Inflow: Ready to Assign","Inflow","Ready to Assign",$0.00,"$5,300.00","$5,300.00"
+"Sep 2023","Everyday Expenses: Groceries","Everyday Expenses","Groceries",$400.00,-$84.12,$315.88
+"Sep 2023","Everyday Expenses: Fuel","Everyday Expenses","Fuel",$100.00,$0.00,$100.00
+"Sep 2023","Savings Goals: Vacation","Savings Goals","Vacation",$100.00,$0.00,$100.00
+"Sep 2023","Credit Card Payments: Visa","Credit Card Payments","Visa",$0.00,$0.00,$0.00
+"Oct 2023","Everyday Expenses: Groceries","Everyday Expenses","Groceries",$400.00,-$120.50,$595.38
+"Oct 2023","Everyday Expenses: Fuel","Everyday Expenses","Fuel",$100.00,-$45.00,$155.00
+"Oct 2023","Savings Goals: Vacation","Savings Goals","Vacation",$100.00,-$50.00,$150.00
+"Oct 2023","Credit Card Payments: Visa","Credit Card Payments","Visa",$0.00,$45.00,$45.00
+`
+
+const actualTransactions = `Account,Date,Payee,Notes,Category,Amount,Split_Amount,Cleared
+Checking,2023-09-01,Starting Balance,,Starting Balances,2500.00,0,Reconciled
+Checking,2023-09-02,Grocery Store,,Groceries,-84.12,0,Cleared
+Checking,2023-09-15,Employer,Paycheck,Income,1800.00,0,Cleared
+Checking,2023-10-05,Savings,,,-200.00,0,Cleared
+Savings,2023-10-05,Checking,,,200.00,0,Cleared
+`
+
+func TestParseSource(t *testing.T) {
+	source, err := ParseSource(" YNAB ")
+	assert.NoError(t, err)
+	assert.Equal(t, YNABSource, source)
+
+	source, err = ParseSource("mint")
+	assert.EqualError(t, err, `invalid budget source "mint", must be one of ynab or actual`)
+	assert.Empty(t, source)
+}
+
+func TestParse(t *testing.T) {
+	t.Run("ynab", func(t *testing.T) {
+		budget, err := Parse(YNABSource, []File{
+			{Name: "Budget as of 2023-10-09 - Plan.csv", Data: []byte(ynabPlan)},
+			{Name: "Budget as of 2023-10-09 - Register.csv", Data: []byte(ynabRegister)},
+		})
+		require.NoError(t, err, "must be able to parse YNAB export")
+
+		require.Len(t, budget.Accounts, 3)
+		checking := budget.Accounts[0]
+		assert.Equal(t, "Checking", checking.Name)
+		assert.EqualValues(t, 384538, checking.Balance, "balance should include the starting balance")
+		require.Len(t, checking.Transactions, 5, "starting balance should not be a transaction")
+		assert.Equal(t, time.Date(2023, 9, 2, 0, 0, 0, 0, time.UTC), checking.Transactions[0].Date)
+		assert.EqualValues(t, 8412, checking.Transactions[0].Amount, "outflows should be positive")
+		assert.Equal(t, "Groceries", checking.Transactions[0].Category)
+		assert.EqualValues(t, -180000, checking.Transactions[1].Amount, "inflows should be negative")
+		assert.Empty(t, checking.Transactions[1].Category, "income should not have a category")
+		assert.True(t, checking.Transactions[3].Transfer, "transfers should be detected by their payee")
+		assert.EqualValues(t, -34500, budget.Accounts[2].Balance, "credit card should be overdrawn")
+
+		assert.Equal(t, []Category{
+			{Group: "Everyday Expenses", Name: "Groceries", Available: 59538, Assigned: 40000},
+			{Group: "Everyday Expenses", Name: "Fuel", Available: 15500, Assigned: 10000},
+			{Group: "Savings Goals", Name: "Vacation", Available: 15000, Assigned: 10000},
+		}, budget.Categories, "only categories from the latest month should be used")
+	})
+
+	t.Run("ynab zip without plan", func(t *testing.T) {
+		buffer := bytes.NewBuffer(nil)
+		archive := zip.NewWriter(buffer)
+		writer, err := archive.Create("Budget - Register.csv")
+		require.NoError(t, err)
+		_, err = writer.Write([]byte(ynabRegister))
+		require.NoError(t, err)
+		require.NoError(t, archive.Close())
+
+		budget, err := Parse(YNABSource, []File{
+			{Name: "export.zip", Data: buffer.Bytes()},
+		})
+		require.NoError(t, err, "must be able to parse zipped YNAB export")
+		assert.Len(t, budget.Accounts, 3)
+		assert.Equal(t, []Category{
+			{Group: "Everyday Expenses", Name: "Groceries"},
+			{Group: "Savings Goals", Name: "Vacation"},
+			{Group: "Everyday Expenses", Name: "Fuel"},
+		}, budget.Categories, "categories should be derived from transactions")
+	})
+
+	t.Run("ynab missing register", func(t *testing.T) {
+		budget, err := Parse(YNABSource, []File{
+			{Name: "plan.csv", Data: []byte(ynabPlan)},
+		})
+		assert.EqualError(t, err, "export does not contain a YNAB register")
+		assert.Nil(t, budget)
+	})
+
+	t.Run("actual", func(t *testing.T) {
+		budget, err := Parse(ActualSource, []File{
+			{Name: "transactions.csv", Data: []byte(actualTransactions)},
+		})
+		require.NoError(t, err, "must be able to parse Actual Budget export")
+
+		require.Len(t, budget.Accounts, 2)
+		checking := budget.Accounts[0]
+		assert.EqualValues(t, 401588, checking.Balance)
+		require.Len(t, checking.Transactions, 3)
+		assert.EqualValues(t, 8412, checking.Transactions[0].Amount, "outflows should be positive")
+		assert.Empty(t, checking.Transactions[1].Category, "income should not have a category")
+		assert.True(t, checking.Transactions[2].Transfer, "payee matching another account should be a transfer")
+		assert.True(t, budget.Accounts[1].Transactions[0].Transfer)
+		assert.Equal(t, []Category{{Name: "Groceries"}}, budget.Categories)
+	})
+}
+
+func TestDetectDateLayout(t *testing.T) {
+	layout, err := detectDateLayout([]string{"09/01/2023", "10/12/2023"})
+	assert.NoError(t, err)
+	assert.Equal(t, "01/02/2006", layout)
+
+	layout, err = detectDateLayout([]string{"09/01/2023", "25/12/2023"})
+	assert.NoError(t, err)
+	assert.Equal(t, "02/01/2006", layout, "a day above 12 means the day is first")
+
+	layout, err = detectDateLayout([]string{"Sept 1st"})
+	assert.EqualError(t, err, "dates are not in a recognized format")
+	assert.Empty(t, layout)
+}
+
+func TestParseAmount(t *testing.T) {
+	for input, expected := range map[string]int64{
+		"$1,234.56":  123456,
+		"1.234,56 €": 123456,
+		"-$84.12":    -8412,
+		"12.5":       1250,
+		"1,000":      100000,
+		"0":          0,
+	} {
+		amount, err := parseAmount(input)
+		assert.NoError(t, err, "input: %s", input)
+		assert.Equal(t, expected, amount, "input: %s", input)
+	}
+}
+
+func TestImport(t *testing.T) {
+	clock := clock.NewMock()
+	clock.Set(time.Date(2023, 10, 9, 13, 32, 0, 0, time.UTC))
+	user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+	repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+	timezone := testutils.MustEz(t, user.Account.GetTimezone)
+
+	budget, err := Parse(YNABSource, []File{
+		{Name: "plan.csv", Data: []byte(ynabPlan)},
+		{Name: "register.csv", Data: []byte(ynabRegister)},
+	})
+	require.NoError(t, err, "must be able to parse YNAB export")
+
+	result, err := Import(context.Background(), repo, budget, Options{
+		Timezone: timezone,
+		Now:      clock.Now(),
+	})
+	require.NoError(t, err, "must be able to import budget")
+	assert.Equal(t, 3, result.BankAccounts)
+	assert.Equal(t, 3, result.Spending)
+	assert.Equal(t, 7, result.Transactions)
+	assert.Equal(t, 1, result.Transfers)
+
+	bankAccounts, err := repo.GetBankAccountsByLinkId(context.Background(), result.LinkId)
+	require.NoError(t, err)
+	require.Len(t, bankAccounts, 3)
+	accounts := map[string]models.BankAccount{}
+	for _, bankAccount := range bankAccounts {
+		accounts[bankAccount.Name] = bankAccount
+	}
+	assert.Equal(t, models.CreditBankAccountType, accounts["Visa"].Type, "overdrawn account should be a credit card")
+	assert.EqualValues(t, 34500, accounts["Visa"].CurrentBalance)
+
+	spending, err := repo.GetSpending(context.Background(), accounts["Checking"].BankAccountId)
+	require.NoError(t, err)
+	require.Len(t, spending, 3, "categories should be created on the account they were spent from")
+	byName := map[string]models.Spending{}
+	for _, item := range spending {
+		byName[item.Name] = item
+	}
+	assert.Equal(t, models.SpendingTypeExpense, byName["Groceries"].SpendingType)
+	assert.EqualValues(t, 59538, byName["Groceries"].CurrentAmount, "available amount should be carried over")
+	assert.EqualValues(t, 59538, byName["Groceries"].TargetAmount)
+	assert.Equal(t, models.SpendingTypeGoal, byName["Vacation"].SpendingType, "categories in a goal group should be goals")
+}
@@ -0,0 +1,359 @@
+package budgetimport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// monthlyRule is used for the funding schedule that is created for the imported budget and for every expense. Both
+// YNAB and Actual Budget budget by calendar month, so money is assigned and spent on the first of each month.
+const monthlyRule = "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=1"
+
+type Options struct {
+	// Timezone is the account's timezone, dates in the export are treated as dates in this timezone.
+	Timezone *time.Location
+	Now      time.Time
+}
+
+// Result describes what was created by an import.
+type Result struct {
+	LinkId       uint64 `json:"linkId"`
+	BankAccounts int    `json:"bankAccounts"`
+	Spending     int    `json:"spending"`
+	Transactions int    `json:"transactions"`
+	Transfers    int    `json:"transfers"`
+	// Warnings are things that could not be imported exactly as they were in the export, but that did not prevent the
+	// rest of the import.
+	Warnings []string `json:"warnings"`
+}
+
+func (r *Result) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Import creates everything in the budget in the account that the repository belongs to. Everything is created under a
+// single new manual link named after the source of the budget, so an import can be removed by removing that link.
+//
+// Each account in the budget becomes a manual bank account. Categories are created as expenses (or as goals when their
+// group is a goal group) on the primary bank account, which is the account that most of the budget's categorized
+// spending came from. The amount available in each category is carried over as the spending object's current amount,
+// and transactions in the primary account are assigned to the spending object for their category. Because the amount
+// available already reflects those transactions, nothing is deducted again when they are imported.
+//
+// Import should be called within a transaction so that nothing is left behind if it fails part way.
+func Import(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	budget *Budget,
+	options Options,
+) (*Result, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"source":     budget.Source,
+		"accounts":   len(budget.Accounts),
+		"categories": len(budget.Categories),
+	}
+
+	if len(budget.Accounts) == 0 {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return nil, errors.New("budget does not contain any accounts")
+	}
+
+	result := &Result{
+		Warnings: make([]string, 0),
+	}
+
+	link := models.Link{
+		LinkType:        models.ManualLinkType,
+		LinkStatus:      models.LinkStatusSetup,
+		InstitutionName: budget.Source.Name(),
+	}
+	if err := repo.CreateLink(span.Context(), &link); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to create link for budget")
+	}
+	result.LinkId = link.LinkId
+
+	bankAccounts := make([]*models.BankAccount, len(budget.Accounts))
+	for i, account := range budget.Accounts {
+		bankAccount := &models.BankAccount{
+			LinkId:           link.LinkId,
+			Name:             account.Name,
+			PlaidName:        account.Name,
+			AvailableBalance: account.Balance,
+			CurrentBalance:   account.Balance,
+			Type:             models.DepositoryBankAccountType,
+			SubType:          models.CheckingBankAccountSubType,
+			LastUpdated:      options.Now,
+		}
+		// Neither app records what kind of account something is in its exports, so anything that is overdrawn is
+		// assumed to be a credit card. monetr tracks the amount owed on a credit card as a positive balance.
+		if account.Balance < 0 {
+			bankAccount.Type = models.CreditBankAccountType
+			bankAccount.SubType = models.CreditCardBankAccountSubType
+			bankAccount.AvailableBalance = -account.Balance
+			bankAccount.CurrentBalance = -account.Balance
+		} else if strings.Contains(strings.ToLower(account.Name), "saving") {
+			bankAccount.SubType = models.SavingsBankAccountSubType
+		}
+		bankAccounts[i] = bankAccount
+	}
+	if err := repo.CreateBankAccounts(span.Context(), bankAccounts...); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to create bank accounts for budget")
+	}
+	result.BankAccounts = len(bankAccounts)
+
+	primary := primaryAccount(budget.Accounts)
+	spending, err := importCategories(span.Context(), repo, budget, bankAccounts[primary], options, result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, err
+	}
+
+	transactions := make([]models.Transaction, 0)
+	for i, account := range budget.Accounts {
+		for _, item := range account.Transactions {
+			name := item.Payee
+			if name == "" {
+				name = item.Memo
+			}
+
+			transaction := models.Transaction{
+				BankAccountId: bankAccounts[i].BankAccountId,
+				Amount:        item.Amount,
+				Categories:    categories(item),
+				Date:          time.Date(item.Date.Year(), item.Date.Month(), item.Date.Day(), 0, 0, 0, 0, options.Timezone),
+				Name:          name,
+				OriginalName:  name,
+				Currency:      "USD",
+				CreatedAt:     options.Now,
+			}
+			if i == primary && item.Amount > 0 {
+				if spendingObject, ok := spending[categoryKey(item.CategoryGroup, item.Category)]; ok {
+					spendingId, spendingAmount := spendingObject.SpendingId, item.Amount
+					transaction.SpendingId = &spendingId
+					transaction.SpendingAmount = &spendingAmount
+				}
+			}
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	if len(transactions) > 0 {
+		if err = repo.InsertTransactions(span.Context(), transactions); err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return nil, errors.Wrap(err, "failed to create transactions for budget")
+		}
+
+		if result.Transfers, err = repo.DetectTransfers(span.Context(), transactions); err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return nil, errors.Wrap(err, "failed to detect transfers in budget")
+		}
+	}
+	result.Transactions = len(transactions)
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+// importCategories creates a funding schedule and a spending object for each category in the budget on the provided
+// bank account. It returns the created spending objects by their category.
+func importCategories(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	budget *Budget,
+	bankAccount *models.BankAccount,
+	options Options,
+	result *Result,
+) (map[string]*models.Spending, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	spending := map[string]*models.Spending{}
+	if len(budget.Categories) == 0 {
+		return spending, nil
+	}
+
+	now := options.Now.In(options.Timezone)
+	ruleSet, err := models.NewRuleSet(fmt.Sprintf(
+		"DTSTART:%s\nRRULE:%s",
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, options.Timezone).UTC().Format("20060102T150405Z"),
+		monthlyRule,
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create monthly rule")
+	}
+
+	fundingSchedule := &models.FundingSchedule{
+		BankAccountId: bankAccount.BankAccountId,
+		Name:          "Monthly",
+		Description:   fmt.Sprintf("Imported from %s", budget.Source.Name()),
+		RuleSet:       ruleSet,
+	}
+	fundingSchedule.NextOccurrence, fundingSchedule.NextOccurrenceOriginal = fundingSchedule.GetNextContributionDateAfter(
+		options.Now,
+		options.Timezone,
+	)
+	if err = repo.CreateFundingSchedule(span.Context(), fundingSchedule); err != nil {
+		return nil, errors.Wrap(err, "failed to create funding schedule for budget")
+	}
+
+	monthlyOutflows, lastSpentFrom := categoryActivity(budget.Accounts)
+	names := map[string]int{}
+	for _, category := range budget.Categories {
+		names[category.Name]++
+	}
+
+	var allocated int64
+	for _, category := range budget.Categories {
+		key := categoryKey(category.Group, category.Name)
+		name := category.Name
+		// Categories only need unique names within their group, but spending needs a unique name per bank account.
+		if names[category.Name] > 1 && category.Group != "" {
+			name = fmt.Sprintf("%s (%s)", category.Name, category.Group)
+		}
+
+		target := category.Assigned
+		if monthlyOutflows[key] > target {
+			target = monthlyOutflows[key]
+		}
+		if category.Available > target {
+			target = category.Available
+		}
+		if target <= 0 {
+			result.warn("Category %q was skipped because nothing has been assigned to it or spent from it", name)
+			continue
+		}
+
+		current := category.Available
+		if current < 0 {
+			result.warn("Category %q was overspent by %s, it was imported with nothing available", name, formatAmount(-current))
+			current = 0
+		}
+		allocated += current
+
+		item := &models.Spending{
+			BankAccountId:     bankAccount.BankAccountId,
+			FundingScheduleId: fundingSchedule.FundingScheduleId,
+			SpendingType:      models.SpendingTypeExpense,
+			Name:              name,
+			Description:       category.Group,
+			TargetAmount:      target,
+			CurrentAmount:     current,
+		}
+		if lastSpent, ok := lastSpentFrom[key]; ok {
+			lastSpent = time.Date(lastSpent.Year(), lastSpent.Month(), lastSpent.Day(), 0, 0, 0, 0, options.Timezone)
+			item.LastSpentFrom = &lastSpent
+		}
+		if strings.Contains(strings.ToLower(category.Group), "goal") {
+			// Neither app exports when a goal is due, so goals are given a year which the user can adjust afterward.
+			item.SpendingType = models.SpendingTypeGoal
+			item.NextRecurrence = util.Midnight(options.Now.AddDate(1, 0, 0), options.Timezone)
+		} else {
+			item.RuleSet = ruleSet
+			item.NextRecurrence = util.Midnight(ruleSet.After(options.Now, false), options.Timezone)
+		}
+		*item = models.CalculateNextContribution(span.Context(), *item, *fundingSchedule, options.Timezone, options.Now)
+
+		if err = repo.CreateSpending(span.Context(), item); err != nil {
+			return nil, errors.Wrapf(err, "failed to create spending for category %q", name)
+		}
+		spending[key] = item
+		result.Spending++
+	}
+
+	if allocated > bankAccount.CurrentBalance {
+		result.warn(
+			"%s more is available in categories than is in %s, spending from other accounts is not tracked",
+			formatAmount(allocated-bankAccount.CurrentBalance),
+			bankAccount.Name,
+		)
+	}
+
+	return spending, nil
+}
+
+// primaryAccount returns the index of the account with the most categorized spending. This is the account that the
+// budget's categories are imported to, since in monetr spending belongs to a single bank account.
+func primaryAccount(accounts []Account) int {
+	primary, most := 0, -1
+	for i, account := range accounts {
+		var count int
+		for _, transaction := range account.Transactions {
+			if transaction.Category != "" && transaction.Amount > 0 {
+				count++
+			}
+		}
+		if count > most {
+			primary, most = i, count
+		}
+	}
+
+	return primary
+}
+
+// categoryActivity returns the most that was spent from each category in any single month, and the last time each
+// category was spent from.
+func categoryActivity(accounts []Account) (map[string]int64, map[string]time.Time) {
+	months := map[string]map[string]int64{}
+	lastSpentFrom := map[string]time.Time{}
+	for _, account := range accounts {
+		for _, transaction := range account.Transactions {
+			if transaction.Category == "" {
+				continue
+			}
+
+			key := categoryKey(transaction.CategoryGroup, transaction.Category)
+			if months[key] == nil {
+				months[key] = map[string]int64{}
+			}
+			months[key][transaction.Date.Format("2006-01")] += transaction.Amount
+			if transaction.Amount > 0 && transaction.Date.After(lastSpentFrom[key]) {
+				lastSpentFrom[key] = transaction.Date
+			}
+		}
+	}
+
+	largest := map[string]int64{}
+	for key, totals := range months {
+		for _, total := range totals {
+			if total > largest[key] {
+				largest[key] = total
+			}
+		}
+	}
+
+	return largest, lastSpentFrom
+}
+
+func categoryKey(group, category string) string {
+	return group + "\x00" + category
+}
+
+func categories(transaction Transaction) []string {
+	result := make([]string, 0, 2)
+	for _, item := range []string{transaction.CategoryGroup, transaction.Category} {
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func formatAmount(amount int64) string {
+	return fmt.Sprintf("$%d.%02d", amount/100, amount%100)
+}
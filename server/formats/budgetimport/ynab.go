@@ -0,0 +1,227 @@
+package budgetimport
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ynabTransferPrefix is how YNAB names the payee of a transfer between two of the budget's accounts.
+	ynabTransferPrefix = "Transfer : "
+	// startingBalancePayee is the payee of the transaction that both YNAB and Actual Budget create for the balance an
+	// account had when it was added to the budget.
+	startingBalancePayee = "Starting Balance"
+	// ynabInflowGroup is the category group that income is assigned to in YNAB, it is not a real category.
+	ynabInflowGroup = "Inflow"
+	// ynabCreditCardGroup holds the categories YNAB uses to set money aside to pay off credit cards. In monetr that
+	// money just stays in the checking account so these are not imported.
+	ynabCreditCardGroup = "Credit Card Payments"
+)
+
+func isYNABRegister(t *table) bool {
+	return t.has("account", "date", "payee", "category group", "category", "memo", "outflow", "inflow")
+}
+
+func isYNABPlan(t *table) bool {
+	return t.has("month", "category group", "category", "available") &&
+		(t.has("budgeted") || t.has("assigned"))
+}
+
+func parseYNAB(files []File) (*Budget, error) {
+	var register, plan *table
+	for _, file := range files {
+		t, err := readTable(file)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case isYNABRegister(t):
+			register = t
+		case isYNABPlan(t):
+			plan = t
+		}
+	}
+
+	if register == nil {
+		return nil, errors.New("export does not contain a YNAB register")
+	}
+
+	budget := &Budget{
+		Source: YNABSource,
+	}
+	if err := parseYNABRegister(budget, register); err != nil {
+		return nil, err
+	}
+
+	if plan != nil {
+		if err := parseYNABPlan(budget, plan); err != nil {
+			return nil, err
+		}
+	} else {
+		budget.Categories = categoriesFromTransactions(budget.Accounts)
+	}
+
+	return budget, nil
+}
+
+func parseYNABRegister(budget *Budget, register *table) error {
+	dates := make([]string, 0, len(register.rows))
+	for _, row := range register.rows {
+		dates = append(dates, register.value(row, "date"))
+	}
+	layout, err := detectDateLayout(dates)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", register.name)
+	}
+
+	accounts := map[string]int{}
+	for i, row := range register.rows {
+		// The header is the first line of the file.
+		line := i + 2
+		accountName := register.value(row, "account")
+		if accountName == "" {
+			return errors.Errorf("transaction on line %d of %s does not have an account", line, register.name)
+		}
+
+		date, err := time.Parse(layout, register.value(row, "date"))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read date on line %d of %s", line, register.name)
+		}
+
+		var outflow, inflow int64
+		if value := register.value(row, "outflow"); value != "" {
+			if outflow, err = parseAmount(value); err != nil {
+				return errors.Wrapf(err, "failed to read outflow on line %d of %s", line, register.name)
+			}
+		}
+		if value := register.value(row, "inflow"); value != "" {
+			if inflow, err = parseAmount(value); err != nil {
+				return errors.Wrapf(err, "failed to read inflow on line %d of %s", line, register.name)
+			}
+		}
+
+		index, ok := accounts[accountName]
+		if !ok {
+			index = len(budget.Accounts)
+			accounts[accountName] = index
+			budget.Accounts = append(budget.Accounts, Account{
+				Name: accountName,
+			})
+		}
+		account := &budget.Accounts[index]
+		account.Balance += inflow - outflow
+
+		payee := register.value(row, "payee")
+		// The starting balance is reflected in the account's balance, it is not something the user actually spent or
+		// received so it is not imported as a transaction.
+		if payee == startingBalancePayee {
+			continue
+		}
+
+		transaction := Transaction{
+			Line:          line,
+			Date:          date,
+			Payee:         payee,
+			Memo:          register.value(row, "memo"),
+			CategoryGroup: register.value(row, "category group"),
+			Category:      register.value(row, "category"),
+			Amount:        outflow - inflow,
+			Transfer:      strings.HasPrefix(payee, ynabTransferPrefix),
+		}
+		if transaction.CategoryGroup == ynabInflowGroup || transaction.Transfer {
+			transaction.CategoryGroup, transaction.Category = "", ""
+		}
+		account.Transactions = append(account.Transactions, transaction)
+	}
+
+	return nil
+}
+
+// parseYNABPlan reads the balance of each category from the most recent month in the plan. The plan contains every
+// month the budget has existed for, but monetr only needs to know where each category stands now.
+func parseYNABPlan(budget *Budget, plan *table) error {
+	assignedColumn := "assigned"
+	if plan.has("budgeted") {
+		assignedColumn = "budgeted"
+	}
+
+	// YNAB writes the months in order, but the month is compared anyway so the rows can be in any order.
+	var latest string
+	var latestDate time.Time
+	for _, row := range plan.rows {
+		month := plan.value(row, "month")
+		date, err := time.Parse("Jan 2006", month)
+		if err != nil {
+			// If the month is in a format that isn't known then fall back to the last month in the file.
+			latest = month
+			continue
+		}
+		if latest == "" || date.After(latestDate) {
+			latest, latestDate = month, date
+		}
+	}
+
+	for i, row := range plan.rows {
+		line := i + 2
+		if plan.value(row, "month") != latest {
+			continue
+		}
+
+		group := plan.value(row, "category group")
+		if group == ynabInflowGroup || group == ynabCreditCardGroup {
+			continue
+		}
+
+		category := Category{
+			Group: group,
+			Name:  plan.value(row, "category"),
+		}
+		if category.Name == "" {
+			continue
+		}
+
+		var err error
+		if value := plan.value(row, assignedColumn); value != "" {
+			if category.Assigned, err = parseAmount(value); err != nil {
+				return errors.Wrapf(err, "failed to read %s amount on line %d of %s", assignedColumn, line, plan.name)
+			}
+		}
+		if value := plan.value(row, "available"); value != "" {
+			if category.Available, err = parseAmount(value); err != nil {
+				return errors.Wrapf(err, "failed to read available amount on line %d of %s", line, plan.name)
+			}
+		}
+		budget.Categories = append(budget.Categories, category)
+	}
+
+	return nil
+}
+
+// categoriesFromTransactions is used when the export does not include the balance of each category. Every category
+// that a transaction was assigned to is returned with nothing available.
+func categoriesFromTransactions(accounts []Account) []Category {
+	seen := map[[2]string]struct{}{}
+	result := make([]Category, 0)
+	for _, account := range accounts {
+		for _, transaction := range account.Transactions {
+			if transaction.Category == "" {
+				continue
+			}
+
+			key := [2]string{transaction.CategoryGroup, transaction.Category}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, Category{
+				Group: transaction.CategoryGroup,
+				Name:  transaction.Category,
+			})
+		}
+	}
+
+	return result
+}
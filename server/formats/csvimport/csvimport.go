@@ -0,0 +1,337 @@
+package csvimport
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// Row is a single transaction read from a CSV file using a mapping.
+type Row struct {
+	// Line is the 1 based line number of the row within the file, this is used to make errors easier to find.
+	Line int `json:"line"`
+	// Date is the date of the transaction as it was presented in the file, at midnight UTC.
+	Date time.Time `json:"date"`
+	// Amount is in cents and uses monetr's sign convention, positive values are money leaving the account.
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+	Merchant    string `json:"merchant"`
+	// Identifier is used to de-duplicate transactions between uploads. If the mapping has an identifier column then
+	// this is the value of that column. Otherwise, it is a fingerprint derived from the contents of the row.
+	Identifier string `json:"-"`
+}
+
+type RowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type Result struct {
+	Rows   []Row      `json:"rows"`
+	Errors []RowError `json:"errors"`
+}
+
+// Parse reads the provided CSV file using the mapping specified. If limit is greater than zero then at most that many
+// rows (including rows that could not be parsed) will be read. Problems with individual rows are returned in the result
+// rather than failing the entire file.
+func Parse(reader io.Reader, mapping models.CSVMapping, limit int) (*Result, error) {
+	mapping.Normalize()
+	if err := mapping.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid csv mapping")
+	}
+
+	layout, err := DateLayout(mapping.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = []rune(mapping.Delimiter)[0]
+	// Banks are not consistent about the number of columns on each row, especially with trailing summary rows.
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	csvReader.TrimLeadingSpace = true
+
+	result := Result{
+		Rows:   make([]Row, 0),
+		Errors: make([]RowError, 0),
+	}
+	occurrences := map[string]int{}
+	line := 0
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read csv file at line %d", line)
+		}
+
+		if line <= mapping.SkipRows || isBlank(record) {
+			continue
+		}
+
+		if limit > 0 && len(result.Rows)+len(result.Errors) >= limit {
+			break
+		}
+
+		row, err := parseRecord(record, mapping, layout)
+		if err != nil {
+			result.Errors = append(result.Errors, RowError{
+				Line:  line,
+				Error: err.Error(),
+			})
+			continue
+		}
+		row.Line = line
+
+		if row.Identifier == "" {
+			// Two identical rows in the same file (like two coffees on the same day) are still two transactions. So the
+			// number of times we have seen this row is part of its fingerprint.
+			fingerprint := fingerprintRow(row)
+			occurrences[fingerprint]++
+			row.Identifier = fmt.Sprintf("csv:%s:%d", fingerprint, occurrences[fingerprint])
+		}
+
+		result.Rows = append(result.Rows, *row)
+	}
+
+	return &result, nil
+}
+
+func parseRecord(record []string, mapping models.CSVMapping, layout string) (*Row, error) {
+	rawDate, err := column(record, mapping.DateColumn, "date")
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(layout, rawDate)
+	if err != nil {
+		return nil, errors.Errorf("invalid date %q, expected format %s", rawDate, mapping.DateFormat)
+	}
+
+	description, err := column(record, mapping.DescriptionColumn, "description")
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		return nil, errors.New("description is blank")
+	}
+
+	var amount int64
+	switch mapping.AmountType {
+	case models.CSVAmountTypeSingle:
+		rawAmount, err := column(record, *mapping.AmountColumn, "amount")
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err = ParseAmount(rawAmount, mapping.DecimalSeparator)
+		if err != nil {
+			return nil, err
+		}
+
+		if mapping.SignConvention == models.CSVSignNegativeDebit {
+			amount = -amount
+		}
+	case models.CSVAmountTypeSplit:
+		rawDebit, err := column(record, *mapping.DebitColumn, "debit")
+		if err != nil {
+			return nil, err
+		}
+		rawCredit, err := column(record, *mapping.CreditColumn, "credit")
+		if err != nil {
+			return nil, err
+		}
+
+		if rawDebit == "" && rawCredit == "" {
+			return nil, errors.New("both debit and credit are blank")
+		}
+
+		if rawDebit != "" {
+			debit, err := ParseAmount(rawDebit, mapping.DecimalSeparator)
+			if err != nil {
+				return nil, err
+			}
+			// The columns themselves indicate the direction, so the sign of the value is not trusted.
+			amount += absolute(debit)
+		}
+
+		if rawCredit != "" {
+			credit, err := ParseAmount(rawCredit, mapping.DecimalSeparator)
+			if err != nil {
+				return nil, err
+			}
+			amount -= absolute(credit)
+		}
+	}
+
+	row := Row{
+		Date:        date,
+		Amount:      amount,
+		Description: description,
+	}
+
+	if mapping.MerchantColumn != nil {
+		// The merchant is optional, if the row is too short then it is just left blank.
+		row.Merchant, _ = column(record, *mapping.MerchantColumn, "merchant")
+	}
+
+	if mapping.IdentifierColumn != nil {
+		identifier, err := column(record, *mapping.IdentifierColumn, "identifier")
+		if err != nil {
+			return nil, err
+		}
+		if identifier == "" {
+			return nil, errors.New("identifier is blank")
+		}
+		row.Identifier = identifier
+	}
+
+	return &row, nil
+}
+
+func column(record []string, index int, name string) (string, error) {
+	if index >= len(record) {
+		return "", errors.Errorf("%s column %d does not exist, row only has %d column(s)", name, index, len(record))
+	}
+
+	return strings.TrimSpace(record[index]), nil
+}
+
+func isBlank(record []string) bool {
+	for _, item := range record {
+		if strings.TrimSpace(item) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func absolute(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func fingerprintRow(row *Row) string {
+	hash := sha256.New()
+	hash.Write([]byte(row.Date.Format("2006-01-02")))
+	hash.Write([]byte(strconv.FormatInt(row.Amount, 10)))
+	hash.Write([]byte(strings.ToLower(row.Description)))
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
+// ParseAmount takes an amount as it was presented in a CSV file and returns it in cents. It tolerates currency symbols,
+// thousands separators, parentheses for negative values and trailing minus signs. The sign of the value is preserved.
+func ParseAmount(input, decimalSeparator string) (int64, error) {
+	original := input
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, errors.New("amount is blank")
+	}
+
+	negative := false
+	if strings.HasPrefix(input, "(") && strings.HasSuffix(input, ")") {
+		negative = true
+		input = input[1 : len(input)-1]
+	}
+	if strings.HasSuffix(input, "-") {
+		negative = true
+		input = strings.TrimSuffix(input, "-")
+	}
+
+	var builder strings.Builder
+	for _, character := range input {
+		switch {
+		case character >= '0' && character <= '9':
+			builder.WriteRune(character)
+		case string(character) == decimalSeparator:
+			builder.WriteRune('.')
+		case character == '-':
+			negative = !negative
+		case character == '+', character == ',', character == '.', character == ' ', character == '\'':
+		// Thousands separators and explicit positive signs are ignored.
+		default:
+			// Currency symbols and codes are ignored as well.
+		}
+	}
+
+	value, err := strconv.ParseFloat(builder.String(), 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid amount %q", original)
+	}
+
+	cents := int64(math.Round(value * 100))
+	if negative {
+		cents = -cents
+	}
+
+	return cents, nil
+}
+
+// DateLayout converts a human-readable date format like YYYY-MM-DD or MM/DD/YY into a layout that can be used by the
+// time package. The supported tokens are YYYY, YY, MMM, MM, M, DD and D, anything else is treated as a literal.
+func DateLayout(format string) (string, error) {
+	tokens := []struct {
+		token  string
+		layout string
+	}{
+		{"YYYY", "2006"},
+		{"YY", "06"},
+		{"MMM", "Jan"},
+		{"MM", "01"},
+		{"M", "1"},
+		{"DD", "02"},
+		{"D", "2"},
+	}
+
+	var builder strings.Builder
+	var hasYear, hasMonth, hasDay bool
+	upper := strings.ToUpper(format)
+	for i := 0; i < len(upper); {
+		matched := false
+		for _, item := range tokens {
+			if strings.HasPrefix(upper[i:], item.token) {
+				builder.WriteString(item.layout)
+				i += len(item.token)
+				matched = true
+				switch item.token[0] {
+				case 'Y':
+					hasYear = true
+				case 'M':
+					hasMonth = true
+				case 'D':
+					hasDay = true
+				}
+				break
+			}
+		}
+		if !matched {
+			if upper[i] >= '0' && upper[i] <= '9' {
+				return "", errors.Errorf("invalid date format %q, cannot contain digits", format)
+			}
+			builder.WriteByte(format[i])
+			i++
+		}
+	}
+
+	if !hasYear || !hasMonth || !hasDay {
+		return "", errors.Errorf("invalid date format %q, must include a year, month and day", format)
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,179 @@
+package csvimport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("single amount column", func(t *testing.T) {
+		input := "Date,Description,Amount,Balance\n" +
+			"10/02/2023,WENDYS #1234,-12.34,100.00\n" +
+			"10/05/2023,\"PAYROLL, INC\",\"1,500.00\",1600.00\n" +
+			"\n"
+		mapping := models.CSVMapping{
+			SkipRows:          1,
+			DateColumn:        0,
+			DateFormat:        "MM/DD/YYYY",
+			AmountColumn:      myownsanity.IntP(2),
+			DescriptionColumn: 1,
+		}
+
+		result, err := Parse(strings.NewReader(input), mapping, 0)
+		require.NoError(t, err, "must be able to parse csv file")
+		assert.Empty(t, result.Errors, "should not have any errors")
+		require.Len(t, result.Rows, 2, "should have two rows")
+
+		assert.Equal(t, 2, result.Rows[0].Line)
+		assert.Equal(t, time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC), result.Rows[0].Date)
+		assert.EqualValues(t, 1234, result.Rows[0].Amount, "debits should be positive")
+		assert.Equal(t, "WENDYS #1234", result.Rows[0].Description)
+		assert.EqualValues(t, -150000, result.Rows[1].Amount, "credits should be negative")
+		assert.Equal(t, "PAYROLL, INC", result.Rows[1].Description)
+		assert.NotEqual(t, result.Rows[0].Identifier, result.Rows[1].Identifier)
+	})
+
+	t.Run("split debit and credit columns", func(t *testing.T) {
+		input := "2023-10-02;Coffee;Starbucks;4,50;\n" +
+			"2023-10-03;Refund;Amazon;;(10,00)\n"
+		mapping := models.CSVMapping{
+			Delimiter:         ";",
+			DecimalSeparator:  ",",
+			DateFormat:        "YYYY-MM-DD",
+			AmountType:        models.CSVAmountTypeSplit,
+			DebitColumn:       myownsanity.IntP(3),
+			CreditColumn:      myownsanity.IntP(4),
+			DescriptionColumn: 1,
+			MerchantColumn:    myownsanity.IntP(2),
+		}
+
+		result, err := Parse(strings.NewReader(input), mapping, 0)
+		require.NoError(t, err, "must be able to parse csv file")
+		require.Len(t, result.Rows, 2, "should have two rows")
+		assert.EqualValues(t, 450, result.Rows[0].Amount)
+		assert.Equal(t, "Starbucks", result.Rows[0].Merchant)
+		assert.EqualValues(t, -1000, result.Rows[1].Amount, "the sign of the credit column should be ignored")
+	})
+
+	t.Run("positive debit with identifiers", func(t *testing.T) {
+		input := "abc,10/2/23,Coffee,4.50\n" +
+			"def,10/2/23,Coffee,4.50\n"
+		mapping := models.CSVMapping{
+			DateColumn:        1,
+			DateFormat:        "M/D/YY",
+			AmountColumn:      myownsanity.IntP(3),
+			SignConvention:    models.CSVSignPositiveDebit,
+			DescriptionColumn: 2,
+			IdentifierColumn:  myownsanity.IntP(0),
+		}
+
+		result, err := Parse(strings.NewReader(input), mapping, 0)
+		require.NoError(t, err, "must be able to parse csv file")
+		require.Len(t, result.Rows, 2, "should have two rows")
+		assert.EqualValues(t, 450, result.Rows[0].Amount)
+		assert.Equal(t, "abc", result.Rows[0].Identifier)
+		assert.Equal(t, "def", result.Rows[1].Identifier)
+	})
+
+	t.Run("identical rows have different fingerprints", func(t *testing.T) {
+		input := "2023-10-02,Coffee,-4.50\n" +
+			"2023-10-02,Coffee,-4.50\n"
+		mapping := models.CSVMapping{
+			DateFormat:        "YYYY-MM-DD",
+			AmountColumn:      myownsanity.IntP(2),
+			DescriptionColumn: 1,
+		}
+
+		first, err := Parse(strings.NewReader(input), mapping, 0)
+		require.NoError(t, err, "must be able to parse csv file")
+		require.Len(t, first.Rows, 2, "should have two rows")
+		assert.NotEqual(t, first.Rows[0].Identifier, first.Rows[1].Identifier, "each row should be unique")
+
+		second, err := Parse(strings.NewReader(input), mapping, 0)
+		require.NoError(t, err, "must be able to parse csv file")
+		assert.Equal(t, first.Rows[0].Identifier, second.Rows[0].Identifier, "fingerprints should be stable")
+		assert.Equal(t, first.Rows[1].Identifier, second.Rows[1].Identifier, "fingerprints should be stable")
+	})
+
+	t.Run("bad rows and limit", func(t *testing.T) {
+		input := "2023-10-02,Coffee,-4.50\n" +
+			"not a date,Coffee,-4.50\n" +
+			"2023-10-03,Tea\n" +
+			"2023-10-04,Lunch,-12.00\n"
+		mapping := models.CSVMapping{
+			DateFormat:        "YYYY-MM-DD",
+			AmountColumn:      myownsanity.IntP(2),
+			DescriptionColumn: 1,
+		}
+
+		result, err := Parse(strings.NewReader(input), mapping, 0)
+		require.NoError(t, err, "bad rows should not fail the entire file")
+		assert.Len(t, result.Rows, 2)
+		require.Len(t, result.Errors, 2)
+		assert.Equal(t, 2, result.Errors[0].Line)
+		assert.Equal(t, 3, result.Errors[1].Line)
+
+		result, err = Parse(strings.NewReader(input), mapping, 2)
+		require.NoError(t, err)
+		assert.Len(t, result.Rows, 1, "only the first two rows should be read")
+		assert.Len(t, result.Errors, 1, "only the first two rows should be read")
+	})
+
+	t.Run("invalid mapping", func(t *testing.T) {
+		result, err := Parse(strings.NewReader(""), models.CSVMapping{
+			DateFormat:        "YYYY-MM-DD",
+			DescriptionColumn: 1,
+		}, 0)
+		assert.EqualError(t, err, "invalid csv mapping: amount column is required when using a single amount column")
+		assert.Nil(t, result)
+	})
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		input     string
+		separator string
+		expected  int64
+	}{
+		{"-12.34", ".", -1234},
+		{"$1,234.56", ".", 123456},
+		{"(45.00)", ".", -4500},
+		{"45.00-", ".", -4500},
+		{"1.234,56 €", ",", 123456},
+		{"+3", ".", 300},
+	}
+	for _, item := range cases {
+		result, err := ParseAmount(item.input, item.separator)
+		assert.NoError(t, err, "must parse %s", item.input)
+		assert.Equal(t, item.expected, result, "amount for %s", item.input)
+	}
+
+	_, err := ParseAmount("", ".")
+	assert.Error(t, err, "blank amounts should fail")
+
+	_, err = ParseAmount("abc", ".")
+	assert.Error(t, err, "amounts without digits should fail")
+}
+
+func TestDateLayout(t *testing.T) {
+	cases := map[string]string{
+		"YYYY-MM-DD":  "2006-01-02",
+		"MM/DD/YYYY":  "01/02/2006",
+		"m/d/yy":      "1/2/06",
+		"DD MMM YYYY": "02 Jan 2006",
+	}
+	for format, expected := range cases {
+		layout, err := DateLayout(format)
+		assert.NoError(t, err, "must convert %s", format)
+		assert.Equal(t, expected, layout, "layout for %s", format)
+	}
+
+	_, err := DateLayout("MM/DD")
+	assert.Error(t, err, "formats without a year should fail")
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ TransactionWriter = &csvWriter{}
+)
+
+var csvHeader = []string{
+	"transaction_id",
+	"date",
+	"name",
+	"custom_name",
+	"original_name",
+	"merchant_name",
+	"amount",
+	"currency",
+	"pending",
+	"spending",
+	"categories",
+}
+
+// csvWriter writes one row per transaction. Amounts are decimals where money leaving the account is negative. Multiple
+// spending names and categories are separated by a semicolon.
+type csvWriter struct {
+	writer  *csv.Writer
+	options Options
+}
+
+func newCSVWriter(writer io.Writer, options Options) (*csvWriter, error) {
+	result := &csvWriter{
+		writer:  csv.NewWriter(writer),
+		options: options,
+	}
+
+	if err := result.writer.Write(csvHeader); err != nil {
+		return nil, errors.Wrap(err, "failed to write csv header")
+	}
+
+	return result, nil
+}
+
+func (c *csvWriter) Write(transaction models.Transaction, spendingNames []string) error {
+	customName := ""
+	if transaction.CustomName != nil {
+		customName = *transaction.CustomName
+	}
+
+	err := c.writer.Write([]string{
+		strconv.FormatUint(transaction.TransactionId, 10),
+		transaction.Date.In(c.options.Timezone).Format("2006-01-02"),
+		transaction.Name,
+		customName,
+		transaction.OriginalName,
+		transaction.MerchantName,
+		formatAmount(transaction.Amount),
+		transaction.Currency,
+		strconv.FormatBool(transaction.IsPending),
+		strings.Join(spendingNames, ";"),
+		strings.Join(transaction.Categories, ";"),
+	})
+
+	return errors.Wrap(err, "failed to write csv row")
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+
+	return errors.Wrap(c.writer.Error(), "failed to flush csv")
+}
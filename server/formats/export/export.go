@@ -0,0 +1,107 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// Format is a file format that transactions can be exported as.
+type Format string
+
+const (
+	CSVFormat    Format = "csv"
+	OFXFormat    Format = "ofx"
+	NDJSONFormat Format = "ndjson"
+)
+
+// ParseFormat returns the export format with the provided name, names are not case-sensitive.
+func ParseFormat(input string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(input))); format {
+	case CSVFormat, OFXFormat, NDJSONFormat:
+		return format, nil
+	default:
+		return "", errors.Errorf("invalid export format %q, must be one of csv, ofx or ndjson", input)
+	}
+}
+
+// ContentType returns the media type that should be used when serving an export in this format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSVFormat:
+		return "text/csv"
+	case OFXFormat:
+		return "application/x-ofx"
+	case NDJSONFormat:
+		return "application/x-ndjson"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Extension returns the file extension (without the leading period) for an export in this format.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// TransactionWriter writes transactions to an export one at a time, so that an export of any size can be written
+// without holding all of it in memory.
+type TransactionWriter interface {
+	// Write adds a single transaction to the export. The spending names are the names of the spending objects that the
+	// transaction is spent from, either directly or through its splits.
+	Write(transaction models.Transaction, spendingNames []string) error
+	// Close finishes the export. It must be called even if no transactions were written, as some formats need to write
+	// data after the last transaction.
+	Close() error
+}
+
+type Options struct {
+	// BankAccount is the bank account that the transactions being exported belong to.
+	BankAccount models.BankAccount
+	// Timezone is used to present the date of each transaction, it should be the account's timezone.
+	Timezone *time.Location
+	// Start and End are the range of dates that were requested for the export, if any. End is exclusive, it is midnight
+	// of the day after the last date that was requested.
+	Start, End *time.Time
+	// Now is the time the export was created.
+	Now time.Time
+}
+
+// NewTransactionWriter returns a writer for the specified format that writes the export to the provided writer.
+func NewTransactionWriter(format Format, writer io.Writer, options Options) (TransactionWriter, error) {
+	if options.Timezone == nil {
+		options.Timezone = time.UTC
+	}
+
+	switch format {
+	case CSVFormat:
+		return newCSVWriter(writer, options)
+	case OFXFormat:
+		return newOFXWriter(writer, options), nil
+	case NDJSONFormat:
+		return newNDJSONWriter(writer), nil
+	default:
+		return nil, errors.Errorf("invalid export format %q", format)
+	}
+}
+
+// formatAmount presents a transaction amount in cents as a decimal. The sign is flipped from monetr's convention so
+// that money leaving the account is negative, which is what banks and other tools expect.
+func formatAmount(amount int64) string {
+	return formatCents(-amount)
+}
+
+// formatCents presents an amount in cents as a decimal without changing its sign.
+func formatCents(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
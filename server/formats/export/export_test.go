@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/formats/ofx"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestData(t *testing.T) (Options, []models.Transaction) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	options := Options{
+		BankAccount: models.BankAccount{
+			BankAccountId:    12,
+			Mask:             "1234",
+			Type:             models.DepositoryBankAccountType,
+			SubType:          models.CheckingBankAccountSubType,
+			CurrentBalance:   150025,
+			AvailableBalance: 149000,
+		},
+		Timezone: timezone,
+		Now:      time.Date(2023, 10, 9, 13, 32, 0, 0, time.UTC),
+	}
+
+	transactions := []models.Transaction{
+		{
+			TransactionId: 1,
+			Amount:        1299,
+			Date:          time.Date(2023, 10, 1, 0, 0, 0, 0, timezone),
+			Name:          "Groceries",
+			CustomName:    myownsanity.StringP("Groceries"),
+			OriginalName:  "CHECKCARD PURCHASE - GROCERY STORE",
+			MerchantName:  "Grocery Store",
+			Currency:      "USD",
+			Categories:    []string{"Food", "Groceries"},
+		},
+		{
+			TransactionId: 2,
+			Amount:        -250000,
+			Date:          time.Date(2023, 10, 2, 0, 0, 0, 0, timezone),
+			Name:          "Payroll",
+			OriginalName:  "DIRECT DEPOSIT PAYROLL",
+			Currency:      "USD",
+		},
+		{
+			TransactionId: 3,
+			Amount:        500,
+			Date:          time.Date(2023, 10, 3, 0, 0, 0, 0, timezone),
+			Name:          "Coffee",
+			OriginalName:  "COFFEE SHOP",
+			Currency:      "USD",
+			IsPending:     true,
+		},
+	}
+
+	return options, transactions
+}
+
+func writeExport(t *testing.T, format Format, options Options, transactions []models.Transaction) string {
+	buffer := bytes.NewBuffer(nil)
+	writer, err := NewTransactionWriter(format, buffer, options)
+	require.NoError(t, err, "must be able to create writer")
+
+	for i, transaction := range transactions {
+		var spendingNames []string
+		if i == 0 {
+			spendingNames = []string{"Food", "Household"}
+		}
+		require.NoError(t, writer.Write(transaction, spendingNames), "must be able to write transaction")
+	}
+	require.NoError(t, writer.Close(), "must be able to close writer")
+
+	return buffer.String()
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" CSV ")
+	assert.NoError(t, err)
+	assert.Equal(t, CSVFormat, format)
+
+	format, err = ParseFormat("xlsx")
+	assert.EqualError(t, err, `invalid export format "xlsx", must be one of csv, ofx or ndjson`)
+	assert.Empty(t, format)
+}
+
+func TestCSVWriter(t *testing.T) {
+	options, transactions := exportTestData(t)
+	result := writeExport(t, CSVFormat, options, transactions)
+
+	assert.Equal(t, strings.Join([]string{
+		"transaction_id,date,name,custom_name,original_name,merchant_name,amount,currency,pending,spending,categories",
+		"1,2023-10-01,Groceries,Groceries,CHECKCARD PURCHASE - GROCERY STORE,Grocery Store,-12.99,USD,false,Food;Household,Food;Groceries",
+		"2,2023-10-02,Payroll,,DIRECT DEPOSIT PAYROLL,,2500.00,USD,false,,",
+		"3,2023-10-03,Coffee,,COFFEE SHOP,,-5.00,USD,true,,",
+		"",
+	}, "\n"), result)
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	options, transactions := exportTestData(t)
+	result := writeExport(t, NDJSONFormat, options, transactions)
+
+	lines := strings.Split(strings.TrimSpace(result), "\n")
+	require.Len(t, lines, 3, "should have one line per transaction")
+
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.EqualValues(t, 1, first["transactionId"])
+	assert.EqualValues(t, 1299, first["amount"], "amounts should be kept in cents")
+	assert.Equal(t, "Groceries", first["customName"])
+	assert.Equal(t, []interface{}{"Food", "Household"}, first["spendingNames"])
+
+	var second map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, []interface{}{}, second["spendingNames"])
+}
+
+func TestOFXWriter(t *testing.T) {
+	t.Run("bank statement", func(t *testing.T) {
+		options, transactions := exportTestData(t)
+		result := writeExport(t, OFXFormat, options, transactions)
+
+		statements, err := ofx.Parse(strings.NewReader(result))
+		require.NoError(t, err, "exported OFX must be readable by the OFX parser")
+		require.Len(t, statements, 1)
+
+		statement := statements[0]
+		assert.Equal(t, "1234", statement.AccountId)
+		assert.Equal(t, "CHECKING", statement.AccountType)
+		assert.Equal(t, "USD", statement.Currency)
+		assert.Empty(t, statement.Errors)
+		require.Len(t, statement.Transactions, 2, "pending transactions should not be exported")
+		assert.Equal(t, "1", statement.Transactions[0].ID)
+		assert.Equal(t, "DEBIT", statement.Transactions[0].Type)
+		assert.EqualValues(t, -1299, statement.Transactions[0].Amount)
+		assert.Equal(t, "Food; Household", statement.Transactions[0].Memo)
+		assert.Equal(t, "CREDIT", statement.Transactions[1].Type)
+		assert.EqualValues(t, 250000, statement.Transactions[1].Amount)
+		require.NotNil(t, statement.LedgerBalance)
+		assert.EqualValues(t, 150025, statement.LedgerBalance.Amount)
+		assert.Contains(t, result, "<DTSTART>20231001</DTSTART>", "start should be the first transaction when not specified")
+	})
+
+	t.Run("requested range", func(t *testing.T) {
+		options, transactions := exportTestData(t)
+		start := time.Date(2023, 9, 1, 0, 0, 0, 0, options.Timezone)
+		end := time.Date(2023, 10, 1, 0, 0, 0, 0, options.Timezone)
+		options.Start, options.End = &start, &end
+		result := writeExport(t, OFXFormat, options, transactions)
+
+		assert.Contains(t, result, "<DTSTART>20230901</DTSTART>")
+		assert.Contains(t, result, "<DTEND>20230930</DTEND>", "end should be the last day that was requested")
+	})
+
+	t.Run("credit card statement", func(t *testing.T) {
+		options, transactions := exportTestData(t)
+		options.BankAccount.Type = models.CreditBankAccountType
+		options.BankAccount.SubType = models.CreditCardBankAccountSubType
+		result := writeExport(t, OFXFormat, options, transactions)
+
+		statements, err := ofx.Parse(strings.NewReader(result))
+		require.NoError(t, err, "exported OFX must be readable by the OFX parser")
+		require.Len(t, statements, 1)
+		assert.Equal(t, "CREDITCARD", statements[0].AccountType)
+		assert.Len(t, statements[0].Transactions, 2)
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		options, _ := exportTestData(t)
+		result := writeExport(t, OFXFormat, options, nil)
+
+		statements, err := ofx.Parse(strings.NewReader(result))
+		require.NoError(t, err, "exported OFX must be readable by the OFX parser")
+		require.Len(t, statements, 1)
+		assert.Empty(t, statements[0].Transactions)
+	})
+}
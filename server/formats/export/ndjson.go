@@ -0,0 +1,47 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ TransactionWriter = &ndjsonWriter{}
+)
+
+// ndjsonTransaction is a transaction as it is returned by the API, with the names of the spending objects it is spent
+// from.
+type ndjsonTransaction struct {
+	models.Transaction
+	SpendingNames []string `json:"spendingNames"`
+}
+
+// ndjsonWriter writes each transaction as a JSON object on its own line. Unlike the other formats, amounts are kept in
+// cents using monetr's sign convention.
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(writer io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+func (n *ndjsonWriter) Write(transaction models.Transaction, spendingNames []string) error {
+	if spendingNames == nil {
+		spendingNames = []string{}
+	}
+
+	return errors.Wrap(n.encoder.Encode(ndjsonTransaction{
+		Transaction:   transaction,
+		SpendingNames: spendingNames,
+	}), "failed to write transaction")
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
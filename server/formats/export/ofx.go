@@ -0,0 +1,199 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ TransactionWriter = &ofxWriter{}
+)
+
+const (
+	ofxDateLayout     = "20060102"
+	ofxDateTimeLayout = "20060102150405"
+	// ofxMaxNameLength is the longest NAME that the OFX spec allows, longer names are truncated.
+	ofxMaxNameLength = 32
+)
+
+// ofxWriter writes an OFX 2.x bank statement, or a credit card statement for credit bank accounts. The statement header
+// is only written once the first transaction is known, so that the statement's start date and currency can be taken
+// from the transactions when they were not specified. Pending transactions are left out, as they are not part of a
+// statement.
+type ofxWriter struct {
+	writer  *bufio.Writer
+	options Options
+	started bool
+	err     error
+}
+
+func newOFXWriter(writer io.Writer, options Options) *ofxWriter {
+	return &ofxWriter{
+		writer:  bufio.NewWriter(writer),
+		options: options,
+	}
+}
+
+func (o *ofxWriter) Write(transaction models.Transaction, spendingNames []string) error {
+	if transaction.IsPending {
+		return nil
+	}
+
+	if !o.started {
+		o.begin(transaction.Date, transaction.Currency)
+	}
+
+	transactionType := "DEBIT"
+	if transaction.IsAddition() {
+		transactionType = "CREDIT"
+	}
+
+	name := transaction.Name
+	if len([]rune(name)) > ofxMaxNameLength {
+		name = string([]rune(name)[:ofxMaxNameLength])
+	}
+
+	o.write("<STMTTRN>")
+	o.element("TRNTYPE", transactionType)
+	o.element("DTPOSTED", transaction.Date.In(o.options.Timezone).Format(ofxDateLayout))
+	o.element("TRNAMT", formatAmount(transaction.Amount))
+	o.element("FITID", strconv.FormatUint(transaction.TransactionId, 10))
+	o.element("NAME", name)
+	if len(spendingNames) > 0 {
+		o.element("MEMO", strings.Join(spendingNames, "; "))
+	}
+	o.write("</STMTTRN>\n")
+
+	return o.err
+}
+
+func (o *ofxWriter) Close() error {
+	if !o.started {
+		o.begin(o.options.Now, "")
+	}
+
+	balanceDate := o.options.Now.In(o.options.Timezone).Format(ofxDateTimeLayout)
+	o.write("</BANKTRANLIST>\n")
+	o.write("<LEDGERBAL>")
+	o.element("BALAMT", formatCents(o.options.BankAccount.CurrentBalance))
+	o.element("DTASOF", balanceDate)
+	o.write("</LEDGERBAL>\n")
+	o.write("<AVAILBAL>")
+	o.element("BALAMT", formatCents(o.options.BankAccount.AvailableBalance))
+	o.element("DTASOF", balanceDate)
+	o.write("</AVAILBAL>\n")
+	if o.isCreditCard() {
+		o.write("</CCSTMTRS>\n</CCSTMTTRNRS>\n</CREDITCARDMSGSRSV1>\n")
+	} else {
+		o.write("</STMTRS>\n</STMTTRNRS>\n</BANKMSGSRSV1>\n")
+	}
+	o.write("</OFX>\n")
+
+	if o.err != nil {
+		return o.err
+	}
+
+	return errors.Wrap(o.writer.Flush(), "failed to flush ofx")
+}
+
+// begin writes everything in the statement up to the first transaction.
+func (o *ofxWriter) begin(first time.Time, currency string) {
+	o.started = true
+
+	start := first
+	if o.options.Start != nil {
+		start = *o.options.Start
+	}
+	end := o.options.Now
+	if o.options.End != nil {
+		// The end of the requested range is exclusive, but DTEND is the last day of the statement.
+		end = o.options.End.AddDate(0, 0, -1)
+	}
+
+	if currency == "" {
+		currency = "USD"
+	}
+
+	o.write(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	o.write(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	o.write("<OFX>\n")
+	o.write("<SIGNONMSGSRSV1><SONRS>")
+	o.write("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	o.element("DTSERVER", o.options.Now.In(o.options.Timezone).Format(ofxDateTimeLayout))
+	o.element("LANGUAGE", "ENG")
+	o.write("</SONRS></SIGNONMSGSRSV1>\n")
+
+	accountId := o.options.BankAccount.Mask
+	if accountId == "" {
+		accountId = strconv.FormatUint(o.options.BankAccount.BankAccountId, 10)
+	}
+
+	if o.isCreditCard() {
+		o.write("<CREDITCARDMSGSRSV1>\n<CCSTMTTRNRS>")
+		o.element("TRNUID", "0")
+		o.write("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+		o.write("<CCSTMTRS>")
+		o.element("CURDEF", currency)
+		o.write("<CCACCTFROM>")
+		o.element("ACCTID", accountId)
+		o.write("</CCACCTFROM>\n")
+	} else {
+		o.write("<BANKMSGSRSV1>\n<STMTTRNRS>")
+		o.element("TRNUID", "0")
+		o.write("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+		o.write("<STMTRS>")
+		o.element("CURDEF", currency)
+		o.write("<BANKACCTFROM>")
+		o.element("BANKID", "monetr")
+		o.element("ACCTID", accountId)
+		o.element("ACCTTYPE", ofxAccountType(o.options.BankAccount.SubType))
+		o.write("</BANKACCTFROM>\n")
+	}
+
+	o.write("<BANKTRANLIST>")
+	o.element("DTSTART", start.In(o.options.Timezone).Format(ofxDateLayout))
+	o.element("DTEND", end.In(o.options.Timezone).Format(ofxDateLayout))
+	o.write("\n")
+}
+
+func (o *ofxWriter) isCreditCard() bool {
+	return o.options.BankAccount.Type == models.CreditBankAccountType
+}
+
+func (o *ofxWriter) element(name, value string) {
+	o.write("<" + name + ">")
+	if o.err == nil {
+		o.err = errors.Wrap(xml.EscapeText(o.writer, []byte(value)), "failed to write ofx")
+	}
+	o.write("</" + name + ">")
+}
+
+// write keeps track of the first error encountered so that the statement can be written without checking every call.
+func (o *ofxWriter) write(value string) {
+	if o.err != nil {
+		return
+	}
+
+	_, err := o.writer.WriteString(value)
+	o.err = errors.Wrap(err, "failed to write ofx")
+}
+
+func ofxAccountType(subType models.BankAccountSubType) string {
+	switch subType {
+	case models.SavingsBankAccountSubType:
+		return "SAVINGS"
+	case models.MoneyMarketBankAccountSubType:
+		return "MONEYMRKT"
+	case models.CDBankAccountSubType:
+		return "CD"
+	default:
+		return "CHECKING"
+	}
+}
@@ -0,0 +1,549 @@
+package journal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/monetr/monetr/server/models"
+)
+
+type accountType string
+
+const (
+	assetAccountType     accountType = "A"
+	liabilityAccountType accountType = "L"
+	equityAccountType    accountType = "E"
+	incomeAccountType    accountType = "R"
+	expenseAccountType   accountType = "X"
+)
+
+const (
+	openingBalancesAccount = "Equity:Opening-Balances"
+	// transfersAccount is used for both sides of a transfer between bank accounts, once both sides are in the journal
+	// its balance will be zero.
+	transfersAccount      = "Equity:Transfers"
+	uncategorizedCategory = "Uncategorized"
+	defaultCurrency       = "USD"
+)
+
+// entryKind determines the order of entries that happen on the same day.
+type entryKind int
+
+const (
+	openingEntryKind entryKind = iota
+	fundingEntryKind
+	transactionEntryKind
+)
+
+type posting struct {
+	account string
+	// amount is in cents, positive amounts increase assets and expenses.
+	amount int64
+}
+
+type entry struct {
+	kind      entryKind
+	id        uint64
+	date      time.Time
+	payee     string
+	narration string
+	currency  string
+	metadata  [][2]string
+	postings  []posting
+}
+
+type journal struct {
+	// accounts is every account used by the journal, sorted by name.
+	accounts     []string
+	accountTypes map[string]accountType
+	currencies   []string
+	entries      []entry
+}
+
+// bankAccountInfo is what is needed to post against a bank account and the spending objects within it.
+type bankAccountInfo struct {
+	root      string
+	available string
+	currency  string
+	spending  map[uint64]string
+	// flows is the sum of all the postings made against the bank account by transactions, it is used to derive the
+	// opening balance.
+	flows int64
+}
+
+type builder struct {
+	options      Options
+	journal      *journal
+	usedNames    map[string]struct{}
+	bankAccounts map[uint64]*bankAccountInfo
+	currencies   map[string]struct{}
+	// categories is the account name component for each category, including the components of its parents.
+	categories map[uint64]string
+}
+
+func build(data Data, options Options) *journal {
+	b := &builder{
+		options: options,
+		journal: &journal{
+			accountTypes: map[string]accountType{},
+		},
+		usedNames:    map[string]struct{}{},
+		bankAccounts: map[uint64]*bankAccountInfo{},
+		currencies:   map[string]struct{}{},
+		categories:   categoryComponents(data.Categories),
+	}
+
+	links := map[uint64]models.Link{}
+	for _, link := range data.Links {
+		links[link.LinkId] = link
+	}
+
+	transactions := make([]models.Transaction, 0, len(data.Transactions))
+	for _, transaction := range data.Transactions {
+		if transaction.IsPending || transaction.DeletedAt != nil {
+			continue
+		}
+		transactions = append(transactions, transaction)
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].Date.Equal(transactions[j].Date) {
+			return transactions[i].Date.Before(transactions[j].Date)
+		}
+		return transactions[i].TransactionId < transactions[j].TransactionId
+	})
+
+	for _, bankAccount := range data.BankAccounts {
+		b.addBankAccount(bankAccount, links[bankAccount.LinkId], transactions)
+	}
+	for _, spending := range data.Spending {
+		b.addSpending(spending)
+	}
+
+	// deducted is how much each spending object has had taken from it by transactions.
+	deducted := map[uint64]int64{}
+	for _, transaction := range transactions {
+		b.addTransaction(transaction, deducted)
+	}
+
+	b.addFunding(data.FundingSchedules, data.Spending, deducted)
+
+	for _, bankAccount := range data.BankAccounts {
+		b.addOpeningBalance(bankAccount)
+	}
+
+	sort.SliceStable(b.journal.entries, func(i, j int) bool {
+		a, c := b.journal.entries[i], b.journal.entries[j]
+		if !a.date.Equal(c.date) {
+			return a.date.Before(c.date)
+		}
+		if a.kind != c.kind {
+			return a.kind < c.kind
+		}
+		return a.id < c.id
+	})
+
+	sort.Strings(b.journal.accounts)
+
+	b.journal.currencies = make([]string, 0, len(b.currencies))
+	for currency := range b.currencies {
+		b.journal.currencies = append(b.journal.currencies, currency)
+	}
+	sort.Strings(b.journal.currencies)
+
+	return b.journal
+}
+
+func (b *builder) addBankAccount(bankAccount models.BankAccount, link models.Link, transactions []models.Transaction) {
+	institution := link.CustomInstitutionName
+	if institution == "" {
+		institution = link.InstitutionName
+	}
+	institution = accountComponent(institution)
+	if institution == "" {
+		institution = "Manual"
+	}
+
+	name := accountComponent(bankAccount.Name)
+	if name == "" {
+		name = fmt.Sprintf("Account-%d", bankAccount.BankAccountId)
+	}
+
+	parent := "Assets"
+	if isLiability(bankAccount) {
+		parent = "Liabilities"
+	}
+
+	root := b.uniqueName(parent+":"+institution+":"+name, bankAccount.BankAccountId)
+	info := &bankAccountInfo{
+		root:      root,
+		available: root + ":Available",
+		currency:  defaultCurrency,
+		spending:  map[uint64]string{},
+	}
+	for _, transaction := range transactions {
+		if transaction.BankAccountId == bankAccount.BankAccountId && transaction.Currency != "" {
+			info.currency = transaction.Currency
+			break
+		}
+	}
+
+	b.bankAccounts[bankAccount.BankAccountId] = info
+}
+
+func (b *builder) addSpending(spending models.Spending) {
+	bankAccount, ok := b.bankAccounts[spending.BankAccountId]
+	if !ok {
+		return
+	}
+
+	group := "Expenses"
+	switch spending.SpendingType {
+	case models.SpendingTypeGoal:
+		group = "Goals"
+	case models.SpendingTypeOverflow:
+		group = "Overflow"
+	}
+
+	name := accountComponent(spending.Name)
+	if name == "" {
+		name = fmt.Sprintf("Spending-%d", spending.SpendingId)
+	}
+
+	bankAccount.spending[spending.SpendingId] = b.uniqueName(bankAccount.root+":"+group+":"+name, spending.SpendingId)
+}
+
+func (b *builder) addTransaction(transaction models.Transaction, deducted map[uint64]int64) {
+	bankAccount, ok := b.bankAccounts[transaction.BankAccountId]
+	if !ok {
+		return
+	}
+
+	currency := transaction.Currency
+	if currency == "" {
+		currency = bankAccount.currency
+	}
+
+	var counter string
+	switch {
+	case transaction.IsTransfer():
+		counter = transfersAccount
+	case transaction.IsAddition():
+		counter = b.categoryAccount("Income", transaction.CategoryId)
+	default:
+		counter = b.categoryAccount("Expenses", transaction.CategoryId)
+	}
+
+	postings := []posting{
+		{
+			account: counter,
+			amount:  transaction.Amount,
+		},
+	}
+
+	// Deposits and transfers are never spent from anything, everything else is taken from the spending objects it was
+	// spent from first. If those spending objects did not have enough allocated to them then the rest is taken from the
+	// available balance, the same way it is in monetr.
+	remaining := transaction.Amount
+	if transaction.Amount > 0 && !transaction.IsTransfer() {
+		spend := func(spendingId uint64, amount int64) {
+			account, ok := bankAccount.spending[spendingId]
+			if !ok || amount == 0 {
+				return
+			}
+			postings = append(postings, posting{
+				account: account,
+				amount:  -amount,
+			})
+			deducted[spendingId] += amount
+			remaining -= amount
+		}
+
+		if len(transaction.Splits) > 0 {
+			for _, split := range transaction.Splits {
+				spend(split.SpendingId, split.SpendingAmount)
+			}
+		} else if transaction.SpendingId != nil {
+			amount := transaction.Amount
+			if transaction.SpendingAmount != nil {
+				amount = *transaction.SpendingAmount
+			}
+			spend(*transaction.SpendingId, amount)
+		}
+	}
+	if remaining != 0 {
+		postings = append(postings, posting{
+			account: bankAccount.available,
+			amount:  -remaining,
+		})
+	}
+	bankAccount.flows -= transaction.Amount
+
+	metadata := [][2]string{
+		{"monetr-transaction-id", strconv.FormatUint(transaction.TransactionId, 10)},
+	}
+	if transaction.OriginalName != "" && transaction.OriginalName != transaction.Name {
+		metadata = append(metadata, [2]string{"original-name", transaction.OriginalName})
+	}
+
+	b.addEntry(entry{
+		kind:     transactionEntryKind,
+		id:       transaction.TransactionId,
+		date:     transaction.Date,
+		payee:    transaction.Name,
+		currency: currency,
+		metadata: metadata,
+		postings: postings,
+	})
+}
+
+func (b *builder) addFunding(fundingSchedules []models.FundingSchedule, spending []models.Spending, deducted map[uint64]int64) {
+	schedules := map[uint64]models.FundingSchedule{}
+	for _, fundingSchedule := range fundingSchedules {
+		schedules[fundingSchedule.FundingScheduleId] = fundingSchedule
+	}
+
+	type fundingEntry struct {
+		entry
+		available string
+		total     int64
+	}
+
+	entries := map[uint64]*fundingEntry{}
+	order := make([]uint64, 0)
+	for _, item := range spending {
+		bankAccount, ok := b.bankAccounts[item.BankAccountId]
+		if !ok {
+			continue
+		}
+
+		funded := item.CurrentAmount + deducted[item.SpendingId]
+		if funded == 0 {
+			continue
+		}
+
+		funding, ok := entries[item.FundingScheduleId]
+		if !ok {
+			funding = &fundingEntry{
+				entry: entry{
+					kind:      fundingEntryKind,
+					id:        item.FundingScheduleId,
+					date:      b.options.Now,
+					payee:     "Allocations",
+					narration: "Funding",
+					currency:  bankAccount.currency,
+					metadata: [][2]string{
+						{"monetr-funding-schedule-id", strconv.FormatUint(item.FundingScheduleId, 10)},
+					},
+				},
+				available: bankAccount.available,
+			}
+			if fundingSchedule, ok := schedules[item.FundingScheduleId]; ok {
+				funding.payee = fundingSchedule.Name
+				if fundingSchedule.LastOccurrence != nil {
+					funding.date = *fundingSchedule.LastOccurrence
+				}
+			}
+			entries[item.FundingScheduleId] = funding
+			order = append(order, item.FundingScheduleId)
+		}
+
+		funding.postings = append(funding.postings, posting{
+			account: bankAccount.spending[item.SpendingId],
+			amount:  funded,
+		})
+		funding.total += funded
+	}
+
+	for _, fundingScheduleId := range order {
+		funding := entries[fundingScheduleId]
+		funding.postings = append(funding.postings, posting{
+			account: funding.available,
+			amount:  -funding.total,
+		})
+		b.addEntry(funding.entry)
+	}
+}
+
+// addOpeningBalance adds an entry for whatever balance the bank account had before the earliest entry in the journal,
+// so that the bank account's total in the journal matches its current balance in monetr.
+func (b *builder) addOpeningBalance(bankAccount models.BankAccount) {
+	info, ok := b.bankAccounts[bankAccount.BankAccountId]
+	if !ok {
+		return
+	}
+
+	balance := bankAccount.CurrentBalance
+	if isLiability(bankAccount) {
+		balance = -balance
+	}
+
+	opening := balance - info.flows
+	if opening == 0 {
+		return
+	}
+
+	date := b.options.Now
+	for _, item := range b.journal.entries {
+		for _, posting := range item.postings {
+			if strings.HasPrefix(posting.account, info.root+":") && item.date.Before(date) {
+				date = item.date
+			}
+		}
+	}
+
+	b.addEntry(entry{
+		kind:      openingEntryKind,
+		id:        bankAccount.BankAccountId,
+		date:      date,
+		payee:     "Opening Balance",
+		narration: bankAccount.Name,
+		currency:  info.currency,
+		metadata: [][2]string{
+			{"monetr-bank-account-id", strconv.FormatUint(bankAccount.BankAccountId, 10)},
+		},
+		postings: []posting{
+			{
+				account: info.available,
+				amount:  opening,
+			},
+			{
+				account: openingBalancesAccount,
+				amount:  -opening,
+			},
+		},
+	})
+}
+
+func (b *builder) addEntry(item entry) {
+	postings := make([]posting, 0, len(item.postings))
+	for _, posting := range item.postings {
+		if posting.amount == 0 {
+			continue
+		}
+		b.useAccount(posting.account)
+		postings = append(postings, posting)
+	}
+	if len(postings) == 0 {
+		return
+	}
+
+	item.postings = postings
+	b.currencies[item.currency] = struct{}{}
+	b.journal.entries = append(b.journal.entries, item)
+}
+
+func (b *builder) useAccount(account string) {
+	if _, ok := b.journal.accountTypes[account]; ok {
+		return
+	}
+
+	var kind accountType
+	switch account[:strings.Index(account+":", ":")] {
+	case "Assets":
+		kind = assetAccountType
+	case "Liabilities":
+		kind = liabilityAccountType
+	case "Income":
+		kind = incomeAccountType
+	case "Expenses":
+		kind = expenseAccountType
+	default:
+		kind = equityAccountType
+	}
+
+	b.journal.accountTypes[account] = kind
+	b.journal.accounts = append(b.journal.accounts, account)
+}
+
+// uniqueName returns the provided account name, unless it has already been used by something else. In that case the id
+// is appended to the name to keep it unique.
+func (b *builder) uniqueName(name string, id uint64) string {
+	if _, ok := b.usedNames[name]; ok {
+		name = fmt.Sprintf("%s-%d", name, id)
+	}
+	b.usedNames[name] = struct{}{}
+
+	return name
+}
+
+func isLiability(bankAccount models.BankAccount) bool {
+	switch bankAccount.Type {
+	case models.CreditBankAccountType, models.LoanBankAccountType:
+		return true
+	default:
+		return false
+	}
+}
+
+// categoryAccount returns the expense or income account for the category a transaction has been assigned.
+func (b *builder) categoryAccount(parent string, categoryId *uint64) string {
+	category := ""
+	if categoryId != nil {
+		category = b.categories[*categoryId]
+	}
+	if category == "" {
+		category = uncategorizedCategory
+	}
+
+	return parent + ":" + category
+}
+
+// categoryComponents builds the account name for each category by joining the names of the category and its parents,
+// so "Restaurants" beneath "Food and Drink" becomes "Food-And-Drink:Restaurants".
+func categoryComponents(categories []models.Category) map[uint64]string {
+	byId := make(map[uint64]models.Category, len(categories))
+	for _, category := range categories {
+		byId[category.CategoryId] = category
+	}
+
+	result := make(map[uint64]string, len(categories))
+	for _, category := range categories {
+		components := make([]string, 0, 1)
+		seen := map[uint64]struct{}{}
+		current := category
+		for {
+			// Categories cannot be moved beneath themselves, but a cycle would otherwise never end.
+			if _, ok := seen[current.CategoryId]; ok {
+				break
+			}
+			seen[current.CategoryId] = struct{}{}
+
+			if component := accountComponent(current.Name); component != "" {
+				components = append([]string{component}, components...)
+			}
+
+			if current.ParentCategoryId == nil {
+				break
+			}
+
+			parent, ok := byId[*current.ParentCategoryId]
+			if !ok {
+				break
+			}
+			current = parent
+		}
+		result[category.CategoryId] = strings.Join(components, ":")
+	}
+
+	return result
+}
+
+// accountComponent converts a name into something that can be used as a single component of an account name in all of
+// the supported formats. Beancount is the strictest, each component must start with a capital letter or a number and
+// can only contain letters, numbers and dashes. So "Car insurance (yearly)" becomes "Car-Insurance-Yearly".
+func accountComponent(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,103 @@
+package journal
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// Format is a plain-text accounting format that monetr data can be exported as.
+type Format string
+
+const (
+	LedgerFormat    Format = "ledger"
+	HLedgerFormat   Format = "hledger"
+	BeancountFormat Format = "beancount"
+)
+
+// ParseFormat returns the journal format with the provided name, names are not case-sensitive.
+func ParseFormat(input string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(input))); format {
+	case LedgerFormat, HLedgerFormat, BeancountFormat:
+		return format, nil
+	default:
+		return "", errors.Errorf("invalid journal format %q, must be one of ledger, hledger or beancount", input)
+	}
+}
+
+// ContentType returns the media type that should be used when serving a journal in this format.
+func (f Format) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// Extension returns the file extension (without the leading period) for a journal in this format.
+func (f Format) Extension() string {
+	switch f {
+	case LedgerFormat:
+		return "ledger"
+	case HLedgerFormat:
+		return "journal"
+	case BeancountFormat:
+		return "beancount"
+	default:
+		return "txt"
+	}
+}
+
+// Data is everything that is included in a journal. Transactions should include their splits, pending transactions are
+// left out of the journal as they are not part of the bank account's current balance yet.
+type Data struct {
+	Links            []models.Link
+	BankAccounts     []models.BankAccount
+	FundingSchedules []models.FundingSchedule
+	Spending         []models.Spending
+	Transactions     []models.Transaction
+	// Categories are used to name the expense and income accounts that transactions are posted against.
+	Categories []models.Category
+}
+
+type Options struct {
+	// Timezone is used to determine the date of each entry, it should be the account's timezone.
+	Timezone *time.Location
+	// Now is the time the journal was created.
+	Now time.Time
+}
+
+// Write converts the provided data into a journal in the specified format.
+//
+// Each bank account becomes an asset (or a liability for credit and loan accounts), and each spending object becomes a
+// sub-account of the bank account it belongs to. Money that is not allocated to any spending object is kept in an
+// Available sub-account, so the bank account's total is always the sum of its sub-accounts. Transactions post against
+// the spending object they were spent from, and against an expense or income account named after their category. Child
+// categories become sub-accounts of their parent category's account.
+//
+// monetr does not keep a history of every contribution made to a spending object, so funding events are
+// reconstructed. Each funding schedule gets a single entry on its most recent occurrence that moves everything the
+// spending objects it funds have received out of the Available sub-account. That way the spending sub-accounts end
+// with the same balance that they have in monetr.
+func Write(writer io.Writer, format Format, data Data, options Options) error {
+	if options.Timezone == nil {
+		options.Timezone = time.UTC
+	}
+
+	var render func(w *bufio.Writer, journal *journal, options Options)
+	switch format {
+	case LedgerFormat, HLedgerFormat:
+		render = func(w *bufio.Writer, journal *journal, options Options) {
+			renderLedger(w, journal, options, format == HLedgerFormat)
+		}
+	case BeancountFormat:
+		render = renderBeancount
+	default:
+		return errors.Errorf("invalid journal format %q", format)
+	}
+
+	buffered := bufio.NewWriter(writer)
+	render(buffered, build(data, options), options)
+
+	return errors.Wrap(buffered.Flush(), "failed to write journal")
+}
@@ -0,0 +1,272 @@
+package journal
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func journalTestData(t *testing.T) (Data, Options) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	lastPayday := time.Date(2023, 10, 1, 0, 0, 0, 0, timezone)
+	groceriesId, groceriesAmount := uint64(4), int64(1299)
+	payrollId, groceriesCategoryId := uint64(21), uint64(23)
+	data := Data{
+		Links: []models.Link{
+			{
+				LinkId:          1,
+				LinkType:        models.ManualLinkType,
+				InstitutionName: "US Bank",
+			},
+		},
+		BankAccounts: []models.BankAccount{
+			{
+				BankAccountId:  2,
+				LinkId:         1,
+				Name:           "Checking Account",
+				Type:           models.DepositoryBankAccountType,
+				SubType:        models.CheckingBankAccountSubType,
+				CurrentBalance: 300000,
+			},
+		},
+		FundingSchedules: []models.FundingSchedule{
+			{
+				FundingScheduleId: 3,
+				BankAccountId:     2,
+				Name:              "Payday",
+				LastOccurrence:    &lastPayday,
+			},
+		},
+		Spending: []models.Spending{
+			{
+				SpendingId:        4,
+				BankAccountId:     2,
+				FundingScheduleId: 3,
+				SpendingType:      models.SpendingTypeExpense,
+				Name:              "Groceries (weekly)",
+				CurrentAmount:     5000,
+			},
+			{
+				SpendingId:        5,
+				BankAccountId:     2,
+				FundingScheduleId: 3,
+				SpendingType:      models.SpendingTypeGoal,
+				Name:              "Vacation",
+				CurrentAmount:     10000,
+			},
+		},
+		Categories: []models.Category{
+			{
+				CategoryId:       21,
+				ParentCategoryId: myownsanity.Uint64P(20),
+				Name:             "Payroll",
+			},
+			{
+				CategoryId: 20,
+				Name:       "Transfer",
+			},
+			{
+				CategoryId: 22,
+				Name:       "Food and Drink",
+			},
+			{
+				CategoryId:       23,
+				ParentCategoryId: myownsanity.Uint64P(22),
+				Name:             "Groceries",
+			},
+		},
+		Transactions: []models.Transaction{
+			{
+				TransactionId: 10,
+				BankAccountId: 2,
+				Amount:        -250000,
+				Date:          time.Date(2023, 10, 2, 0, 0, 0, 0, timezone),
+				Name:          "Payroll",
+				OriginalName:  "DIRECT DEPOSIT PAYROLL",
+				Currency:      "USD",
+				Categories:    []string{"Payroll"},
+				CategoryId:    &payrollId,
+			},
+			{
+				TransactionId:  11,
+				BankAccountId:  2,
+				Amount:         1299,
+				SpendingId:     &groceriesId,
+				SpendingAmount: &groceriesAmount,
+				Date:           time.Date(2023, 10, 3, 0, 0, 0, 0, timezone),
+				Name:           "Grocery Store; Downtown",
+				OriginalName:   "Grocery Store; Downtown",
+				Currency:       "USD",
+				Categories:     []string{"Food and Drink", "Groceries"},
+				CategoryId:     &groceriesCategoryId,
+			},
+			{
+				TransactionId: 12,
+				BankAccountId: 2,
+				Amount:        3000,
+				Date:          time.Date(2023, 10, 4, 0, 0, 0, 0, timezone),
+				Name:          "Dinner",
+				OriginalName:  "Dinner",
+				Currency:      "USD",
+				// Legacy categories are not used, this transaction has not been assigned a category.
+				Categories: []string{"Food and Drink", "Restaurants"},
+				Splits: []models.TransactionSplit{
+					{
+						SpendingId:     4,
+						Amount:         1000,
+						SpendingAmount: 1000,
+					},
+					{
+						SpendingId:     5,
+						Amount:         2000,
+						SpendingAmount: 2000,
+					},
+				},
+			},
+			{
+				TransactionId: 13,
+				BankAccountId: 2,
+				Amount:        500,
+				Date:          time.Date(2023, 10, 5, 0, 0, 0, 0, timezone),
+				Name:          "Coffee",
+				OriginalName:  "Coffee",
+				Currency:      "USD",
+				IsPending:     true,
+			},
+		},
+	}
+
+	return data, Options{
+		Timezone: timezone,
+		Now:      time.Date(2023, 10, 9, 13, 32, 0, 0, time.UTC),
+	}
+}
+
+var postingPattern = regexp.MustCompile(`^\s+(\S+:\S+)\s+(-?\d+)\.(\d{2}) USD$`)
+
+// balances reads the postings back out of a journal and returns the balance of each account in cents. Every entry in
+// the journal must balance.
+func balances(t *testing.T, journal string) map[string]int64 {
+	result := map[string]int64{}
+	var entry int64
+	for _, line := range strings.Split(journal, "\n") {
+		if strings.TrimSpace(line) == "" {
+			assert.Zero(t, entry, "every entry in the journal must balance")
+			entry = 0
+			continue
+		}
+
+		match := postingPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		whole, err := strconv.ParseInt(match[2], 10, 64)
+		require.NoError(t, err)
+		cents, err := strconv.ParseInt(match[3], 10, 64)
+		require.NoError(t, err)
+		amount := whole*100 + cents
+		if strings.HasPrefix(match[2], "-") {
+			amount = whole*100 - cents
+		}
+		result[match[1]] += amount
+		entry += amount
+	}
+	assert.Zero(t, entry, "every entry in the journal must balance")
+
+	return result
+}
+
+func writeJournal(t *testing.T, format Format, data Data, options Options) string {
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, Write(buffer, format, data, options), "must be able to write journal")
+
+	return buffer.String()
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" Beancount ")
+	assert.NoError(t, err)
+	assert.Equal(t, BeancountFormat, format)
+
+	format, err = ParseFormat("gnucash")
+	assert.EqualError(t, err, `invalid journal format "gnucash", must be one of ledger, hledger or beancount`)
+	assert.Empty(t, format)
+}
+
+func TestWrite(t *testing.T) {
+	for _, format := range []Format{LedgerFormat, HLedgerFormat, BeancountFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			data, options := journalTestData(t)
+			result := writeJournal(t, format, data, options)
+
+			accounts := balances(t, result)
+			assert.EqualValues(t, 5000, accounts["Assets:US-Bank:Checking-Account:Expenses:Groceries-Weekly"], "spending sub-account should end with the spending's current amount")
+			assert.EqualValues(t, 10000, accounts["Assets:US-Bank:Checking-Account:Goals:Vacation"], "goal sub-account should end with the goal's current amount")
+			var total int64
+			for account, balance := range accounts {
+				if strings.HasPrefix(account, "Assets:US-Bank:Checking-Account:") {
+					total += balance
+				}
+			}
+			assert.EqualValues(t, 300000, total, "bank account should end with its current balance")
+			assert.EqualValues(t, 1299, accounts["Expenses:Food-And-Drink:Groceries"], "child categories should be sub-accounts of their parent")
+			assert.EqualValues(t, 3000, accounts["Expenses:Uncategorized"])
+			assert.EqualValues(t, -250000, accounts["Income:Transfer:Payroll"])
+			assert.NotContains(t, result, "Coffee", "pending transactions should not be included")
+		})
+	}
+
+	t.Run("ledger syntax", func(t *testing.T) {
+		data, options := journalTestData(t)
+		result := writeJournal(t, LedgerFormat, data, options)
+
+		assert.Contains(t, result, "account Assets:US-Bank:Checking-Account:Available\n")
+		assert.Contains(t, result, "2023-10-03 * Grocery Store, Downtown\n    ; monetr-transaction-id: 11\n")
+		assert.Contains(t, result, "2023-10-01 * Payday\n    ; Funding\n")
+	})
+
+	t.Run("hledger syntax", func(t *testing.T) {
+		data, options := journalTestData(t)
+		result := writeJournal(t, HLedgerFormat, data, options)
+
+		assert.Contains(t, result, "account Assets:US-Bank:Checking-Account:Available  ; type: A\n")
+		assert.Contains(t, result, "account Income:Transfer:Payroll  ; type: R\n")
+		assert.Contains(t, result, "commodity 1000.00 USD\n")
+	})
+
+	t.Run("beancount syntax", func(t *testing.T) {
+		data, options := journalTestData(t)
+		result := writeJournal(t, BeancountFormat, data, options)
+
+		assert.Contains(t, result, `option "operating_currency" "USD"`)
+		assert.Contains(t, result, "2023-10-01 open Assets:US-Bank:Checking-Account:Available\n", "accounts should be opened on the earliest entry")
+		assert.Contains(t, result, "2023-10-03 * \"Grocery Store; Downtown\" \"\"\n  monetr-transaction-id: \"11\"\n")
+	})
+
+	t.Run("credit card", func(t *testing.T) {
+		data, options := journalTestData(t)
+		data.BankAccounts[0].Type = models.CreditBankAccountType
+		data.BankAccounts[0].SubType = models.CreditCardBankAccountSubType
+		data.BankAccounts[0].CurrentBalance = 4299
+		result := writeJournal(t, BeancountFormat, data, options)
+
+		accounts := balances(t, result)
+		var total int64
+		for account, balance := range accounts {
+			if strings.HasPrefix(account, "Liabilities:US-Bank:Checking-Account:") {
+				total += balance
+			}
+		}
+		assert.EqualValues(t, -4299, total, "the amount owed on a credit card should be a negative balance")
+	})
+}
@@ -0,0 +1,120 @@
+package journal
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const journalDateLayout = "2006-01-02"
+
+// renderLedger writes the journal in Ledger's syntax. hledger reads the same syntax, but declares account types and
+// commodities differently.
+func renderLedger(w *bufio.Writer, journal *journal, options Options, hledger bool) {
+	fmt.Fprintf(w, "; Exported from monetr on %s\n\n", options.Now.In(options.Timezone).Format(journalDateLayout))
+
+	for _, currency := range journal.currencies {
+		if hledger {
+			fmt.Fprintf(w, "commodity 1000.00 %s\n", currency)
+		} else {
+			fmt.Fprintf(w, "commodity %s\n    format 1000.00 %s\n", currency, currency)
+		}
+	}
+	if len(journal.currencies) > 0 {
+		w.WriteString("\n")
+	}
+
+	for _, account := range journal.accounts {
+		if hledger {
+			fmt.Fprintf(w, "account %s  ; type: %s\n", account, journal.accountTypes[account])
+		} else {
+			fmt.Fprintf(w, "account %s\n", account)
+		}
+	}
+
+	width := accountWidth(journal)
+	for _, item := range journal.entries {
+		fmt.Fprintf(w, "\n%s * %s\n", item.date.In(options.Timezone).Format(journalDateLayout), ledgerText(item.payee))
+		if item.narration != "" {
+			fmt.Fprintf(w, "    ; %s\n", ledgerText(item.narration))
+		}
+		for _, metadata := range item.metadata {
+			fmt.Fprintf(w, "    ; %s: %s\n", metadata[0], ledgerText(metadata[1]))
+		}
+		for _, posting := range item.postings {
+			fmt.Fprintf(w, "    %-*s  %12s %s\n", width, posting.account, formatCents(posting.amount), item.currency)
+		}
+	}
+}
+
+// renderBeancount writes the journal in Beancount's syntax. Beancount requires every account to be opened before it is
+// used, so all of the accounts are opened on the date of the earliest entry.
+func renderBeancount(w *bufio.Writer, journal *journal, options Options) {
+	fmt.Fprintf(w, ";; Exported from monetr on %s\n\n", options.Now.In(options.Timezone).Format(journalDateLayout))
+	w.WriteString(`option "title" "monetr"` + "\n")
+	for _, currency := range journal.currencies {
+		fmt.Fprintf(w, "option \"operating_currency\" %s\n", beancountString(currency))
+	}
+
+	opened := options.Now
+	for _, item := range journal.entries {
+		if item.date.Before(opened) {
+			opened = item.date
+		}
+	}
+	if len(journal.accounts) > 0 {
+		w.WriteString("\n")
+	}
+	for _, account := range journal.accounts {
+		fmt.Fprintf(w, "%s open %s\n", opened.In(options.Timezone).Format(journalDateLayout), account)
+	}
+
+	width := accountWidth(journal)
+	for _, item := range journal.entries {
+		fmt.Fprintf(w, "\n%s * %s %s\n",
+			item.date.In(options.Timezone).Format(journalDateLayout),
+			beancountString(item.payee),
+			beancountString(item.narration),
+		)
+		for _, metadata := range item.metadata {
+			fmt.Fprintf(w, "  %s: %s\n", metadata[0], beancountString(metadata[1]))
+		}
+		for _, posting := range item.postings {
+			fmt.Fprintf(w, "  %-*s  %12s %s\n", width, posting.account, formatCents(posting.amount), item.currency)
+		}
+	}
+}
+
+// accountWidth is the length of the longest account name, it is used to line up the amounts of postings.
+func accountWidth(journal *journal) int {
+	width := 0
+	for _, account := range journal.accounts {
+		if len(account) > width {
+			width = len(account)
+		}
+	}
+
+	return width
+}
+
+// ledgerText makes sure that text will be read back as a single value. Semicolons would start a comment and line
+// breaks would end the entry.
+func ledgerText(input string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(input, ";", ",")), " ")
+}
+
+func beancountString(input string) string {
+	return strconv.Quote(strings.Join(strings.Fields(input), " "))
+}
+
+// formatCents presents an amount in cents as a decimal.
+func formatCents(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
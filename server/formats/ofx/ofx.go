@@ -0,0 +1,255 @@
+package ofx
+
+import (
+	"bufio"
+	"bytes"
+	"html"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Element is a single node within an OFX document. OFX 1.x documents are SGML and do not close their leaf elements,
+// while OFX 2.x documents are XML and close everything. Both are normalized into this same tree so that the rest of the
+// parser does not need to care which version of the spec a bank decided to export.
+type Element struct {
+	Name     string
+	Value    string
+	Children []*Element
+}
+
+// Child returns the first direct child of the element with the provided name. Names are matched case-insensitively as
+// some banks will lowercase their tags even though the spec says they should be uppercase.
+func (e *Element) Child(name string) *Element {
+	if e == nil {
+		return nil
+	}
+
+	for _, child := range e.Children {
+		if strings.EqualFold(child.Name, name) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// ChildrenNamed returns all the direct children of the element with the provided name.
+func (e *Element) ChildrenNamed(name string) []*Element {
+	if e == nil {
+		return nil
+	}
+
+	result := make([]*Element, 0)
+	for _, child := range e.Children {
+		if strings.EqualFold(child.Name, name) {
+			result = append(result, child)
+		}
+	}
+
+	return result
+}
+
+// Path walks the tree using the provided names and returns the element at the end of the path, or nil if any part of
+// the path does not exist.
+func (e *Element) Path(names ...string) *Element {
+	current := e
+	for _, name := range names {
+		current = current.Child(name)
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+// Text returns the trimmed value of the child element at the provided path. If the element does not exist then an
+// empty string is returned.
+func (e *Element) Text(names ...string) string {
+	element := e.Path(names...)
+	if element == nil {
+		return ""
+	}
+
+	return element.Value
+}
+
+type tokenKind uint8
+
+const (
+	openToken tokenKind = iota
+	closeToken
+	textToken
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// ParseDocument reads an entire OFX file (either 1.x SGML or 2.x XML) and returns the root OFX element. Any headers
+// before the root element are ignored.
+func ParseDocument(reader io.Reader) (*Element, error) {
+	data, err := io.ReadAll(bufio.NewReader(reader))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read OFX file")
+	}
+
+	// OFX 1.x files are very frequently in a Windows code page rather than UTF-8. If the file is not valid UTF-8 then
+	// treat it as Latin-1, which is close enough for the characters that banks actually put in transaction names.
+	if !utf8.Valid(data) {
+		data = latin1ToUTF8(data)
+	}
+
+	start := indexOfRoot(data)
+	if start < 0 {
+		return nil, errors.New("file does not contain an OFX element")
+	}
+
+	tokens, err := tokenize(data[start:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 || tokens[0].kind != openToken || !strings.EqualFold(tokens[0].value, "OFX") {
+		return nil, errors.New("file does not contain an OFX element")
+	}
+
+	root, _, err := parseElement(tokens, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func indexOfRoot(data []byte) int {
+	upper := bytes.ToUpper(data)
+	index := bytes.Index(upper, []byte("<OFX>"))
+	if index >= 0 {
+		return index
+	}
+
+	// Some XML exports include attributes or whitespace on the root element.
+	return bytes.Index(upper, []byte("<OFX "))
+}
+
+func tokenize(data []byte) ([]token, error) {
+	tokens := make([]token, 0, 256)
+	for i := 0; i < len(data); {
+		switch data[i] {
+		case '<':
+			end := bytes.IndexByte(data[i:], '>')
+			if end < 0 {
+				return nil, errors.New("malformed OFX file, unterminated tag")
+			}
+			tag := strings.TrimSpace(string(data[i+1 : i+end]))
+			i += end + 1
+
+			switch {
+			case tag == "":
+				return nil, errors.New("malformed OFX file, empty tag")
+			case strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+				// Processing instructions and comments have nothing we care about.
+				continue
+			case strings.HasPrefix(tag, "/"):
+				tokens = append(tokens, token{
+					kind:  closeToken,
+					value: strings.ToUpper(strings.TrimSpace(tag[1:])),
+				})
+			default:
+				// Self-closing XML elements are treated as an empty leaf.
+				selfClosing := strings.HasSuffix(tag, "/")
+				tag = strings.TrimSuffix(tag, "/")
+				// Drop any attributes, OFX does not use them but XML exports might include a namespace.
+				if index := strings.IndexAny(tag, " \t\r\n"); index >= 0 {
+					tag = tag[:index]
+				}
+				name := strings.ToUpper(tag)
+				tokens = append(tokens, token{
+					kind:  openToken,
+					value: name,
+				})
+				if selfClosing {
+					tokens = append(tokens, token{
+						kind:  closeToken,
+						value: name,
+					})
+				}
+			}
+		default:
+			end := bytes.IndexByte(data[i:], '<')
+			if end < 0 {
+				end = len(data) - i
+			}
+			text := strings.TrimSpace(string(data[i : i+end]))
+			i += end
+			if text != "" {
+				tokens = append(tokens, token{
+					kind:  textToken,
+					value: html.UnescapeString(text),
+				})
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// parseElement expects the token at the provided index to be an opening tag. It will return the parsed element and the
+// index of the next token that has not been consumed.
+func parseElement(tokens []token, index int) (*Element, int, error) {
+	element := &Element{
+		Name: tokens[index].value,
+	}
+	index++
+
+	// If the opening tag is immediately followed by text then this is a leaf element. In SGML files the leaf will not
+	// be closed, in XML files it will be.
+	if index < len(tokens) && tokens[index].kind == textToken {
+		element.Value = tokens[index].value
+		index++
+		if index < len(tokens) && tokens[index].kind == closeToken && tokens[index].value == element.Name {
+			index++
+		}
+		return element, index, nil
+	}
+
+	for index < len(tokens) {
+		current := tokens[index]
+		switch current.kind {
+		case closeToken:
+			if current.value == element.Name {
+				return element, index + 1, nil
+			}
+
+			// We have hit a closing tag for one of our parents. This happens when an aggregate is not closed properly,
+			// or when this element is an empty SGML leaf. Either way let the parent handle it.
+			return element, index, nil
+		case openToken:
+			child, next, err := parseElement(tokens, index)
+			if err != nil {
+				return nil, index, err
+			}
+			element.Children = append(element.Children, child)
+			index = next
+		case textToken:
+			// Stray text inside an aggregate, this is not valid but is not worth failing the entire file over.
+			index++
+		}
+	}
+
+	return element, index, nil
+}
+
+func latin1ToUTF8(data []byte) []byte {
+	result := make([]rune, len(data))
+	for i, b := range data {
+		result[i] = rune(b)
+	}
+
+	return []byte(string(result))
+}
@@ -0,0 +1,266 @@
+package ofx
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Balance struct {
+	// Amount is the balance in the smallest unit of the currency (cents) as presented by the bank. A positive balance
+	// means there is money in the account.
+	Amount int64
+	Date   *time.Time
+}
+
+type Transaction struct {
+	// Type is the OFX TRNTYPE of the transaction, like DEBIT, CREDIT, POS or ATM.
+	Type string
+	// ID is the FITID of the transaction. It is unique for the transaction within the account at the financial
+	// institution and is used to de-duplicate transactions across multiple uploads.
+	ID         string
+	DatePosted time.Time
+	// DateUser is the date the user initiated the transaction, this is typically the date the card was swiped.
+	DateUser *time.Time
+	// Amount is the amount of the transaction in the smallest unit of the currency (cents). OFX represents debits as
+	// negative values and credits as positive values; this is preserved here.
+	Amount   int64
+	Name     string
+	Payee    string
+	Memo     string
+	Currency string
+}
+
+type Statement struct {
+	// AccountId is the ACCTID of the account the statement is for.
+	AccountId string
+	// AccountType is the ACCTTYPE from the statement, or CREDITCARD for credit card statements.
+	AccountType      string
+	Currency         string
+	Transactions     []Transaction
+	LedgerBalance    *Balance
+	AvailableBalance *Balance
+	// Errors contains a problem for each STMTTRN in the statement that could not be parsed. A single bad transaction
+	// does not prevent the rest of the statement from being read.
+	Errors []error
+}
+
+// Parse reads an OFX 1.x, OFX 2.x or QFX file and returns every bank and credit card statement present in the file.
+func Parse(reader io.Reader) ([]Statement, error) {
+	root, err := ParseDocument(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := make([]Statement, 0, 1)
+	for _, response := range root.ChildrenNamed("BANKMSGSRSV1") {
+		for _, wrapper := range response.ChildrenNamed("STMTTRNRS") {
+			for _, statementResponse := range wrapper.ChildrenNamed("STMTRS") {
+				statement, err := parseStatement(statementResponse, "BANKACCTFROM")
+				if err != nil {
+					return nil, err
+				}
+				statements = append(statements, *statement)
+			}
+		}
+	}
+
+	for _, response := range root.ChildrenNamed("CREDITCARDMSGSRSV1") {
+		for _, wrapper := range response.ChildrenNamed("CCSTMTTRNRS") {
+			for _, statementResponse := range wrapper.ChildrenNamed("CCSTMTRS") {
+				statement, err := parseStatement(statementResponse, "CCACCTFROM")
+				if err != nil {
+					return nil, err
+				}
+				statements = append(statements, *statement)
+			}
+		}
+	}
+
+	if len(statements) == 0 {
+		return nil, errors.New("OFX file does not contain any bank or credit card statements")
+	}
+
+	return statements, nil
+}
+
+func parseStatement(element *Element, accountElement string) (*Statement, error) {
+	statement := Statement{
+		AccountId:    element.Text(accountElement, "ACCTID"),
+		AccountType:  element.Text(accountElement, "ACCTTYPE"),
+		Currency:     strings.ToUpper(element.Text("CURDEF")),
+		Transactions: make([]Transaction, 0),
+		Errors:       make([]error, 0),
+	}
+	if accountElement == "CCACCTFROM" {
+		statement.AccountType = "CREDITCARD"
+	}
+
+	for _, list := range element.ChildrenNamed("BANKTRANLIST") {
+		for _, item := range list.ChildrenNamed("STMTTRN") {
+			transaction, err := parseTransaction(item, statement.Currency)
+			if err != nil {
+				statement.Errors = append(statement.Errors, err)
+				continue
+			}
+			statement.Transactions = append(statement.Transactions, *transaction)
+		}
+	}
+
+	var err error
+	if statement.LedgerBalance, err = parseBalance(element.Child("LEDGERBAL")); err != nil {
+		return nil, errors.Wrap(err, "failed to parse ledger balance")
+	}
+
+	if statement.AvailableBalance, err = parseBalance(element.Child("AVAILBAL")); err != nil {
+		return nil, errors.Wrap(err, "failed to parse available balance")
+	}
+
+	return &statement, nil
+}
+
+func parseTransaction(element *Element, currency string) (*Transaction, error) {
+	transaction := Transaction{
+		Type:     strings.ToUpper(element.Text("TRNTYPE")),
+		ID:       element.Text("FITID"),
+		Name:     element.Text("NAME"),
+		Payee:    element.Text("PAYEE", "NAME"),
+		Memo:     element.Text("MEMO"),
+		Currency: currency,
+	}
+
+	if override := element.Text("CURRENCY", "CURSYM"); override != "" {
+		transaction.Currency = strings.ToUpper(override)
+	}
+
+	if transaction.ID == "" {
+		return nil, errors.New("transaction is missing a FITID")
+	}
+
+	posted, err := ParseDate(element.Text("DTPOSTED"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse posted date of transaction %s", transaction.ID)
+	}
+	transaction.DatePosted = posted
+
+	if raw := element.Text("DTUSER"); raw != "" {
+		user, err := ParseDate(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse user date of transaction %s", transaction.ID)
+		}
+		transaction.DateUser = &user
+	}
+
+	transaction.Amount, err = ParseAmount(element.Text("TRNAMT"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse amount of transaction %s", transaction.ID)
+	}
+
+	return &transaction, nil
+}
+
+func parseBalance(element *Element) (*Balance, error) {
+	if element == nil {
+		return nil, nil
+	}
+
+	amount, err := ParseAmount(element.Text("BALAMT"))
+	if err != nil {
+		return nil, err
+	}
+
+	balance := Balance{
+		Amount: amount,
+	}
+
+	if raw := element.Text("DTASOF"); raw != "" {
+		date, err := ParseDate(raw)
+		if err != nil {
+			return nil, err
+		}
+		balance.Date = &date
+	}
+
+	return &balance, nil
+}
+
+// ParseAmount takes an OFX amount string and returns it in cents. OFX allows either a period or a comma as the decimal
+// separator, and some banks will include a leading plus sign.
+func ParseAmount(input string) (int64, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, errors.New("amount is blank")
+	}
+
+	if strings.Contains(input, ".") {
+		// If there is a period then any commas are just thousands separators.
+		input = strings.ReplaceAll(input, ",", "")
+	} else {
+		input = strings.ReplaceAll(input, ",", ".")
+	}
+	value, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid amount %q", input)
+	}
+
+	// ParseFloat accepts values like NaN and Inf, which cannot be represented as an amount.
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errors.Errorf("invalid amount %q", input)
+	}
+
+	return int64(math.Round(value * 100)), nil
+}
+
+// ParseDate parses the OFX datetime format, which is YYYYMMDDHHMMSS.XXX[gmt offset:tz name]. Everything after the
+// date portion is optional. If no offset is provided then the date is assumed to be in UTC as the spec specifies.
+func ParseDate(input string) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if len(input) < 8 {
+		return time.Time{}, errors.Errorf("invalid date %q", input)
+	}
+
+	location := time.UTC
+	if start := strings.IndexByte(input, '['); start >= 0 {
+		zone := strings.TrimSuffix(input[start+1:], "]")
+		input = input[:start]
+
+		offsetString := zone
+		if index := strings.IndexByte(zone, ':'); index >= 0 {
+			offsetString = zone[:index]
+		}
+
+		offset, err := strconv.ParseFloat(offsetString, 64)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid timezone offset %q", zone)
+		}
+		location = time.FixedZone(zone, int(offset*60*60))
+	}
+
+	// Drop the fractional seconds, they are not relevant for transactions.
+	if index := strings.IndexByte(input, '.'); index >= 0 {
+		input = input[:index]
+	}
+
+	var layout string
+	switch len(input) {
+	case 8:
+		layout = "20060102"
+	case 12:
+		layout = "200601021504"
+	case 14:
+		layout = "20060102150405"
+	default:
+		return time.Time{}, errors.Errorf("invalid date %q", input)
+	}
+
+	result, err := time.ParseInLocation(layout, input, location)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid date %q", input)
+	}
+
+	return result, nil
+}
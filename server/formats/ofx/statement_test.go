@@ -0,0 +1,228 @@
+package ofx
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sgmlSample = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<DTSERVER>20231015120000.000[-5:EST]
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>1
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<STMTRS>
+<CURDEF>USD
+<BANKACCTFROM>
+<BANKID>123456789
+<ACCTID>0000111122
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20231001
+<DTEND>20231015
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20231002120000.000[-5:EST]
+<TRNAMT>-12.34
+<FITID>2023100201
+<NAME>WENDYS #1234
+<MEMO>POS PURCHASE
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20231005
+<TRNAMT>1500,00
+<FITID>2023100501
+<NAME>PAYROLL &amp; CO
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>2345.67
+<DTASOF>20231015
+</LEDGERBAL>
+<AVAILBAL>
+<BALAMT>2300.00
+<DTASOF>20231015
+</AVAILBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlSample = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <SIGNONMSGSRSV1>
+    <SONRS>
+      <STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+      <DTSERVER>20231015120000</DTSERVER>
+      <LANGUAGE>ENG</LANGUAGE>
+    </SONRS>
+  </SIGNONMSGSRSV1>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <TRNUID>1</TRNUID>
+      <STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+      <CCSTMTRS>
+        <CURDEF>CAD</CURDEF>
+        <CCACCTFROM><ACCTID>4111111111111111</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <DTSTART>20231001</DTSTART>
+          <DTEND>20231015</DTEND>
+          <STMTTRN>
+            <TRNTYPE>POS</TRNTYPE>
+            <DTPOSTED>20231003</DTPOSTED>
+            <DTUSER>20231002</DTUSER>
+            <TRNAMT>-45.10</TRNAMT>
+            <FITID>abc-123</FITID>
+            <NAME>Grocery Store</NAME>
+            <MEMO></MEMO>
+          </STMTTRN>
+        </BANKTRANLIST>
+        <LEDGERBAL>
+          <BALAMT>-45.10</BALAMT>
+          <DTASOF>20231015</DTASOF>
+        </LEDGERBAL>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestParse(t *testing.T) {
+	t.Run("sgml", func(t *testing.T) {
+		statements, err := Parse(strings.NewReader(sgmlSample))
+		require.NoError(t, err, "must be able to parse OFX 1.x file")
+		require.Len(t, statements, 1, "should have a single statement")
+
+		statement := statements[0]
+		assert.Equal(t, "0000111122", statement.AccountId)
+		assert.Equal(t, "CHECKING", statement.AccountType)
+		assert.Equal(t, "USD", statement.Currency)
+		require.Len(t, statement.Transactions, 2, "should have two transactions")
+
+		debit := statement.Transactions[0]
+		assert.Equal(t, "DEBIT", debit.Type)
+		assert.Equal(t, "2023100201", debit.ID)
+		assert.EqualValues(t, -1234, debit.Amount)
+		assert.Equal(t, "WENDYS #1234", debit.Name)
+		assert.Equal(t, "POS PURCHASE", debit.Memo)
+		assert.Equal(t, time.Date(2023, 10, 2, 17, 0, 0, 0, time.UTC), debit.DatePosted.UTC())
+
+		credit := statement.Transactions[1]
+		assert.EqualValues(t, 150000, credit.Amount)
+		assert.Equal(t, "PAYROLL & CO", credit.Name, "entities should be unescaped")
+
+		require.NotNil(t, statement.LedgerBalance)
+		assert.EqualValues(t, 234567, statement.LedgerBalance.Amount)
+		require.NotNil(t, statement.AvailableBalance)
+		assert.EqualValues(t, 230000, statement.AvailableBalance.Amount)
+	})
+
+	t.Run("xml credit card", func(t *testing.T) {
+		statements, err := Parse(strings.NewReader(xmlSample))
+		require.NoError(t, err, "must be able to parse OFX 2.x file")
+		require.Len(t, statements, 1, "should have a single statement")
+
+		statement := statements[0]
+		assert.Equal(t, "4111111111111111", statement.AccountId)
+		assert.Equal(t, "CREDITCARD", statement.AccountType)
+		assert.Equal(t, "CAD", statement.Currency)
+		require.Len(t, statement.Transactions, 1)
+
+		transaction := statement.Transactions[0]
+		assert.Equal(t, "abc-123", transaction.ID)
+		assert.EqualValues(t, -4510, transaction.Amount)
+		assert.Empty(t, transaction.Memo)
+		require.NotNil(t, transaction.DateUser)
+		assert.Equal(t, time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC), *transaction.DateUser)
+		assert.Nil(t, statement.AvailableBalance)
+	})
+
+	t.Run("not an ofx file", func(t *testing.T) {
+		statements, err := Parse(strings.NewReader("date,amount\n2023-10-01,12.00\n"))
+		assert.EqualError(t, err, "file does not contain an OFX element")
+		assert.Nil(t, statements)
+	})
+
+	t.Run("missing fitid", func(t *testing.T) {
+		input := strings.Replace(sgmlSample, "<FITID>2023100201\n", "", 1)
+		statements, err := Parse(strings.NewReader(input))
+		require.NoError(t, err, "a single bad transaction should not fail the entire file")
+		require.Len(t, statements, 1)
+		assert.Len(t, statements[0].Transactions, 1, "only the valid transaction should be returned")
+		require.Len(t, statements[0].Errors, 1)
+		assert.EqualError(t, statements[0].Errors[0], "transaction is missing a FITID")
+	})
+}
+
+func TestParseDate(t *testing.T) {
+	t.Run("date only", func(t *testing.T) {
+		result, err := ParseDate("20231015")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2023, 10, 15, 0, 0, 0, 0, time.UTC), result)
+	})
+
+	t.Run("with fractional seconds and offset", func(t *testing.T) {
+		result, err := ParseDate("20231015083000.123[+5.5:IST]")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2023, 10, 15, 3, 0, 0, 0, time.UTC), result.UTC())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseDate("2023-10")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]int64{
+		"-12.34":   -1234,
+		"+1.5":     150,
+		"0.005":    1,
+		"1500,00":  150000,
+		"100":      10000,
+		"1,234.56": 123456,
+	}
+	for input, expected := range cases {
+		result, err := ParseAmount(input)
+		assert.NoError(t, err, "must parse %s", input)
+		assert.Equal(t, expected, result, "amount for %s", input)
+	}
+
+	_, err := ParseAmount("")
+	assert.Error(t, err, "blank amounts should fail")
+
+	for _, input := range []string{"NaN", "Inf", "-Infinity", "1e400"} {
+		_, err = ParseAmount(input)
+		assert.Error(t, err, "non-finite amount %s should fail", input)
+	}
+}
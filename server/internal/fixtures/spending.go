@@ -0,0 +1,46 @@
+package fixtures
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/stretchr/testify/require"
+)
+
+// GivenIHaveAnExpense will create a monthly expense that is funded by the provided funding schedule, and that already
+// has the specified amount allocated to it.
+func GivenIHaveAnExpense(t *testing.T, clock clock.Clock, fundingSchedule *models.FundingSchedule, currentAmount int64) models.Spending {
+	require.NotNil(t, fundingSchedule, "must provide a valid funding schedule")
+	require.NotNil(t, fundingSchedule.BankAccount, "funding schedule must include its bank account")
+	bankAccount := fundingSchedule.BankAccount
+
+	db := testutils.GetPgDatabase(t)
+	repo := repository.NewRepositoryFromSession(clock, bankAccount.Link.CreatedByUserId, bankAccount.AccountId, db)
+
+	timezone := testutils.MustEz(t, bankAccount.Account.GetTimezone)
+	rule := testutils.RuleToSet(t, timezone, "FREQ=MONTHLY;BYMONTHDAY=1", clock.Now())
+	nextRecurrence := util.Midnight(rule.After(clock.Now(), false), timezone)
+
+	spending := models.Spending{
+		AccountId:         bankAccount.AccountId,
+		BankAccountId:     bankAccount.BankAccountId,
+		FundingScheduleId: fundingSchedule.FundingScheduleId,
+		SpendingType:      models.SpendingTypeExpense,
+		Name:              gofakeit.Generate("Expense {uuid}"),
+		TargetAmount:      currentAmount * 2,
+		CurrentAmount:     currentAmount,
+		RuleSet:           rule,
+		NextRecurrence:    nextRecurrence,
+		DateCreated:       clock.Now().UTC(),
+	}
+
+	require.NoError(t, repo.CreateSpending(context.Background(), &spending), "must be able to create expense")
+
+	return spending
+}
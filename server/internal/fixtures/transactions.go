@@ -121,3 +121,56 @@ func CountPendingTransactions(t *testing.T, accountId uint64) int64 {
 
 	return int64(count)
 }
+
+// GivenIHaveATransfer creates an outflow on the from bank account and a matching inflow on the to bank account, and
+// then runs transfer detection on them. The returned transactions are linked to each other.
+func GivenIHaveATransfer(t *testing.T, clock clock.Clock, from, to models.BankAccount, amount int64) (outflow, inflow models.Transaction) {
+	require.NotZero(t, from.BankAccountId, "from bank account Id must be included")
+	require.NotZero(t, to.BankAccountId, "to bank account Id must be included")
+	require.Equal(t, from.AccountId, to.AccountId, "transfers must be between bank accounts in the same account")
+	require.NotNil(t, from.Account, "bank account must include account object")
+
+	db := testutils.GetPgDatabase(t)
+	repo := repository.NewRepositoryFromSession(clock, from.Link.CreatedByUserId, from.AccountId, db)
+
+	timezone, err := from.Account.GetTimezone()
+	require.NoError(t, err, "must be able to get the timezone from the account")
+
+	date := util.Midnight(clock.Now(), timezone)
+	transactions := make([]models.Transaction, 0, 2)
+	for _, item := range []struct {
+		bankAccount models.BankAccount
+		amount      int64
+		name        string
+	}{
+		{from, amount, "ONLINE TRANSFER TO SAVINGS"},
+		{to, -amount, "ONLINE TRANSFER FROM CHECKING"},
+	} {
+		transaction := models.Transaction{
+			AccountId:          item.bankAccount.AccountId,
+			BankAccountId:      item.bankAccount.BankAccountId,
+			PlaidTransactionId: gofakeit.UUID(),
+			Amount:             item.amount,
+			Date:               date,
+			Name:               item.name,
+			OriginalName:       item.name,
+			IsPending:          false,
+			CreatedAt:          clock.Now(),
+		}
+
+		err = repo.CreateTransaction(context.Background(), item.bankAccount.BankAccountId, &transaction)
+		require.NoError(t, err, "must be able to seed transaction")
+		transactions = append(transactions, transaction)
+	}
+
+	linked, err := repo.DetectTransfers(context.Background(), transactions)
+	require.NoError(t, err, "must be able to detect transfers")
+	require.Equal(t, 1, linked, "the seeded transactions must be linked as a transfer")
+
+	outflowResult, err := repo.GetTransaction(context.Background(), from.BankAccountId, transactions[0].TransactionId)
+	require.NoError(t, err, "must be able to retrieve the outflow")
+	inflowResult, err := repo.GetTransaction(context.Background(), to.BankAccountId, transactions[1].TransactionId)
+	require.NoError(t, err, "must be able to retrieve the inflow")
+
+	return *outflowResult, *inflowResult
+}
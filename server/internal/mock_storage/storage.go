@@ -0,0 +1,73 @@
+package mock_storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/monetr/monetr/server/storage"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ storage.Storage = &MockStorage{}
+)
+
+// MockStorage keeps files in memory so that tests can exercise code that stores and reads files without needing a
+// real storage backend.
+type MockStorage struct {
+	lock  sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMockStorage() *MockStorage {
+	return &MockStorage{
+		files: map[string][]byte{},
+	}
+}
+
+func (m *MockStorage) Store(ctx context.Context, buf io.ReadSeekCloser, contentType string) (uri string, err error) {
+	data, err := io.ReadAll(buf)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read buffer")
+	}
+
+	uri = fmt.Sprintf("mock://%s", uuid.NewString())
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.files[uri] = data
+
+	return uri, nil
+}
+
+func (m *MockStorage) Read(ctx context.Context, uri string) (buf io.ReadCloser, err error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	data, ok := m.files[uri]
+	if !ok {
+		return nil, errors.Errorf("file does not exist: %s", uri)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MockStorage) Remove(ctx context.Context, uri string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.files, uri)
+
+	return nil
+}
+
+// Count returns the number of files that have been stored.
+func (m *MockStorage) Count() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.files)
+}
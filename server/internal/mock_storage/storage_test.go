@@ -0,0 +1,35 @@
+package mock_storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.csv")
+	require.NoError(t, os.WriteFile(path, []byte("date,amount\n"), 0644))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	store := NewMockStorage()
+	uri, err := store.Store(context.Background(), file, "text/csv")
+	assert.NoError(t, err, "must be able to store file")
+	assert.NotEmpty(t, uri, "must return a uri")
+	assert.Equal(t, 1, store.Count())
+
+	reader, err := store.Read(context.Background(), uri)
+	require.NoError(t, err, "must be able to read file")
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "date,amount\n", string(data))
+
+	_, err = store.Read(context.Background(), "mock://missing")
+	assert.Error(t, err, "missing files should return an error")
+}
@@ -13,6 +13,13 @@ func TestFloat32P(t *testing.T) {
 	assert.Equal(t, input, *result, "and the underlying value should match the input")
 }
 
+func TestIntP(t *testing.T) {
+	input := 12345
+	result := IntP(input)
+	assert.NotNil(t, result, "resulting pointer should never be nil")
+	assert.Equal(t, input, *result, "and the underlying value should match the input")
+}
+
 func TestInt32P(t *testing.T) {
 	var input int32 = 12345
 	result := Int32P(input)
@@ -20,6 +27,20 @@ func TestInt32P(t *testing.T) {
 	assert.Equal(t, input, *result, "and the underlying value should match the input")
 }
 
+func TestInt64P(t *testing.T) {
+	var input int64 = 12345
+	result := Int64P(input)
+	assert.NotNil(t, result, "resulting pointer should never be nil")
+	assert.Equal(t, input, *result, "and the underlying value should match the input")
+}
+
+func TestUint64P(t *testing.T) {
+	var input uint64 = 12345
+	result := Uint64P(input)
+	assert.NotNil(t, result, "resulting pointer should never be nil")
+	assert.Equal(t, input, *result, "and the underlying value should match the input")
+}
+
 func TestMax(t *testing.T) {
 	assert.Equal(t, 2, Max(1, 2))
 	assert.Equal(t, 1000, Max(1000, 100))
@@ -31,3 +52,13 @@ func TestMin(t *testing.T) {
 	assert.Equal(t, 100, Min(1000, 100))
 	assert.Equal(t, 500, Min(500, 500))
 }
+
+func TestUint64PEqual(t *testing.T) {
+	a, b := uint64(1), uint64(1)
+	assert.True(t, Uint64PEqual(&a, &b), "should compare values not pointers")
+	assert.True(t, Uint64PEqual(nil, nil), "nil pointers should be equal")
+	assert.False(t, Uint64PEqual(&a, nil), "should not be equal")
+	assert.False(t, Uint64PEqual(nil, &b), "should not be equal")
+	c := uint64(2)
+	assert.False(t, Uint64PEqual(&a, &c), "should not be equal")
+}
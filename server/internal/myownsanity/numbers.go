@@ -8,10 +8,32 @@ func Float64P(value float64) *float64 {
 	return &value
 }
 
+func IntP(value int) *int {
+	return &value
+}
+
 func Int32P(value int32) *int32 {
 	return &value
 }
 
+func Int64P(value int64) *int64 {
+	return &value
+}
+
+func Uint64P(value uint64) *uint64 {
+	return &value
+}
+
+// Uint64PEqual will compare whether or not two uint64 pointers are equal. Two nil pointers are equal, otherwise their
+// values are compared rather than the pointers themselves.
+func Uint64PEqual(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return *a == *b
+}
+
 type Number interface {
 	int | int32 | int64
 }
@@ -0,0 +1,7 @@
+-- Transactions that are imported from a file (like OFX or QFX) will have an identifier provided by the bank. This is
+-- used to prevent the same transaction from being imported twice when files overlap.
+ALTER TABLE "transactions" ADD COLUMN "upload_identifier" TEXT NULL;
+
+CREATE UNIQUE INDEX "ix_uq_transactions_upload_identifier"
+ON "transactions" ("account_id", "bank_account_id", "upload_identifier")
+WHERE "upload_identifier" IS NOT NULL;
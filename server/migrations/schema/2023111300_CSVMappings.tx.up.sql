@@ -0,0 +1,24 @@
+CREATE TABLE "csv_mappings" (
+  csv_mapping_id     BIGSERIAL   NOT NULL,
+  account_id         BIGINT      NOT NULL,
+  bank_account_id    BIGINT      NOT NULL,
+  delimiter          TEXT        NOT NULL,
+  skip_rows          INT         NOT NULL,
+  date_column        INT         NOT NULL,
+  date_format        TEXT        NOT NULL,
+  amount_type        TEXT        NOT NULL,
+  amount_column      INT         NULL,
+  debit_column       INT         NULL,
+  credit_column      INT         NULL,
+  sign_convention    TEXT        NOT NULL,
+  decimal_separator  TEXT        NOT NULL,
+  description_column INT         NOT NULL,
+  merchant_column    INT         NULL,
+  identifier_column  INT         NULL,
+  created_at         TIMESTAMPTZ NOT NULL,
+  updated_at         TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_csv_mappings PRIMARY KEY ("csv_mapping_id"),
+  CONSTRAINT uq_csv_mappings_bank_account_id UNIQUE ("account_id", "bank_account_id"),
+  CONSTRAINT fk_csv_mappings_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_csv_mappings_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE
+);
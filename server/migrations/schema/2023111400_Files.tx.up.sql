@@ -0,0 +1,18 @@
+CREATE TABLE "files" (
+  file_id            BIGSERIAL   NOT NULL,
+  account_id         BIGINT      NOT NULL,
+  bank_account_id    BIGINT      NOT NULL,
+  name               TEXT        NOT NULL,
+  content_type       TEXT        NOT NULL,
+  size               BIGINT      NOT NULL,
+  object_uri         TEXT        NOT NULL,
+  created_at         TIMESTAMPTZ NOT NULL,
+  created_by_user_id BIGINT      NOT NULL,
+  CONSTRAINT pk_files PRIMARY KEY ("file_id", "account_id"),
+  CONSTRAINT fk_files_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_files_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_files_created_by_user FOREIGN KEY ("created_by_user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "ix_files_bank_account_created_at"
+ON "files" ("account_id", "bank_account_id", "created_at" DESC);
@@ -0,0 +1,7 @@
+-- Transactions are searched by their name, merchant name and original name. The expression here must match the one
+-- used by the repository exactly, otherwise the index will not be used.
+CREATE INDEX "ix_transactions_search"
+ON "transactions" USING GIN (
+  to_tsvector('simple', coalesce("name", '') || ' ' || coalesce("merchant_name", '') || ' ' || coalesce("original_name", ''))
+)
+WHERE "deleted_at" IS NULL;
@@ -0,0 +1,22 @@
+CREATE TABLE "transaction_splits" (
+  transaction_split_id BIGSERIAL   NOT NULL,
+  account_id           BIGINT      NOT NULL,
+  bank_account_id      BIGINT      NOT NULL,
+  transaction_id       BIGINT      NOT NULL,
+  spending_id          BIGINT      NOT NULL,
+  amount               BIGINT      NOT NULL,
+  spending_amount      BIGINT      NOT NULL,
+  created_at           TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_transaction_splits PRIMARY KEY ("transaction_split_id", "account_id", "bank_account_id"),
+  CONSTRAINT fk_transaction_splits_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_transaction_splits_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_transaction_splits_transaction FOREIGN KEY ("transaction_id", "account_id", "bank_account_id") REFERENCES "transactions" ("transaction_id", "account_id", "bank_account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_transaction_splits_spending FOREIGN KEY ("spending_id", "account_id", "bank_account_id") REFERENCES "spending" ("spending_id", "account_id", "bank_account_id") ON DELETE CASCADE,
+  CONSTRAINT uq_transaction_splits_spending UNIQUE ("account_id", "bank_account_id", "transaction_id", "spending_id")
+);
+
+CREATE INDEX "ix_transaction_splits_transaction"
+ON "transaction_splits" ("account_id", "bank_account_id", "transaction_id");
+
+CREATE INDEX "ix_transaction_splits_spending"
+ON "transaction_splits" ("account_id", "bank_account_id", "spending_id");
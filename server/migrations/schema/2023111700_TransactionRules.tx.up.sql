@@ -0,0 +1,16 @@
+CREATE TABLE "transaction_rules" (
+  transaction_rule_id BIGSERIAL   NOT NULL,
+  account_id          BIGINT      NOT NULL,
+  name                TEXT        NOT NULL,
+  priority            INT         NOT NULL,
+  is_enabled          BOOLEAN     NOT NULL,
+  conditions          JSONB       NOT NULL,
+  actions             JSONB       NOT NULL,
+  created_at          TIMESTAMPTZ NOT NULL,
+  updated_at          TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_transaction_rules PRIMARY KEY ("transaction_rule_id", "account_id"),
+  CONSTRAINT fk_transaction_rules_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "ix_transaction_rules_priority"
+ON "transaction_rules" ("account_id", "priority", "transaction_rule_id");
@@ -0,0 +1,9 @@
+-- Transactions that move money between two bank accounts in the same account are linked to the transaction on the
+-- other side of the transfer. The status indicates whether the pair was matched automatically, confirmed by the user,
+-- or rejected by the user. Rejected transactions are not linked and will not be matched again.
+ALTER TABLE "transactions" ADD COLUMN "transfer_transaction_id" BIGINT NULL;
+ALTER TABLE "transactions" ADD COLUMN "transfer_status" TEXT NULL;
+
+CREATE INDEX "ix_transactions_transfer"
+ON "transactions" ("account_id", "transfer_transaction_id")
+WHERE "transfer_transaction_id" IS NOT NULL;
@@ -0,0 +1,24 @@
+CREATE TABLE "spending_suggestions" (
+  spending_suggestion_id BIGSERIAL   NOT NULL,
+  account_id             BIGINT      NOT NULL,
+  bank_account_id        BIGINT      NOT NULL,
+  name                   TEXT        NOT NULL,
+  merchant_key           TEXT        NOT NULL,
+  frequency              TEXT        NOT NULL,
+  ruleset                TEXT        NOT NULL,
+  target_amount          BIGINT      NOT NULL,
+  next_recurrence        TIMESTAMPTZ NOT NULL,
+  last_occurrence        TIMESTAMPTZ NOT NULL,
+  occurrences            INT         NOT NULL,
+  status                 TEXT        NOT NULL,
+  spending_id            BIGINT,
+  created_at             TIMESTAMPTZ NOT NULL,
+  updated_at             TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_spending_suggestions PRIMARY KEY ("spending_suggestion_id", "account_id", "bank_account_id"),
+  CONSTRAINT uq_spending_suggestions_merchant UNIQUE ("account_id", "bank_account_id", "merchant_key", "frequency"),
+  CONSTRAINT fk_spending_suggestions_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_spending_suggestions_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "ix_spending_suggestions_status"
+ON "spending_suggestions" ("account_id", "bank_account_id", "status");
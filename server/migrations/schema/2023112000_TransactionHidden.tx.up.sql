@@ -0,0 +1,2 @@
+-- Hidden transactions are still part of the bank account's balances, but are excluded from reports.
+ALTER TABLE "transactions" ADD COLUMN "is_hidden" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,13 @@
+CREATE TABLE "transaction_attachments" (
+  account_id      BIGINT      NOT NULL,
+  bank_account_id BIGINT      NOT NULL,
+  transaction_id  BIGINT      NOT NULL,
+  file_id         BIGINT      NOT NULL,
+  created_at      TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_transaction_attachments PRIMARY KEY ("account_id", "bank_account_id", "transaction_id", "file_id"),
+  CONSTRAINT fk_transaction_attachments_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_transaction_attachments_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_transaction_attachments_transaction FOREIGN KEY ("transaction_id", "account_id", "bank_account_id") REFERENCES "transactions" ("transaction_id", "account_id", "bank_account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_transaction_attachments_file FOREIGN KEY ("file_id", "account_id") REFERENCES "files" ("file_id", "account_id") ON DELETE CASCADE,
+  CONSTRAINT uq_transaction_attachments_file UNIQUE ("account_id", "file_id")
+);
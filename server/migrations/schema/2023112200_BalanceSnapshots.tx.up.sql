@@ -0,0 +1,15 @@
+CREATE TABLE "balance_snapshots" (
+  account_id      BIGINT      NOT NULL,
+  bank_account_id BIGINT      NOT NULL,
+  date            TIMESTAMPTZ NOT NULL,
+  current         BIGINT      NOT NULL,
+  available       BIGINT      NOT NULL,
+  free            BIGINT      NOT NULL,
+  expenses        BIGINT      NOT NULL,
+  goals           BIGINT      NOT NULL,
+  is_estimated    BOOLEAN     NOT NULL DEFAULT false,
+  created_at      TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_balance_snapshots PRIMARY KEY ("account_id", "bank_account_id", "date"),
+  CONSTRAINT fk_balance_snapshots_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_balance_snapshots_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE
+);
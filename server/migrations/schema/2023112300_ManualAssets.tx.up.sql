@@ -0,0 +1,22 @@
+CREATE TABLE "manual_assets" (
+  manual_asset_id BIGSERIAL   NOT NULL,
+  account_id      BIGINT      NOT NULL,
+  name            TEXT        NOT NULL,
+  asset_type      TEXT        NOT NULL,
+  current_value   BIGINT      NOT NULL,
+  created_at      TIMESTAMPTZ NOT NULL,
+  updated_at      TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_manual_assets PRIMARY KEY ("manual_asset_id", "account_id"),
+  CONSTRAINT fk_manual_assets_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE
+);
+
+CREATE TABLE "manual_asset_values" (
+  account_id      BIGINT      NOT NULL,
+  manual_asset_id BIGINT      NOT NULL,
+  date            TIMESTAMPTZ NOT NULL,
+  value           BIGINT      NOT NULL,
+  created_at      TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_manual_asset_values PRIMARY KEY ("account_id", "manual_asset_id", "date"),
+  CONSTRAINT fk_manual_asset_values_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_manual_asset_values_manual_asset FOREIGN KEY ("manual_asset_id", "account_id") REFERENCES "manual_assets" ("manual_asset_id", "account_id") ON DELETE CASCADE
+);
@@ -0,0 +1,20 @@
+CREATE TABLE "reconciliations" (
+  reconciliation_id         BIGSERIAL   NOT NULL,
+  account_id                BIGINT      NOT NULL,
+  bank_account_id           BIGINT      NOT NULL,
+  statement_date            TIMESTAMPTZ NOT NULL,
+  statement_balance         BIGINT      NOT NULL,
+  cleared_balance           BIGINT      NOT NULL,
+  difference                BIGINT      NOT NULL,
+  adjustment_transaction_id BIGINT,
+  created_by_user_id        BIGINT      NOT NULL,
+  created_at                TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_reconciliations PRIMARY KEY ("reconciliation_id", "account_id", "bank_account_id"),
+  CONSTRAINT fk_reconciliations_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_reconciliations_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_reconciliations_adjustment_transaction FOREIGN KEY ("adjustment_transaction_id", "account_id", "bank_account_id") REFERENCES "transactions" ("transaction_id", "account_id", "bank_account_id"),
+  CONSTRAINT fk_reconciliations_created_by_user FOREIGN KEY ("created_by_user_id") REFERENCES "users" ("user_id")
+);
+
+CREATE INDEX "ix_reconciliations_statement_date"
+ON "reconciliations" ("account_id", "bank_account_id", "statement_date" DESC);
@@ -0,0 +1,32 @@
+CREATE TABLE "categories" (
+  category_id        BIGSERIAL   NOT NULL,
+  account_id         BIGINT      NOT NULL,
+  parent_category_id BIGINT,
+  name               TEXT        NOT NULL,
+  created_at         TIMESTAMPTZ NOT NULL,
+  updated_at         TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_categories PRIMARY KEY ("category_id", "account_id"),
+  CONSTRAINT fk_categories_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_categories_parent FOREIGN KEY ("parent_category_id", "account_id") REFERENCES "categories" ("category_id", "account_id")
+);
+
+-- Category names only need to be unique among their siblings.
+CREATE UNIQUE INDEX "ux_categories_name"
+ON "categories" ("account_id", COALESCE("parent_category_id", 0), LOWER("name"));
+
+CREATE TABLE "category_mappings" (
+  account_id     BIGINT      NOT NULL,
+  plaid_category TEXT        NOT NULL,
+  category_id    BIGINT      NOT NULL,
+  created_at     TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_category_mappings PRIMARY KEY ("account_id", "plaid_category"),
+  CONSTRAINT fk_category_mappings_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_category_mappings_category FOREIGN KEY ("category_id", "account_id") REFERENCES "categories" ("category_id", "account_id") ON DELETE CASCADE
+);
+
+ALTER TABLE "transactions" ADD COLUMN "category_id" BIGINT;
+ALTER TABLE "transactions" ADD CONSTRAINT fk_transactions_category FOREIGN KEY ("category_id", "account_id") REFERENCES "categories" ("category_id", "account_id");
+
+CREATE INDEX "ix_transactions_category"
+ON "transactions" ("account_id", "category_id")
+WHERE "category_id" IS NOT NULL;
@@ -0,0 +1,19 @@
+CREATE TABLE "spending_ledger" (
+  spending_ledger_entry_id BIGSERIAL   NOT NULL,
+  account_id               BIGINT      NOT NULL,
+  bank_account_id          BIGINT      NOT NULL,
+  spending_id              BIGINT      NOT NULL,
+  reason                   TEXT        NOT NULL,
+  reference_id             BIGINT,
+  amount                   BIGINT      NOT NULL,
+  current_amount           BIGINT      NOT NULL,
+  used_amount              BIGINT      NOT NULL,
+  created_at               TIMESTAMPTZ NOT NULL,
+  CONSTRAINT pk_spending_ledger PRIMARY KEY ("spending_ledger_entry_id", "account_id", "bank_account_id"),
+  CONSTRAINT fk_spending_ledger_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_spending_ledger_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_spending_ledger_spending FOREIGN KEY ("spending_id", "account_id", "bank_account_id") REFERENCES "spending" ("spending_id", "account_id", "bank_account_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "ix_spending_ledger_spending"
+ON "spending_ledger" ("account_id", "bank_account_id", "spending_id", "created_at" DESC, "spending_ledger_entry_id" DESC);
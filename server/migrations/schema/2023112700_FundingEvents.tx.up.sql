@@ -0,0 +1,19 @@
+CREATE TABLE "funding_events" (
+  funding_event_id                  BIGSERIAL   NOT NULL,
+  account_id                        BIGINT      NOT NULL,
+  bank_account_id                   BIGINT      NOT NULL,
+  funding_schedule_id               BIGINT      NOT NULL,
+  previous_last_occurrence          TIMESTAMPTZ,
+  previous_next_occurrence          TIMESTAMPTZ NOT NULL,
+  previous_next_occurrence_original TIMESTAMPTZ NOT NULL,
+  contributions                     JSONB       NOT NULL,
+  created_at                        TIMESTAMPTZ NOT NULL,
+  reverted_at                       TIMESTAMPTZ,
+  CONSTRAINT pk_funding_events PRIMARY KEY ("funding_event_id", "account_id", "bank_account_id"),
+  CONSTRAINT fk_funding_events_account FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_funding_events_bank_account FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id") ON DELETE CASCADE,
+  CONSTRAINT fk_funding_events_funding_schedule FOREIGN KEY ("funding_schedule_id", "account_id", "bank_account_id") REFERENCES "funding_schedules" ("funding_schedule_id", "account_id", "bank_account_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "ix_funding_events_funding_schedule"
+ON "funding_events" ("account_id", "bank_account_id", "funding_schedule_id", "created_at" DESC, "funding_event_id" DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE "spending" ADD COLUMN "rollover_policy" TEXT NOT NULL DEFAULT 'rollover';
+ALTER TABLE "spending" ADD COLUMN "sweep_spending_id" BIGINT;
+ALTER TABLE "spending" ADD COLUMN "carried_deficit" BIGINT NOT NULL DEFAULT 0;
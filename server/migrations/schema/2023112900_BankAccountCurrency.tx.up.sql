@@ -0,0 +1,14 @@
+ALTER TABLE "bank_accounts" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'USD';
+
+-- Existing bank accounts take the currency of their most recent transaction.
+UPDATE "bank_accounts"
+SET "currency" = "latest"."currency"
+FROM (
+  SELECT DISTINCT ON ("transactions"."bank_account_id")
+    "transactions"."bank_account_id",
+    "transactions"."currency"
+  FROM "transactions"
+  WHERE "transactions"."deleted_at" IS NULL
+  ORDER BY "transactions"."bank_account_id", "transactions"."date" DESC, "transactions"."transaction_id" DESC
+) AS "latest"
+WHERE "latest"."bank_account_id" = "bank_accounts"."bank_account_id";
@@ -0,0 +1,7 @@
+-- Default categories are only seeded once per account, so that an account that removes all of its categories does not
+-- have them created again. Accounts that already have categories have been seeded.
+ALTER TABLE "accounts" ADD COLUMN "categories_seeded_at" TIMESTAMPTZ;
+
+UPDATE "accounts"
+SET "categories_seeded_at" = now()
+WHERE "account_id" IN (SELECT DISTINCT "account_id" FROM "categories");
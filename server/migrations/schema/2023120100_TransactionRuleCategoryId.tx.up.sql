@@ -0,0 +1,38 @@
+-- Transaction rules now reference categories by their Id rather than by name. Names are resolved to the account's
+-- category with the same name, preferring top level categories.
+UPDATE "transaction_rules" AS "rule"
+SET "conditions" = ("rule"."conditions" - 'category') || jsonb_build_object('categoryId', (
+  SELECT "category"."category_id"
+  FROM "categories" AS "category"
+  WHERE "category"."account_id" = "rule"."account_id"
+    AND LOWER("category"."name") = LOWER("rule"."conditions"->>'category')
+  ORDER BY "category"."parent_category_id" NULLS FIRST, "category"."category_id"
+  LIMIT 1
+))
+WHERE "rule"."conditions" ? 'category';
+
+UPDATE "transaction_rules" AS "rule"
+SET "actions" = ("rule"."actions" - 'category') || jsonb_build_object('categoryId', (
+  SELECT "category"."category_id"
+  FROM "categories" AS "category"
+  WHERE "category"."account_id" = "rule"."account_id"
+    AND LOWER("category"."name") = LOWER("rule"."actions"->>'category')
+  ORDER BY "category"."parent_category_id" NULLS FIRST, "category"."category_id"
+  LIMIT 1
+))
+WHERE "rule"."actions" ? 'category';
+
+-- A rule whose category condition could not be resolved would match more transactions than it used to, so it is
+-- disabled instead. An action that could not be resolved is removed, and the rule is disabled if it has no other
+-- actions.
+UPDATE "transaction_rules"
+SET "is_enabled" = false, "conditions" = "conditions" - 'categoryId'
+WHERE "conditions"->'categoryId' = 'null'::jsonb;
+
+UPDATE "transaction_rules"
+SET "actions" = "actions" - 'categoryId'
+WHERE "actions"->'categoryId' = 'null'::jsonb;
+
+UPDATE "transaction_rules"
+SET "is_enabled" = false
+WHERE "actions" = '{}'::jsonb;
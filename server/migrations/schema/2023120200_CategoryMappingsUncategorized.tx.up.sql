@@ -0,0 +1,3 @@
+-- A mapping without a category leaves transactions with that Plaid category uncategorized. Mappings are kept this way
+-- when their top level category is deleted, so the category is not created again by the next sync.
+ALTER TABLE "category_mappings" ALTER COLUMN "category_id" DROP NOT NULL;
@@ -20,7 +20,10 @@ type Account struct {
 	SubscriptionActiveUntil      *time.Time                 `json:"subscriptionActiveUntil" pg:"subscription_active_until"`
 	SubscriptionStatus           *stripe.SubscriptionStatus `json:"subscriptionStatus" pg:"subscription_status"`
 	TrialEndsAt                  *time.Time                 `json:"trialEndsAt" pg:"trial_ends_at"`
-	CreatedAt                    time.Time                  `json:"createdAt" pg:"created_at,notnull"`
+	// CategoriesSeededAt is set once the default categories have been created for the account. Categories are never
+	// seeded again after this, even if the account removes all of them.
+	CategoriesSeededAt *time.Time `json:"-" pg:"categories_seeded_at"`
+	CreatedAt          time.Time  `json:"createdAt" pg:"created_at,notnull"`
 }
 
 func (a *Account) GetTimezone() (*time.Location, error) {
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// BalanceSnapshot is a bank account's balances as of the end of a single day. Snapshots are recorded every night so
+// that the history of a bank account's balances can be charted.
+type BalanceSnapshot struct {
+	tableName string `pg:"balance_snapshots"`
+
+	AccountId     uint64       `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account       *Account     `json:"-" pg:"rel:has-one"`
+	BankAccountId uint64       `json:"bankAccountId" pg:"bank_account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	BankAccount   *BankAccount `json:"-" pg:"rel:has-one"`
+	// Date is midnight of the day the snapshot is for, in the account's timezone.
+	Date      time.Time `json:"date" pg:"date,notnull,pk"`
+	Current   int64     `json:"current" pg:"current,notnull,use_zero"`
+	Available int64     `json:"available" pg:"available,notnull,use_zero"`
+	Free      int64     `json:"free" pg:"free,notnull,use_zero"`
+	Expenses  int64     `json:"expenses" pg:"expenses,notnull,use_zero"`
+	Goals     int64     `json:"goals" pg:"goals,notnull,use_zero"`
+	// IsEstimated is true for snapshots that were reconstructed from transaction history rather than recorded on the
+	// day. The current and available balances of an estimated snapshot are derived from the transactions since, but
+	// there is no history of how much was allocated to expenses and goals. So the allocations of an estimated snapshot
+	// are the allocations as of when it was reconstructed.
+	IsEstimated bool      `json:"isEstimated" pg:"is_estimated,notnull,use_zero"`
+	CreatedAt   time.Time `json:"-" pg:"created_at,notnull"`
+}
+
+type BalanceHistoryInterval string
+
+const (
+	DailyBalanceHistoryInterval   BalanceHistoryInterval = "daily"
+	WeeklyBalanceHistoryInterval  BalanceHistoryInterval = "weekly"
+	MonthlyBalanceHistoryInterval BalanceHistoryInterval = "monthly"
+)
+
+func ParseBalanceHistoryInterval(input string) (BalanceHistoryInterval, error) {
+	switch interval := BalanceHistoryInterval(strings.ToLower(strings.TrimSpace(input))); interval {
+	case DailyBalanceHistoryInterval, WeeklyBalanceHistoryInterval, MonthlyBalanceHistoryInterval:
+		return interval, nil
+	default:
+		return "", errors.Errorf("invalid interval %q, must be one of daily, weekly or monthly", input)
+	}
+}
+
+// Start returns the beginning of the interval that the provided time falls within. Weeks start on Sunday.
+func (i BalanceHistoryInterval) Start(input time.Time, timezone *time.Location) time.Time {
+	midnight := util.Midnight(input, timezone)
+	switch i {
+	case WeeklyBalanceHistoryInterval:
+		return midnight.AddDate(0, 0, -int(midnight.Weekday()))
+	case MonthlyBalanceHistoryInterval:
+		return time.Date(midnight.Year(), midnight.Month(), 1, 0, 0, 0, 0, timezone)
+	default:
+		return midnight
+	}
+}
+
+// Next returns the beginning of the interval after the one that starts at the provided time.
+func (i BalanceHistoryInterval) Next(start time.Time) time.Time {
+	switch i {
+	case WeeklyBalanceHistoryInterval:
+		return start.AddDate(0, 0, 7)
+	case MonthlyBalanceHistoryInterval:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// SummarizeBalanceSnapshots groups the provided snapshots by the interval and returns the balances as of the end of
+// each interval, dated at the start of the interval. The snapshots do not need to be sorted, but the result will be
+// sorted by date with the oldest interval first.
+func SummarizeBalanceSnapshots(
+	snapshots []BalanceSnapshot,
+	interval BalanceHistoryInterval,
+	timezone *time.Location,
+) []BalanceSnapshot {
+	latest := map[int64]BalanceSnapshot{}
+	for _, snapshot := range snapshots {
+		start := interval.Start(snapshot.Date, timezone).Unix()
+		if existing, ok := latest[start]; ok && existing.Date.After(snapshot.Date) {
+			continue
+		}
+		latest[start] = snapshot
+	}
+
+	result := make([]BalanceSnapshot, 0, len(latest))
+	for _, snapshot := range latest {
+		snapshot.Date = interval.Start(snapshot.Date, timezone)
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result
+}
+
+// EstimateBalanceSnapshots reconstructs the balances of a bank account as of the end of each day from the start date
+// up to (but not including) today, using the account's balances now and the transactions since the start date. The
+// balances are worked out backwards from today by undoing each day's transactions. Pending and deleted transactions
+// are ignored because they are not reflected in the current balance.
+//
+// The allocations to expenses and goals cannot be derived from transactions, so the provided allocations are used for
+// every day.
+func EstimateBalanceSnapshots(
+	bankAccount BankAccount,
+	expenses, goals int64,
+	transactions []Transaction,
+	start, now time.Time,
+	timezone *time.Location,
+) []BalanceSnapshot {
+	start = util.Midnight(start, timezone)
+	today := util.Midnight(now, timezone)
+	if !start.Before(today) {
+		return []BalanceSnapshot{}
+	}
+
+	// Debits reduce the balance of a depository account, but they increase the amount owed on a credit card or loan.
+	direction := int64(1)
+	if bankAccount.Type.IsLiability() {
+		direction = -1
+	}
+
+	changes := map[int64]int64{}
+	for _, transaction := range transactions {
+		if transaction.IsPending || transaction.DeletedAt != nil {
+			continue
+		}
+
+		changes[util.Midnight(transaction.Date, timezone).Unix()] += transaction.Amount
+	}
+
+	current, available := bankAccount.CurrentBalance, bankAccount.AvailableBalance
+	result := make([]BalanceSnapshot, 0)
+	for day := today; day.After(start); {
+		// Undoing the day's transactions gives the balance as of the end of the previous day.
+		current += direction * changes[day.Unix()]
+		available += direction * changes[day.Unix()]
+		day = day.AddDate(0, 0, -1)
+
+		result = append(result, BalanceSnapshot{
+			AccountId:     bankAccount.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+			Date:          day,
+			Current:       current,
+			Available:     available,
+			Free:          available - expenses - goals,
+			Expenses:      expenses,
+			Goals:         goals,
+			IsEstimated:   true,
+			CreatedAt:     now,
+		})
+	}
+
+	// The snapshots are built backwards, flip them so that they are in the order they occurred.
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBalanceHistoryInterval(t *testing.T) {
+	interval, err := ParseBalanceHistoryInterval(" Weekly ")
+	assert.NoError(t, err)
+	assert.Equal(t, WeeklyBalanceHistoryInterval, interval)
+
+	interval, err = ParseBalanceHistoryInterval("hourly")
+	assert.EqualError(t, err, `invalid interval "hourly", must be one of daily, weekly or monthly`)
+	assert.Empty(t, interval)
+}
+
+func TestSummarizeBalanceSnapshots(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	snapshots := make([]BalanceSnapshot, 0)
+	// Wednesday October 25th through Thursday November 2nd, with the balance going up by a dollar every day.
+	for i := 0; i < 9; i++ {
+		snapshots = append(snapshots, BalanceSnapshot{
+			Date:    time.Date(2023, 10, 25+i, 0, 0, 0, 0, timezone),
+			Current: int64(100 * (i + 1)),
+		})
+	}
+
+	t.Run("daily", func(t *testing.T) {
+		result := SummarizeBalanceSnapshots(snapshots, DailyBalanceHistoryInterval, timezone)
+		assert.Len(t, result, 9)
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		result := SummarizeBalanceSnapshots(snapshots, WeeklyBalanceHistoryInterval, timezone)
+		require.Len(t, result, 2)
+		assert.Equal(t, time.Date(2023, 10, 22, 0, 0, 0, 0, timezone), result[0].Date, "weeks should start on sunday")
+		assert.EqualValues(t, 400, result[0].Current, "should be the balance as of saturday")
+		assert.Equal(t, time.Date(2023, 10, 29, 0, 0, 0, 0, timezone), result[1].Date)
+		assert.EqualValues(t, 900, result[1].Current)
+	})
+
+	t.Run("monthly", func(t *testing.T) {
+		result := SummarizeBalanceSnapshots(snapshots, MonthlyBalanceHistoryInterval, timezone)
+		require.Len(t, result, 2)
+		assert.Equal(t, time.Date(2023, 10, 1, 0, 0, 0, 0, timezone), result[0].Date)
+		assert.EqualValues(t, 700, result[0].Current, "should be the balance as of october 31st")
+		assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), result[1].Date)
+		assert.EqualValues(t, 900, result[1].Current)
+	})
+}
+
+func TestEstimateBalanceSnapshots(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+	now := time.Date(2023, 11, 3, 14, 0, 0, 0, timezone)
+
+	transactions := []Transaction{
+		{
+			Amount: 2500,
+			Date:   time.Date(2023, 11, 3, 0, 0, 0, 0, timezone),
+		},
+		{
+			Amount: -100000,
+			Date:   time.Date(2023, 11, 2, 0, 0, 0, 0, timezone),
+		},
+		{
+			Amount:    999999,
+			Date:      time.Date(2023, 11, 2, 0, 0, 0, 0, timezone),
+			IsPending: true,
+		},
+		{
+			Amount: 4000,
+			Date:   time.Date(2023, 11, 1, 0, 0, 0, 0, timezone),
+		},
+	}
+
+	t.Run("depository", func(t *testing.T) {
+		bankAccount := BankAccount{
+			AccountId:        1,
+			BankAccountId:    2,
+			Type:             DepositoryBankAccountType,
+			CurrentBalance:   200000,
+			AvailableBalance: 200000,
+		}
+		result := EstimateBalanceSnapshots(bankAccount, 30000, 10000, transactions, now.AddDate(0, 0, -3), now, timezone)
+		require.Len(t, result, 3, "should have a snapshot for every day before today")
+
+		assert.Equal(t, time.Date(2023, 10, 31, 0, 0, 0, 0, timezone), result[0].Date)
+		assert.EqualValues(t, 106500, result[0].Current, "should undo every transaction after october 31st")
+		assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), result[1].Date)
+		assert.EqualValues(t, 102500, result[1].Current)
+		assert.Equal(t, time.Date(2023, 11, 2, 0, 0, 0, 0, timezone), result[2].Date)
+		assert.EqualValues(t, 202500, result[2].Current, "pending transactions should be ignored")
+		assert.EqualValues(t, 202500-40000, result[2].Free, "free should subtract the provided allocations")
+		for _, snapshot := range result {
+			assert.True(t, snapshot.IsEstimated)
+			assert.EqualValues(t, 2, snapshot.BankAccountId)
+		}
+	})
+
+	t.Run("credit card", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type:           CreditBankAccountType,
+			CurrentBalance: 5000,
+		}
+		result := EstimateBalanceSnapshots(bankAccount, 0, 0, transactions[:1], now.AddDate(0, 0, -1), now, timezone)
+		require.Len(t, result, 1)
+		assert.EqualValues(t, 2500, result[0].Current, "purchases should be undone by reducing the amount owed")
+	})
+
+	t.Run("start is today", func(t *testing.T) {
+		result := EstimateBalanceSnapshots(BankAccount{}, 0, 0, transactions, now, now, timezone)
+		assert.Empty(t, result)
+	})
+}
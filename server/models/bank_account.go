@@ -12,6 +12,16 @@ const (
 	OtherBankAccountType      BankAccountType = "other"
 )
 
+// IsLiability is true for accounts where the balance is an amount that is owed rather than an amount that is held.
+func (t BankAccountType) IsLiability() bool {
+	switch t {
+	case CreditBankAccountType, LoanBankAccountType:
+		return true
+	default:
+		return false
+	}
+}
+
 type BankAccountSubType string
 
 const (
@@ -31,6 +41,9 @@ const (
 	// I'll add other bank account sub types later. Right now I'm really only working with depository anyway.
 )
 
+// DefaultCurrency is the currency used for bank accounts when their currency is not known.
+const DefaultCurrency = "USD"
+
 type BankAccountStatus string
 
 const (
@@ -59,5 +72,17 @@ type BankAccount struct {
 	Type              BankAccountType    `json:"accountType" pg:"account_type" example:"depository"`
 	SubType           BankAccountSubType `json:"accountSubType" pg:"account_sub_type" example:"checking"`
 	Status            BankAccountStatus  `json:"status" pg:"status,notnull"`
-	LastUpdated       time.Time          `json:"lastUpdated" pg:"last_updated,notnull"`
+	// Currency is the ISO 4217 currency code of the bank account, transactions that are created for the bank account
+	// by monetr use it.
+	Currency    string    `json:"currency" pg:"currency,notnull,default:'USD'" example:"USD"`
+	LastUpdated time.Time `json:"lastUpdated" pg:"last_updated,notnull"`
+}
+
+// GetCurrency returns the currency of the bank account, or the default currency if it is not known.
+func (b BankAccount) GetCurrency() string {
+	if b.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return b.Currency
 }
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CategoryPathSeparator is used to join the levels of a Plaid category into the path that category mappings are keyed
+// by.
+const CategoryPathSeparator = " > "
+
+// MaxCategoryMappingDepth is the deepest level of a Plaid category that gets a category of its own when a transaction
+// is categorized. Plaid's hierarchy goes three levels deep, but the third level is too specific to be useful for most
+// budgets, so those transactions are categorized by their first two levels.
+const MaxCategoryMappingDepth = 2
+
+// Category is a user editable category that transactions can be assigned to. Categories form a tree per account, top
+// level categories do not have a parent.
+type Category struct {
+	tableName string `pg:"categories"`
+
+	CategoryId       uint64    `json:"categoryId" pg:"category_id,notnull,pk,type:'bigserial'"`
+	AccountId        uint64    `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account          *Account  `json:"-" pg:"rel:has-one"`
+	ParentCategoryId *uint64   `json:"parentCategoryId" pg:"parent_category_id"`
+	Name             string    `json:"name" pg:"name,notnull"`
+	CreatedAt        time.Time `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt        time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+// CategoryMapping assigns transactions with a Plaid category to one of the account's categories. Mappings are keyed by
+// the Plaid category path, the most specific mapping for a transaction's Plaid category is used. A mapping without a
+// category leaves those transactions uncategorized, this is kept when the category a mapping used is deleted so that
+// the category is not created again by the next sync.
+type CategoryMapping struct {
+	tableName string `pg:"category_mappings"`
+
+	AccountId     uint64    `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account       *Account  `json:"-" pg:"rel:has-one"`
+	PlaidCategory string    `json:"plaidCategory" pg:"plaid_category,notnull,pk"`
+	CategoryId    *uint64   `json:"categoryId" pg:"category_id,on_delete:CASCADE"`
+	Category      *Category `json:"-" pg:"rel:has-one"`
+	CreatedAt     time.Time `json:"createdAt" pg:"created_at,notnull"`
+}
+
+// DefaultCategory is a top level category that is created for every account, along with its children. Each category
+// is mapped to the Plaid category of the same name.
+type DefaultCategory struct {
+	Name     string
+	Children []string
+}
+
+// DefaultCategories are seeded from Plaid's category hierarchy.
+var DefaultCategories = []DefaultCategory{
+	{"Bank Fees", []string{"ATM", "Foreign Transaction", "Late Payment", "Overdraft"}},
+	{"Cash Advance", nil},
+	{"Community", []string{"Education", "Government Departments and Agencies", "Religious"}},
+	{"Food and Drink", []string{"Bar", "Nightlife", "Restaurants"}},
+	{"Healthcare", []string{"Healthcare Services", "Physicians"}},
+	{"Interest", []string{"Interest Charged", "Interest Earned"}},
+	{"Payment", []string{"Credit Card", "Loan", "Rent"}},
+	{"Recreation", []string{"Arts and Entertainment", "Gyms and Fitness Centers"}},
+	{"Service", []string{"Financial", "Insurance", "Subscription", "Telecommunication Services", "Utilities"}},
+	{"Shops", []string{"Clothing and Accessories", "Computers and Electronics", "Digital Purchase", "Pharmacies", "Supermarkets and Groceries"}},
+	{"Tax", []string{"Payment", "Refund"}},
+	{"Transfer", []string{"Credit", "Debit", "Deposit", "Payroll", "Withdrawal"}},
+	{"Travel", []string{"Airlines and Aviation Services", "Car Service", "Gas Stations", "Lodging", "Public Transportation Services", "Taxi"}},
+}
+
+// CategoryPath trims each level of a Plaid category, removes any blank levels and truncates it to the provided depth.
+// A depth of zero or less will not truncate the category.
+func CategoryPath(categories []string, depth int) []string {
+	path := make([]string, 0, len(categories))
+	for _, item := range categories {
+		if item = strings.TrimSpace(item); item != "" {
+			path = append(path, item)
+		}
+	}
+
+	if depth > 0 && len(path) > depth {
+		path = path[:depth]
+	}
+
+	return path
+}
+
+// TransactionCategorySource returns the Plaid category that a transaction should be categorized by. The original
+// categories from Plaid are preferred, the categories of the transaction are used when there are no original
+// categories.
+func TransactionCategorySource(originalCategories, categories []string) []string {
+	if len(CategoryPath(originalCategories, 0)) > 0 {
+		return originalCategories
+	}
+
+	return categories
+}
+
+// CategoryPathKey returns the key that category mappings are stored under for the provided Plaid category.
+func CategoryPathKey(categories []string) string {
+	return strings.Join(CategoryPath(categories, 0), CategoryPathSeparator)
+}
+
+// MatchCategoryMapping returns the category Id of the most specific mapping for the provided Plaid category, and the
+// number of levels of the category that matched. If no mapping matches then the depth is zero. Mappings without a
+// category are expected to be stored with a category Id of zero.
+func MatchCategoryMapping(mappings map[string]uint64, categories []string) (categoryId uint64, depth int) {
+	path := CategoryPath(categories, 0)
+	for depth = len(path); depth > 0; depth-- {
+		if categoryId, ok := mappings[CategoryPathKey(path[:depth])]; ok {
+			return categoryId, depth
+		}
+	}
+
+	return 0, 0
+}
+
+// ValidateCategoryParent checks that the parent of a category exists within the provided categories and that the
+// category is not being moved beneath itself. The category Id may be zero for a category that does not exist yet.
+func ValidateCategoryParent(categories []Category, categoryId uint64, parentCategoryId *uint64) error {
+	if parentCategoryId == nil {
+		return nil
+	}
+
+	parents := make(map[uint64]*uint64, len(categories))
+	for _, category := range categories {
+		parents[category.CategoryId] = category.ParentCategoryId
+	}
+
+	if _, ok := parents[*parentCategoryId]; !ok {
+		return errors.New("parent category does not exist")
+	}
+
+	// Walk up from the new parent, if we reach the category itself then it would become its own ancestor.
+	for current := parentCategoryId; current != nil; current = parents[*current] {
+		if categoryId != 0 && *current == categoryId {
+			return errors.New("category cannot be moved beneath itself")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryPath(t *testing.T) {
+	assert.Equal(t, []string{"Food and Drink", "Restaurants"}, CategoryPath([]string{
+		" Food and Drink ",
+		"",
+		"Restaurants",
+		"Coffee Shop",
+	}, MaxCategoryMappingDepth), "blank levels should be removed before truncating")
+	assert.Empty(t, CategoryPath(nil, MaxCategoryMappingDepth))
+
+	assert.Equal(t, "Food and Drink > Restaurants > Coffee Shop", CategoryPathKey([]string{
+		"Food and Drink",
+		"Restaurants",
+		"Coffee Shop",
+	}), "keys should never be truncated")
+}
+
+func TestMatchCategoryMapping(t *testing.T) {
+	mappings := map[string]uint64{
+		"Food and Drink":               1,
+		"Food and Drink > Restaurants": 2,
+	}
+
+	categoryId, depth := MatchCategoryMapping(mappings, []string{"Food and Drink", "Restaurants", "Coffee Shop"})
+	assert.EqualValues(t, 2, categoryId, "the most specific mapping should be used")
+	assert.Equal(t, 2, depth)
+
+	categoryId, depth = MatchCategoryMapping(mappings, []string{"Food and Drink", "Bar"})
+	assert.EqualValues(t, 1, categoryId)
+	assert.Equal(t, 1, depth)
+
+	categoryId, depth = MatchCategoryMapping(mappings, []string{"Travel", "Taxi"})
+	assert.Zero(t, categoryId)
+	assert.Zero(t, depth)
+}
+
+func TestValidateCategoryParent(t *testing.T) {
+	one, two, three, missing := uint64(1), uint64(2), uint64(3), uint64(4)
+	categories := []Category{
+		{CategoryId: one},
+		{CategoryId: two, ParentCategoryId: &one},
+		{CategoryId: three, ParentCategoryId: &two},
+	}
+
+	assert.NoError(t, ValidateCategoryParent(categories, 0, nil))
+	assert.NoError(t, ValidateCategoryParent(categories, 0, &three), "new categories can be added anywhere")
+	assert.NoError(t, ValidateCategoryParent(categories, three, &one))
+	assert.EqualError(t, ValidateCategoryParent(categories, 0, &missing), "parent category does not exist")
+	assert.EqualError(t, ValidateCategoryParent(categories, one, &one), "category cannot be moved beneath itself")
+	assert.EqualError(t, ValidateCategoryParent(categories, one, &three), "category cannot be moved beneath itself", "categories cannot be moved beneath their children")
+}
+
+func TestTransactionCategorySource(t *testing.T) {
+	assert.Equal(t, []string{"Travel", "Taxi"}, TransactionCategorySource(
+		[]string{"Travel", "Taxi"},
+		[]string{"Groceries"},
+	), "original categories should be preferred")
+	assert.Equal(t, []string{"Groceries"}, TransactionCategorySource(
+		[]string{" ", ""},
+		[]string{"Groceries"},
+	), "blank original categories should fall back to the categories")
+	assert.Empty(t, TransactionCategorySource(nil, nil))
+}
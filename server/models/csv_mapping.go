@@ -0,0 +1,134 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type CSVAmountType string
+
+const (
+	// CSVAmountTypeSingle is used when the CSV file has a single column for the amount of the transaction, and the
+	// direction of the money is indicated by the sign of the value.
+	CSVAmountTypeSingle CSVAmountType = "single"
+	// CSVAmountTypeSplit is used when the CSV file has separate columns for debits and credits. Typically only one of
+	// the columns will have a value for a given row.
+	CSVAmountTypeSplit CSVAmountType = "split"
+)
+
+type CSVSignConvention string
+
+const (
+	// CSVSignNegativeDebit means that money leaving the account is represented as a negative value. This is how most
+	// banks present checking and savings accounts.
+	CSVSignNegativeDebit CSVSignConvention = "negativeDebit"
+	// CSVSignPositiveDebit means that money leaving the account is represented as a positive value. This is how monetr
+	// represents transactions, and is common for credit card exports.
+	CSVSignPositiveDebit CSVSignConvention = "positiveDebit"
+)
+
+// CSVMapping describes how the columns of a CSV file exported by a bank should be read in order to create transactions
+// for a manual bank account. Each bank account can have a single mapping which is used whenever a CSV file is uploaded
+// for that bank account. All column references are zero based indexes.
+type CSVMapping struct {
+	tableName string `pg:"csv_mappings"`
+
+	CSVMappingId      uint64            `json:"csvMappingId" pg:"csv_mapping_id,notnull,pk,type:'bigserial'"`
+	AccountId         uint64            `json:"-" pg:"account_id,notnull,on_delete:CASCADE,type:'bigint'"`
+	Account           *Account          `json:"-" pg:"rel:has-one"`
+	BankAccountId     uint64            `json:"bankAccountId" pg:"bank_account_id,notnull,on_delete:CASCADE,type:'bigint',unique"`
+	BankAccount       *BankAccount      `json:"-" pg:"rel:has-one"`
+	Delimiter         string            `json:"delimiter" pg:"delimiter,notnull"`
+	SkipRows          int               `json:"skipRows" pg:"skip_rows,notnull,use_zero"`
+	DateColumn        int               `json:"dateColumn" pg:"date_column,notnull,use_zero"`
+	DateFormat        string            `json:"dateFormat" pg:"date_format,notnull"`
+	AmountType        CSVAmountType     `json:"amountType" pg:"amount_type,notnull"`
+	AmountColumn      *int              `json:"amountColumn" pg:"amount_column,use_zero"`
+	DebitColumn       *int              `json:"debitColumn" pg:"debit_column,use_zero"`
+	CreditColumn      *int              `json:"creditColumn" pg:"credit_column,use_zero"`
+	SignConvention    CSVSignConvention `json:"signConvention" pg:"sign_convention,notnull"`
+	DecimalSeparator  string            `json:"decimalSeparator" pg:"decimal_separator,notnull"`
+	DescriptionColumn int               `json:"descriptionColumn" pg:"description_column,notnull,use_zero"`
+	MerchantColumn    *int              `json:"merchantColumn" pg:"merchant_column,use_zero"`
+	// IdentifierColumn can be specified if the bank includes a unique Id for each transaction in the file. If it is
+	// present it is used to de-duplicate transactions between uploads. Otherwise a fingerprint of the row is used.
+	IdentifierColumn *int      `json:"identifierColumn" pg:"identifier_column,use_zero"`
+	CreatedAt        time.Time `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt        time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+// Normalize fills in defaults for any optional fields of the mapping that were not provided.
+func (m *CSVMapping) Normalize() {
+	if m.Delimiter == "" {
+		m.Delimiter = ","
+	}
+	if m.DecimalSeparator == "" {
+		m.DecimalSeparator = "."
+	}
+	if m.AmountType == "" {
+		m.AmountType = CSVAmountTypeSingle
+	}
+	if m.SignConvention == "" {
+		m.SignConvention = CSVSignNegativeDebit
+	}
+	m.DateFormat = strings.TrimSpace(m.DateFormat)
+}
+
+// Validate will return an error describing the first problem with the mapping if it cannot be used to read a file.
+func (m CSVMapping) Validate() error {
+	if len([]rune(m.Delimiter)) != 1 {
+		return errors.New("delimiter must be a single character")
+	}
+
+	switch m.DecimalSeparator {
+	case ".", ",":
+	default:
+		return errors.New("decimal separator must be either a period or a comma")
+	}
+
+	if m.SkipRows < 0 {
+		return errors.New("skip rows cannot be negative")
+	}
+
+	if m.DateFormat == "" {
+		return errors.New("date format is required")
+	}
+
+	columns := map[string]*int{
+		"date":        &m.DateColumn,
+		"description": &m.DescriptionColumn,
+		"amount":      m.AmountColumn,
+		"debit":       m.DebitColumn,
+		"credit":      m.CreditColumn,
+		"merchant":    m.MerchantColumn,
+		"identifier":  m.IdentifierColumn,
+	}
+	for name, column := range columns {
+		if column != nil && *column < 0 {
+			return errors.Errorf("%s column cannot be negative", name)
+		}
+	}
+
+	switch m.AmountType {
+	case CSVAmountTypeSingle:
+		if m.AmountColumn == nil {
+			return errors.New("amount column is required when using a single amount column")
+		}
+	case CSVAmountTypeSplit:
+		if m.DebitColumn == nil || m.CreditColumn == nil {
+			return errors.New("debit and credit columns are required when using split amount columns")
+		}
+	default:
+		return errors.New("amount type must be either single or split")
+	}
+
+	switch m.SignConvention {
+	case CSVSignNegativeDebit, CSVSignPositiveDebit:
+	default:
+		return errors.New("sign convention must be either negativeDebit or positiveDebit")
+	}
+
+	return nil
+}
@@ -6,9 +6,9 @@ type File struct {
 	tableName string `pg:"files"`
 
 	FileId          uint64       `json:"fileId" pg:"file_id,notnull,pk,type:'bigserial'"`
-	AccountId       uint64       `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigserial'"`
+	AccountId       uint64       `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
 	Account         *Account     `json:"-" pg:"rel:has-one"`
-	BankAccountId   uint64       `json:"bankAccountId" pg:"bank_account_id,notnull,on_delete:RESTRICT,type:'bigint'"`
+	BankAccountId   uint64       `json:"bankAccountId" pg:"bank_account_id,notnull,on_delete:CASCADE,type:'bigint'"`
 	BankAccount     *BankAccount `json:"-" pg:"rel:has-one"`
 	Name            string       `json:"name" pg:"name,notnull"`
 	ContentType     string       `json:"contentType" pg:"content_type,notnull"`
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FundingEvent records a single time a funding schedule was processed, and what it contributed to each of its spending
+// objects. A funding event can be reverted if it happened by mistake, which removes those contributions and moves the
+// funding schedule back to the occurrence it was on before it was processed.
+type FundingEvent struct {
+	tableName string `pg:"funding_events"`
+
+	FundingEventId    uint64           `json:"fundingEventId" pg:"funding_event_id,notnull,pk,type:'bigserial'"`
+	AccountId         uint64           `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account           *Account         `json:"-" pg:"rel:has-one"`
+	BankAccountId     uint64           `json:"bankAccountId" pg:"bank_account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	BankAccount       *BankAccount     `json:"-" pg:"rel:has-one"`
+	FundingScheduleId uint64           `json:"fundingScheduleId" pg:"funding_schedule_id,notnull,on_delete:CASCADE"`
+	FundingSchedule   *FundingSchedule `json:"-" pg:"rel:has-one"`
+	// PreviousLastOccurrence, PreviousNextOccurrence and PreviousNextOccurrenceOriginal are the occurrences of the
+	// funding schedule before it was processed, they are restored when the funding event is reverted.
+	PreviousLastOccurrence         *time.Time                 `json:"previousLastOccurrence" pg:"previous_last_occurrence"`
+	PreviousNextOccurrence         time.Time                  `json:"previousNextOccurrence" pg:"previous_next_occurrence,notnull"`
+	PreviousNextOccurrenceOriginal time.Time                  `json:"previousNextOccurrenceOriginal" pg:"previous_next_occurrence_original,notnull"`
+	Contributions                  []FundingEventContribution `json:"contributions" pg:"contributions,notnull,type:'jsonb'"`
+	CreatedAt                      time.Time                  `json:"createdAt" pg:"created_at,notnull"`
+	RevertedAt                     *time.Time                 `json:"revertedAt" pg:"reverted_at"`
+}
+
+// FundingEventContribution is the amount that was contributed to a single spending object by a funding event. The
+// amount is also what the next contribution amount of the spending object was before it was funded, so it is restored
+// as the next contribution amount when the funding event is reverted.
+type FundingEventContribution struct {
+	SpendingId uint64 `json:"spendingId"`
+	Amount     int64  `json:"amount"`
+}
+
+// Revert removes the funding event's contributions from the provided spending objects, and restores the funding
+// schedule to the occurrence it was on before the funding event. Spending objects that are not provided are assumed to
+// have been removed since, and are skipped. The spending objects that were changed are returned. If the funds of any
+// of the spending objects have already been spent, so that removing the contribution would make its balance negative,
+// then nothing is changed and an error is returned.
+func (f FundingEvent) Revert(fundingSchedule *FundingSchedule, spending []Spending) ([]Spending, error) {
+	if f.RevertedAt != nil {
+		return nil, errors.New("funding event has already been reverted")
+	}
+
+	if fundingSchedule.FundingScheduleId != f.FundingScheduleId {
+		return nil, errors.New("funding schedule does not belong to the funding event")
+	}
+
+	byId := make(map[uint64]Spending, len(spending))
+	for _, item := range spending {
+		byId[item.SpendingId] = item
+	}
+
+	updates := make([]Spending, 0, len(f.Contributions))
+	for _, contribution := range f.Contributions {
+		item, ok := byId[contribution.SpendingId]
+		if !ok {
+			continue
+		}
+
+		if item.CurrentAmount < contribution.Amount {
+			return nil, errors.Errorf("cannot revert funding event, funds contributed to %q have already been spent", item.Name)
+		}
+
+		item.CurrentAmount -= contribution.Amount
+		item.NextContributionAmount = contribution.Amount
+		updates = append(updates, item)
+	}
+
+	fundingSchedule.LastOccurrence = f.PreviousLastOccurrence
+	fundingSchedule.NextOccurrence = f.PreviousNextOccurrence
+	fundingSchedule.NextOccurrenceOriginal = f.PreviousNextOccurrenceOriginal
+
+	return updates, nil
+}
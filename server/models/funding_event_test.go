@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFundingEvent_Revert(t *testing.T) {
+	lastOccurrence := time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)
+	event := FundingEvent{
+		FundingEventId:                 1,
+		FundingScheduleId:              2,
+		PreviousLastOccurrence:         &lastOccurrence,
+		PreviousNextOccurrence:         time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC),
+		PreviousNextOccurrenceOriginal: time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC),
+		Contributions: []FundingEventContribution{
+			{SpendingId: 3, Amount: 500},
+			{SpendingId: 4, Amount: 250},
+			{SpendingId: 5, Amount: 100},
+		},
+	}
+
+	newFundingSchedule := func() *FundingSchedule {
+		return &FundingSchedule{
+			FundingScheduleId:      2,
+			LastOccurrence:         &event.PreviousNextOccurrence,
+			NextOccurrence:         time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC),
+			NextOccurrenceOriginal: time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	t.Run("reverts contributions", func(t *testing.T) {
+		fundingSchedule := newFundingSchedule()
+		updates, err := event.Revert(fundingSchedule, []Spending{
+			{SpendingId: 3, CurrentAmount: 1000, NextContributionAmount: 400},
+			{SpendingId: 4, CurrentAmount: 250, NextContributionAmount: 0},
+		})
+		require.NoError(t, err)
+		require.Len(t, updates, 2, "spending that has been removed should be skipped")
+
+		assert.EqualValues(t, 500, updates[0].CurrentAmount)
+		assert.EqualValues(t, 500, updates[0].NextContributionAmount)
+		assert.EqualValues(t, 0, updates[1].CurrentAmount)
+		assert.EqualValues(t, 250, updates[1].NextContributionAmount)
+
+		assert.Equal(t, &lastOccurrence, fundingSchedule.LastOccurrence)
+		assert.Equal(t, event.PreviousNextOccurrence, fundingSchedule.NextOccurrence)
+		assert.Equal(t, event.PreviousNextOccurrenceOriginal, fundingSchedule.NextOccurrenceOriginal)
+	})
+
+	t.Run("funds already spent", func(t *testing.T) {
+		fundingSchedule := newFundingSchedule()
+		updates, err := event.Revert(fundingSchedule, []Spending{
+			{SpendingId: 3, Name: "Rent", CurrentAmount: 499},
+		})
+		assert.EqualError(t, err, `cannot revert funding event, funds contributed to "Rent" have already been spent`)
+		assert.Nil(t, updates)
+		assert.Equal(t, time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC), fundingSchedule.NextOccurrence, "the funding schedule should not be changed")
+	})
+
+	t.Run("already reverted", func(t *testing.T) {
+		reverted := event
+		reverted.RevertedAt = &lastOccurrence
+		_, err := reverted.Revert(newFundingSchedule(), nil)
+		assert.EqualError(t, err, "funding event has already been reverted")
+	})
+}
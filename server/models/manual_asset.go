@@ -0,0 +1,56 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ManualAssetType string
+
+const (
+	PropertyManualAssetType ManualAssetType = "property"
+	VehicleManualAssetType  ManualAssetType = "vehicle"
+	OtherManualAssetType    ManualAssetType = "other"
+)
+
+func ParseManualAssetType(input string) (ManualAssetType, error) {
+	switch assetType := ManualAssetType(strings.ToLower(strings.TrimSpace(input))); assetType {
+	case PropertyManualAssetType, VehicleManualAssetType, OtherManualAssetType:
+		return assetType, nil
+	default:
+		return "", errors.Errorf("invalid asset type %q, must be one of property, vehicle or other", input)
+	}
+}
+
+// ManualAsset is something of value that is not held at a financial institution, like a house or a car. Its value is
+// entered by the user rather than synced, and every value that is entered is kept so that it can be included in the
+// account's net worth history.
+type ManualAsset struct {
+	tableName string `pg:"manual_assets"`
+
+	ManualAssetId uint64          `json:"manualAssetId" pg:"manual_asset_id,notnull,pk,type:'bigserial'"`
+	AccountId     uint64          `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account       *Account        `json:"-" pg:"rel:has-one"`
+	Name          string          `json:"name" pg:"name,notnull"`
+	AssetType     ManualAssetType `json:"assetType" pg:"asset_type,notnull"`
+	// CurrentValue is the most recent value of the asset in cents. It is kept up to date as values are recorded.
+	CurrentValue int64     `json:"currentValue" pg:"current_value,notnull,use_zero"`
+	CreatedAt    time.Time `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt    time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+// ManualAssetValue is the value of a manual asset as of a single day.
+type ManualAssetValue struct {
+	tableName string `pg:"manual_asset_values"`
+
+	AccountId     uint64       `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account       *Account     `json:"-" pg:"rel:has-one"`
+	ManualAssetId uint64       `json:"manualAssetId" pg:"manual_asset_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	ManualAsset   *ManualAsset `json:"-" pg:"rel:has-one"`
+	// Date is midnight of the day the value is for, in the account's timezone.
+	Date      time.Time `json:"date" pg:"date,notnull,pk"`
+	Value     int64     `json:"value" pg:"value,notnull,use_zero"`
+	CreatedAt time.Time `json:"-" pg:"created_at,notnull"`
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ManualNetWorthAccountType is the account type that manual assets are grouped under in the net worth breakdown.
+	ManualNetWorthAccountType = "manual"
+	// ManualNetWorthInstitution is the institution that manual assets are grouped under in the net worth breakdown.
+	ManualNetWorthInstitution = "Manual Assets"
+)
+
+// NetWorth is the total value of everything tracked in an account. Depository, investment and other bank accounts as
+// well as manual assets are counted as assets, while the balances of credit and loan bank accounts are counted as
+// liabilities. All amounts are in cents.
+type NetWorth struct {
+	Assets       int64                 `json:"assets"`
+	Liabilities  int64                 `json:"liabilities"`
+	NetWorth     int64                 `json:"netWorth"`
+	Institutions []NetWorthBreakdown   `json:"institutions"`
+	AccountTypes []NetWorthBreakdown   `json:"accountTypes"`
+	History      []NetWorthHistoryItem `json:"history"`
+}
+
+// NetWorthBreakdown is the share of the net worth that comes from a single institution or account type.
+type NetWorthBreakdown struct {
+	// LinkId is only present when the breakdown is for an institution, it is omitted for manual assets.
+	LinkId      *uint64 `json:"linkId,omitempty"`
+	Name        string  `json:"name"`
+	Assets      int64   `json:"assets"`
+	Liabilities int64   `json:"liabilities"`
+	NetWorth    int64   `json:"netWorth"`
+}
+
+// NetWorthHistoryItem is the net worth as of the end of an interval, dated at the start of the interval.
+type NetWorthHistoryItem struct {
+	Date        time.Time `json:"date"`
+	Assets      int64     `json:"assets"`
+	Liabilities int64     `json:"liabilities"`
+	NetWorth    int64     `json:"netWorth"`
+	// IsEstimated is true when any of the balance snapshots that the item was built from were estimated.
+	IsEstimated bool `json:"isEstimated"`
+}
+
+func (b *NetWorthBreakdown) add(amount int64, isLiability bool) {
+	if isLiability {
+		b.Liabilities += amount
+	} else {
+		b.Assets += amount
+	}
+	b.NetWorth = b.Assets - b.Liabilities
+}
+
+// CalculateNetWorth totals the current balances of the provided bank accounts and the current values of the provided
+// manual assets, broken down by institution and by account type. Bank accounts that are not active are not included.
+// The history of the result is left empty.
+func CalculateNetWorth(links []Link, bankAccounts []BankAccount, manualAssets []ManualAsset) NetWorth {
+	institutionNames := map[uint64]string{}
+	for _, link := range links {
+		name := strings.TrimSpace(link.CustomInstitutionName)
+		if name == "" {
+			name = link.InstitutionName
+		}
+		institutionNames[link.LinkId] = name
+	}
+
+	total := NetWorthBreakdown{}
+	institutions := map[uint64]*NetWorthBreakdown{}
+	accountTypes := map[string]*NetWorthBreakdown{}
+	accountType := func(name string) *NetWorthBreakdown {
+		item, ok := accountTypes[name]
+		if !ok {
+			item = &NetWorthBreakdown{
+				Name: name,
+			}
+			accountTypes[name] = item
+		}
+		return item
+	}
+
+	for _, bankAccount := range bankAccounts {
+		if bankAccount.Status != ActiveBankAccountStatus {
+			continue
+		}
+
+		isLiability := bankAccount.Type.IsLiability()
+		institution, ok := institutions[bankAccount.LinkId]
+		if !ok {
+			linkId := bankAccount.LinkId
+			institution = &NetWorthBreakdown{
+				LinkId: &linkId,
+				Name:   institutionNames[bankAccount.LinkId],
+			}
+			institutions[bankAccount.LinkId] = institution
+		}
+		institution.add(bankAccount.CurrentBalance, isLiability)
+
+		typeName := string(bankAccount.Type)
+		if typeName == "" {
+			typeName = string(OtherBankAccountType)
+		}
+		accountType(typeName).add(bankAccount.CurrentBalance, isLiability)
+		total.add(bankAccount.CurrentBalance, isLiability)
+	}
+
+	result := NetWorth{
+		Institutions: make([]NetWorthBreakdown, 0, len(institutions)+1),
+		AccountTypes: make([]NetWorthBreakdown, 0, len(accountTypes)+1),
+		History:      make([]NetWorthHistoryItem, 0),
+	}
+	for _, institution := range institutions {
+		result.Institutions = append(result.Institutions, *institution)
+	}
+	sort.Slice(result.Institutions, func(i, j int) bool {
+		if result.Institutions[i].Name == result.Institutions[j].Name {
+			return *result.Institutions[i].LinkId < *result.Institutions[j].LinkId
+		}
+		return result.Institutions[i].Name < result.Institutions[j].Name
+	})
+
+	if len(manualAssets) > 0 {
+		manual := NetWorthBreakdown{
+			Name: ManualNetWorthInstitution,
+		}
+		for _, asset := range manualAssets {
+			manual.add(asset.CurrentValue, false)
+			accountType(ManualNetWorthAccountType).add(asset.CurrentValue, false)
+			total.add(asset.CurrentValue, false)
+		}
+		result.Institutions = append(result.Institutions, manual)
+	}
+
+	for _, name := range []string{
+		string(DepositoryBankAccountType),
+		string(InvestmentBankAccountType),
+		string(OtherBankAccountType),
+		ManualNetWorthAccountType,
+		string(CreditBankAccountType),
+		string(LoanBankAccountType),
+	} {
+		if item, ok := accountTypes[name]; ok {
+			result.AccountTypes = append(result.AccountTypes, *item)
+			delete(accountTypes, name)
+		}
+	}
+	// Plaid might give us account types that we don't know about yet, those are added at the end.
+	remaining := make([]NetWorthBreakdown, 0, len(accountTypes))
+	for _, item := range accountTypes {
+		remaining = append(remaining, *item)
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].Name < remaining[j].Name
+	})
+	result.AccountTypes = append(result.AccountTypes, remaining...)
+
+	result.Assets = total.Assets
+	result.Liabilities = total.Liabilities
+	result.NetWorth = total.NetWorth
+
+	return result
+}
+
+// CalculateNetWorthHistory builds the net worth as of the end of each interval between the start and the end from the
+// balance snapshots of the provided bank accounts and the recorded values of manual assets. Snapshots for bank
+// accounts that are not provided are ignored. When a bank account does not have a snapshot or an asset does not have
+// a value within an interval, the most recent one before it is used instead, so the latest snapshot and value from
+// before the start should be provided as well. Intervals before anything was recorded are omitted.
+func CalculateNetWorthHistory(
+	bankAccounts []BankAccount,
+	snapshots []BalanceSnapshot,
+	values []ManualAssetValue,
+	interval BalanceHistoryInterval,
+	start, end time.Time,
+	timezone *time.Location,
+) []NetWorthHistoryItem {
+	accountTypes := map[uint64]BankAccountType{}
+	for _, bankAccount := range bankAccounts {
+		accountTypes[bankAccount.BankAccountId] = bankAccount.Type
+	}
+
+	snapshots = append([]BalanceSnapshot{}, snapshots...)
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Date.Before(snapshots[j].Date)
+	})
+	values = append([]ManualAssetValue{}, values...)
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Date.Before(values[j].Date)
+	})
+
+	latestSnapshots := map[uint64]BalanceSnapshot{}
+	latestValues := map[uint64]int64{}
+	result := make([]NetWorthHistoryItem, 0)
+	for period := interval.Start(start, timezone); period.Before(end); period = interval.Next(period) {
+		asOf := interval.Next(period)
+		if asOf.After(end) {
+			asOf = end
+		}
+
+		for len(snapshots) > 0 && snapshots[0].Date.Before(asOf) {
+			if _, ok := accountTypes[snapshots[0].BankAccountId]; ok {
+				latestSnapshots[snapshots[0].BankAccountId] = snapshots[0]
+			}
+			snapshots = snapshots[1:]
+		}
+		for len(values) > 0 && values[0].Date.Before(asOf) {
+			latestValues[values[0].ManualAssetId] = values[0].Value
+			values = values[1:]
+		}
+
+		if len(latestSnapshots) == 0 && len(latestValues) == 0 {
+			continue
+		}
+
+		total := NetWorthBreakdown{}
+		item := NetWorthHistoryItem{
+			Date: period,
+		}
+		for bankAccountId, snapshot := range latestSnapshots {
+			total.add(snapshot.Current, accountTypes[bankAccountId].IsLiability())
+			item.IsEstimated = item.IsEstimated || snapshot.IsEstimated
+		}
+		for _, value := range latestValues {
+			total.add(value, false)
+		}
+		item.Assets = total.Assets
+		item.Liabilities = total.Liabilities
+		item.NetWorth = total.NetWorth
+		result = append(result, item)
+	}
+
+	return result
+}
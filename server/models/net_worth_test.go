@@ -0,0 +1,167 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateNetWorth(t *testing.T) {
+	links := []Link{
+		{
+			LinkId:          1,
+			InstitutionName: "US Bank",
+		},
+		{
+			LinkId:                2,
+			InstitutionName:       "Chase",
+			CustomInstitutionName: "My Credit Card",
+		},
+	}
+	bankAccounts := []BankAccount{
+		{
+			BankAccountId:  1,
+			LinkId:         1,
+			Type:           DepositoryBankAccountType,
+			Status:         ActiveBankAccountStatus,
+			CurrentBalance: 500000,
+		},
+		{
+			BankAccountId:  2,
+			LinkId:         1,
+			Type:           LoanBankAccountType,
+			Status:         ActiveBankAccountStatus,
+			CurrentBalance: 200000,
+		},
+		{
+			BankAccountId:  3,
+			LinkId:         2,
+			Type:           CreditBankAccountType,
+			Status:         ActiveBankAccountStatus,
+			CurrentBalance: 50000,
+		},
+		{
+			BankAccountId:  4,
+			LinkId:         2,
+			Type:           DepositoryBankAccountType,
+			Status:         InactiveBankAccountStatus,
+			CurrentBalance: 999999,
+		},
+	}
+	manualAssets := []ManualAsset{
+		{
+			Name:         "House",
+			AssetType:    PropertyManualAssetType,
+			CurrentValue: 25000000,
+		},
+	}
+
+	result := CalculateNetWorth(links, bankAccounts, manualAssets)
+	assert.EqualValues(t, 25500000, result.Assets, "inactive bank accounts should not be included")
+	assert.EqualValues(t, 250000, result.Liabilities)
+	assert.EqualValues(t, 25250000, result.NetWorth)
+
+	require.Len(t, result.Institutions, 3)
+	assert.Equal(t, "My Credit Card", result.Institutions[0].Name, "custom institution names should be used")
+	assert.EqualValues(t, -50000, result.Institutions[0].NetWorth)
+	assert.Equal(t, "US Bank", result.Institutions[1].Name)
+	assert.EqualValues(t, 300000, result.Institutions[1].NetWorth)
+	assert.Equal(t, ManualNetWorthInstitution, result.Institutions[2].Name)
+	assert.Nil(t, result.Institutions[2].LinkId)
+
+	require.Len(t, result.AccountTypes, 4)
+	assert.Equal(t, "depository", result.AccountTypes[0].Name)
+	assert.Equal(t, ManualNetWorthAccountType, result.AccountTypes[1].Name)
+	assert.Equal(t, "credit", result.AccountTypes[2].Name)
+	assert.EqualValues(t, 50000, result.AccountTypes[2].Liabilities)
+	assert.Equal(t, "loan", result.AccountTypes[3].Name)
+	assert.Empty(t, result.History)
+}
+
+func TestCalculateNetWorthHistory(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	bankAccounts := []BankAccount{
+		{
+			BankAccountId: 1,
+			Type:          DepositoryBankAccountType,
+		},
+		{
+			BankAccountId: 2,
+			Type:          CreditBankAccountType,
+		},
+	}
+	snapshots := []BalanceSnapshot{
+		{
+			BankAccountId: 1,
+			Date:          time.Date(2023, 11, 1, 0, 0, 0, 0, timezone),
+			Current:       1000,
+			IsEstimated:   true,
+		},
+		{
+			BankAccountId: 2,
+			Date:          time.Date(2023, 11, 1, 0, 0, 0, 0, timezone),
+			Current:       300,
+		},
+		{
+			BankAccountId: 1,
+			Date:          time.Date(2023, 11, 3, 0, 0, 0, 0, timezone),
+			Current:       2000,
+		},
+		{
+			// Snapshots for bank accounts that were not provided should be ignored.
+			BankAccountId: 3,
+			Date:          time.Date(2023, 11, 3, 0, 0, 0, 0, timezone),
+			Current:       123456,
+		},
+	}
+	values := []ManualAssetValue{
+		{
+			ManualAssetId: 1,
+			Date:          time.Date(2023, 11, 2, 0, 0, 0, 0, timezone),
+			Value:         5000,
+		},
+	}
+
+	result := CalculateNetWorthHistory(
+		bankAccounts,
+		snapshots,
+		values,
+		DailyBalanceHistoryInterval,
+		time.Date(2023, 10, 30, 0, 0, 0, 0, timezone),
+		time.Date(2023, 11, 4, 0, 0, 0, 0, timezone),
+		timezone,
+	)
+	require.Len(t, result, 3, "days before anything was recorded should be omitted")
+
+	assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), result[0].Date)
+	assert.EqualValues(t, 700, result[0].NetWorth)
+	assert.True(t, result[0].IsEstimated)
+
+	assert.Equal(t, time.Date(2023, 11, 2, 0, 0, 0, 0, timezone), result[1].Date)
+	assert.EqualValues(t, 6000, result[1].Assets, "the balance from the previous day should be carried forward")
+	assert.EqualValues(t, 300, result[1].Liabilities)
+	assert.EqualValues(t, 5700, result[1].NetWorth)
+
+	assert.Equal(t, time.Date(2023, 11, 3, 0, 0, 0, 0, timezone), result[2].Date)
+	assert.EqualValues(t, 6700, result[2].NetWorth)
+	assert.False(t, result[2].IsEstimated)
+
+	t.Run("monthly", func(t *testing.T) {
+		result := CalculateNetWorthHistory(
+			bankAccounts,
+			snapshots,
+			values,
+			MonthlyBalanceHistoryInterval,
+			time.Date(2023, 10, 30, 0, 0, 0, 0, timezone),
+			time.Date(2023, 11, 4, 0, 0, 0, 0, timezone),
+			timezone,
+		)
+		require.Len(t, result, 1)
+		assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), result[0].Date)
+		assert.EqualValues(t, 6700, result[0].NetWorth, "should be the net worth as of the end date")
+	})
+}
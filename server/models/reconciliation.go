@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/monetr/monetr/server/util"
+)
+
+// ReconciliationAdjustmentName is the name given to the transaction that is created to account for the difference
+// found when a bank account is reconciled.
+const ReconciliationAdjustmentName = "Reconciliation Adjustment"
+
+// Reconciliation records that the balance of a manual bank account was checked against a statement from the bank. The
+// transactions on or before the statement date are locked once a bank account has been reconciled, so that the
+// reconciled balance cannot silently change.
+type Reconciliation struct {
+	tableName string `pg:"reconciliations"`
+
+	ReconciliationId uint64       `json:"reconciliationId" pg:"reconciliation_id,notnull,pk,type:'bigserial'"`
+	AccountId        uint64       `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account          *Account     `json:"-" pg:"rel:has-one"`
+	BankAccountId    uint64       `json:"bankAccountId" pg:"bank_account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	BankAccount      *BankAccount `json:"-" pg:"rel:has-one"`
+	// StatementDate is midnight of the last day included in the statement, in the account's timezone.
+	StatementDate    time.Time `json:"statementDate" pg:"statement_date,notnull"`
+	StatementBalance int64     `json:"statementBalance" pg:"statement_balance,notnull,use_zero"`
+	// ClearedBalance is what monetr calculated the balance to be as of the end of the statement date, from the current
+	// balance of the bank account and the cleared transactions after the statement date.
+	ClearedBalance int64 `json:"clearedBalance" pg:"cleared_balance,notnull,use_zero"`
+	// Difference is the statement balance minus the cleared balance. The balances of the bank account are adjusted by
+	// this amount when it is reconciled.
+	Difference              int64        `json:"difference" pg:"difference,notnull,use_zero"`
+	AdjustmentTransactionId *uint64      `json:"adjustmentTransactionId" pg:"adjustment_transaction_id"`
+	AdjustmentTransaction   *Transaction `json:"-" pg:"rel:has-one"`
+	CreatedByUserId         uint64       `json:"createdByUserId" pg:"created_by_user_id,notnull"`
+	CreatedByUser           *User        `json:"-" pg:"rel:has-one,fk:created_by_user_id"`
+	CreatedAt               time.Time    `json:"createdAt" pg:"created_at,notnull"`
+}
+
+// Reconcile compares the statement balance with the balance of the bank account as of the end of the statement date.
+// That balance is worked out from the current balance of the bank account by undoing every cleared transaction that
+// is dated after the statement date. Pending and deleted transactions are not cleared, so they are ignored, as are
+// transactions on or before the statement date.
+func Reconcile(
+	bankAccount BankAccount,
+	transactions []Transaction,
+	statementDate time.Time,
+	statementBalance int64,
+	timezone *time.Location,
+) Reconciliation {
+	statementDate = util.Midnight(statementDate, timezone)
+
+	// Debits reduce the balance of a depository account, but they increase the amount owed on a credit card or loan.
+	direction := int64(1)
+	if bankAccount.Type.IsLiability() {
+		direction = -1
+	}
+
+	cleared := bankAccount.CurrentBalance
+	for _, transaction := range transactions {
+		if transaction.IsPending || transaction.DeletedAt != nil {
+			continue
+		}
+
+		if !util.Midnight(transaction.Date, timezone).After(statementDate) {
+			continue
+		}
+
+		cleared += direction * transaction.Amount
+	}
+
+	return Reconciliation{
+		AccountId:        bankAccount.AccountId,
+		BankAccountId:    bankAccount.BankAccountId,
+		StatementDate:    statementDate,
+		StatementBalance: statementBalance,
+		ClearedBalance:   cleared,
+		Difference:       statementBalance - cleared,
+	}
+}
+
+// AdjustmentAmount is the amount of the transaction that would account for the difference. A positive difference on a
+// depository account is money that monetr did not know about, so it is a deposit, which is a negative amount. On a
+// credit card or loan a positive difference is more owed than monetr knew about, which is a debit.
+func (r Reconciliation) AdjustmentAmount(accountType BankAccountType) int64 {
+	if accountType.IsLiability() {
+		return r.Difference
+	}
+
+	return -r.Difference
+}
+
+// IsLocked returns true if a transaction on the provided date is included in the reconciliation.
+func (r Reconciliation) IsLocked(date time.Time, timezone *time.Location) bool {
+	return !util.Midnight(date, timezone).After(r.StatementDate.In(timezone))
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+	statementDate := time.Date(2023, 10, 31, 0, 0, 0, 0, timezone)
+
+	transactions := []Transaction{
+		{
+			Amount: 2500,
+			Date:   time.Date(2023, 11, 2, 0, 0, 0, 0, timezone),
+		},
+		{
+			Amount: -10000,
+			Date:   time.Date(2023, 11, 1, 0, 0, 0, 0, timezone),
+		},
+		{
+			Amount:    999999,
+			Date:      time.Date(2023, 11, 1, 0, 0, 0, 0, timezone),
+			IsPending: true,
+		},
+		{
+			// Transactions on the statement date are included in the statement balance already.
+			Amount: 4000,
+			Date:   statementDate,
+		},
+	}
+
+	t.Run("depository", func(t *testing.T) {
+		bankAccount := BankAccount{
+			BankAccountId:  1,
+			Type:           DepositoryBankAccountType,
+			CurrentBalance: 50000,
+		}
+		result := Reconcile(bankAccount, transactions, statementDate.Add(13*time.Hour), 45000, timezone)
+		assert.Equal(t, statementDate, result.StatementDate)
+		assert.EqualValues(t, 42500, result.ClearedBalance, "should undo the cleared transactions after the statement date")
+		assert.EqualValues(t, 2500, result.Difference)
+		assert.EqualValues(t, -2500, result.AdjustmentAmount(bankAccount.Type), "a missing deposit should be adjusted with a credit")
+	})
+
+	t.Run("credit card", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type:           CreditBankAccountType,
+			CurrentBalance: 50000,
+		}
+		result := Reconcile(bankAccount, transactions, statementDate, 60000, timezone)
+		assert.EqualValues(t, 57500, result.ClearedBalance, "purchases after the statement date were not owed yet")
+		assert.EqualValues(t, 2500, result.Difference)
+		assert.EqualValues(t, 2500, result.AdjustmentAmount(bankAccount.Type), "more owed should be adjusted with a debit")
+	})
+}
+
+func TestReconciliation_IsLocked(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	reconciliation := Reconciliation{
+		StatementDate: time.Date(2023, 10, 31, 0, 0, 0, 0, timezone),
+	}
+	assert.True(t, reconciliation.IsLocked(time.Date(2023, 10, 31, 23, 0, 0, 0, timezone), timezone))
+	assert.True(t, reconciliation.IsLocked(time.Date(2023, 10, 1, 0, 0, 0, 0, timezone), timezone))
+	assert.False(t, reconciliation.IsLocked(time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), timezone))
+}
@@ -42,7 +42,15 @@ type Spending struct {
 	NextContributionAmount int64            `json:"nextContributionAmount" pg:"next_contribution_amount,notnull,use_zero"`
 	IsBehind               bool             `json:"isBehind" pg:"is_behind,notnull,use_zero"`
 	IsPaused               bool             `json:"isPaused" pg:"is_paused,notnull,use_zero"`
-	DateCreated            time.Time        `json:"dateCreated" pg:"date_created,notnull"`
+	// RolloverPolicy determines what happens to the money left over or overspent when an expense recurs.
+	RolloverPolicy SpendingRolloverPolicy `json:"rolloverPolicy" pg:"rollover_policy,notnull,default:'rollover'"`
+	// SweepSpendingId is the goal or overflow spending object that money left over is moved to when the rollover
+	// policy is sweep.
+	SweepSpendingId *uint64 `json:"sweepSpendingId" pg:"sweep_spending_id"`
+	// CarriedDeficit is the amount overspent in the previous period that must be allocated for the next recurrence, in
+	// addition to the target amount. It is maintained by the API when the rollover policy is carry deficit.
+	CarriedDeficit int64     `json:"carriedDeficit" pg:"carried_deficit,notnull,use_zero"`
+	DateCreated    time.Time `json:"dateCreated" pg:"date_created,notnull"`
 }
 
 func (e Spending) GetIsStale(now time.Time) bool {
@@ -156,13 +164,22 @@ func CalculateNextContribution(
 
 	// The amount of funds needed for each individual spending event.
 	perSpendingAmount := spending.TargetAmount
+	// Any deficit carried over from the previous period needs to be covered by the very next spending event, in addition
+	// to its target amount.
+	carriedDeficit := spending.GetCarriedDeficit()
 	// The amount of funds currently allocated towards this spending item. This is not increased until the next funding
 	// event, or the user transfers funds to this spending item.
 	currentAmount := spending.GetProgressAmount()
 
+	// The total amount that will be spent between now and the next funding event.
+	amountBeforeFirst := perSpendingAmount * eventsBeforeFirst
+	if eventsBeforeFirst > 0 {
+		amountBeforeFirst += carriedDeficit
+	}
+
 	// We are behind if we do not currently have enough funds for all the spending events between now and the next time
 	// this spending object will receive funding.
-	spending.IsBehind = eventsBeforeFirst > 0 && (perSpendingAmount*eventsBeforeFirst) > currentAmount
+	spending.IsBehind = eventsBeforeFirst > 0 && amountBeforeFirst > currentAmount
 
 	// The total contribution amount is the amount of money that needs to be allocated to this spending item during the
 	// next funding event in order to cover all the spending events that will happen between then and the subsequent
@@ -173,19 +190,23 @@ func CalculateNextContribution(
 		// We need to subtract the spending that will happen before the next period though.
 		// We have $5 allocated but between now and the next funding we need to spend $5. So we cannot take the $5 we
 		// currently have into account when we calculate how much will be needed for the next funding event.
-		amountAfterCurrentSpending := myownsanity.Max(0, currentAmount-(perSpendingAmount*eventsBeforeFirst))
+		amountAfterCurrentSpending := myownsanity.Max(0, currentAmount-amountBeforeFirst)
 		// The total amount we need is determined by how many times we will need the target amount during the next period
 		// between funding events multiplied by how much each spending event costs.
 		// If the current spending object is over-allocated for this funding period and the next funding period then
 		// this can result in a negative contribution amount. Because we would be subtracting more than the calculated
 		// amount that we need.
 		nextSpendingPeriodTotal := perSpendingAmount * eventsBeforeSecond
+		if eventsBeforeFirst == 0 {
+			// If nothing is spent before the next funding event then the carried deficit is part of the next period.
+			nextSpendingPeriodTotal += carriedDeficit
+		}
 		// By taking the min of the amount we will have allocated and the amount needed. We can safely arrive at a 0
 		// contribution amount when we are over-allocated.
 		totalContributionAmount = nextSpendingPeriodTotal - myownsanity.Min(amountAfterCurrentSpending, nextSpendingPeriodTotal)
 	} else {
 		// Otherwise we can simply look at how much we need vs how much we already have.
-		amountNeeded := myownsanity.Max(0, perSpendingAmount-currentAmount)
+		amountNeeded := myownsanity.Max(0, perSpendingAmount+carriedDeficit-currentAmount)
 		// And how many times we will have a funding event before our due date.
 		numberOfContributions := fundingSchedule.GetNumberOfContributionsBetween(now, nextRecurrence, timezone)
 		// Then determine how much we would need at each of those funding events.
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"time"
+
+	"github.com/monetr/monetr/server/util"
+)
+
+// SpendingActual is the total amount spent from a spending object on a single day, including the portions of split
+// transactions that were split to it.
+type SpendingActual struct {
+	SpendingId       uint64    `json:"spendingId" pg:"spending_id"`
+	Date             time.Time `json:"date" pg:"date"`
+	Amount           int64     `json:"amount" pg:"amount,use_zero"`
+	TransactionCount int64     `json:"transactionCount" pg:"transaction_count,use_zero"`
+}
+
+// SpendingBudgetPeriod compares how much was budgeted for a single recurrence period of an expense against how much was
+// actually spent from it during that period. The period starts on one recurrence of the expense and ends on the next.
+type SpendingBudgetPeriod struct {
+	SpendingId uint64    `json:"spendingId"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	// Budgeted is the current target amount of the expense, target amounts are not tracked historically.
+	Budgeted         int64 `json:"budgeted"`
+	Spent            int64 `json:"spent"`
+	TransactionCount int64 `json:"transactionCount"`
+	// Remaining is the budgeted amount minus the amount spent, it is negative when the period is over budget.
+	Remaining    int64 `json:"remaining"`
+	IsOverBudget bool  `json:"isOverBudget"`
+	// IsComplete is false for the current period, which has not ended yet.
+	IsComplete bool `json:"isComplete"`
+}
+
+// SpendingBudgetSummary is the budget vs actual history of a single expense, summarized so that its target amount can
+// be compared against what is typically spent.
+type SpendingBudgetSummary struct {
+	SpendingId    uint64 `json:"spendingId"`
+	BankAccountId uint64 `json:"bankAccountId"`
+	Name          string `json:"name"`
+	TargetAmount  int64  `json:"targetAmount"`
+	// CompletePeriods is the number of complete periods the averages are based on.
+	CompletePeriods   int   `json:"completePeriods"`
+	AverageSpent      int64 `json:"averageSpent"`
+	MaximumSpent      int64 `json:"maximumSpent"`
+	PeriodsOverBudget int   `json:"periodsOverBudget"`
+	// Current is the period that has not ended yet, if there is one.
+	Current *SpendingBudgetPeriod `json:"current"`
+}
+
+// GetBudgetPeriods returns up to count recurrence periods of the expense, oldest first. The last period is the one
+// that contains now. Periods that ended before the expense was created are not included, and if the rule has no
+// recurrence before the first period then it starts on the day the expense was created. Only expenses have periods.
+func (e Spending) GetBudgetPeriods(count int, now time.Time, timezone *time.Location) [][2]time.Time {
+	if e.SpendingType != SpendingTypeExpense || e.RuleSet == nil || count <= 0 {
+		return nil
+	}
+
+	end := e.NextRecurrence
+	// If the next recurrence is stale then move it forward so that the last period contains now.
+	for !end.IsZero() && !end.After(now) {
+		end = e.RuleSet.After(end, false)
+	}
+	if end.IsZero() {
+		return nil
+	}
+
+	// Transactions are dated at midnight, so the day the expense was created is included in full.
+	var created time.Time
+	if !e.DateCreated.IsZero() {
+		created = util.Midnight(e.DateCreated, timezone)
+	}
+	periods := make([][2]time.Time, 0, count)
+	for len(periods) < count && end.After(created) {
+		start := e.RuleSet.Before(end, false)
+		if start.IsZero() {
+			// The first recurrence of the rule has no recurrence before it, so the first period starts on the day
+			// the expense was created instead.
+			if created.IsZero() {
+				break
+			}
+			start = created
+		}
+
+		periods = append(periods, [2]time.Time{start, end})
+		end = start
+	}
+
+	// Periods were built from the most recent backwards.
+	for i, j := 0, len(periods)-1; i < j; i, j = i+1, j-1 {
+		periods[i], periods[j] = periods[j], periods[i]
+	}
+
+	return periods
+}
+
+// CalculateSpendingBudget returns the budget vs actual of each of the provided periods for the expense. Actuals for
+// other spending objects or outside of the periods are ignored.
+func CalculateSpendingBudget(
+	spending Spending,
+	periods [][2]time.Time,
+	actuals []SpendingActual,
+	now time.Time,
+) []SpendingBudgetPeriod {
+	result := make([]SpendingBudgetPeriod, len(periods))
+	for i, period := range periods {
+		result[i] = SpendingBudgetPeriod{
+			SpendingId: spending.SpendingId,
+			Start:      period[0],
+			End:        period[1],
+			Budgeted:   spending.TargetAmount,
+			IsComplete: !period[1].After(now),
+		}
+	}
+
+	for _, actual := range actuals {
+		if actual.SpendingId != spending.SpendingId {
+			continue
+		}
+
+		for i := range result {
+			if !actual.Date.Before(result[i].Start) && actual.Date.Before(result[i].End) {
+				result[i].Spent += actual.Amount
+				result[i].TransactionCount += actual.TransactionCount
+				break
+			}
+		}
+	}
+
+	for i := range result {
+		result[i].Remaining = result[i].Budgeted - result[i].Spent
+		result[i].IsOverBudget = result[i].Remaining < 0
+	}
+
+	return result
+}
+
+// SummarizeSpendingBudget summarizes the budget vs actual periods of an expense. Only complete periods are included in
+// the averages, the incomplete period is returned as the current period.
+func SummarizeSpendingBudget(spending Spending, periods []SpendingBudgetPeriod) SpendingBudgetSummary {
+	summary := SpendingBudgetSummary{
+		SpendingId:    spending.SpendingId,
+		BankAccountId: spending.BankAccountId,
+		Name:          spending.Name,
+		TargetAmount:  spending.TargetAmount,
+	}
+
+	var total int64
+	for i := range periods {
+		period := periods[i]
+		if !period.IsComplete {
+			summary.Current = &period
+			continue
+		}
+
+		summary.CompletePeriods++
+		total += period.Spent
+		if period.Spent > summary.MaximumSpent {
+			summary.MaximumSpent = period.Spent
+		}
+		if period.IsOverBudget {
+			summary.PeriodsOverBudget++
+		}
+	}
+
+	if summary.CompletePeriods > 0 {
+		summary.AverageSpent = total / int64(summary.CompletePeriods)
+	}
+
+	return summary
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpending_GetBudgetPeriods(t *testing.T) {
+	ruleset, err := NewRuleSet("DTSTART:20230101T000000Z\nRRULE:FREQ=MONTHLY;BYMONTHDAY=1")
+	require.NoError(t, err)
+
+	now := time.Date(2023, 11, 15, 12, 0, 0, 0, time.UTC)
+	spending := Spending{
+		SpendingId:     1,
+		SpendingType:   SpendingTypeExpense,
+		RuleSet:        ruleset,
+		NextRecurrence: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+		DateCreated:    time.Date(2023, 8, 20, 0, 0, 0, 0, time.UTC),
+	}
+
+	periods := spending.GetBudgetPeriods(6, now, time.UTC)
+	require.Len(t, periods, 4, "periods that ended before the spending was created should not be included")
+	assert.Equal(t, time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), periods[0][0])
+	assert.Equal(t, time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC), periods[0][1])
+	assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC), periods[3][0])
+	assert.Equal(t, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), periods[3][1])
+
+	assert.Len(t, spending.GetBudgetPeriods(2, now, time.UTC), 2)
+
+	t.Run("stale next recurrence", func(t *testing.T) {
+		stale := spending
+		stale.NextRecurrence = time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+		periods := stale.GetBudgetPeriods(1, now, time.UTC)
+		require.Len(t, periods, 1)
+		assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC), periods[0][0], "the last period should contain now")
+	})
+
+	t.Run("first recurrence", func(t *testing.T) {
+		ruleset, err := NewRuleSet("DTSTART:20231201T000000Z\nRRULE:FREQ=MONTHLY;BYMONTHDAY=1")
+		require.NoError(t, err)
+
+		created := spending
+		created.RuleSet = ruleset
+		created.DateCreated = time.Date(2023, 11, 10, 15, 30, 0, 0, time.UTC)
+		periods := created.GetBudgetPeriods(6, now, time.UTC)
+		require.Len(t, periods, 1)
+		assert.Equal(t, time.Date(2023, 11, 10, 0, 0, 0, 0, time.UTC), periods[0][0], "the first period should start on the day the spending was created")
+	})
+
+	t.Run("goals do not have periods", func(t *testing.T) {
+		goal := spending
+		goal.SpendingType = SpendingTypeGoal
+		assert.Empty(t, goal.GetBudgetPeriods(6, now, time.UTC))
+	})
+}
+
+func TestCalculateSpendingBudget(t *testing.T) {
+	ruleset, err := NewRuleSet("DTSTART:20230101T000000Z\nRRULE:FREQ=MONTHLY;BYMONTHDAY=1")
+	require.NoError(t, err)
+
+	now := time.Date(2023, 11, 15, 12, 0, 0, 0, time.UTC)
+	spending := Spending{
+		SpendingId:     1,
+		BankAccountId:  2,
+		Name:           "Groceries",
+		SpendingType:   SpendingTypeExpense,
+		TargetAmount:   2500,
+		RuleSet:        ruleset,
+		NextRecurrence: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+	actuals := []SpendingActual{
+		{SpendingId: 1, Date: time.Date(2023, 9, 10, 0, 0, 0, 0, time.UTC), Amount: 3000, TransactionCount: 2},
+		{SpendingId: 1, Date: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC), Amount: 1000, TransactionCount: 1},
+		{SpendingId: 1, Date: time.Date(2023, 11, 2, 0, 0, 0, 0, time.UTC), Amount: 500, TransactionCount: 1},
+		{SpendingId: 9, Date: time.Date(2023, 11, 2, 0, 0, 0, 0, time.UTC), Amount: 99999, TransactionCount: 1},
+	}
+
+	periods := CalculateSpendingBudget(spending, spending.GetBudgetPeriods(3, now, time.UTC), actuals, now)
+	require.Len(t, periods, 3)
+
+	assert.EqualValues(t, 3000, periods[0].Spent)
+	assert.EqualValues(t, -500, periods[0].Remaining)
+	assert.True(t, periods[0].IsOverBudget)
+	assert.True(t, periods[0].IsComplete)
+	assert.EqualValues(t, 1000, periods[1].Spent, "spending on the first day of a period belongs to that period")
+	assert.False(t, periods[1].IsOverBudget)
+	assert.EqualValues(t, 500, periods[2].Spent, "other spending objects should be ignored")
+	assert.False(t, periods[2].IsComplete)
+
+	summary := SummarizeSpendingBudget(spending, periods)
+	assert.Equal(t, "Groceries", summary.Name)
+	assert.EqualValues(t, 2, summary.BankAccountId)
+	assert.Equal(t, 2, summary.CompletePeriods)
+	assert.EqualValues(t, 2000, summary.AverageSpent)
+	assert.EqualValues(t, 3000, summary.MaximumSpent)
+	assert.Equal(t, 1, summary.PeriodsOverBudget)
+	require.NotNil(t, summary.Current)
+	assert.EqualValues(t, 500, summary.Current.Spent)
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// SpendingLedgerReason describes why the balance of a spending object changed.
+type SpendingLedgerReason string
+
+const (
+	// SpendingLedgerReasonFunding is used when a spending object receives its contribution from a funding schedule.
+	// The reference Id is the funding event.
+	SpendingLedgerReasonFunding SpendingLedgerReason = "funding"
+	// SpendingLedgerReasonFundingReverted is used when a funding event is reverted and its contribution is removed from
+	// the spending object. The reference Id is the funding event.
+	SpendingLedgerReasonFundingReverted SpendingLedgerReason = "funding_reverted"
+	// SpendingLedgerReasonTransaction is used when a transaction is spent from a spending object, or when that is
+	// changed or undone. The reference Id is the transaction if only a single transaction was changed.
+	SpendingLedgerReasonTransaction SpendingLedgerReason = "transaction"
+	// SpendingLedgerReasonTransfer is used when funds are moved between spending objects or Safe-To-Spend.
+	SpendingLedgerReasonTransfer SpendingLedgerReason = "transfer"
+	// SpendingLedgerReasonManual is used when a spending object or its funding schedule is edited by the user.
+	SpendingLedgerReasonManual SpendingLedgerReason = "manual"
+	// SpendingLedgerReasonRecurrence is used when a spending object is updated because it has recurred.
+	SpendingLedgerReasonRecurrence SpendingLedgerReason = "recurrence"
+)
+
+// SpendingLedgerEntry records a single change to the current or used amount of a spending object. Entries are only ever
+// inserted, the ledger of a spending object is the history of how it arrived at its current balance.
+type SpendingLedgerEntry struct {
+	tableName string `pg:"spending_ledger"`
+
+	SpendingLedgerEntryId uint64               `json:"spendingLedgerEntryId" pg:"spending_ledger_entry_id,notnull,pk,type:'bigserial'"`
+	AccountId             uint64               `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account               *Account             `json:"-" pg:"rel:has-one"`
+	BankAccountId         uint64               `json:"bankAccountId" pg:"bank_account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	BankAccount           *BankAccount         `json:"-" pg:"rel:has-one"`
+	SpendingId            uint64               `json:"spendingId" pg:"spending_id,notnull,on_delete:CASCADE"`
+	Spending              *Spending            `json:"-" pg:"rel:has-one"`
+	Reason                SpendingLedgerReason `json:"reason" pg:"reason,notnull"`
+	// ReferenceId is the Id of the object that caused the change, what it refers to depends on the reason. It is nil
+	// when the change was not caused by a single object.
+	ReferenceId *uint64 `json:"referenceId" pg:"reference_id"`
+	// Amount is the change to the current amount of the spending object, it is negative when funds were removed.
+	Amount        int64     `json:"amount" pg:"amount,notnull,use_zero"`
+	CurrentAmount int64     `json:"currentAmount" pg:"current_amount,notnull,use_zero"`
+	UsedAmount    int64     `json:"usedAmount" pg:"used_amount,notnull,use_zero"`
+	CreatedAt     time.Time `json:"createdAt" pg:"created_at,notnull"`
+}
+
+// NewSpendingLedgerEntries compares the spending objects before and after they were updated, and returns a ledger
+// entry for each spending object whose current or used amount changed. Spending objects that are not present in before
+// are treated as if they previously had no balance.
+func NewSpendingLedgerEntries(
+	before, after []Spending,
+	reason SpendingLedgerReason,
+	referenceId *uint64,
+	now time.Time,
+) []SpendingLedgerEntry {
+	existing := make(map[uint64]Spending, len(before))
+	for _, item := range before {
+		existing[item.SpendingId] = item
+	}
+
+	entries := make([]SpendingLedgerEntry, 0, len(after))
+	for _, item := range after {
+		previous := existing[item.SpendingId]
+		if previous.CurrentAmount == item.CurrentAmount && previous.UsedAmount == item.UsedAmount {
+			continue
+		}
+
+		entries = append(entries, SpendingLedgerEntry{
+			AccountId:     item.AccountId,
+			BankAccountId: item.BankAccountId,
+			SpendingId:    item.SpendingId,
+			Reason:        reason,
+			ReferenceId:   referenceId,
+			Amount:        item.CurrentAmount - previous.CurrentAmount,
+			CurrentAmount: item.CurrentAmount,
+			UsedAmount:    item.UsedAmount,
+			CreatedAt:     now,
+		})
+	}
+
+	return entries
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSpendingLedgerEntries(t *testing.T) {
+	now := time.Date(2023, 11, 26, 12, 0, 0, 0, time.UTC)
+	referenceId := uint64(42)
+	before := []Spending{
+		{SpendingId: 1, CurrentAmount: 1000},
+		{SpendingId: 2, CurrentAmount: 500, UsedAmount: 100},
+		{SpendingId: 3, CurrentAmount: 250},
+	}
+	after := []Spending{
+		{AccountId: 9, BankAccountId: 8, SpendingId: 1, CurrentAmount: 1500},
+		{AccountId: 9, BankAccountId: 8, SpendingId: 2, CurrentAmount: 500, UsedAmount: 200},
+		{AccountId: 9, BankAccountId: 8, SpendingId: 3, CurrentAmount: 250},
+	}
+
+	entries := NewSpendingLedgerEntries(before, after, SpendingLedgerReasonFunding, &referenceId, now)
+	require.Len(t, entries, 2, "spending objects whose balances did not change should not have an entry")
+
+	assert.EqualValues(t, 1, entries[0].SpendingId)
+	assert.EqualValues(t, 9, entries[0].AccountId)
+	assert.EqualValues(t, 8, entries[0].BankAccountId)
+	assert.EqualValues(t, 500, entries[0].Amount)
+	assert.EqualValues(t, 1500, entries[0].CurrentAmount)
+	assert.Equal(t, SpendingLedgerReasonFunding, entries[0].Reason)
+	assert.Equal(t, &referenceId, entries[0].ReferenceId)
+	assert.Equal(t, now, entries[0].CreatedAt)
+
+	assert.EqualValues(t, 2, entries[1].SpendingId)
+	assert.EqualValues(t, 0, entries[1].Amount, "a change to only the used amount should still be recorded")
+	assert.EqualValues(t, 200, entries[1].UsedAmount)
+
+	t.Run("new spending", func(t *testing.T) {
+		entries := NewSpendingLedgerEntries(nil, after[:1], SpendingLedgerReasonTransfer, nil, now)
+		require.Len(t, entries, 1)
+		assert.EqualValues(t, 1500, entries[0].Amount)
+		assert.Nil(t, entries[0].ReferenceId)
+	})
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+type SpendingReportInterval string
+
+const (
+	WeeklySpendingReportInterval  SpendingReportInterval = "weekly"
+	MonthlySpendingReportInterval SpendingReportInterval = "monthly"
+	YearlySpendingReportInterval  SpendingReportInterval = "yearly"
+)
+
+func ParseSpendingReportInterval(input string) (SpendingReportInterval, error) {
+	switch interval := SpendingReportInterval(strings.ToLower(strings.TrimSpace(input))); interval {
+	case WeeklySpendingReportInterval, MonthlySpendingReportInterval, YearlySpendingReportInterval:
+		return interval, nil
+	default:
+		return "", errors.Errorf("invalid interval %q, must be one of weekly, monthly or yearly", input)
+	}
+}
+
+// Start returns the beginning of the period that the provided time falls within. Weeks start on Sunday.
+func (i SpendingReportInterval) Start(input time.Time, timezone *time.Location) time.Time {
+	midnight := util.Midnight(input, timezone)
+	switch i {
+	case WeeklySpendingReportInterval:
+		return midnight.AddDate(0, 0, -int(midnight.Weekday()))
+	case YearlySpendingReportInterval:
+		return time.Date(midnight.Year(), time.January, 1, 0, 0, 0, 0, timezone)
+	default:
+		return time.Date(midnight.Year(), midnight.Month(), 1, 0, 0, 0, 0, timezone)
+	}
+}
+
+// Next returns the beginning of the period after the one that starts at the provided time.
+func (i SpendingReportInterval) Next(start time.Time) time.Time {
+	switch i {
+	case WeeklySpendingReportInterval:
+		return start.AddDate(0, 0, 7)
+	case YearlySpendingReportInterval:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+type SpendingReportGroupBy string
+
+const (
+	SpendingSpendingReportGroupBy    SpendingReportGroupBy = "spending"
+	CategorySpendingReportGroupBy    SpendingReportGroupBy = "category"
+	MerchantSpendingReportGroupBy    SpendingReportGroupBy = "merchant"
+	BankAccountSpendingReportGroupBy SpendingReportGroupBy = "bank_account"
+)
+
+func ParseSpendingReportGroupBy(input string) (SpendingReportGroupBy, error) {
+	switch groupBy := SpendingReportGroupBy(strings.ToLower(strings.TrimSpace(input))); groupBy {
+	case SpendingSpendingReportGroupBy, CategorySpendingReportGroupBy, MerchantSpendingReportGroupBy, BankAccountSpendingReportGroupBy:
+		return groupBy, nil
+	default:
+		return "", errors.Errorf("invalid group %q, must be one of spending, category, merchant or bank_account", input)
+	}
+}
+
+// UngroupedName is the name used for transactions that do not belong to anything for the grouping. For example
+// transactions that are not spent from a spending object are spent from Safe-To-Spend.
+func (g SpendingReportGroupBy) UngroupedName() string {
+	switch g {
+	case SpendingSpendingReportGroupBy:
+		return "Safe-To-Spend"
+	case CategorySpendingReportGroupBy:
+		return "Uncategorized"
+	default:
+		return "Unknown"
+	}
+}
+
+// SpendingReportRow is the aggregate of the transactions for a single group within a single period.
+type SpendingReportRow struct {
+	// Period is the date that the period starts on, without a timezone.
+	Period           time.Time `pg:"period"`
+	GroupId          *uint64   `pg:"group_id"`
+	GroupName        string    `pg:"group_name"`
+	Income           int64     `pg:"income,use_zero"`
+	Expenses         int64     `pg:"expenses,use_zero"`
+	TransactionCount int64     `pg:"transaction_count,use_zero"`
+}
+
+// SpendingReport is the actual income and spending of an account over a date range, bucketed by period. Transfers and
+// hidden transactions are not included. All amounts are positive, the net is income minus expenses.
+type SpendingReport struct {
+	Interval SpendingReportInterval `json:"interval"`
+	GroupBy  SpendingReportGroupBy  `json:"groupBy"`
+	Start    time.Time              `json:"start"`
+	End      time.Time              `json:"end"`
+	Income   int64                  `json:"income"`
+	Expenses int64                  `json:"expenses"`
+	Net      int64                  `json:"net"`
+	Periods  []SpendingReportPeriod `json:"periods"`
+}
+
+type SpendingReportPeriod struct {
+	Date     time.Time             `json:"date"`
+	Income   int64                 `json:"income"`
+	Expenses int64                 `json:"expenses"`
+	Net      int64                 `json:"net"`
+	Groups   []SpendingReportGroup `json:"groups"`
+}
+
+type SpendingReportGroup struct {
+	// Id is the Id of the spending object, category or bank account for the group. It is null for merchants and for
+	// transactions that do not belong to anything.
+	Id               *uint64 `json:"id"`
+	Name             string  `json:"name"`
+	Income           int64   `json:"income"`
+	Expenses         int64   `json:"expenses"`
+	Net              int64   `json:"net"`
+	TransactionCount int64   `json:"transactionCount"`
+}
+
+// BuildSpendingReport arranges the aggregated rows into periods from the start of the period containing the start date
+// up until the end date. Every period in the range is included even if it has no transactions. Groups within a period
+// are sorted by their expenses, largest first.
+func BuildSpendingReport(
+	rows []SpendingReportRow,
+	interval SpendingReportInterval,
+	groupBy SpendingReportGroupBy,
+	start, end time.Time,
+	timezone *time.Location,
+) SpendingReport {
+	report := SpendingReport{
+		Interval: interval,
+		GroupBy:  groupBy,
+		Start:    start,
+		End:      end,
+		Periods:  make([]SpendingReportPeriod, 0),
+	}
+
+	indexes := map[time.Time]int{}
+	for date := interval.Start(start, timezone); date.Before(end); date = interval.Next(date) {
+		indexes[date] = len(report.Periods)
+		report.Periods = append(report.Periods, SpendingReportPeriod{
+			Date:   date,
+			Groups: make([]SpendingReportGroup, 0),
+		})
+	}
+
+	for _, row := range rows {
+		date := time.Date(row.Period.Year(), row.Period.Month(), row.Period.Day(), 0, 0, 0, 0, timezone)
+		index, ok := indexes[date]
+		if !ok {
+			continue
+		}
+
+		name := row.GroupName
+		if row.GroupId == nil && groupBy != MerchantSpendingReportGroupBy {
+			name = groupBy.UngroupedName()
+		}
+
+		period := &report.Periods[index]
+		period.Income += row.Income
+		period.Expenses += row.Expenses
+		period.Net = period.Income - period.Expenses
+		period.Groups = append(period.Groups, SpendingReportGroup{
+			Id:               row.GroupId,
+			Name:             name,
+			Income:           row.Income,
+			Expenses:         row.Expenses,
+			Net:              row.Income - row.Expenses,
+			TransactionCount: row.TransactionCount,
+		})
+
+		report.Income += row.Income
+		report.Expenses += row.Expenses
+	}
+	report.Net = report.Income - report.Expenses
+
+	for i := range report.Periods {
+		groups := report.Periods[i].Groups
+		sort.SliceStable(groups, func(a, b int) bool {
+			if groups[a].Expenses != groups[b].Expenses {
+				return groups[a].Expenses > groups[b].Expenses
+			}
+
+			return groups[a].Name < groups[b].Name
+		})
+	}
+
+	return report
+}
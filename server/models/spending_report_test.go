@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendingReportInterval_Start(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	// Wednesday, November 15th 2023.
+	input := time.Date(2023, 11, 15, 13, 30, 0, 0, timezone)
+	assert.Equal(t, time.Date(2023, 11, 12, 0, 0, 0, 0, timezone), WeeklySpendingReportInterval.Start(input, timezone), "weeks should start on sunday")
+	assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), MonthlySpendingReportInterval.Start(input, timezone))
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, timezone), YearlySpendingReportInterval.Start(input, timezone))
+}
+
+func TestBuildSpendingReport(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	spendingId := uint64(1)
+	rows := []SpendingReportRow{
+		{
+			Period:           time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+			GroupId:          &spendingId,
+			GroupName:        "Groceries",
+			Expenses:         5000,
+			TransactionCount: 2,
+		},
+		{
+			Period:           time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+			GroupName:        "",
+			Income:           200000,
+			Expenses:         10000,
+			TransactionCount: 3,
+		},
+		{
+			// Rows outside of the report are ignored.
+			Period:   time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+			Expenses: 999,
+		},
+	}
+
+	report := BuildSpendingReport(
+		rows,
+		MonthlySpendingReportInterval,
+		SpendingSpendingReportGroupBy,
+		time.Date(2023, 9, 1, 0, 0, 0, 0, timezone),
+		time.Date(2023, 11, 16, 0, 0, 0, 0, timezone),
+		timezone,
+	)
+
+	assert.EqualValues(t, 200000, report.Income)
+	assert.EqualValues(t, 15000, report.Expenses)
+	assert.EqualValues(t, 185000, report.Net)
+
+	require.Len(t, report.Periods, 3, "empty periods should be included")
+	assert.Equal(t, time.Date(2023, 9, 1, 0, 0, 0, 0, timezone), report.Periods[0].Date)
+	assert.Empty(t, report.Periods[0].Groups)
+	assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), report.Periods[2].Date)
+
+	october := report.Periods[1]
+	assert.EqualValues(t, 185000, october.Net)
+	require.Len(t, october.Groups, 2)
+	assert.Equal(t, "Safe-To-Spend", october.Groups[0].Name, "groups should be sorted by their expenses")
+	assert.Nil(t, october.Groups[0].Id)
+	assert.EqualValues(t, 190000, october.Groups[0].Net)
+	assert.Equal(t, "Groceries", october.Groups[1].Name)
+	assert.EqualValues(t, -5000, october.Groups[1].Net)
+	assert.EqualValues(t, 2, october.Groups[1].TransactionCount)
+}
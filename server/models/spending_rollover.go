@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/pkg/errors"
+)
+
+// SpendingRolloverPolicy determines what happens to the money left over, or the money overspent, when an expense
+// recurs. The amount left over is what was budgeted for the period that ended, minus what was actually spent from the
+// expense during it, but never more than the expense still has allocated.
+type SpendingRolloverPolicy string
+
+const (
+	// SpendingRolloverPolicyRollover keeps any money left over allocated to the expense, it counts towards the next
+	// period. This is the default.
+	SpendingRolloverPolicyRollover SpendingRolloverPolicy = "rollover"
+	// SpendingRolloverPolicyRelease returns any money left over to Safe-To-Spend.
+	SpendingRolloverPolicyRelease SpendingRolloverPolicy = "release"
+	// SpendingRolloverPolicySweep moves any money left over to the goal or overflow spending object specified by the
+	// expense's sweep spending Id.
+	SpendingRolloverPolicySweep SpendingRolloverPolicy = "sweep"
+	// SpendingRolloverPolicyCarryDeficit keeps any money left over like rollover does, but if more was spent than was
+	// budgeted then the difference is added to what needs to be allocated for the next period.
+	SpendingRolloverPolicyCarryDeficit SpendingRolloverPolicy = "carry_deficit"
+)
+
+// ParseSpendingRolloverPolicy will parse the rollover policy provided by a client. An empty policy is treated as the
+// default rollover policy.
+func ParseSpendingRolloverPolicy(input string) (SpendingRolloverPolicy, error) {
+	switch policy := SpendingRolloverPolicy(strings.ToLower(strings.TrimSpace(input))); policy {
+	case "":
+		return SpendingRolloverPolicyRollover, nil
+	case SpendingRolloverPolicyRollover,
+		SpendingRolloverPolicyRelease,
+		SpendingRolloverPolicySweep,
+		SpendingRolloverPolicyCarryDeficit:
+		return policy, nil
+	default:
+		return "", errors.Errorf("invalid rollover policy %q, must be one of rollover, release, sweep or carry_deficit", input)
+	}
+}
+
+// GetRolloverPolicy returns the rollover policy of the spending object. Goals and overflow spending objects do not
+// recur, so they always roll over.
+func (e Spending) GetRolloverPolicy() SpendingRolloverPolicy {
+	if e.SpendingType != SpendingTypeExpense || e.RolloverPolicy == "" {
+		return SpendingRolloverPolicyRollover
+	}
+
+	return e.RolloverPolicy
+}
+
+// GetCarriedDeficit returns the amount overspent in the previous period that must be allocated for the next recurrence
+// in addition to the target amount. It is only carried by expenses with the carry deficit policy.
+func (e Spending) GetCarriedDeficit() int64 {
+	if e.GetRolloverPolicy() != SpendingRolloverPolicyCarryDeficit {
+		return 0
+	}
+
+	return myownsanity.Max(0, e.CarriedDeficit)
+}
+
+// ApplyRolloverPolicy is called when the expense reaches its next recurrence, with the amount that was spent from it
+// during the period that is ending. The budget for that period is the target amount plus any deficit that was carried
+// into it. The amount that was removed from the expense is returned, it should be released to Safe-To-Spend or moved
+// to the sweep spending object depending on the policy. This does not move the next recurrence forward, that is still
+// done by CalculateNextContribution.
+func (e *Spending) ApplyRolloverPolicy(spent int64) (surplus int64) {
+	remaining := e.TargetAmount + e.GetCarriedDeficit() - spent
+	e.CarriedDeficit = 0
+
+	switch e.GetRolloverPolicy() {
+	case SpendingRolloverPolicyRelease, SpendingRolloverPolicySweep:
+		surplus = myownsanity.Min(myownsanity.Max(0, e.CurrentAmount), myownsanity.Max(0, remaining))
+		e.CurrentAmount -= surplus
+		return surplus
+	case SpendingRolloverPolicyCarryDeficit:
+		if remaining < 0 {
+			e.CarriedDeficit = -remaining
+		}
+		return 0
+	default:
+		return 0
+	}
+}
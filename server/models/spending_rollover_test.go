@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpendingRolloverPolicy(t *testing.T) {
+	policy, err := ParseSpendingRolloverPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, SpendingRolloverPolicyRollover, policy, "an empty policy should be the default")
+
+	policy, err = ParseSpendingRolloverPolicy(" Carry_Deficit ")
+	assert.NoError(t, err)
+	assert.Equal(t, SpendingRolloverPolicyCarryDeficit, policy)
+
+	_, err = ParseSpendingRolloverPolicy("bogus")
+	assert.EqualError(t, err, `invalid rollover policy "bogus", must be one of rollover, release, sweep or carry_deficit`)
+}
+
+func TestSpending_ApplyRolloverPolicy(t *testing.T) {
+	newExpense := func(policy SpendingRolloverPolicy) Spending {
+		return Spending{
+			SpendingType:   SpendingTypeExpense,
+			TargetAmount:   1000,
+			CurrentAmount:  1500,
+			RolloverPolicy: policy,
+		}
+	}
+
+	t.Run("rollover", func(t *testing.T) {
+		expense := newExpense(SpendingRolloverPolicyRollover)
+		assert.EqualValues(t, 0, expense.ApplyRolloverPolicy(400))
+		assert.EqualValues(t, 1500, expense.CurrentAmount)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		expense := newExpense(SpendingRolloverPolicyRelease)
+		assert.EqualValues(t, 600, expense.ApplyRolloverPolicy(400), "only the unspent budget should be released")
+		assert.EqualValues(t, 900, expense.CurrentAmount)
+
+		expense = newExpense(SpendingRolloverPolicyRelease)
+		expense.CurrentAmount = 200
+		assert.EqualValues(t, 200, expense.ApplyRolloverPolicy(400), "no more than is allocated should be released")
+		assert.EqualValues(t, 0, expense.CurrentAmount)
+
+		expense = newExpense(SpendingRolloverPolicyRelease)
+		assert.EqualValues(t, 0, expense.ApplyRolloverPolicy(1200), "nothing is left over when the expense was overspent")
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		expense := newExpense(SpendingRolloverPolicySweep)
+		assert.EqualValues(t, 1000, expense.ApplyRolloverPolicy(0))
+		assert.EqualValues(t, 500, expense.CurrentAmount)
+	})
+
+	t.Run("carry deficit", func(t *testing.T) {
+		expense := newExpense(SpendingRolloverPolicyCarryDeficit)
+		assert.EqualValues(t, 0, expense.ApplyRolloverPolicy(1300))
+		assert.EqualValues(t, 1500, expense.CurrentAmount, "money left over should roll over")
+		assert.EqualValues(t, 300, expense.CarriedDeficit)
+
+		// The carried deficit is part of the budget for the next period.
+		assert.EqualValues(t, 0, expense.ApplyRolloverPolicy(1300))
+		assert.EqualValues(t, 0, expense.CarriedDeficit, "the deficit should be covered by the next period")
+	})
+
+	t.Run("goals always roll over", func(t *testing.T) {
+		goal := newExpense(SpendingRolloverPolicyRelease)
+		goal.SpendingType = SpendingTypeGoal
+		assert.EqualValues(t, 0, goal.ApplyRolloverPolicy(0))
+		assert.EqualValues(t, 1500, goal.CurrentAmount)
+	})
+}
+
+func TestSpending_CalculateNextContributionCarriedDeficit(t *testing.T) {
+	central, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err, "must be able to load timezone")
+	now := time.Date(2022, 06, 14, 22, 37, 43, 0, central)
+	nextFunding := time.Date(2022, 06, 15, 0, 0, 0, 0, central)
+	nextRecurrence := time.Date(2022, 7, 8, 0, 0, 0, 0, central)
+
+	fundingRule := RuleToSet(t, central, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", now)
+	spendingRule := RuleToSet(t, central, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8", now)
+
+	spending := Spending{
+		SpendingType:   SpendingTypeExpense,
+		TargetAmount:   25000,
+		CurrentAmount:  6960,
+		RuleSet:        spendingRule,
+		NextRecurrence: nextRecurrence.UTC(),
+		RolloverPolicy: SpendingRolloverPolicyCarryDeficit,
+		CarriedDeficit: 2000,
+	}
+
+	err = spending.CalculateNextContribution(
+		context.Background(),
+		central.String(),
+		GiveMeAFundingSchedule(nextFunding.UTC(), fundingRule),
+		now,
+	)
+	assert.NoError(t, err, "must be able to calculate the next contribution")
+	assert.EqualValues(t, 10020, spending.NextContributionAmount, "the carried deficit should be split across the contributions")
+
+	spending.RolloverPolicy = SpendingRolloverPolicyRollover
+	err = spending.CalculateNextContribution(
+		context.Background(),
+		central.String(),
+		GiveMeAFundingSchedule(nextFunding.UTC(), fundingRule),
+		now,
+	)
+	assert.NoError(t, err, "must be able to calculate the next contribution")
+	assert.EqualValues(t, 9020, spending.NextContributionAmount, "the deficit is only carried by the carry deficit policy")
+}
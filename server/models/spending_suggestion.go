@@ -0,0 +1,342 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/monetr/monetr/server/util"
+)
+
+type SpendingSuggestionStatus string
+
+const (
+	SpendingSuggestionStatusPending   SpendingSuggestionStatus = "pending"
+	SpendingSuggestionStatusAccepted  SpendingSuggestionStatus = "accepted"
+	SpendingSuggestionStatusDismissed SpendingSuggestionStatus = "dismissed"
+)
+
+type RecurringFrequency string
+
+const (
+	RecurringFrequencyWeekly   RecurringFrequency = "weekly"
+	RecurringFrequencyBiweekly RecurringFrequency = "biweekly"
+	RecurringFrequencyMonthly  RecurringFrequency = "monthly"
+	// RecurringFrequencyMonthlyWeekday is used for transactions that happen on the Nth weekday of every month, like the
+	// second tuesday.
+	RecurringFrequencyMonthlyWeekday RecurringFrequency = "monthlyWeekday"
+	RecurringFrequencyYearly         RecurringFrequency = "yearly"
+)
+
+// RecurringTransactionLookback is how far back transactions are considered when detecting recurring transactions. It
+// is a bit more than a year so that yearly transactions can be detected.
+const RecurringTransactionLookback = 400 * 24 * time.Hour
+
+// SpendingSuggestion is an expense that monetr thinks the user should create based on a recurring transaction in
+// their history. Suggestions are unique per merchant and frequency, so once a suggestion has been accepted or
+// dismissed it will not be suggested again.
+type SpendingSuggestion struct {
+	tableName string `pg:"spending_suggestions"`
+
+	SpendingSuggestionId uint64                   `json:"spendingSuggestionId" pg:"spending_suggestion_id,notnull,pk,type:'bigserial'"`
+	AccountId            uint64                   `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account              *Account                 `json:"-" pg:"rel:has-one"`
+	BankAccountId        uint64                   `json:"bankAccountId" pg:"bank_account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	BankAccount          *BankAccount             `json:"-" pg:"rel:has-one"`
+	Name                 string                   `json:"name" pg:"name,notnull"`
+	MerchantKey          string                   `json:"-" pg:"merchant_key,notnull"`
+	Frequency            RecurringFrequency       `json:"frequency" pg:"frequency,notnull"`
+	RuleSet              *RuleSet                 `json:"ruleset" pg:"ruleset,notnull,type:'text'"`
+	TargetAmount         int64                    `json:"targetAmount" pg:"target_amount,notnull,use_zero"`
+	NextRecurrence       time.Time                `json:"nextRecurrence" pg:"next_recurrence,notnull"`
+	LastOccurrence       time.Time                `json:"lastOccurrence" pg:"last_occurrence,notnull"`
+	Occurrences          int                      `json:"occurrences" pg:"occurrences,notnull,use_zero"`
+	Status               SpendingSuggestionStatus `json:"status" pg:"status,notnull"`
+	// SpendingId is the spending object that was created when the suggestion was accepted.
+	SpendingId *uint64   `json:"spendingId" pg:"spending_id"`
+	CreatedAt  time.Time `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt  time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+// recurringFrequencies are the frequencies that recurring transactions are detected at. The interval and tolerance
+// are in days, and every frequency requires a minimum number of transactions before it will be suggested. Monthly
+// transactions on the Nth weekday of the month can be anywhere from 28 to 35 days apart.
+var recurringFrequencies = []struct {
+	frequency          RecurringFrequency
+	interval           int
+	tolerance          int
+	minimumOccurrences int
+}{
+	{RecurringFrequencyWeekly, 7, 1, 4},
+	{RecurringFrequencyBiweekly, 14, 2, 3},
+	{RecurringFrequencyMonthly, 30, 5, 3},
+	{RecurringFrequencyYearly, 365, 10, 2},
+}
+
+var rruleWeekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// NormalizeMerchantName returns the name that is used to group transactions from the same merchant together. Anything
+// that looks like a reference number or a date is removed, since those usually change between transactions.
+func NormalizeMerchantName(transaction Transaction) string {
+	name := transaction.OriginalMerchantName
+	if strings.TrimSpace(name) == "" {
+		name = transaction.OriginalName
+	}
+	if strings.TrimSpace(name) == "" {
+		// Manually created transactions might not have an original name.
+		name = transaction.Name
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '&'
+	})
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if strings.IndexFunc(field, unicode.IsDigit) >= 0 {
+			continue
+		}
+
+		result = append(result, field)
+	}
+
+	return strings.Join(result, " ")
+}
+
+// DetectRecurringTransactions groups the provided transactions by merchant and amount and returns a suggestion for
+// every group that recurs at a regular frequency and is still active. Deposits, transfers and pending transactions
+// are ignored, as are groups where the most recent transaction is already spent from something. The returned
+// suggestions are not associated with an account and have a pending status.
+func DetectRecurringTransactions(transactions []Transaction, timezone *time.Location, now time.Time) []SpendingSuggestion {
+	merchants := map[string][]Transaction{}
+	for _, transaction := range transactions {
+		if transaction.Amount <= 0 || transaction.IsTransfer() || transaction.IsPending || transaction.DeletedAt != nil {
+			continue
+		}
+
+		key := NormalizeMerchantName(transaction)
+		if key == "" {
+			continue
+		}
+
+		merchants[key] = append(merchants[key], transaction)
+	}
+
+	keys := make([]string, 0, len(merchants))
+	for key := range merchants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	suggestions := make([]SpendingSuggestion, 0)
+	for _, key := range keys {
+		// A merchant could have more than one group of amounts that recur at the same frequency. Only one suggestion can
+		// be made per merchant and frequency, so keep the one that has happened the most.
+		byFrequency := map[RecurringFrequency]SpendingSuggestion{}
+		order := make([]RecurringFrequency, 0)
+		for _, group := range groupTransactionsByAmount(merchants[key]) {
+			suggestion, ok := detectRecurrence(key, group, timezone, now)
+			if !ok {
+				continue
+			}
+
+			existing, ok := byFrequency[suggestion.Frequency]
+			if !ok {
+				order = append(order, suggestion.Frequency)
+			} else if existing.Occurrences >= suggestion.Occurrences {
+				continue
+			}
+			byFrequency[suggestion.Frequency] = suggestion
+		}
+
+		for _, frequency := range order {
+			suggestions = append(suggestions, byFrequency[frequency])
+		}
+	}
+
+	return suggestions
+}
+
+// groupTransactionsByAmount splits the transactions into groups whose amounts are within 10% of the smallest amount in
+// that group. Bills like utilities change a little bit every month, but should still be grouped together. Each group
+// is sorted by date, oldest first.
+func groupTransactionsByAmount(transactions []Transaction) [][]Transaction {
+	sorted := make([]Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Amount < sorted[j].Amount
+	})
+
+	groups := make([][]Transaction, 0)
+	var start int64
+	for _, transaction := range sorted {
+		if len(groups) == 0 || transaction.Amount > start+(start/10) {
+			groups = append(groups, make([]Transaction, 0))
+			start = transaction.Amount
+		}
+
+		groups[len(groups)-1] = append(groups[len(groups)-1], transaction)
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			if group[i].Date.Equal(group[j].Date) {
+				return group[i].TransactionId < group[j].TransactionId
+			}
+			return group[i].Date.Before(group[j].Date)
+		})
+	}
+
+	return groups
+}
+
+func detectRecurrence(key string, transactions []Transaction, timezone *time.Location, now time.Time) (SpendingSuggestion, bool) {
+	if len(transactions) < 2 {
+		return SpendingSuggestion{}, false
+	}
+
+	latest := transactions[len(transactions)-1]
+	if latest.SpendingId != nil || len(latest.Splits) > 0 {
+		// The user is already budgeting for this.
+		return SpendingSuggestion{}, false
+	}
+
+	dates := make([]time.Time, len(transactions))
+	for i, transaction := range transactions {
+		dates[i] = util.Midnight(transaction.Date, timezone).In(timezone)
+	}
+
+	intervals := make([]int, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		intervals[i-1] = int(math.Round(dates[i].Sub(dates[i-1]).Hours() / 24))
+	}
+	sorted := make([]int, len(intervals))
+	copy(sorted, intervals)
+	sort.Ints(sorted)
+	median := sorted[len(sorted)/2]
+
+	last := dates[len(dates)-1]
+	for _, item := range recurringFrequencies {
+		if median < item.interval-item.tolerance || median > item.interval+item.tolerance {
+			continue
+		}
+
+		if len(dates) < item.minimumOccurrences {
+			return SpendingSuggestion{}, false
+		}
+
+		// Allow for the occasional missed or late transaction, but most of them need to be on schedule.
+		matched := 0
+		for _, interval := range intervals {
+			if interval >= item.interval-item.tolerance && interval <= item.interval+item.tolerance {
+				matched++
+			}
+		}
+		if matched*4 < len(intervals)*3 {
+			return SpendingSuggestion{}, false
+		}
+
+		// If the transaction has not happened for two intervals then it has probably stopped.
+		if now.Sub(last) > time.Duration(2*item.interval+item.tolerance)*24*time.Hour {
+			return SpendingSuggestion{}, false
+		}
+
+		frequency, rule := item.frequency, ""
+		weekday := rruleWeekdays[last.Weekday()]
+		switch item.frequency {
+		case RecurringFrequencyWeekly:
+			rule = fmt.Sprintf("FREQ=WEEKLY;INTERVAL=1;BYDAY=%s", weekday)
+		case RecurringFrequencyBiweekly:
+			rule = fmt.Sprintf("FREQ=WEEKLY;INTERVAL=2;BYDAY=%s", weekday)
+		case RecurringFrequencyMonthly:
+			frequency, rule = monthlyRecurrenceRule(dates)
+		case RecurringFrequencyYearly:
+			day := last.Day()
+			if last.Month() == time.February && day == 29 {
+				day = -1
+			}
+			rule = fmt.Sprintf("FREQ=YEARLY;INTERVAL=1;BYMONTH=%d;BYMONTHDAY=%d", last.Month(), day)
+		}
+
+		ruleSet, err := NewRuleSet(fmt.Sprintf(
+			"DTSTART:%s\nRRULE:%s",
+			last.UTC().Format("20060102T150405Z"),
+			rule,
+		))
+		if err != nil {
+			return SpendingSuggestion{}, false
+		}
+
+		next := ruleSet.After(now, false)
+		if next.IsZero() {
+			return SpendingSuggestion{}, false
+		}
+
+		name := strings.TrimSpace(latest.MerchantName)
+		if name == "" {
+			name = strings.TrimSpace(latest.Name)
+		}
+
+		return SpendingSuggestion{
+			BankAccountId:  latest.BankAccountId,
+			Name:           name,
+			MerchantKey:    key,
+			Frequency:      frequency,
+			RuleSet:        ruleSet,
+			TargetAmount:   latest.Amount,
+			NextRecurrence: util.Midnight(next, timezone),
+			LastOccurrence: last,
+			Occurrences:    len(dates),
+			Status:         SpendingSuggestionStatusPending,
+		}, true
+	}
+
+	return SpendingSuggestion{}, false
+}
+
+// monthlyRecurrenceRule returns the rule for transactions that happen once a month. If the transactions always happen
+// on the same day of the month then that day is used. Otherwise, if they always happen on the same weekday of the
+// month (like the second tuesday) then that is used instead.
+func monthlyRecurrenceRule(dates []time.Time) (RecurringFrequency, string) {
+	last := dates[len(dates)-1]
+	sameDay, sameOrdinal, allLast := true, true, true
+	ordinal := (last.Day()-1)/7 + 1
+	for _, date := range dates {
+		if date.Day() != last.Day() {
+			sameDay = false
+		}
+
+		if date.Weekday() != last.Weekday() {
+			sameOrdinal, allLast = false, false
+			continue
+		}
+
+		if (date.Day()-1)/7+1 != ordinal {
+			sameOrdinal = false
+		}
+
+		// The date is the last of its weekday in the month if a week later is in the next month.
+		if date.AddDate(0, 0, 7).Month() == date.Month() {
+			allLast = false
+		}
+	}
+
+	weekday := rruleWeekdays[last.Weekday()]
+	switch {
+	case sameDay:
+	case sameOrdinal && ordinal <= 4:
+		return RecurringFrequencyMonthlyWeekday, fmt.Sprintf("FREQ=MONTHLY;INTERVAL=1;BYDAY=%d%s", ordinal, weekday)
+	case allLast:
+		return RecurringFrequencyMonthlyWeekday, fmt.Sprintf("FREQ=MONTHLY;INTERVAL=1;BYDAY=-1%s", weekday)
+	}
+
+	day := last.Day()
+	if day > 28 {
+		// Not every month has more than 28 days, so use the last day of the month instead.
+		day = -1
+	}
+
+	return RecurringFrequencyMonthly, fmt.Sprintf("FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=%d", day)
+}
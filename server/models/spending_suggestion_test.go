@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeMerchantName(t *testing.T) {
+	assert.Equal(t, "debit for checkcard netflix com", NormalizeMerchantName(Transaction{
+		OriginalName: "DEBIT FOR CHECKCARD XXXXXX1234 10/02/23 NETFLIX.COM",
+	}))
+	assert.Equal(t, "h&m", NormalizeMerchantName(Transaction{
+		OriginalName:         "H&M #4421",
+		OriginalMerchantName: "H&M",
+	}))
+}
+
+func TestDetectRecurringTransactions(t *testing.T) {
+	timezone := time.UTC
+	var transactionId uint64
+	given := func(name string, amount int64, dates ...time.Time) []Transaction {
+		result := make([]Transaction, len(dates))
+		for i, date := range dates {
+			transactionId++
+			result[i] = Transaction{
+				TransactionId: transactionId,
+				BankAccountId: 1,
+				Amount:        amount,
+				Date:          date,
+				Name:          name,
+				OriginalName:  name,
+			}
+		}
+		return result
+	}
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, timezone)
+	}
+
+	detect := func(t *testing.T, now time.Time, transactions []Transaction) []SpendingSuggestion {
+		suggestions := DetectRecurringTransactions(transactions, timezone, now)
+		for _, suggestion := range suggestions {
+			require.NotNil(t, suggestion.RuleSet, "suggestion must have a ruleset")
+		}
+		return suggestions
+	}
+
+	t.Run("monthly on the same day", func(t *testing.T) {
+		transactions := given("NETFLIX.COM", 1599, date(2023, 8, 5), date(2023, 9, 5), date(2023, 10, 5))
+		suggestions := detect(t, date(2023, 10, 20), transactions)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, RecurringFrequencyMonthly, suggestions[0].Frequency)
+		assert.EqualValues(t, 1599, suggestions[0].TargetAmount)
+		assert.Equal(t, 3, suggestions[0].Occurrences)
+		assert.Equal(t, date(2023, 11, 5), suggestions[0].NextRecurrence)
+		assert.Contains(t, suggestions[0].RuleSet.String(), "BYMONTHDAY=5")
+	})
+
+	t.Run("monthly with a changing amount", func(t *testing.T) {
+		transactions := append(
+			given("CITY UTILITIES", 10000, date(2023, 7, 20), date(2023, 8, 21)),
+			given("CITY UTILITIES", 10550, date(2023, 9, 20), date(2023, 10, 20))...,
+		)
+		suggestions := detect(t, date(2023, 10, 25), transactions)
+		require.Len(t, suggestions, 1)
+		assert.EqualValues(t, 10550, suggestions[0].TargetAmount, "the most recent amount should be used")
+		assert.Equal(t, 4, suggestions[0].Occurrences)
+	})
+
+	t.Run("nth weekday of the month", func(t *testing.T) {
+		// The second tuesday of every month.
+		transactions := given("BOOK CLUB", 2500, date(2023, 8, 8), date(2023, 9, 12), date(2023, 10, 10))
+		suggestions := detect(t, date(2023, 10, 20), transactions)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, RecurringFrequencyMonthlyWeekday, suggestions[0].Frequency)
+		assert.Equal(t, date(2023, 11, 14), suggestions[0].NextRecurrence)
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		transactions := given("LAWN SERVICE", 4000, date(2023, 10, 2), date(2023, 10, 9), date(2023, 10, 16), date(2023, 10, 23))
+		suggestions := detect(t, date(2023, 10, 24), transactions)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, RecurringFrequencyWeekly, suggestions[0].Frequency)
+		assert.Equal(t, date(2023, 10, 30), suggestions[0].NextRecurrence)
+	})
+
+	t.Run("biweekly", func(t *testing.T) {
+		transactions := given("DAYCARE", 30000, date(2023, 9, 1), date(2023, 9, 15), date(2023, 9, 29), date(2023, 10, 13))
+		suggestions := detect(t, date(2023, 10, 14), transactions)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, RecurringFrequencyBiweekly, suggestions[0].Frequency)
+		assert.Equal(t, date(2023, 10, 27), suggestions[0].NextRecurrence)
+	})
+
+	t.Run("yearly", func(t *testing.T) {
+		transactions := given("AMAZON PRIME", 13900, date(2022, 3, 14), date(2023, 3, 14))
+		suggestions := detect(t, date(2023, 10, 14), transactions)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, RecurringFrequencyYearly, suggestions[0].Frequency)
+		assert.Equal(t, date(2024, 3, 14), suggestions[0].NextRecurrence)
+	})
+
+	t.Run("not recurring", func(t *testing.T) {
+		transactions := given("GAS STATION", 4000, date(2023, 9, 1), date(2023, 9, 4), date(2023, 9, 20), date(2023, 10, 13))
+		assert.Empty(t, detect(t, date(2023, 10, 14), transactions))
+	})
+
+	t.Run("stopped recurring", func(t *testing.T) {
+		transactions := given("OLD GYM", 3000, date(2023, 3, 1), date(2023, 4, 1), date(2023, 5, 1))
+		assert.Empty(t, detect(t, date(2023, 10, 14), transactions))
+	})
+
+	t.Run("already spent from something", func(t *testing.T) {
+		transactions := given("NETFLIX.COM", 1599, date(2023, 8, 5), date(2023, 9, 5), date(2023, 10, 5))
+		spendingId := uint64(1)
+		transactions[2].SpendingId = &spendingId
+		assert.Empty(t, detect(t, date(2023, 10, 20), transactions))
+	})
+
+	t.Run("deposits are ignored", func(t *testing.T) {
+		transactions := given("PAYROLL", -200000, date(2023, 8, 5), date(2023, 9, 5), date(2023, 10, 5))
+		assert.Empty(t, detect(t, date(2023, 10, 20), transactions))
+	})
+}
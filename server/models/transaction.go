@@ -20,6 +20,7 @@ type Transaction struct {
 	BankAccount               *BankAccount `json:"-" pg:"rel:has-one"`
 	PlaidTransactionId        string       `json:"-" pg:"plaid_transaction_id,unique:per_bank_account"`
 	PendingPlaidTransactionId *string      `json:"-" pg:"pending_plaid_transaction_id"`
+	UploadIdentifier          *string      `json:"-" pg:"upload_identifier"`
 	Amount                    int64        `json:"amount" pg:"amount,notnull,use_zero"`
 	SpendingId                *uint64      `json:"spendingId" pg:"spending_id,on_delete:SET NULL"`
 	Spending                  *Spending    `json:"spending,omitempty" pg:"rel:has-one"`
@@ -40,12 +41,30 @@ type Transaction struct {
 	IsPending            bool       `json:"isPending" pg:"is_pending,notnull,use_zero"`
 	CreatedAt            time.Time  `json:"createdAt" pg:"created_at,notnull,default:now()"`
 	DeletedAt            *time.Time `json:"deletedAt" pg:"deleted_at"`
+	// TransferTransactionId is the Id of the transaction on the other side of a transfer between two bank accounts.
+	// Transfers are not spent from anything and are excluded from reports.
+	TransferTransactionId *uint64         `json:"transferTransactionId" pg:"transfer_transaction_id"`
+	TransferStatus        *TransferStatus `json:"transferStatus" pg:"transfer_status"`
+	// IsHidden is set by the user for transactions that should not be included in reports.
+	IsHidden bool `json:"isHidden" pg:"is_hidden,notnull,use_zero"`
+	// CategoryId is the account's category that the transaction is assigned to. New transactions from Plaid are assigned
+	// a category based on the account's category mappings. Categories and OriginalCategories are kept as they were
+	// received for reference.
+	CategoryId *uint64 `json:"categoryId" pg:"category_id"`
+	// Splits are used instead of SpendingId when the transaction is spent from more than one spending object. They are
+	// stored in their own table and are only populated when they are explicitly retrieved.
+	Splits []TransactionSplit `json:"splits,omitempty" pg:"-"`
 }
 
 func (t Transaction) IsAddition() bool {
 	return t.Amount < 0 // Deposits will show as negative amounts.
 }
 
+// IsTransfer returns true if the transaction is linked to another transaction as one side of a transfer.
+func (t Transaction) IsTransfer() bool {
+	return t.TransferTransactionId != nil
+}
+
 // AddSpendingToTransaction will take the provided spending object and deduct as much as possible from this transaction
 // from that spending object. It does not change the spendingId on the transaction, it simply performs the deductions.
 func (t *Transaction) AddSpendingToTransaction(ctx context.Context, spending *Spending, account *Account) error {
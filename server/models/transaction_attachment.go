@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TransactionAttachment links a file, like a photo or PDF of a receipt, to a transaction. A file can only be attached
+// to a single transaction.
+type TransactionAttachment struct {
+	tableName string `pg:"transaction_attachments"`
+
+	AccountId     uint64       `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account       *Account     `json:"-" pg:"rel:has-one"`
+	BankAccountId uint64       `json:"bankAccountId" pg:"bank_account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	BankAccount   *BankAccount `json:"-" pg:"rel:has-one"`
+	TransactionId uint64       `json:"transactionId" pg:"transaction_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	FileId        uint64       `json:"fileId" pg:"file_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	File          *File        `json:"file,omitempty" pg:"rel:has-one"`
+	CreatedAt     time.Time    `json:"createdAt" pg:"created_at,notnull"`
+}
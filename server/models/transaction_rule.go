@@ -0,0 +1,194 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TransactionRule is a user defined rule that is evaluated against transactions as they are created. Rules are
+// evaluated in order of their priority (ascending), if multiple matching rules specify the same action then the rule
+// with the lowest priority wins.
+type TransactionRule struct {
+	tableName string `pg:"transaction_rules"`
+
+	TransactionRuleId uint64                    `json:"transactionRuleId" pg:"transaction_rule_id,notnull,pk,type:'bigserial'"`
+	AccountId         uint64                    `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account           *Account                  `json:"-" pg:"rel:has-one"`
+	Name              string                    `json:"name" pg:"name,notnull"`
+	Priority          int                       `json:"priority" pg:"priority,notnull,use_zero"`
+	IsEnabled         bool                      `json:"isEnabled" pg:"is_enabled,notnull,use_zero"`
+	Conditions        TransactionRuleConditions `json:"conditions" pg:"conditions,notnull,type:'jsonb'"`
+	Actions           TransactionRuleActions    `json:"actions" pg:"actions,notnull,type:'jsonb'"`
+	CreatedAt         time.Time                 `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt         time.Time                 `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+// TransactionRuleConditions must all match a transaction for the rule's actions to be applied. Conditions that are
+// not specified are ignored, but at least one condition must be specified.
+type TransactionRuleConditions struct {
+	// Name is matched case-insensitively against any part of the name or the original name of the transaction.
+	Name *string `json:"name,omitempty"`
+	// Merchant is matched case-insensitively against any part of the merchant name or the original merchant name.
+	Merchant *string `json:"merchant,omitempty"`
+	// MinimumAmount and MaximumAmount are compared against the absolute amount of the transaction in cents. Both are
+	// inclusive.
+	MinimumAmount *int64  `json:"minimumAmount,omitempty"`
+	MaximumAmount *int64  `json:"maximumAmount,omitempty"`
+	BankAccountId *uint64 `json:"bankAccountId,omitempty"`
+	// CategoryId is matched against the category that the transaction has been assigned.
+	CategoryId *uint64 `json:"categoryId,omitempty"`
+}
+
+// TransactionRuleActions are applied to a transaction when a rule matches it. At least one action must be specified.
+type TransactionRuleActions struct {
+	// SpendingId will spend the transaction from the specified spending object. This is only applied to debits that
+	// are not already spent from something or part of a transfer. Because spending objects belong to a bank account,
+	// rules that set the spending must have a bank account condition.
+	SpendingId *uint64 `json:"spendingId,omitempty"`
+	// Name will change the name of the transaction, the original name is left as is.
+	Name *string `json:"name,omitempty"`
+	// CategoryId will assign the transaction to this category, replacing the category it was assigned from its Plaid
+	// categories.
+	CategoryId *uint64 `json:"categoryId,omitempty"`
+}
+
+// Normalize trims the text fields of the rule and removes any that are blank.
+func (r *TransactionRule) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Conditions.Name = trimStringP(r.Conditions.Name)
+	r.Conditions.Merchant = trimStringP(r.Conditions.Merchant)
+	r.Actions.Name = trimStringP(r.Actions.Name)
+}
+
+// Validate makes sure that the rule can be evaluated. It does not verify that the bank account, spending objects or
+// categories the rule references exist.
+func (r TransactionRule) Validate() error {
+	if r.Name == "" {
+		return errors.New("rule must have a name")
+	}
+
+	conditions := r.Conditions
+	if conditions.Name == nil && conditions.Merchant == nil && conditions.MinimumAmount == nil &&
+		conditions.MaximumAmount == nil && conditions.BankAccountId == nil && conditions.CategoryId == nil {
+		return errors.New("rule must have at least one condition")
+	}
+
+	if conditions.MinimumAmount != nil && *conditions.MinimumAmount < 0 {
+		return errors.New("minimum amount cannot be negative")
+	}
+
+	if conditions.MaximumAmount != nil && *conditions.MaximumAmount < 0 {
+		return errors.New("maximum amount cannot be negative")
+	}
+
+	if conditions.MinimumAmount != nil && conditions.MaximumAmount != nil &&
+		*conditions.MinimumAmount > *conditions.MaximumAmount {
+		return errors.New("minimum amount cannot be greater than the maximum amount")
+	}
+
+	actions := r.Actions
+	if actions.SpendingId == nil && actions.Name == nil && actions.CategoryId == nil {
+		return errors.New("rule must have at least one action")
+	}
+
+	if actions.SpendingId != nil && conditions.BankAccountId == nil {
+		return errors.New("rules that set the spending must have a bank account condition")
+	}
+
+	return nil
+}
+
+// Matches returns true if every condition of the rule matches the provided transaction.
+func (r TransactionRule) Matches(transaction Transaction) bool {
+	conditions := r.Conditions
+	if conditions.BankAccountId != nil && *conditions.BankAccountId != transaction.BankAccountId {
+		return false
+	}
+
+	if conditions.Name != nil && !containsFold(*conditions.Name, transaction.Name, transaction.OriginalName) {
+		return false
+	}
+
+	if conditions.Merchant != nil &&
+		!containsFold(*conditions.Merchant, transaction.MerchantName, transaction.OriginalMerchantName) {
+		return false
+	}
+
+	amount := transaction.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+
+	if conditions.MinimumAmount != nil && amount < *conditions.MinimumAmount {
+		return false
+	}
+
+	if conditions.MaximumAmount != nil && amount > *conditions.MaximumAmount {
+		return false
+	}
+
+	if conditions.CategoryId != nil &&
+		(transaction.CategoryId == nil || *conditions.CategoryId != *transaction.CategoryId) {
+		return false
+	}
+
+	return true
+}
+
+// EvaluateTransactionRules applies the name and category actions of any matching rules to the transaction. The rules
+// must already be sorted by priority. If a matching rule wants to spend the transaction from a spending object then
+// that spending Id is returned, but it is not set on the transaction. The caller is responsible for deducting the
+// transaction from that spending object.
+func EvaluateTransactionRules(rules []TransactionRule, transaction *Transaction) (spendingId *uint64) {
+	var renamed, categorized bool
+	for _, rule := range rules {
+		if !rule.IsEnabled || !rule.Matches(*transaction) {
+			continue
+		}
+
+		actions := rule.Actions
+		if actions.Name != nil && !renamed {
+			transaction.Name = *actions.Name
+			renamed = true
+		}
+
+		if actions.CategoryId != nil && !categorized {
+			categoryId := *actions.CategoryId
+			transaction.CategoryId = &categoryId
+			categorized = true
+		}
+
+		if actions.SpendingId != nil && spendingId == nil && !transaction.IsAddition() &&
+			transaction.SpendingId == nil && len(transaction.Splits) == 0 && !transaction.IsTransfer() {
+			spendingId = actions.SpendingId
+		}
+	}
+
+	return spendingId
+}
+
+func trimStringP(input *string) *string {
+	if input == nil {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(*input)
+	if trimmed == "" {
+		return nil
+	}
+
+	return &trimmed
+}
+
+func containsFold(needle string, haystacks ...string) bool {
+	needle = strings.ToLower(needle)
+	for _, haystack := range haystacks {
+		if strings.Contains(strings.ToLower(haystack), needle) {
+			return true
+		}
+	}
+
+	return false
+}
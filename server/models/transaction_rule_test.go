@@ -0,0 +1,213 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionRule_Validate(t *testing.T) {
+	var bankAccountId, spendingId uint64 = 1, 2
+	var minimum, maximum int64 = 500, 100
+
+	valid := TransactionRule{
+		Name: "Groceries",
+		Conditions: TransactionRuleConditions{
+			Merchant:      myownsanity.StringP("Kroger"),
+			BankAccountId: &bankAccountId,
+		},
+		Actions: TransactionRuleActions{
+			SpendingId: &spendingId,
+		},
+	}
+	assert.NoError(t, valid.Validate())
+
+	cases := map[string]TransactionRule{
+		"rule must have a name": {
+			Conditions: valid.Conditions,
+			Actions:    valid.Actions,
+		},
+		"rule must have at least one condition": {
+			Name:    "Groceries",
+			Actions: valid.Actions,
+		},
+		"rule must have at least one action": {
+			Name:       "Groceries",
+			Conditions: valid.Conditions,
+		},
+		"rules that set the spending must have a bank account condition": {
+			Name: "Groceries",
+			Conditions: TransactionRuleConditions{
+				Merchant: myownsanity.StringP("Kroger"),
+			},
+			Actions: valid.Actions,
+		},
+		"minimum amount cannot be greater than the maximum amount": {
+			Name: "Groceries",
+			Conditions: TransactionRuleConditions{
+				MinimumAmount: &minimum,
+				MaximumAmount: &maximum,
+			},
+			Actions: TransactionRuleActions{
+				CategoryId: myownsanity.Uint64P(1),
+			},
+		},
+	}
+	for expected, rule := range cases {
+		assert.EqualError(t, rule.Validate(), expected)
+	}
+}
+
+func TestTransactionRule_Normalize(t *testing.T) {
+	rule := TransactionRule{
+		Name: " Groceries ",
+		Conditions: TransactionRuleConditions{
+			Name:     myownsanity.StringP("  "),
+			Merchant: myownsanity.StringP(" Kroger "),
+		},
+	}
+	rule.Normalize()
+	assert.Equal(t, "Groceries", rule.Name)
+	assert.Nil(t, rule.Conditions.Name, "blank conditions should be removed")
+	assert.Equal(t, "Kroger", *rule.Conditions.Merchant)
+}
+
+func TestEvaluateTransactionRules(t *testing.T) {
+	var bankAccountId, groceriesId, householdId, groceriesCategoryId uint64 = 1, 2, 3, 4
+	var minimum int64 = 1000
+	rules := []TransactionRule{
+		{
+			Name:      "Large Costco",
+			Priority:  1,
+			IsEnabled: true,
+			Conditions: TransactionRuleConditions{
+				Name:          myownsanity.StringP("costco"),
+				MinimumAmount: &minimum,
+				BankAccountId: &bankAccountId,
+			},
+			Actions: TransactionRuleActions{
+				SpendingId: &householdId,
+			},
+		},
+		{
+			Name:      "Costco",
+			Priority:  2,
+			IsEnabled: true,
+			Conditions: TransactionRuleConditions{
+				Name:          myownsanity.StringP("COSTCO"),
+				BankAccountId: &bankAccountId,
+			},
+			Actions: TransactionRuleActions{
+				SpendingId: &groceriesId,
+				Name:       myownsanity.StringP("Costco"),
+				CategoryId: &groceriesCategoryId,
+			},
+		},
+		{
+			Name:      "Disabled",
+			Priority:  3,
+			IsEnabled: false,
+			Conditions: TransactionRuleConditions{
+				Name: myownsanity.StringP("costco"),
+			},
+			Actions: TransactionRuleActions{
+				Name: myownsanity.StringP("Should not be used"),
+			},
+		},
+	}
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		transaction := Transaction{
+			BankAccountId: bankAccountId,
+			Amount:        2500,
+			Name:          "COSTCO WHSE #1234",
+			OriginalName:  "COSTCO WHSE #1234",
+		}
+		spendingId := EvaluateTransactionRules(rules, &transaction)
+		if assert.NotNil(t, spendingId) {
+			assert.Equal(t, householdId, *spendingId)
+		}
+		assert.Equal(t, "Costco", transaction.Name, "name should come from the second rule")
+		assert.Equal(t, "COSTCO WHSE #1234", transaction.OriginalName, "original name should not change")
+		if assert.NotNil(t, transaction.CategoryId) {
+			assert.Equal(t, groceriesCategoryId, *transaction.CategoryId, "category should come from the second rule")
+		}
+	})
+
+	t.Run("amount condition", func(t *testing.T) {
+		transaction := Transaction{
+			BankAccountId: bankAccountId,
+			Amount:        500,
+			Name:          "COSTCO WHSE #1234",
+		}
+		spendingId := EvaluateTransactionRules(rules, &transaction)
+		if assert.NotNil(t, spendingId) {
+			assert.Equal(t, groceriesId, *spendingId)
+		}
+	})
+
+	t.Run("deposits are not spent from anything", func(t *testing.T) {
+		transaction := Transaction{
+			BankAccountId: bankAccountId,
+			Amount:        -2500,
+			Name:          "COSTCO REFUND",
+		}
+		assert.Nil(t, EvaluateTransactionRules(rules, &transaction))
+		assert.Equal(t, "Costco", transaction.Name, "other actions should still apply")
+	})
+
+	t.Run("transfers are not spent from anything", func(t *testing.T) {
+		var otherTransactionId uint64 = 10
+		transaction := Transaction{
+			BankAccountId:         bankAccountId,
+			Amount:                2500,
+			Name:                  "COSTCO WHSE #1234",
+			TransferTransactionId: &otherTransactionId,
+		}
+		assert.Nil(t, EvaluateTransactionRules(rules, &transaction))
+	})
+
+	t.Run("other bank account", func(t *testing.T) {
+		transaction := Transaction{
+			BankAccountId: 4,
+			Amount:        2500,
+			Name:          "COSTCO WHSE #1234",
+		}
+		assert.Nil(t, EvaluateTransactionRules(rules, &transaction))
+		assert.Equal(t, "COSTCO WHSE #1234", transaction.Name)
+	})
+
+	t.Run("category condition", func(t *testing.T) {
+		var restaurantsId, coffeeId uint64 = 5, 6
+		categoryRules := []TransactionRule{
+			{
+				Name:      "Coffee",
+				IsEnabled: true,
+				Conditions: TransactionRuleConditions{
+					Name:       myownsanity.StringP("starbucks"),
+					CategoryId: &restaurantsId,
+				},
+				Actions: TransactionRuleActions{
+					CategoryId: &coffeeId,
+				},
+			},
+		}
+
+		transaction := Transaction{
+			BankAccountId: bankAccountId,
+			Amount:        500,
+			Name:          "STARBUCKS",
+			Categories:    []string{"Food and Drink", "Restaurants"},
+		}
+		EvaluateTransactionRules(categoryRules, &transaction)
+		assert.Nil(t, transaction.CategoryId, "uncategorized transactions should not match a category condition")
+
+		transaction.CategoryId = &restaurantsId
+		EvaluateTransactionRules(categoryRules, &transaction)
+		if assert.NotNil(t, transaction.CategoryId) {
+			assert.Equal(t, coffeeId, *transaction.CategoryId, "category should be replaced by the rule")
+		}
+		assert.Equal(t, []string{"Food and Drink", "Restaurants"}, transaction.Categories, "legacy categories should not change")
+	})
+}
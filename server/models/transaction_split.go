@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TransactionSplit is a portion of a transaction that is spent from a single spending object. A transaction that has
+// splits is not spent from anything directly, its SpendingId will always be nil. Instead, each split deducts its amount
+// from its own spending object.
+type TransactionSplit struct {
+	tableName string `pg:"transaction_splits"`
+
+	TransactionSplitId uint64       `json:"transactionSplitId" pg:"transaction_split_id,notnull,pk,type:'bigserial'"`
+	AccountId          uint64       `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	Account            *Account     `json:"-" pg:"rel:has-one"`
+	BankAccountId      uint64       `json:"bankAccountId" pg:"bank_account_id,notnull,pk,on_delete:CASCADE,type:'bigint'"`
+	BankAccount        *BankAccount `json:"-" pg:"rel:has-one"`
+	TransactionId      uint64       `json:"transactionId" pg:"transaction_id,notnull,on_delete:CASCADE,type:'bigint'"`
+	SpendingId         uint64       `json:"spendingId" pg:"spending_id,notnull,on_delete:CASCADE,type:'bigint'"`
+	Spending           *Spending    `json:"-" pg:"rel:has-one"`
+	// Amount is the portion of the transaction that this split represents, in cents. The amounts of all the splits for
+	// a transaction must add up to the amount of the transaction.
+	Amount int64 `json:"amount" pg:"amount,notnull,use_zero"`
+	// SpendingAmount is how much was actually deducted from the spending object. Like the SpendingAmount on the
+	// transaction, this can be less than the amount of the split if the spending object did not have enough allocated
+	// to it. This is what is returned to the spending object if the split is changed or removed.
+	SpendingAmount int64     `json:"spendingAmount" pg:"spending_amount,notnull,use_zero"`
+	CreatedAt      time.Time `json:"createdAt" pg:"created_at,notnull"`
+}
+
+// ValidateTransactionSplits makes sure that the provided splits can be applied to a transaction with the specified
+// amount. An empty set of splits is always valid, it means the transaction is not split.
+func ValidateTransactionSplits(amount int64, splits []TransactionSplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	if amount <= 0 {
+		return errors.New("deposits cannot be split")
+	}
+
+	if len(splits) < 2 {
+		return errors.New("a transaction must be split at least two ways")
+	}
+
+	var total int64
+	seen := map[uint64]struct{}{}
+	for _, split := range splits {
+		if split.SpendingId == 0 {
+			return errors.New("each split must specify a spending Id")
+		}
+
+		if _, ok := seen[split.SpendingId]; ok {
+			return errors.New("each split must use a different spending object")
+		}
+		seen[split.SpendingId] = struct{}{}
+
+		if split.Amount <= 0 {
+			return errors.New("split amounts must be greater than zero")
+		}
+
+		total += split.Amount
+	}
+
+	if total != amount {
+		return errors.Errorf("split amounts must add up to the transaction amount, expected %d but got %d", amount, total)
+	}
+
+	return nil
+}
+
+// TransactionSplitsEqual returns true if both sets of splits deduct the same amounts from the same spending objects,
+// regardless of their order.
+func TransactionSplitsEqual(a, b []TransactionSplit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	amounts := make(map[uint64]int64, len(a))
+	for _, split := range a {
+		amounts[split.SpendingId] = split.Amount
+	}
+
+	for _, split := range b {
+		amount, ok := amounts[split.SpendingId]
+		if !ok || amount != split.Amount {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DeductFromSpending takes up to the provided amount from the spending object and returns how much was actually taken.
+// If the spending object does not have enough allocated to it then only what it has is taken.
+func DeductFromSpending(spending *Spending, amount int64) int64 {
+	deducted := amount
+	if spending.CurrentAmount < amount {
+		deducted = spending.CurrentAmount
+	}
+
+	spending.CurrentAmount -= deducted
+
+	if spending.SpendingType == SpendingTypeGoal {
+		// Goals also keep track of how much has been spent, so increment the used amount.
+		spending.UsedAmount += deducted
+	}
+
+	return deducted
+}
+
+// ReturnToSpending reverts a previous DeductFromSpending by giving the amount that was deducted back to the spending
+// object.
+func ReturnToSpending(spending *Spending, deducted int64) {
+	spending.CurrentAmount += deducted
+
+	if spending.SpendingType == SpendingTypeGoal {
+		spending.UsedAmount -= deducted
+	}
+}
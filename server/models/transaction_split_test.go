@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransactionSplits(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		err := ValidateTransactionSplits(1000, []TransactionSplit{
+			{SpendingId: 1, Amount: 600},
+			{SpendingId: 2, Amount: 400},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, ValidateTransactionSplits(-1000, nil), "no splits is always valid")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cases := map[string]struct {
+			amount int64
+			splits []TransactionSplit
+		}{
+			"deposits cannot be split": {
+				amount: -1000,
+				splits: []TransactionSplit{{SpendingId: 1, Amount: 500}, {SpendingId: 2, Amount: 500}},
+			},
+			"a transaction must be split at least two ways": {
+				amount: 1000,
+				splits: []TransactionSplit{{SpendingId: 1, Amount: 1000}},
+			},
+			"each split must use a different spending object": {
+				amount: 1000,
+				splits: []TransactionSplit{{SpendingId: 1, Amount: 500}, {SpendingId: 1, Amount: 500}},
+			},
+			"split amounts must be greater than zero": {
+				amount: 1000,
+				splits: []TransactionSplit{{SpendingId: 1, Amount: 1100}, {SpendingId: 2, Amount: -100}},
+			},
+			"split amounts must add up to the transaction amount, expected 1000 but got 900": {
+				amount: 1000,
+				splits: []TransactionSplit{{SpendingId: 1, Amount: 500}, {SpendingId: 2, Amount: 400}},
+			},
+		}
+		for expected, item := range cases {
+			assert.EqualError(t, ValidateTransactionSplits(item.amount, item.splits), expected)
+		}
+	})
+}
+
+func TestDeductFromSpending(t *testing.T) {
+	t.Run("expense", func(t *testing.T) {
+		spending := Spending{
+			SpendingType:  SpendingTypeExpense,
+			CurrentAmount: 500,
+		}
+		assert.EqualValues(t, 300, DeductFromSpending(&spending, 300))
+		assert.EqualValues(t, 200, spending.CurrentAmount)
+		assert.EqualValues(t, 200, DeductFromSpending(&spending, 300), "should only deduct what is available")
+		assert.EqualValues(t, 0, spending.CurrentAmount)
+
+		ReturnToSpending(&spending, 500)
+		assert.EqualValues(t, 500, spending.CurrentAmount)
+	})
+
+	t.Run("goal", func(t *testing.T) {
+		spending := Spending{
+			SpendingType:  SpendingTypeGoal,
+			CurrentAmount: 500,
+		}
+		assert.EqualValues(t, 300, DeductFromSpending(&spending, 300))
+		assert.EqualValues(t, 200, spending.CurrentAmount)
+		assert.EqualValues(t, 300, spending.UsedAmount)
+
+		ReturnToSpending(&spending, 300)
+		assert.EqualValues(t, 500, spending.CurrentAmount)
+		assert.EqualValues(t, 0, spending.UsedAmount)
+	})
+}
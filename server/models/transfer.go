@@ -0,0 +1,109 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+type TransferStatus string
+
+const (
+	// TransferStatusMatched is used for transfers that were paired automatically.
+	TransferStatusMatched TransferStatus = "matched"
+	// TransferStatusConfirmed is used for transfers that the user has confirmed are actually transfers.
+	TransferStatusConfirmed TransferStatus = "confirmed"
+	// TransferStatusRejected is used for transactions that the user has unlinked from a transfer. These transactions
+	// are not linked to anything and will not be matched as a transfer again.
+	TransferStatusRejected TransferStatus = "rejected"
+)
+
+// TransferMatchWindow is how far apart the two sides of a transfer can be. Transfers between banks can take a few
+// business days to show up on the receiving side.
+const TransferMatchWindow = 4 * 24 * time.Hour
+
+type TransferPair struct {
+	// Outflow is the transaction for the money leaving a bank account, it has a positive amount.
+	Outflow Transaction
+	// Inflow is the transaction for the money entering the other bank account, it has a negative amount.
+	Inflow Transaction
+}
+
+// IsTransferCandidate returns true if the transaction could be one side of a transfer. Transactions that are already
+// part of a transfer, that the user has rejected as a transfer, or that are spent from something are not candidates.
+func (t Transaction) IsTransferCandidate() bool {
+	return t.Amount != 0 &&
+		t.DeletedAt == nil &&
+		t.TransferTransactionId == nil &&
+		t.TransferStatus == nil &&
+		t.SpendingId == nil &&
+		len(t.Splits) == 0
+}
+
+// FindTransferPairs pairs outflows on one bank account with inflows of the same amount on another bank account that
+// are within the transfer match window of each other. Each transaction is used at most once. When an outflow has more
+// than one possible inflow, the one closest in date is used, and then the one with the lowest Id.
+func FindTransferPairs(transactions []Transaction) []TransferPair {
+	outflows := make([]Transaction, 0)
+	inflows := make([]Transaction, 0)
+	for _, transaction := range transactions {
+		if !transaction.IsTransferCandidate() {
+			continue
+		}
+
+		if transaction.IsAddition() {
+			inflows = append(inflows, transaction)
+		} else {
+			outflows = append(outflows, transaction)
+		}
+	}
+
+	// Process the outflows oldest first so that the result does not depend on the order of the input.
+	sort.Slice(outflows, func(i, j int) bool {
+		if outflows[i].Date.Equal(outflows[j].Date) {
+			return outflows[i].TransactionId < outflows[j].TransactionId
+		}
+		return outflows[i].Date.Before(outflows[j].Date)
+	})
+
+	used := map[uint64]struct{}{}
+	pairs := make([]TransferPair, 0)
+	for _, outflow := range outflows {
+		best := -1
+		var bestDistance time.Duration
+		for i, inflow := range inflows {
+			if _, ok := used[inflow.TransactionId]; ok {
+				continue
+			}
+
+			if inflow.BankAccountId == outflow.BankAccountId || inflow.Amount != -outflow.Amount {
+				continue
+			}
+
+			distance := inflow.Date.Sub(outflow.Date)
+			if distance < 0 {
+				distance = -distance
+			}
+			if distance > TransferMatchWindow {
+				continue
+			}
+
+			if best == -1 || distance < bestDistance ||
+				(distance == bestDistance && inflow.TransactionId < inflows[best].TransactionId) {
+				best = i
+				bestDistance = distance
+			}
+		}
+
+		if best == -1 {
+			continue
+		}
+
+		used[inflows[best].TransactionId] = struct{}{}
+		pairs = append(pairs, TransferPair{
+			Outflow: outflow,
+			Inflow:  inflows[best],
+		})
+	}
+
+	return pairs
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTransferPairs(t *testing.T) {
+	day := func(n int) time.Time {
+		return time.Date(2023, 10, n, 0, 0, 0, 0, time.UTC)
+	}
+	spendingId := uint64(9)
+	rejected := TransferStatusRejected
+
+	transactions := []Transaction{
+		// A transfer from checking to savings that arrives two days later.
+		{TransactionId: 1, BankAccountId: 1, Amount: 10000, Date: day(2)},
+		{TransactionId: 2, BankAccountId: 2, Amount: -10000, Date: day(4)},
+		// A deposit of the same amount on the same bank account is not a transfer.
+		{TransactionId: 3, BankAccountId: 1, Amount: -10000, Date: day(2)},
+		// Too far apart.
+		{TransactionId: 4, BankAccountId: 1, Amount: 2500, Date: day(1)},
+		{TransactionId: 5, BankAccountId: 2, Amount: -2500, Date: day(10)},
+		// Two possible inflows, the closer one should be used.
+		{TransactionId: 6, BankAccountId: 1, Amount: 500, Date: day(15)},
+		{TransactionId: 7, BankAccountId: 2, Amount: -500, Date: day(18)},
+		{TransactionId: 8, BankAccountId: 3, Amount: -500, Date: day(16)},
+		// Spent from something, so it was not a transfer.
+		{TransactionId: 9, BankAccountId: 1, Amount: 700, Date: day(20), SpendingId: &spendingId},
+		{TransactionId: 10, BankAccountId: 2, Amount: -700, Date: day(20)},
+		// Rejected by the user.
+		{TransactionId: 11, BankAccountId: 1, Amount: 800, Date: day(20), TransferStatus: &rejected},
+		{TransactionId: 12, BankAccountId: 2, Amount: -800, Date: day(20)},
+	}
+
+	pairs := FindTransferPairs(transactions)
+	if assert.Len(t, pairs, 2) {
+		assert.EqualValues(t, 1, pairs[0].Outflow.TransactionId)
+		assert.EqualValues(t, 2, pairs[0].Inflow.TransactionId)
+		assert.EqualValues(t, 6, pairs[1].Outflow.TransactionId)
+		assert.EqualValues(t, 8, pairs[1].Inflow.TransactionId)
+	}
+}
@@ -41,9 +41,20 @@ func (r *repositoryBase) DeleteAccount(ctx context.Context) error {
 	defer span.Finish()
 
 	dataTypes := []interface{}{
+		&models.SpendingSuggestion{},
+		&models.TransactionAttachment{},
+		&models.TransactionRule{},
+		&models.Reconciliation{},
+		&models.SpendingLedgerEntry{},
+		&models.TransactionSplit{},
 		&models.Transaction{},
+		&models.CategoryMapping{},
+		&models.Category{},
 		&models.Spending{},
+		&models.FundingEvent{},
 		&models.FundingSchedule{},
+		&models.CSVMapping{},
+		&models.File{},
 		&models.BankAccount{},
 		&models.Link{},
 		&models.User{},
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// UpsertBalanceSnapshots will store the provided snapshots, replacing any snapshot that already exists for the same
+// bank account and day. This includes estimated snapshots, as a recorded snapshot is always more accurate.
+func (r *repositoryBase) UpsertBalanceSnapshots(ctx context.Context, snapshots []models.BalanceSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"count": len(snapshots),
+	}
+
+	now := r.clock.Now().UTC()
+	for i := range snapshots {
+		snapshots[i].AccountId = r.AccountId()
+		snapshots[i].CreatedAt = now
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &snapshots).
+		OnConflict(`("account_id", "bank_account_id", "date") DO UPDATE`).
+		Set(`"current" = EXCLUDED."current"`).
+		Set(`"available" = EXCLUDED."available"`).
+		Set(`"free" = EXCLUDED."free"`).
+		Set(`"expenses" = EXCLUDED."expenses"`).
+		Set(`"goals" = EXCLUDED."goals"`).
+		Set(`"is_estimated" = EXCLUDED."is_estimated"`).
+		Set(`"created_at" = EXCLUDED."created_at"`).
+		Insert(&snapshots)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to store balance snapshots")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// BackfillBalanceSnapshots will store the provided snapshots for any day that does not already have a snapshot for the
+// same bank account. It returns the number of snapshots that were stored.
+func (r *repositoryBase) BackfillBalanceSnapshots(ctx context.Context, snapshots []models.BalanceSnapshot) (int, error) {
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"count": len(snapshots),
+	}
+
+	now := r.clock.Now().UTC()
+	for i := range snapshots {
+		snapshots[i].AccountId = r.AccountId()
+		snapshots[i].CreatedAt = now
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &snapshots).
+		OnConflict(`("account_id", "bank_account_id", "date") DO NOTHING`).
+		Insert(&snapshots)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, errors.Wrap(err, "failed to backfill balance snapshots")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result.RowsAffected(), nil
+}
+
+// GetBalanceSnapshots returns the snapshots for the specified bank account that are on or after the start and before
+// the end, sorted by date with the oldest first.
+func (r *repositoryBase) GetBalanceSnapshots(
+	ctx context.Context,
+	bankAccountId uint64,
+	start, end time.Time,
+) ([]models.BalanceSnapshot, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"start":         start,
+		"end":           end,
+	}
+
+	items := make([]models.BalanceSnapshot, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"balance_snapshot"."account_id" = ?`, r.AccountId()).
+		Where(`"balance_snapshot"."bank_account_id" = ?`, bankAccountId).
+		Where(`"balance_snapshot"."date" >= ?`, start).
+		Where(`"balance_snapshot"."date" < ?`, end).
+		Order(`date ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve balance snapshots")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// GetBalanceSnapshotsByDateRange returns the snapshots for every bank account in the current account that are on or
+// after the start and before the end, sorted by date with the oldest first.
+func (r *repositoryBase) GetBalanceSnapshotsByDateRange(ctx context.Context, start, end time.Time) ([]models.BalanceSnapshot, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"start": start,
+		"end":   end,
+	}
+
+	items := make([]models.BalanceSnapshot, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"balance_snapshot"."account_id" = ?`, r.AccountId()).
+		Where(`"balance_snapshot"."date" >= ?`, start).
+		Where(`"balance_snapshot"."date" < ?`, end).
+		Order(`date ASC`).
+		Order(`bank_account_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve balance snapshots")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// GetLatestBalanceSnapshotsBefore returns the most recent snapshot before the provided time for every bank account in
+// the current account that has one, sorted by bank account.
+func (r *repositoryBase) GetLatestBalanceSnapshotsBefore(ctx context.Context, end time.Time) ([]models.BalanceSnapshot, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"end": end,
+	}
+
+	items := make([]models.BalanceSnapshot, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		DistinctOn(`"balance_snapshot"."bank_account_id"`).
+		Where(`"balance_snapshot"."account_id" = ?`, r.AccountId()).
+		Where(`"balance_snapshot"."date" < ?`, end).
+		Order(`bank_account_id ASC`).
+		Order(`date DESC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve latest balance snapshots")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
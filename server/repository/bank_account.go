@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/monetr/monetr/server/models"
@@ -39,6 +40,7 @@ func (r *repositoryBase) CreateBankAccounts(ctx context.Context, bankAccounts ..
 		if bankAccounts[i].Status == "" {
 			bankAccounts[i].Status = models.ActiveBankAccountStatus
 		}
+		bankAccounts[i].Currency = bankAccounts[i].GetCurrency()
 	}
 	if _, err := r.txn.ModelContext(span.Context(), &bankAccounts).Insert(&bankAccounts); err != nil {
 		span.Status = sentry.SpanStatusInternalError
@@ -133,3 +135,32 @@ func (r *repositoryBase) UpdateBankAccounts(ctx context.Context, accounts ...mod
 
 	return nil
 }
+
+// UpdateBankAccountBalances will set the current and available balance of the specified bank account. Unlike
+// UpdateBankAccounts this will persist balances of zero.
+func (r *repositoryBase) UpdateBankAccountBalances(ctx context.Context, bankAccountId uint64, current, available int64) error {
+	span := sentry.StartSpan(ctx, "function")
+	defer span.Finish()
+	span.Description = "UpdateBankAccountBalances"
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &models.BankAccount{}).
+		Set(`"current_balance" = ?`, current).
+		Set(`"available_balance" = ?`, available).
+		Set(`"last_updated" = ?`, time.Now().UTC()).
+		Where(`"bank_account"."account_id" = ?`, r.AccountId()).
+		Where(`"bank_account"."bank_account_id" = ?`, bankAccountId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update bank account balances")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
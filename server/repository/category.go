@@ -0,0 +1,518 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetCategories returns all of the categories for the current account, sorted by name.
+func (r *repositoryBase) GetCategories(ctx context.Context) ([]models.Category, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	items := make([]models.Category, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"category"."account_id" = ?`, r.AccountId()).
+		Order(`name ASC`).
+		Order(`category_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve categories")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+func (r *repositoryBase) GetCategory(ctx context.Context, categoryId uint64) (*models.Category, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"categoryId": categoryId,
+	}
+
+	var result models.Category
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"category"."account_id" = ?`, r.AccountId()).
+		Where(`"category"."category_id" = ?`, categoryId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve category")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+func (r *repositoryBase) CreateCategory(ctx context.Context, category *models.Category) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := r.clock.Now().UTC()
+	category.CategoryId = 0
+	category.AccountId = r.AccountId()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	if _, err := r.txn.ModelContext(span.Context(), category).Insert(category); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create category")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) UpdateCategory(ctx context.Context, category *models.Category) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"categoryId": category.CategoryId,
+	}
+
+	category.AccountId = r.AccountId()
+	category.UpdatedAt = r.clock.Now().UTC()
+
+	result, err := r.txn.ModelContext(span.Context(), category).
+		Column("parent_category_id", "name", "updated_at").
+		WherePK().
+		Returning(`*`).
+		Update(category)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update category")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to update category")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// DeleteCategory removes a category. Its children, its transactions and its mappings are all moved to its parent. If
+// the category is a top level category then its children become top level categories, and its transactions and
+// mappings are left without a category. The mappings are kept so that new transactions with the same Plaid category
+// are left uncategorized, rather than the deleted category being created again.
+func (r *repositoryBase) DeleteCategory(ctx context.Context, categoryId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"categoryId": categoryId,
+	}
+
+	category, err := r.GetCategory(span.Context(), categoryId)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.txn.ModelContext(span.Context(), &models.Category{}).
+		Set(`"parent_category_id" = ?`, category.ParentCategoryId).
+		Where(`"category"."account_id" = ?`, r.AccountId()).
+		Where(`"category"."parent_category_id" = ?`, categoryId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to move child categories")
+	}
+
+	_, err = r.txn.ModelContext(span.Context(), &models.Transaction{}).
+		Set(`"category_id" = ?`, category.ParentCategoryId).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."category_id" = ?`, categoryId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to move transactions to parent category")
+	}
+
+	_, err = r.txn.ModelContext(span.Context(), &models.CategoryMapping{}).
+		Set(`"category_id" = ?`, category.ParentCategoryId).
+		Where(`"category_mapping"."account_id" = ?`, r.AccountId()).
+		Where(`"category_mapping"."category_id" = ?`, categoryId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to move category mappings to parent category")
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &models.Category{}).
+		Where(`"category"."account_id" = ?`, r.AccountId()).
+		Where(`"category"."category_id" = ?`, categoryId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to delete category")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to delete category")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// GetCategoryMappings returns all of the category mappings for the current account, sorted by Plaid category.
+func (r *repositoryBase) GetCategoryMappings(ctx context.Context) ([]models.CategoryMapping, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	items := make([]models.CategoryMapping, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"category_mapping"."account_id" = ?`, r.AccountId()).
+		Order(`plaid_category ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve category mappings")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// UpsertCategoryMapping stores the mapping, replacing the category of an existing mapping for the same Plaid category.
+// Transactions that have already been categorized are not changed.
+func (r *repositoryBase) UpsertCategoryMapping(ctx context.Context, mapping *models.CategoryMapping) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"plaidCategory": mapping.PlaidCategory,
+		"categoryId":    mapping.CategoryId,
+	}
+
+	mapping.AccountId = r.AccountId()
+	mapping.CreatedAt = r.clock.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), mapping).
+		OnConflict(`("account_id", "plaid_category") DO UPDATE`).
+		Set(`"category_id" = EXCLUDED."category_id"`).
+		Returning(`*`).
+		Insert(mapping)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to store category mapping")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) DeleteCategoryMapping(ctx context.Context, plaidCategory string) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"plaidCategory": plaidCategory,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &models.CategoryMapping{}).
+		Where(`"category_mapping"."account_id" = ?`, r.AccountId()).
+		Where(`"category_mapping"."plaid_category" = ?`, plaidCategory).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to delete category mapping")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to delete category mapping")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// EnsureDefaultCategories seeds the default categories and their mappings for an account that has not had them
+// seeded yet. The account is marked as seeded in the same transaction, so the defaults are never created again even if
+// the account removes all of its categories. When the categories are seeded, transactions that were created before
+// categories existed are assigned a category the same way AssignCategories would assign one.
+func (r *repositoryBase) EnsureDefaultCategories(ctx context.Context) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	result, err := r.txn.ModelContext(span.Context(), &models.Account{}).
+		Set(`"categories_seeded_at" = ?`, r.clock.Now().UTC()).
+		Where(`"account"."account_id" = ?`, r.AccountId()).
+		Where(`"account"."categories_seeded_at" IS NULL`).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to mark account categories as seeded")
+	}
+
+	if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	resolver := &categoryResolver{
+		repo:       r,
+		mappings:   map[string]uint64{},
+		categories: map[string]uint64{},
+	}
+	for _, item := range models.DefaultCategories {
+		if _, err = resolver.resolve(span.Context(), []string{item.Name}); err != nil {
+			return err
+		}
+
+		for _, child := range item.Children {
+			if _, err = resolver.resolve(span.Context(), []string{item.Name, child}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = r.migrateLegacyCategories(span.Context(), resolver); err != nil {
+		return err
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// AssignCategories sets the category of each transaction that does not have one yet, based on its original (Plaid)
+// categories. Categories and mappings are created for any Plaid category that the account does not have a mapping for
+// yet. This must be called before the transactions are inserted.
+func (r *repositoryBase) AssignCategories(ctx context.Context, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if err := r.EnsureDefaultCategories(span.Context()); err != nil {
+		return err
+	}
+
+	resolver, err := r.newCategoryResolver(span.Context())
+	if err != nil {
+		return err
+	}
+
+	assigned := 0
+	for i := range transactions {
+		if transactions[i].CategoryId != nil {
+			continue
+		}
+
+		categoryId, err := resolver.resolve(
+			span.Context(),
+			models.TransactionCategorySource(transactions[i].OriginalCategories, transactions[i].Categories),
+		)
+		if err != nil {
+			return err
+		}
+
+		if categoryId != 0 {
+			transactions[i].CategoryId = &categoryId
+			assigned++
+		}
+	}
+
+	span.Data = map[string]interface{}{
+		"count":    len(transactions),
+		"assigned": assigned,
+	}
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// migrateLegacyCategories assigns a category to every transaction in the account that does not have one. The category
+// is resolved from the same Plaid category that AssignCategories uses, and transactions with the same category are
+// updated together.
+func (r *repositoryBase) migrateLegacyCategories(ctx context.Context, resolver *categoryResolver) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	var legacy []struct {
+		TransactionId      uint64   `pg:"transaction_id"`
+		Categories         []string `pg:"categories,array"`
+		OriginalCategories []string `pg:"original_categories,array"`
+	}
+	_, err := r.txn.QueryContext(span.Context(), &legacy, `
+		SELECT
+			"transaction"."transaction_id",
+			"transaction"."categories",
+			"transaction"."original_categories"
+		FROM "transactions" AS "transaction"
+		WHERE "transaction"."account_id" = ?
+		  AND "transaction"."category_id" IS NULL
+		  AND (cardinality("transaction"."categories") > 0 OR cardinality("transaction"."original_categories") > 0)
+	`, r.AccountId())
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to retrieve legacy transaction categories")
+	}
+
+	byCategory := map[uint64][]uint64{}
+	for _, item := range legacy {
+		categoryId, err := resolver.resolve(
+			span.Context(),
+			models.TransactionCategorySource(item.OriginalCategories, item.Categories),
+		)
+		if err != nil {
+			return err
+		}
+
+		if categoryId == 0 {
+			continue
+		}
+
+		byCategory[categoryId] = append(byCategory[categoryId], item.TransactionId)
+	}
+
+	migrated := 0
+	for categoryId, transactionIds := range byCategory {
+		result, err := r.txn.ModelContext(span.Context(), &models.Transaction{}).
+			Set(`"category_id" = ?`, categoryId).
+			Where(`"transaction"."account_id" = ?`, r.AccountId()).
+			Where(`"transaction"."category_id" IS NULL`).
+			WhereIn(`"transaction"."transaction_id" IN (?)`, transactionIds).
+			Update()
+		if err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return errors.Wrap(err, "failed to migrate legacy transaction categories")
+		}
+		migrated += result.RowsAffected()
+	}
+
+	span.Data = map[string]interface{}{
+		"categories":   len(byCategory),
+		"transactions": migrated,
+	}
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) newCategoryResolver(ctx context.Context) (*categoryResolver, error) {
+	categories, err := r.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := r.GetCategoryMappings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := &categoryResolver{
+		repo:       r,
+		mappings:   make(map[string]uint64, len(mappings)),
+		categories: make(map[string]uint64, len(categories)),
+	}
+	for _, mapping := range mappings {
+		var categoryId uint64
+		if mapping.CategoryId != nil {
+			categoryId = *mapping.CategoryId
+		}
+		resolver.mappings[mapping.PlaidCategory] = categoryId
+	}
+	for _, category := range categories {
+		resolver.categories[categoryNameKey(category.ParentCategoryId, category.Name)] = category.CategoryId
+	}
+
+	return resolver, nil
+}
+
+// categoryResolver turns Plaid categories into the account's categories, creating any categories and mappings that are
+// missing along the way.
+type categoryResolver struct {
+	repo *repositoryBase
+	// mappings are keyed by the Plaid category path. Mappings without a category are stored as zero.
+	mappings map[string]uint64
+	// categories are keyed by their parent and their lowercase name, the same way they are unique in the database.
+	categories map[string]uint64
+}
+
+func categoryNameKey(parentCategoryId *uint64, name string) string {
+	var parent uint64
+	if parentCategoryId != nil {
+		parent = *parentCategoryId
+	}
+
+	return strconv.FormatUint(parent, 10) + "/" + strings.ToLower(name)
+}
+
+// resolve returns the category for the provided Plaid category, or zero if the Plaid category is blank or is mapped to
+// no category.
+func (c *categoryResolver) resolve(ctx context.Context, categories []string) (uint64, error) {
+	path := models.CategoryPath(categories, models.MaxCategoryMappingDepth)
+	categoryId, depth := models.MatchCategoryMapping(c.mappings, path)
+	if depth == len(path) {
+		return categoryId, nil
+	}
+
+	// The category this Plaid category was mapped to has been deleted, so nothing should be created beneath it.
+	if depth > 0 && categoryId == 0 {
+		return 0, nil
+	}
+
+	var parentCategoryId *uint64
+	if depth > 0 {
+		parent := categoryId
+		parentCategoryId = &parent
+	}
+
+	for ; depth < len(path); depth++ {
+		name := path[depth]
+		categoryId, ok := c.categories[categoryNameKey(parentCategoryId, name)]
+		if !ok {
+			category := models.Category{
+				ParentCategoryId: parentCategoryId,
+				Name:             name,
+			}
+			if err := c.repo.CreateCategory(ctx, &category); err != nil {
+				return 0, err
+			}
+			categoryId = category.CategoryId
+			c.categories[categoryNameKey(parentCategoryId, name)] = categoryId
+		}
+
+		mapping := models.CategoryMapping{
+			PlaidCategory: models.CategoryPathKey(path[:depth+1]),
+			CategoryId:    myownsanity.Uint64P(categoryId),
+		}
+		if err := c.repo.UpsertCategoryMapping(ctx, &mapping); err != nil {
+			return 0, err
+		}
+		c.mappings[mapping.PlaidCategory] = categoryId
+
+		id := categoryId
+		parentCategoryId = &id
+	}
+
+	return *parentCategoryId, nil
+}
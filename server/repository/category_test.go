@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryBase_DeleteCategory(t *testing.T) {
+	t.Run("top level category is not created again", func(t *testing.T) {
+		clock := clock.NewMock()
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+		require.NoError(t, repo.EnsureDefaultCategories(context.Background()), "must seed default categories")
+
+		categories, err := repo.GetCategories(context.Background())
+		require.NoError(t, err, "must retrieve categories")
+		var recreationId uint64
+		for _, category := range categories {
+			if category.Name == "Recreation" && category.ParentCategoryId == nil {
+				recreationId = category.CategoryId
+			}
+		}
+		require.NotZero(t, recreationId, "default categories should include recreation")
+
+		require.NoError(t, repo.DeleteCategory(context.Background(), recreationId), "must delete category")
+
+		mappings, err := repo.GetCategoryMappings(context.Background())
+		require.NoError(t, err, "must retrieve category mappings")
+		found := false
+		for _, mapping := range mappings {
+			if mapping.PlaidCategory == "Recreation" {
+				found = true
+				assert.Nil(t, mapping.CategoryId, "mapping should be kept without a category")
+			}
+		}
+		assert.True(t, found, "mapping for the deleted category should be kept")
+
+		transactions := []models.Transaction{
+			{OriginalCategories: []string{"Recreation"}},
+			{OriginalCategories: []string{"Recreation", "Golf"}},
+		}
+		require.NoError(t, repo.AssignCategories(context.Background(), transactions), "must assign categories")
+		assert.Nil(t, transactions[0].CategoryId, "transaction should be left uncategorized")
+		assert.Nil(t, transactions[1].CategoryId, "transaction should be left uncategorized")
+
+		categories, err = repo.GetCategories(context.Background())
+		require.NoError(t, err, "must retrieve categories")
+		for _, category := range categories {
+			assert.NotEqual(t, "Recreation", category.Name, "deleted category should not be created again")
+			assert.NotEqual(t, "Golf", category.Name, "categories should not be created beneath a deleted category")
+		}
+	})
+}
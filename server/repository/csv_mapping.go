@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetCSVMapping will return the CSV mapping for the specified bank account. If the bank account does not have a mapping
+// then pg.ErrNoRows is returned.
+func (r *repositoryBase) GetCSVMapping(ctx context.Context, bankAccountId uint64) (*models.CSVMapping, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+	}
+
+	var result models.CSVMapping
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"csv_mapping"."account_id" = ?`, r.AccountId()).
+		Where(`"csv_mapping"."bank_account_id" = ?`, bankAccountId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve csv mapping")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+// UpsertCSVMapping will create or replace the CSV mapping for the bank account specified on the mapping provided.
+func (r *repositoryBase) UpsertCSVMapping(ctx context.Context, mapping *models.CSVMapping) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": mapping.BankAccountId,
+	}
+
+	now := time.Now().UTC()
+	mapping.AccountId = r.AccountId()
+	mapping.CreatedAt = now
+	mapping.UpdatedAt = now
+
+	_, err := r.txn.ModelContext(span.Context(), mapping).
+		OnConflict(`("account_id", "bank_account_id") DO UPDATE`).
+		Set(`"delimiter" = EXCLUDED."delimiter"`).
+		Set(`"skip_rows" = EXCLUDED."skip_rows"`).
+		Set(`"date_column" = EXCLUDED."date_column"`).
+		Set(`"date_format" = EXCLUDED."date_format"`).
+		Set(`"amount_type" = EXCLUDED."amount_type"`).
+		Set(`"amount_column" = EXCLUDED."amount_column"`).
+		Set(`"debit_column" = EXCLUDED."debit_column"`).
+		Set(`"credit_column" = EXCLUDED."credit_column"`).
+		Set(`"sign_convention" = EXCLUDED."sign_convention"`).
+		Set(`"decimal_separator" = EXCLUDED."decimal_separator"`).
+		Set(`"description_column" = EXCLUDED."description_column"`).
+		Set(`"merchant_column" = EXCLUDED."merchant_column"`).
+		Set(`"identifier_column" = EXCLUDED."identifier_column"`).
+		Set(`"updated_at" = EXCLUDED."updated_at"`).
+		Returning(`*`).
+		Insert(mapping)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to save csv mapping")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
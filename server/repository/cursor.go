@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cursor marks a position within a list that is sorted by a date and then by an Id, both descending. Unlike an offset
+// a cursor is not affected by items being added to the beginning of the list while it is being paged through.
+type Cursor struct {
+	Date time.Time
+	Id   uint64
+}
+
+func NewCursor(date time.Time, id uint64) *Cursor {
+	return &Cursor{
+		Date: date,
+		Id:   id,
+	}
+}
+
+// String returns an opaque representation of the cursor that can be given to clients and parsed with ParseCursor.
+func (c Cursor) String() string {
+	raw := fmt.Sprintf("%d:%d", c.Date.UnixNano(), c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor will parse a cursor that was previously created with Cursor.String.
+func ParseCursor(input string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(input))
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	nanoseconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || id == 0 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return NewCursor(time.Unix(0, nanoseconds).UTC(), id), nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		date := time.Date(2023, 10, 5, 4, 0, 0, 123, time.UTC)
+		cursor := NewCursor(date, 1234)
+		encoded := cursor.String()
+		assert.NotContains(t, encoded, "1234", "cursor should be opaque")
+
+		parsed, err := ParseCursor(encoded)
+		require.NoError(t, err, "must be able to parse cursor")
+		assert.True(t, date.Equal(parsed.Date), "dates should match")
+		assert.EqualValues(t, 1234, parsed.Id)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, input := range []string{
+			"",
+			"not a cursor!",
+			"MTIzNA",      // 1234
+			"YWJjOjEyMzQ", // abc:1234
+			"MTIzNDo",     // 1234:
+			"MTIzNDow",    // 1234:0
+			"MTIzNDoxOjI", // 1234:1:2
+		} {
+			cursor, err := ParseCursor(input)
+			assert.EqualError(t, err, "invalid cursor", "input: %s", input)
+			assert.Nil(t, cursor)
+		}
+	})
+}
@@ -109,8 +109,15 @@ func (r *repositoryBase) CreateSpending(ctx context.Context, spending *models.Sp
 }
 
 // UpdateSpending should only be called with complete expense models. Do not use partial models with missing data for
-// this action.
-func (r *repositoryBase) UpdateSpending(ctx context.Context, bankAccountId uint64, updates []models.Spending) error {
+// this action. Any change to the current or used amount of the spending objects is recorded in the spending ledger with
+// the provided reason and reference Id.
+func (r *repositoryBase) UpdateSpending(
+	ctx context.Context,
+	bankAccountId uint64,
+	updates []models.Spending,
+	reason models.SpendingLedgerReason,
+	referenceId *uint64,
+) error {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
@@ -125,13 +132,32 @@ func (r *repositoryBase) UpdateSpending(ctx context.Context, bankAccountId uint6
 		"accountId":     r.AccountId(),
 		"bankAccountId": bankAccountId,
 		"spendingIds":   spendingIds,
+		"reason":        reason,
+		"referenceId":   referenceId,
 	}
 
-	_, err := r.txn.ModelContext(span.Context(), &updates).
-		Update(&updates)
+	if len(updates) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	// Retrieve the balances as they are before the update so that the changes can be recorded in the ledger.
+	existing := make([]models.Spending, 0, len(updates))
+	err := r.txn.ModelContext(span.Context(), &existing).
+		Column("spending_id", "current_amount", "used_amount").
+		Where(`"spending"."account_id" = ?`, r.AccountId()).
+		Where(`"spending"."bank_account_id" = ?`, bankAccountId).
+		WhereIn(`"spending"."spending_id" IN (?)`, spendingIds).
+		Select(&existing)
 	if err != nil {
 		span.Status = sentry.SpanStatusInternalError
-		return errors.Wrap(err, "failed to update expenses")
+		return errors.Wrap(err, "failed to retrieve existing balances of expenses")
+	}
+
+	entries := models.NewSpendingLedgerEntries(existing, updates, reason, referenceId, r.clock.Now())
+	if err = r.storeSpending(span.Context(), updates, entries); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return err
 	}
 
 	span.Status = sentry.SpanStatusOK
@@ -139,6 +165,16 @@ func (r *repositoryBase) UpdateSpending(ctx context.Context, bankAccountId uint6
 	return nil
 }
 
+// storeSpending writes the provided spending objects and the ledger entries that describe how they changed. The
+// spending objects must already have their account and bank account set.
+func (r *repositoryBase) storeSpending(ctx context.Context, updates []models.Spending, entries []models.SpendingLedgerEntry) error {
+	if _, err := r.txn.ModelContext(ctx, &updates).Update(&updates); err != nil {
+		return errors.Wrap(err, "failed to update expenses")
+	}
+
+	return r.createSpendingLedgerEntries(ctx, entries)
+}
+
 func (r *repositoryBase) GetSpendingById(ctx context.Context, bankAccountId, spendingId uint64) (*models.Spending, error) {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
@@ -176,6 +212,11 @@ func (r *repositoryBase) DeleteSpending(ctx context.Context, bankAccountId, spen
 		"spendingId":    spendingId,
 	}
 
+	if err := r.removeSplitsForSpending(span.Context(), bankAccountId, spendingId); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return err
+	}
+
 	_, err := r.txn.ModelContext(span.Context(), &models.Transaction{}).
 		Set(`"spending_id" = NULL`).
 		Set(`"spending_amount" = NULL`).
@@ -188,6 +229,19 @@ func (r *repositoryBase) DeleteSpending(ctx context.Context, bankAccountId, spen
 		return errors.Wrap(err, "failed to remove spending from any transactions")
 	}
 
+	// Expenses that sweep their leftover money to this spending object go back to keeping it themselves.
+	_, err = r.txn.ModelContext(span.Context(), &models.Spending{}).
+		Set(`"rollover_policy" = ?`, models.SpendingRolloverPolicyRollover).
+		Set(`"sweep_spending_id" = NULL`).
+		Where(`"spending"."account_id" = ?`, r.AccountId()).
+		Where(`"spending"."bank_account_id" = ?`, bankAccountId).
+		Where(`"spending"."sweep_spending_id" = ?`, spendingId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove spending from any sweeping expenses")
+	}
+
 	result, err := r.txn.ModelContext(span.Context(), &models.Spending{}).
 		Where(`"spending"."account_id" = ?`, r.AccountId()).
 		Where(`"spending"."bank_account_id" = ?`, bankAccountId).
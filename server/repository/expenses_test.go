@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryBase_DeleteSpending(t *testing.T) {
+	t.Run("spending used by a split", func(t *testing.T) {
+		clock := clock.NewMock()
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, clock, &bankAccount, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		firstExpense := fixtures.GivenIHaveAnExpense(t, clock, fundingSchedule, 10000)
+		secondExpense := fixtures.GivenIHaveAnExpense(t, clock, fundingSchedule, 10000)
+		transaction := fixtures.GivenIHaveATransaction(t, clock, bankAccount)
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+
+		firstAmount := transaction.Amount / 2
+		secondAmount := transaction.Amount - firstAmount
+		{ // Split the transaction across both of the expenses.
+			existing := transaction
+			updated := transaction
+			updated.Splits = []models.TransactionSplit{
+				{SpendingId: firstExpense.SpendingId, Amount: firstAmount},
+				{SpendingId: secondExpense.SpendingId, Amount: secondAmount},
+			}
+			_, err := repo.ProcessTransactionSpentFrom(context.Background(), bankAccount.BankAccountId, &updated, &existing)
+			require.NoError(t, err, "must be able to split the transaction")
+			require.NoError(t, repo.UpdateTransaction(context.Background(), bankAccount.BankAccountId, &updated), "must update transaction")
+		}
+
+		require.NoError(t, repo.DeleteSpending(context.Background(), bankAccount.BankAccountId, firstExpense.SpendingId), "must delete the first expense")
+
+		existing, err := repo.GetTransaction(context.Background(), bankAccount.BankAccountId, transaction.TransactionId)
+		require.NoError(t, err, "must retrieve the transaction")
+		assert.Empty(t, existing.Splits, "transaction should no longer be split")
+		require.NotNil(t, existing.SpendingId, "transaction should be spent from the remaining expense")
+		assert.Equal(t, secondExpense.SpendingId, *existing.SpendingId)
+		require.NotNil(t, existing.SpendingAmount)
+		assert.Equal(t, secondAmount, *existing.SpendingAmount, "the amount deducted by the remaining split should be kept")
+
+		second, err := repo.GetSpendingById(context.Background(), bankAccount.BankAccountId, secondExpense.SpendingId)
+		require.NoError(t, err, "must retrieve the second expense")
+		assert.EqualValues(t, 10000-secondAmount, second.CurrentAmount, "remaining expense should not change")
+
+		{ // The transaction can still be edited afterwards.
+			updated := *existing
+			updated.Name = "Renamed"
+			_, err = repo.ProcessTransactionSpentFrom(context.Background(), bankAccount.BankAccountId, &updated, existing)
+			require.NoError(t, err, "must be able to process the renamed transaction")
+			require.NoError(t, repo.UpdateTransaction(context.Background(), bankAccount.BankAccountId, &updated), "must update the renamed transaction")
+		}
+	})
+}
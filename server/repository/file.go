@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// CreateFile records a file that has already been written to storage. The account Id and created timestamp are set
+// by this method.
+func (r *repositoryBase) CreateFile(ctx context.Context, file *models.File) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": file.BankAccountId,
+	}
+
+	file.AccountId = r.AccountId()
+	file.CreatedAt = time.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), file).Insert(file)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create file")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// GetFiles returns the files that have been uploaded for the specified bank account, newest first. If after is provided
+// then only files created before that cursor are returned and the offset should be zero.
+func (r *repositoryBase) GetFiles(ctx context.Context, bankAccountId uint64, limit, offset int, after *Cursor) ([]models.File, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"limit":         limit,
+		"offset":        offset,
+		"after":         after,
+	}
+
+	items := make([]models.File, 0)
+	query := r.txn.ModelContext(span.Context(), &items).
+		Where(`"file"."account_id" = ?`, r.AccountId()).
+		Where(`"file"."bank_account_id" = ?`, bankAccountId)
+	if after != nil {
+		query = query.Where(`("file"."created_at", "file"."file_id") < (?, ?)`, after.Date, after.Id)
+	}
+	err := query.
+		Limit(limit).
+		Offset(offset).
+		Order(`created_at DESC`).
+		Order(`file_id DESC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve files")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+func (r *repositoryBase) GetFile(ctx context.Context, bankAccountId, fileId uint64) (*models.File, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"fileId":        fileId,
+	}
+
+	var result models.File
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"file"."account_id" = ?`, r.AccountId()).
+		Where(`"file"."bank_account_id" = ?`, bankAccountId).
+		Where(`"file"."file_id" = ?`, fileId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve file")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
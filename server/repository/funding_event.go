@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+func (r *repositoryBase) CreateFundingEvent(ctx context.Context, event *models.FundingEvent) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":     event.BankAccountId,
+		"fundingScheduleId": event.FundingScheduleId,
+	}
+
+	event.FundingEventId = 0
+	event.AccountId = r.AccountId()
+	event.CreatedAt = r.clock.Now().UTC()
+	event.RevertedAt = nil
+	if event.Contributions == nil {
+		event.Contributions = make([]models.FundingEventContribution, 0)
+	}
+
+	if _, err := r.txn.ModelContext(span.Context(), event).Insert(event); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create funding event")
+	}
+
+	span.Data["fundingEventId"] = event.FundingEventId
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) GetFundingEvent(ctx context.Context, bankAccountId, fundingEventId uint64) (*models.FundingEvent, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":  bankAccountId,
+		"fundingEventId": fundingEventId,
+	}
+
+	var result models.FundingEvent
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"funding_event"."account_id" = ?`, r.AccountId()).
+		Where(`"funding_event"."bank_account_id" = ?`, bankAccountId).
+		Where(`"funding_event"."funding_event_id" = ?`, fundingEventId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve funding event")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+// GetFundingEvents returns the funding events of the funding schedule, most recent first.
+func (r *repositoryBase) GetFundingEvents(ctx context.Context, bankAccountId, fundingScheduleId uint64, limit, offset int) ([]models.FundingEvent, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":     bankAccountId,
+		"fundingScheduleId": fundingScheduleId,
+		"limit":             limit,
+		"offset":            offset,
+	}
+
+	items := make([]models.FundingEvent, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"funding_event"."account_id" = ?`, r.AccountId()).
+		Where(`"funding_event"."bank_account_id" = ?`, bankAccountId).
+		Where(`"funding_event"."funding_schedule_id" = ?`, fundingScheduleId).
+		Order(`created_at DESC`).
+		Order(`funding_event_id DESC`).
+		Limit(limit).
+		Offset(offset).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve funding events")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// GetLatestFundingEvent returns the most recent funding event of the funding schedule that has not been reverted. If
+// there is no such funding event then nil is returned without an error.
+func (r *repositoryBase) GetLatestFundingEvent(ctx context.Context, bankAccountId, fundingScheduleId uint64) (*models.FundingEvent, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":     bankAccountId,
+		"fundingScheduleId": fundingScheduleId,
+	}
+
+	var result models.FundingEvent
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"funding_event"."account_id" = ?`, r.AccountId()).
+		Where(`"funding_event"."bank_account_id" = ?`, bankAccountId).
+		Where(`"funding_event"."funding_schedule_id" = ?`, fundingScheduleId).
+		Where(`"funding_event"."reverted_at" IS NULL`).
+		Order(`created_at DESC`).
+		Order(`funding_event_id DESC`).
+		Limit(1).
+		Select(&result)
+	switch err {
+	case nil:
+		span.Status = sentry.SpanStatusOK
+		return &result, nil
+	case pg.ErrNoRows:
+		span.Status = sentry.SpanStatusOK
+		return nil, nil
+	default:
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve latest funding event")
+	}
+}
+
+// RevertFundingEvent marks the funding event as reverted, and stores the occurrences of the funding schedule that were
+// restored by reverting it. The contributions of the funding event must be removed from its spending objects separately.
+func (r *repositoryBase) RevertFundingEvent(
+	ctx context.Context,
+	event *models.FundingEvent,
+	fundingSchedule *models.FundingSchedule,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":     event.BankAccountId,
+		"fundingEventId":    event.FundingEventId,
+		"fundingScheduleId": fundingSchedule.FundingScheduleId,
+	}
+
+	now := r.clock.Now().UTC()
+	event.AccountId = r.AccountId()
+	event.RevertedAt = &now
+	_, err := r.txn.ModelContext(span.Context(), event).
+		Column("reverted_at").
+		WherePK().
+		Update(event)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to mark funding event as reverted")
+	}
+
+	// The funding schedule is not updated with UpdateFundingSchedule because the last occurrence might need to be
+	// restored to null.
+	fundingSchedule.AccountId = r.AccountId()
+	result, err := r.txn.ModelContext(span.Context(), fundingSchedule).
+		Column("last_occurrence", "next_occurrence", "next_occurrence_original").
+		WherePK().
+		Update(fundingSchedule)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to restore funding schedule occurrences")
+	} else if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.New("no rows updated")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
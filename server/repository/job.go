@@ -19,6 +19,9 @@ type JobRepository interface {
 	GetPlaidLinksByAccount(ctx context.Context) ([]PlaidLinksForAccount, error)
 	GetLinksForExpiredAccounts(ctx context.Context) ([]models.Link, error)
 	GetBankAccountsWithStaleSpending(ctx context.Context) ([]BankAccountWithStaleSpendingItem, error)
+	GetBankAccountsWithRecentTransactions(ctx context.Context) ([]BankAccountWithRecentTransactionsItem, error)
+	GetAccountsWithActiveBankAccounts(ctx context.Context) ([]AccountWithActiveBankAccountsItem, error)
+	GetAccountsWithoutCategories(ctx context.Context) ([]AccountWithoutCategoriesItem, error)
 }
 
 type ProcessFundingSchedulesItem struct {
@@ -44,6 +47,19 @@ type BankAccountWithStaleSpendingItem struct {
 	BankAccountId uint64 `pg:"bank_account_id"`
 }
 
+type BankAccountWithRecentTransactionsItem struct {
+	AccountId     uint64 `pg:"account_id"`
+	BankAccountId uint64 `pg:"bank_account_id"`
+}
+
+type AccountWithActiveBankAccountsItem struct {
+	AccountId uint64 `pg:"account_id"`
+}
+
+type AccountWithoutCategoriesItem struct {
+	AccountId uint64 `pg:"account_id"`
+}
+
 type jobRepository struct {
 	txn   pg.DBI
 	clock clock.Clock
@@ -186,3 +202,60 @@ func (j *jobRepository) GetBankAccountsWithStaleSpending(ctx context.Context) ([
 
 	return result, err
 }
+
+// GetBankAccountsWithRecentTransactions returns the bank accounts that have had a transaction created in the last day.
+func (j *jobRepository) GetBankAccountsWithRecentTransactions(ctx context.Context) ([]BankAccountWithRecentTransactionsItem, error) {
+	span := sentry.StartSpan(ctx, "GetBankAccountsWithRecentTransactions")
+	defer span.Finish()
+
+	var result []BankAccountWithRecentTransactionsItem
+	err := j.txn.ModelContext(span.Context(), &models.BankAccount{}).
+		ColumnExpr(`"bank_account"."account_id"`).
+		ColumnExpr(`"bank_account"."bank_account_id"`).
+		Join(`INNER JOIN "transactions" AS "transaction"`).
+		JoinOn(`"transaction"."account_id" = "bank_account"."account_id" AND "transaction"."bank_account_id" = "bank_account"."bank_account_id"`).
+		Where(`"transaction"."created_at" > ?`, j.clock.Now().Add(-24*time.Hour)).
+		GroupExpr(`"bank_account"."account_id"`).
+		GroupExpr(`"bank_account"."bank_account_id"`).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve bank accounts with recent transactions")
+	}
+
+	return result, err
+}
+
+// GetAccountsWithActiveBankAccounts returns every account that has at least one active bank account.
+func (j *jobRepository) GetAccountsWithActiveBankAccounts(ctx context.Context) ([]AccountWithActiveBankAccountsItem, error) {
+	span := sentry.StartSpan(ctx, "GetAccountsWithActiveBankAccounts")
+	defer span.Finish()
+
+	var result []AccountWithActiveBankAccountsItem
+	err := j.txn.ModelContext(span.Context(), &models.BankAccount{}).
+		ColumnExpr(`"bank_account"."account_id"`).
+		Where(`"bank_account"."status" = ?`, models.ActiveBankAccountStatus).
+		GroupExpr(`"bank_account"."account_id"`).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve accounts with active bank accounts")
+	}
+
+	return result, err
+}
+
+// GetAccountsWithoutCategories returns every account that has not had the default categories seeded yet.
+func (j *jobRepository) GetAccountsWithoutCategories(ctx context.Context) ([]AccountWithoutCategoriesItem, error) {
+	span := sentry.StartSpan(ctx, "GetAccountsWithoutCategories")
+	defer span.Finish()
+
+	var result []AccountWithoutCategoriesItem
+	err := j.txn.ModelContext(span.Context(), &models.Account{}).
+		ColumnExpr(`"account"."account_id"`).
+		Where(`"account"."categories_seeded_at" IS NULL`).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve accounts without categories")
+	}
+
+	return result, err
+}
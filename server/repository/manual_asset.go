@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+func (r *repositoryBase) GetManualAssets(ctx context.Context) ([]models.ManualAsset, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	items := make([]models.ManualAsset, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"manual_asset"."account_id" = ?`, r.AccountId()).
+		Order(`manual_asset_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve manual assets")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+func (r *repositoryBase) GetManualAsset(ctx context.Context, manualAssetId uint64) (*models.ManualAsset, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"manualAssetId": manualAssetId,
+	}
+
+	var result models.ManualAsset
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"manual_asset"."account_id" = ?`, r.AccountId()).
+		Where(`"manual_asset"."manual_asset_id" = ?`, manualAssetId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve manual asset")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+// CreateManualAsset will create the manual asset and record its current value as the value for the provided date.
+func (r *repositoryBase) CreateManualAsset(ctx context.Context, asset *models.ManualAsset, date time.Time) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := r.clock.Now().UTC()
+	asset.ManualAssetId = 0
+	asset.AccountId = r.AccountId()
+	asset.CreatedAt = now
+	asset.UpdatedAt = now
+
+	if _, err := r.txn.ModelContext(span.Context(), asset).Insert(asset); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create manual asset")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return r.RecordManualAssetValue(span.Context(), asset, date, asset.CurrentValue)
+}
+
+// UpdateManualAsset will update the name and type of the manual asset. The value of an asset can only be changed by
+// recording a new value.
+func (r *repositoryBase) UpdateManualAsset(ctx context.Context, asset *models.ManualAsset) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"manualAssetId": asset.ManualAssetId,
+	}
+
+	asset.AccountId = r.AccountId()
+	asset.UpdatedAt = r.clock.Now().UTC()
+
+	result, err := r.txn.ModelContext(span.Context(), asset).
+		Column("name", "asset_type", "updated_at").
+		WherePK().
+		Returning(`*`).
+		Update(asset)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update manual asset")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to update manual asset")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// DeleteManualAsset will remove the manual asset along with all of its recorded values.
+func (r *repositoryBase) DeleteManualAsset(ctx context.Context, manualAssetId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"manualAssetId": manualAssetId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &models.ManualAsset{}).
+		Where(`"manual_asset"."account_id" = ?`, r.AccountId()).
+		Where(`"manual_asset"."manual_asset_id" = ?`, manualAssetId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to delete manual asset")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to delete manual asset")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// RecordManualAssetValue stores the value of the manual asset for the provided date, replacing any value that was
+// already recorded for that date. The current value of the asset is then updated to the most recently dated value, so
+// recording a value in the past will not change the current value. The provided asset is updated in place.
+func (r *repositoryBase) RecordManualAssetValue(ctx context.Context, asset *models.ManualAsset, date time.Time, value int64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"manualAssetId": asset.ManualAssetId,
+		"date":          date,
+	}
+
+	now := r.clock.Now().UTC()
+	item := models.ManualAssetValue{
+		AccountId:     r.AccountId(),
+		ManualAssetId: asset.ManualAssetId,
+		Date:          date,
+		Value:         value,
+		CreatedAt:     now,
+	}
+	_, err := r.txn.ModelContext(span.Context(), &item).
+		OnConflict(`("account_id", "manual_asset_id", "date") DO UPDATE`).
+		Set(`"value" = EXCLUDED."value"`).
+		Set(`"created_at" = EXCLUDED."created_at"`).
+		Insert(&item)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to record manual asset value")
+	}
+
+	asset.UpdatedAt = now
+	_, err = r.txn.ModelContext(span.Context(), asset).
+		Set(`"current_value" = (?)`, r.txn.ModelContext(span.Context(), &models.ManualAssetValue{}).
+			Column("value").
+			Where(`"manual_asset_value"."account_id" = ?`, r.AccountId()).
+			Where(`"manual_asset_value"."manual_asset_id" = ?`, asset.ManualAssetId).
+			Order(`date DESC`).
+			Limit(1),
+		).
+		Set(`"updated_at" = ?`, now).
+		Where(`"manual_asset"."account_id" = ?`, r.AccountId()).
+		Where(`"manual_asset"."manual_asset_id" = ?`, asset.ManualAssetId).
+		Returning(`*`).
+		Update(asset)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update current value of manual asset")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// GetManualAssetValues returns every value that has been recorded for the manual asset, oldest first.
+func (r *repositoryBase) GetManualAssetValues(ctx context.Context, manualAssetId uint64) ([]models.ManualAssetValue, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"manualAssetId": manualAssetId,
+	}
+
+	items := make([]models.ManualAssetValue, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"manual_asset_value"."account_id" = ?`, r.AccountId()).
+		Where(`"manual_asset_value"."manual_asset_id" = ?`, manualAssetId).
+		Order(`date ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve manual asset values")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// GetManualAssetValuesBefore returns the values recorded for every manual asset in the current account that are dated
+// before the provided time, oldest first.
+func (r *repositoryBase) GetManualAssetValuesBefore(ctx context.Context, end time.Time) ([]models.ManualAssetValue, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"end": end,
+	}
+
+	items := make([]models.ManualAssetValue, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"manual_asset_value"."account_id" = ?`, r.AccountId()).
+		Where(`"manual_asset_value"."date" < ?`, end).
+		Order(`date ASC`).
+		Order(`manual_asset_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve manual asset values")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
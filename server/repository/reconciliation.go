@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+func (r *repositoryBase) CreateReconciliation(ctx context.Context, reconciliation *models.Reconciliation) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": reconciliation.BankAccountId,
+	}
+
+	reconciliation.ReconciliationId = 0
+	reconciliation.AccountId = r.AccountId()
+	reconciliation.CreatedByUserId = r.UserId()
+	reconciliation.CreatedAt = r.clock.Now().UTC()
+
+	if _, err := r.txn.ModelContext(span.Context(), reconciliation).Insert(reconciliation); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create reconciliation")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// GetLatestReconciliation returns the reconciliation with the most recent statement date for the bank account. If the
+// bank account has never been reconciled then nil is returned without an error.
+func (r *repositoryBase) GetLatestReconciliation(ctx context.Context, bankAccountId uint64) (*models.Reconciliation, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+	}
+
+	var result models.Reconciliation
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"reconciliation"."account_id" = ?`, r.AccountId()).
+		Where(`"reconciliation"."bank_account_id" = ?`, bankAccountId).
+		Order(`statement_date DESC`).
+		Order(`reconciliation_id DESC`).
+		Limit(1).
+		Select(&result)
+	switch err {
+	case nil:
+		span.Status = sentry.SpanStatusOK
+		return &result, nil
+	case pg.ErrNoRows:
+		span.Status = sentry.SpanStatusOK
+		return nil, nil
+	default:
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve latest reconciliation")
+	}
+}
+
+// GetReconciliations returns the reconciliations for the bank account with the most recent statement date first.
+func (r *repositoryBase) GetReconciliations(ctx context.Context, bankAccountId uint64, limit, offset int) ([]models.Reconciliation, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"limit":         limit,
+		"offset":        offset,
+	}
+
+	items := make([]models.Reconciliation, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"reconciliation"."account_id" = ?`, r.AccountId()).
+		Where(`"reconciliation"."bank_account_id" = ?`, bankAccountId).
+		Order(`statement_date DESC`).
+		Order(`reconciliation_id DESC`).
+		Limit(limit).
+		Offset(offset).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve reconciliations")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"strconv"
+	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/getsentry/sentry-go"
@@ -15,67 +16,194 @@ type BaseRepository interface {
 	AccountId() uint64
 
 	AddExpenseToTransaction(ctx context.Context, transaction *models.Transaction, spending *models.Spending) error
+	// ApplyTransactionRules evaluates the account's transaction rules against new transactions before they are
+	// inserted. The spending objects they are spent from are updated by DeductTransactionSpending once they have been
+	// inserted.
+	ApplyTransactionRules(ctx context.Context, transactions []models.Transaction) error
+	// AssignCategories sets the category of new transactions from their Plaid categories using the account's category
+	// mappings, creating categories and mappings for any Plaid category that is not mapped yet.
+	AssignCategories(ctx context.Context, transactions []models.Transaction) error
+	// BackfillBalanceSnapshots stores the provided snapshots for any day that does not already have a snapshot, and
+	// returns the number that were stored.
+	BackfillBalanceSnapshots(ctx context.Context, snapshots []models.BalanceSnapshot) (int, error)
+	// ConfirmTransfer marks both sides of the transfer that the transaction belongs to as confirmed by the user.
+	ConfirmTransfer(ctx context.Context, bankAccountId, transactionId uint64) error
 	CreateBankAccounts(ctx context.Context, bankAccounts ...*models.BankAccount) error
+	CreateCategory(ctx context.Context, category *models.Category) error
+	CreateFile(ctx context.Context, file *models.File) error
+	// CreateFundingEvent records that a funding schedule was processed and the contributions it made.
+	CreateFundingEvent(ctx context.Context, event *models.FundingEvent) error
 	CreateFundingSchedule(ctx context.Context, fundingSchedule *models.FundingSchedule) error
 	CreateLink(ctx context.Context, link *models.Link) error
+	// CreateManualAsset creates the manual asset and records its current value as the value for the provided date.
+	CreateManualAsset(ctx context.Context, asset *models.ManualAsset, date time.Time) error
 	CreatePlaidLink(ctx context.Context, link *models.PlaidLink) error
+	CreateReconciliation(ctx context.Context, reconciliation *models.Reconciliation) error
 	CreateSpending(ctx context.Context, expense *models.Spending) error
 	CreateTransaction(ctx context.Context, bankAccountId uint64, transaction *models.Transaction) error
+	CreateTransactionAttachment(ctx context.Context, attachment *models.TransactionAttachment) error
+	CreateTransactionRule(ctx context.Context, rule *models.TransactionRule) error
+	// DeductTransactionSpending takes the spending amount of new transactions away from the spending objects they are
+	// spent from, recording a ledger entry for each transaction. It must be called after the transactions are inserted.
+	DeductTransactionSpending(ctx context.Context, transactions []models.Transaction) error
 	// DeleteAccount removes all of the records from the database related to the current account. This action cannot be
 	// undone. Any Plaid links should be removed BEFORE calling this function.
 	DeleteAccount(ctx context.Context) error
+	// DeleteCategory removes the category, moving its children, transactions and mappings to its parent.
+	DeleteCategory(ctx context.Context, categoryId uint64) error
+	DeleteCategoryMapping(ctx context.Context, plaidCategory string) error
 	DeleteFundingSchedule(ctx context.Context, bankAccountId, fundingScheduleId uint64) error
+	DeleteManualAsset(ctx context.Context, manualAssetId uint64) error
 	DeletePlaidLink(ctx context.Context, plaidLinkId uint64) error
 	DeleteSpending(ctx context.Context, bankAccountId, spendingId uint64) error
 	DeleteTransaction(ctx context.Context, bankAccountId, transactionId uint64) error
+	DeleteTransactionAttachment(ctx context.Context, bankAccountId, transactionId, fileId uint64) error
+	// DeleteTransactionAttachments removes the records of every file attached to the provided transactions and returns
+	// the removed files so that they can be removed from storage.
+	DeleteTransactionAttachments(ctx context.Context, transactionIds []uint64) ([]models.File, error)
+	DeleteTransactionRule(ctx context.Context, transactionRuleId uint64) error
+	// DetectTransfers links any of the provided transactions that look like one side of a transfer between two of the
+	// account's bank accounts to the other side of that transfer. It returns the number of transfers linked.
+	DetectTransfers(ctx context.Context, transactions []models.Transaction) (int, error)
+	// EnsureDefaultCategories seeds the default categories for an account that has not been seeded yet, and assigns
+	// categories to its existing transactions. An account is only ever seeded once.
+	EnsureDefaultCategories(ctx context.Context) error
 	GetAccount(ctx context.Context) (*models.Account, error)
 	GetBalances(ctx context.Context, bankAccountId uint64) (*Balances, error)
+	// GetBalanceSnapshots returns the bank account's snapshots on or after the start and before the end, oldest first.
+	GetBalanceSnapshots(ctx context.Context, bankAccountId uint64, start, end time.Time) ([]models.BalanceSnapshot, error)
+	// GetBalanceSnapshotsByDateRange returns the snapshots for every bank account on or after the start and before the
+	// end, oldest first.
+	GetBalanceSnapshotsByDateRange(ctx context.Context, start, end time.Time) ([]models.BalanceSnapshot, error)
 	GetBankAccount(ctx context.Context, bankAccountId uint64) (*models.BankAccount, error)
 	GetBankAccounts(ctx context.Context) ([]models.BankAccount, error)
 	GetBankAccountsByLinkId(ctx context.Context, linkId uint64) ([]models.BankAccount, error)
+	GetCategories(ctx context.Context) ([]models.Category, error)
+	GetCategory(ctx context.Context, categoryId uint64) (*models.Category, error)
+	GetCategoryMappings(ctx context.Context) ([]models.CategoryMapping, error)
+	GetCSVMapping(ctx context.Context, bankAccountId uint64) (*models.CSVMapping, error)
+	GetFile(ctx context.Context, bankAccountId, fileId uint64) (*models.File, error)
+	GetFiles(ctx context.Context, bankAccountId uint64, limit, offset int, after *Cursor) ([]models.File, error)
+	GetFundingEvent(ctx context.Context, bankAccountId, fundingEventId uint64) (*models.FundingEvent, error)
+	// GetFundingEvents returns the funding events of the funding schedule, most recent first.
+	GetFundingEvents(ctx context.Context, bankAccountId, fundingScheduleId uint64, limit, offset int) ([]models.FundingEvent, error)
 	GetFundingSchedule(ctx context.Context, bankAccountId, fundingScheduleId uint64) (*models.FundingSchedule, error)
 	GetFundingSchedules(ctx context.Context, bankAccountId uint64) ([]models.FundingSchedule, error)
 	GetFundingStats(ctx context.Context, bankAccountId uint64) ([]FundingStats, error)
 	GetIsSetup(ctx context.Context) (bool, error)
+	// GetLatestBalanceSnapshotsBefore returns the most recent snapshot of each bank account that is before the provided
+	// time.
+	GetLatestBalanceSnapshotsBefore(ctx context.Context, end time.Time) ([]models.BalanceSnapshot, error)
+	// GetLatestFundingEvent returns the most recent funding event of the funding schedule that has not been reverted, or
+	// nil if there is not one.
+	GetLatestFundingEvent(ctx context.Context, bankAccountId, fundingScheduleId uint64) (*models.FundingEvent, error)
+	// GetLatestReconciliation returns the bank account's reconciliation with the most recent statement date, or nil if
+	// it has never been reconciled.
+	GetLatestReconciliation(ctx context.Context, bankAccountId uint64) (*models.Reconciliation, error)
 	GetLink(ctx context.Context, linkId uint64) (*models.Link, error)
 	GetLinkIsManual(ctx context.Context, linkId uint64) (bool, error)
 	GetLinkIsManualByBankAccountId(ctx context.Context, bankAccountId uint64) (bool, error)
 	GetLinks(ctx context.Context) ([]models.Link, error)
+	GetManualAsset(ctx context.Context, manualAssetId uint64) (*models.ManualAsset, error)
+	GetManualAssets(ctx context.Context) ([]models.ManualAsset, error)
+	// GetManualAssetValues returns every value recorded for the manual asset, oldest first.
+	GetManualAssetValues(ctx context.Context, manualAssetId uint64) ([]models.ManualAssetValue, error)
+	// GetManualAssetValuesBefore returns the values recorded for every manual asset before the provided time, oldest
+	// first.
+	GetManualAssetValuesBefore(ctx context.Context, end time.Time) ([]models.ManualAssetValue, error)
 
 	// Plaid syncing
 	GetLastPlaidSync(ctx context.Context, linkId uint64) (*models.PlaidSync, error)
 	RecordPlaidSync(ctx context.Context, plaidLinkId uint64, trigger, nextCursor string, added, modified, removed int) error
 
 	GetNumberOfPlaidLinks(ctx context.Context) (int, error)
+	// GetReconciliations returns the bank account's reconciliations, most recent statement date first.
+	GetReconciliations(ctx context.Context, bankAccountId uint64, limit, offset int) ([]models.Reconciliation, error)
 	GetSettings(ctx context.Context) (*models.Settings, error)
 	GetSpending(ctx context.Context, bankAccountId uint64) ([]models.Spending, error)
 	GetSpendingByFundingSchedule(ctx context.Context, bankAccountId, fundingScheduleId uint64) ([]models.Spending, error)
 	GetSpendingById(ctx context.Context, bankAccountId, expenseId uint64) (*models.Spending, error)
+	// GetSpendingActuals returns the amount spent from each of the provided spending objects per day between the start
+	// (inclusive) and the end (exclusive). Split transactions count the amount of the split.
+	GetSpendingActuals(ctx context.Context, bankAccountId uint64, spendingIds []uint64, start, end time.Time) ([]models.SpendingActual, error)
 	GetSpendingExists(ctx context.Context, bankAccountId, spendingId uint64) (bool, error)
+	// GetSpendingLedger returns the ledger entries of the specified spending object, newest first. If after is provided
+	// then only entries created before that cursor are returned.
+	GetSpendingLedger(ctx context.Context, bankAccountId, spendingId uint64, limit, offset int, after *Cursor) ([]models.SpendingLedgerEntry, error)
+	// GetSpendingReport aggregates the account's transactions by period and group. Transfers, hidden transactions and
+	// deleted transactions are not included.
+	GetSpendingReport(ctx context.Context, options SpendingReportOptions) ([]models.SpendingReportRow, error)
+	GetSpendingSuggestion(ctx context.Context, bankAccountId, spendingSuggestionId uint64) (*models.SpendingSuggestion, error)
+	// GetSpendingSuggestions returns the pending spending suggestions for the specified bank account.
+	GetSpendingSuggestions(ctx context.Context, bankAccountId uint64) ([]models.SpendingSuggestion, error)
 	GetTransaction(ctx context.Context, bankAccountId, transactionId uint64) (*models.Transaction, error)
-	GetTransactions(ctx context.Context, bankAccountId uint64, limit, offset int) ([]models.Transaction, error)
+	GetTransactionAttachment(ctx context.Context, bankAccountId, transactionId, fileId uint64) (*models.File, error)
+	GetTransactionAttachments(ctx context.Context, bankAccountId, transactionId uint64) ([]models.File, error)
+	GetTransactionRule(ctx context.Context, transactionRuleId uint64) (*models.TransactionRule, error)
+	// GetTransactionRules returns the account's transaction rules in the order they are evaluated.
+	GetTransactionRules(ctx context.Context) ([]models.TransactionRule, error)
+	// GetTransactions returns the non-deleted transactions for a bank account that match the provided filters, newest
+	// first.
+	GetTransactions(ctx context.Context, bankAccountId uint64, limit, offset int, filters TransactionFilters) ([]models.Transaction, error)
 	// GetRecentDepositTransactions will return all deposit transactions for the specified bank account within the past
 	// 24 hours.
 	GetRecentDepositTransactions(ctx context.Context, bankAccountId uint64) ([]models.Transaction, error)
 	GetTransactionsByPlaidId(ctx context.Context, linkId uint64, plaidTransactionIds []string) (map[string]models.Transaction, error)
 	GetTransactionsByPlaidTransactionId(ctx context.Context, linkId uint64, plaidTransactionIds []string) ([]models.Transaction, error)
-	GetTransactionsForSpending(ctx context.Context, bankAccountId, spendingId uint64, limit, offset int) ([]models.Transaction, error)
+	// GetTransactionsByUploadIdentifier returns transactions that were imported from a file for the specified bank
+	// account, keyed by their upload identifier.
+	GetTransactionsByUploadIdentifier(ctx context.Context, bankAccountId uint64, uploadIdentifiers []string) (map[string]models.Transaction, error)
+	// GetTransactionsByDateRange returns the non-deleted transactions for a bank account between the two dates, inclusive.
+	GetTransactionsByDateRange(ctx context.Context, bankAccountId uint64, start, end time.Time) ([]models.Transaction, error)
+	// GetTransactionsByIds returns the non-deleted transactions with the provided Ids, keyed by their transaction Id.
+	GetTransactionsByIds(ctx context.Context, bankAccountId uint64, transactionIds []uint64) (map[uint64]models.Transaction, error)
+	GetTransactionsForSpending(ctx context.Context, bankAccountId, spendingId uint64, limit, offset int, after *Cursor) ([]models.Transaction, error)
 	InsertTransactions(ctx context.Context, transactions []models.Transaction) error
 	ProcessTransactionSpentFrom(ctx context.Context, bankAccountId uint64, input, existing *models.Transaction) (updatedExpenses []models.Spending, _ error)
+	// RecordManualAssetValue stores the value of the manual asset for the provided date and updates the asset's current
+	// value to its most recently dated value.
+	RecordManualAssetValue(ctx context.Context, asset *models.ManualAsset, date time.Time, value int64) error
+	// ReplaceSpendingSuggestions stores newly detected spending suggestions for a bank account, replacing any pending
+	// suggestions that were not detected again.
+	ReplaceSpendingSuggestions(ctx context.Context, bankAccountId uint64, suggestions []models.SpendingSuggestion) error
+	// RevertFundingEvent marks the funding event as reverted and stores the restored occurrences of its funding
+	// schedule. The contributions must be removed from the spending objects separately.
+	RevertFundingEvent(ctx context.Context, event *models.FundingEvent, fundingSchedule *models.FundingSchedule) error
+	// StreamTransactions calls the provided function for each transaction in the bank account, oldest first, without
+	// loading all of the transactions into memory at once.
+	StreamTransactions(ctx context.Context, bankAccountId uint64, start, end *time.Time, fn func(transaction models.Transaction, spendingNames []string) error) error
+	// UnlinkTransfer removes the link between both sides of a transfer and prevents them from being matched again.
+	UnlinkTransfer(ctx context.Context, bankAccountId, transactionId uint64) error
 	UpdateBankAccounts(ctx context.Context, accounts ...models.BankAccount) error
-	UpdateSpending(ctx context.Context, bankAccountId uint64, updates []models.Spending) error
+	UpdateBankAccountBalances(ctx context.Context, bankAccountId uint64, current, available int64) error
+	// UpdateManualAsset updates the name and type of the manual asset, but not its value.
+	UpdateManualAsset(ctx context.Context, asset *models.ManualAsset) error
+	// UpdateSpending updates the provided spending objects and records any change to their balances in the spending
+	// ledger with the provided reason and reference Id.
+	UpdateSpending(ctx context.Context, bankAccountId uint64, updates []models.Spending, reason models.SpendingLedgerReason, referenceId *uint64) error
+	UpdateSpendingSuggestion(ctx context.Context, suggestion *models.SpendingSuggestion) error
+	UpdateTransactionRule(ctx context.Context, rule *models.TransactionRule) error
 	UpdateLink(ctx context.Context, link *models.Link) error
 	// UpdateLinkManualSyncTimestampMaybe will take a link ID as a candidate to be manually resynced. If that link has not
 	// been manually synced in the last 30 minutes then it will bump the last manual sync timestamp on that link and
 	// return `true`. If the link has been manually synced in the last 30 minutes, then it will return `false`.
 	UpdateLinkManualSyncTimestampMaybe(ctx context.Context, linkId uint64) (ok bool, err error)
+	UpdateCategory(ctx context.Context, category *models.Category) error
 	UpdateFundingSchedule(ctx context.Context, fundingSchedule *models.FundingSchedule) error
 	UpdatePlaidLink(ctx context.Context, plaidLink *models.PlaidLink) error
 	UpdateTransaction(ctx context.Context, bankAccountId uint64, transaction *models.Transaction) error
+	// UpsertBalanceSnapshots stores the provided snapshots, replacing any existing snapshot for the same day.
+	UpsertBalanceSnapshots(ctx context.Context, snapshots []models.BalanceSnapshot) error
+	// UpsertCategoryMapping stores the mapping, replacing any existing mapping for the same Plaid category.
+	UpsertCategoryMapping(ctx context.Context, mapping *models.CategoryMapping) error
+	UpsertCSVMapping(ctx context.Context, mapping *models.CSVMapping) error
 
 	// UpdateTransactions is unique in that it REQUIRES that all data on each transaction object be populated. It is
 	// doing a bulk update, so if data is missing it has the potential to overwrite a transaction incorrectly.
 	UpdateTransactions(ctx context.Context, transactions []*models.Transaction) error
+	// UpdateTransactionsBulk stores many transaction updates at once, adjusting each affected spending object only once.
+	// Every update must be paired with the existing transaction at the same index.
+	UpdateTransactionsBulk(ctx context.Context, bankAccountId uint64, updates []*models.Transaction, existing []models.Transaction) ([]models.Spending, error)
 }
 
 type Repository interface {
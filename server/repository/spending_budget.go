@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+func (r *repositoryBase) GetSpendingActuals(
+	ctx context.Context,
+	bankAccountId uint64,
+	spendingIds []uint64,
+	start, end time.Time,
+) ([]models.SpendingActual, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"spendingIds":   spendingIds,
+		"start":         start,
+		"end":           end,
+	}
+
+	result := make([]models.SpendingActual, 0)
+	if len(spendingIds) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return result, nil
+	}
+
+	_, err := r.txn.QueryContext(span.Context(), &result, `
+		SELECT
+			"actual"."spending_id",
+			"actual"."date",
+			SUM("actual"."amount") AS "amount",
+			COUNT(DISTINCT "actual"."transaction_id") AS "transaction_count"
+		FROM (
+			SELECT
+				"transaction"."spending_id",
+				"transaction"."date",
+				"transaction"."amount",
+				"transaction"."transaction_id"
+			FROM "transactions" AS "transaction"
+			WHERE "transaction"."account_id" = ?0
+			  AND "transaction"."bank_account_id" = ?1
+			  AND "transaction"."deleted_at" IS NULL
+			  AND "transaction"."spending_id" IN (?2)
+			  AND "transaction"."date" >= ?3
+			  AND "transaction"."date" < ?4
+			UNION ALL
+			SELECT
+				"split"."spending_id",
+				"transaction"."date",
+				"split"."amount",
+				"transaction"."transaction_id"
+			FROM "transaction_splits" AS "split"
+			INNER JOIN "transactions" AS "transaction" ON
+				"transaction"."account_id" = "split"."account_id" AND
+				"transaction"."bank_account_id" = "split"."bank_account_id" AND
+				"transaction"."transaction_id" = "split"."transaction_id"
+			WHERE "split"."account_id" = ?0
+			  AND "split"."bank_account_id" = ?1
+			  AND "split"."spending_id" IN (?2)
+			  AND "transaction"."deleted_at" IS NULL
+			  AND "transaction"."date" >= ?3
+			  AND "transaction"."date" < ?4
+		) AS "actual"
+		GROUP BY "actual"."spending_id", "actual"."date"
+		ORDER BY "actual"."date"
+	`, r.AccountId(), bankAccountId, pg.In(spendingIds), start, end)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve spending actuals")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetSpendingLedger returns the ledger entries of the specified spending object, newest first. If after is provided then
+// only entries created before that cursor are returned and the offset should be zero.
+func (r *repositoryBase) GetSpendingLedger(
+	ctx context.Context,
+	bankAccountId, spendingId uint64,
+	limit, offset int,
+	after *Cursor,
+) ([]models.SpendingLedgerEntry, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"spendingId":    spendingId,
+		"limit":         limit,
+		"offset":        offset,
+		"after":         after,
+	}
+
+	items := make([]models.SpendingLedgerEntry, 0)
+	query := r.txn.ModelContext(span.Context(), &items).
+		Where(`"spending_ledger_entry"."account_id" = ?`, r.AccountId()).
+		Where(`"spending_ledger_entry"."bank_account_id" = ?`, bankAccountId).
+		Where(`"spending_ledger_entry"."spending_id" = ?`, spendingId)
+	if after != nil {
+		query = query.Where(`("spending_ledger_entry"."created_at", "spending_ledger_entry"."spending_ledger_entry_id") < (?, ?)`, after.Date, after.Id)
+	}
+	err := query.
+		Limit(limit).
+		Offset(offset).
+		Order(`created_at DESC`).
+		Order(`spending_ledger_entry_id DESC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve spending ledger")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// createSpendingLedgerEntries inserts the provided ledger entries. Entries are never updated or removed once they have
+// been created, they are only removed when their spending object is removed.
+func (r *repositoryBase) createSpendingLedgerEntries(ctx context.Context, entries []models.SpendingLedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"count": len(entries),
+	}
+
+	if _, err := r.txn.ModelContext(span.Context(), &entries).Insert(&entries); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create spending ledger entries")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// SpendingReportOptions describes what a spending report should aggregate.
+type SpendingReportOptions struct {
+	GroupBy  models.SpendingReportGroupBy
+	Interval models.SpendingReportInterval
+	// Start is inclusive, End is exclusive. Start should be the beginning of a period for the interval.
+	Start time.Time
+	End   time.Time
+	// Timezone is used to determine which period each transaction falls within.
+	Timezone *time.Location
+	// BankAccountId will limit the report to a single bank account when it is provided.
+	BankAccountId *uint64
+}
+
+// spendingReportPeriod returns the expression for the first day of the period each transaction falls within. Weeks are
+// shifted by a day because Postgres weeks start on Monday and ours start on Sunday.
+func spendingReportPeriod(interval models.SpendingReportInterval) string {
+	switch interval {
+	case models.WeeklySpendingReportInterval:
+		return `(date_trunc('week', ("transaction"."date" AT TIME ZONE ?) + interval '1 day') - interval '1 day')::date`
+	case models.YearlySpendingReportInterval:
+		return `(date_trunc('year', "transaction"."date" AT TIME ZONE ?))::date`
+	default:
+		return `(date_trunc('month', "transaction"."date" AT TIME ZONE ?))::date`
+	}
+}
+
+func (r *repositoryBase) GetSpendingReport(ctx context.Context, options SpendingReportOptions) ([]models.SpendingReportRow, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"groupBy":  options.GroupBy,
+		"interval": options.Interval,
+		"start":    options.Start,
+		"end":      options.End,
+	}
+
+	amount := pg.Safe(`"transaction"."amount"`)
+	rows := make([]models.SpendingReportRow, 0)
+	query := r.txn.ModelContext(span.Context(), (*models.Transaction)(nil)).
+		ColumnExpr(spendingReportPeriod(options.Interval)+` AS "period"`, options.Timezone.String())
+
+	switch options.GroupBy {
+	case models.SpendingSpendingReportGroupBy:
+		// Split transactions are reported under each of the spending objects they were split between.
+		amount = pg.Safe(`COALESCE("split"."amount", "transaction"."amount")`)
+		query = query.
+			Join(`LEFT JOIN "transaction_splits" AS "split"`).
+			JoinOn(`"split"."account_id" = "transaction"."account_id"`).
+			JoinOn(`"split"."bank_account_id" = "transaction"."bank_account_id"`).
+			JoinOn(`"split"."transaction_id" = "transaction"."transaction_id"`).
+			Join(`LEFT JOIN "spending" AS "spending"`).
+			JoinOn(`"spending"."account_id" = "transaction"."account_id"`).
+			JoinOn(`"spending"."spending_id" = COALESCE("split"."spending_id", "transaction"."spending_id")`).
+			ColumnExpr(`COALESCE("split"."spending_id", "transaction"."spending_id") AS "group_id"`).
+			ColumnExpr(`COALESCE("spending"."name", '') AS "group_name"`)
+	case models.CategorySpendingReportGroupBy:
+		query = query.
+			Join(`LEFT JOIN "categories" AS "category"`).
+			JoinOn(`"category"."account_id" = "transaction"."account_id"`).
+			JoinOn(`"category"."category_id" = "transaction"."category_id"`).
+			ColumnExpr(`"transaction"."category_id" AS "group_id"`).
+			ColumnExpr(`COALESCE("category"."name", '') AS "group_name"`)
+	case models.MerchantSpendingReportGroupBy:
+		query = query.
+			ColumnExpr(`NULL::bigint AS "group_id"`).
+			ColumnExpr(`COALESCE(NULLIF(TRIM("transaction"."merchant_name"), ''), "transaction"."name") AS "group_name"`)
+	case models.BankAccountSpendingReportGroupBy:
+		query = query.
+			Join(`INNER JOIN "bank_accounts" AS "bank_account"`).
+			JoinOn(`"bank_account"."account_id" = "transaction"."account_id"`).
+			JoinOn(`"bank_account"."bank_account_id" = "transaction"."bank_account_id"`).
+			ColumnExpr(`"transaction"."bank_account_id" AS "group_id"`).
+			ColumnExpr(`"bank_account"."name" AS "group_name"`)
+	default:
+		span.Status = sentry.SpanStatusInvalidArgument
+		return nil, errors.Errorf("invalid spending report group %q", options.GroupBy)
+	}
+
+	query = query.
+		ColumnExpr(`COALESCE(SUM(CASE WHEN ? < 0 THEN -? ELSE 0 END), 0) AS "income"`, amount, amount).
+		ColumnExpr(`COALESCE(SUM(CASE WHEN ? > 0 THEN ? ELSE 0 END), 0) AS "expenses"`, amount, amount).
+		ColumnExpr(`COUNT(DISTINCT "transaction"."transaction_id") AS "transaction_count"`).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."deleted_at" IS NULL`).
+		Where(`"transaction"."is_hidden" = false`).
+		Where(`"transaction"."transfer_transaction_id" IS NULL`).
+		Where(`"transaction"."date" >= ?`, options.Start).
+		Where(`"transaction"."date" < ?`, options.End).
+		Group("period", "group_id", "group_name").
+		Order("period")
+
+	if options.BankAccountId != nil {
+		query = query.Where(`"transaction"."bank_account_id" = ?`, *options.BankAccountId)
+	}
+
+	if err := query.Select(&rows); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve spending report")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return rows, nil
+}
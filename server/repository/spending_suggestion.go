@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetSpendingSuggestions returns the pending spending suggestions for the specified bank account, ordered by name.
+func (r *repositoryBase) GetSpendingSuggestions(ctx context.Context, bankAccountId uint64) ([]models.SpendingSuggestion, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+	}
+
+	items := make([]models.SpendingSuggestion, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"spending_suggestion"."account_id" = ?`, r.AccountId()).
+		Where(`"spending_suggestion"."bank_account_id" = ?`, bankAccountId).
+		Where(`"spending_suggestion"."status" = ?`, models.SpendingSuggestionStatusPending).
+		Order(`name ASC`).
+		Order(`spending_suggestion_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve spending suggestions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+func (r *repositoryBase) GetSpendingSuggestion(ctx context.Context, bankAccountId, spendingSuggestionId uint64) (*models.SpendingSuggestion, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":        bankAccountId,
+		"spendingSuggestionId": spendingSuggestionId,
+	}
+
+	var result models.SpendingSuggestion
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"spending_suggestion"."account_id" = ?`, r.AccountId()).
+		Where(`"spending_suggestion"."bank_account_id" = ?`, bankAccountId).
+		Where(`"spending_suggestion"."spending_suggestion_id" = ?`, spendingSuggestionId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve spending suggestion")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+func (r *repositoryBase) UpdateSpendingSuggestion(ctx context.Context, suggestion *models.SpendingSuggestion) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	suggestion.AccountId = r.AccountId()
+	suggestion.UpdatedAt = time.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), suggestion).
+		WherePK().
+		Update(suggestion)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update spending suggestion")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// ReplaceSpendingSuggestions stores the latest detected suggestions for the specified bank account. Pending
+// suggestions that were detected again are updated, and pending suggestions that were not detected again are removed.
+// Suggestions that have already been accepted or dismissed are left alone so that they are not suggested again.
+func (r *repositoryBase) ReplaceSpendingSuggestions(ctx context.Context, bankAccountId uint64, suggestions []models.SpendingSuggestion) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"count":         len(suggestions),
+	}
+
+	now := time.Now().UTC()
+	for i := range suggestions {
+		suggestion := suggestions[i]
+		suggestion.SpendingSuggestionId = 0
+		suggestion.AccountId = r.AccountId()
+		suggestion.BankAccountId = bankAccountId
+		suggestion.Status = models.SpendingSuggestionStatusPending
+		suggestion.SpendingId = nil
+		suggestion.CreatedAt = now
+		suggestion.UpdatedAt = now
+
+		_, err := r.txn.ModelContext(span.Context(), &suggestion).
+			OnConflict(`ON CONSTRAINT uq_spending_suggestions_merchant DO UPDATE`).
+			Set(`"name" = EXCLUDED."name"`).
+			Set(`"ruleset" = EXCLUDED."ruleset"`).
+			Set(`"target_amount" = EXCLUDED."target_amount"`).
+			Set(`"next_recurrence" = EXCLUDED."next_recurrence"`).
+			Set(`"last_occurrence" = EXCLUDED."last_occurrence"`).
+			Set(`"occurrences" = EXCLUDED."occurrences"`).
+			Set(`"updated_at" = EXCLUDED."updated_at"`).
+			Where(`"spending_suggestion"."status" = ?`, models.SpendingSuggestionStatusPending).
+			Insert(&suggestion)
+		// Nothing is returned when the suggestion was already accepted or dismissed, which is fine.
+		if err != nil && errors.Cause(err) != pg.ErrNoRows {
+			span.Status = sentry.SpanStatusInternalError
+			return errors.Wrap(err, "failed to store spending suggestion")
+		}
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &models.SpendingSuggestion{}).
+		Where(`"spending_suggestion"."account_id" = ?`, r.AccountId()).
+		Where(`"spending_suggestion"."bank_account_id" = ?`, bankAccountId).
+		Where(`"spending_suggestion"."status" = ?`, models.SpendingSuggestionStatusPending).
+		Where(`"spending_suggestion"."updated_at" < ?`, now).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove outdated spending suggestions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
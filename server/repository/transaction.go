@@ -6,6 +6,7 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
 	"github.com/monetr/monetr/server/models"
 	"github.com/pkg/errors"
 )
@@ -64,7 +65,43 @@ func (r *repositoryBase) GetTransactionsByPlaidId(ctx context.Context, linkId ui
 	return result, nil
 }
 
-func (r *repositoryBase) GetTransactions(ctx context.Context, bankAccountId uint64, limit, offset int) ([]models.Transaction, error) {
+// GetTransactionsByUploadIdentifier returns a map of the transactions in the specified bank account whose upload
+// identifier is one of the ones provided, keyed by that identifier. Soft-deleted transactions are included so that a
+// transaction that was deleted by the user is not imported again.
+func (r *repositoryBase) GetTransactionsByUploadIdentifier(ctx context.Context, bankAccountId uint64, uploadIdentifiers []string) (map[string]models.Transaction, error) {
+	if len(uploadIdentifiers) == 0 {
+		return map[string]models.Transaction{}, nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+	}
+
+	var items []models.Transaction
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		WhereIn(`"transaction"."upload_identifier" IN (?)`, uploadIdentifiers).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transactions by upload identifier")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	result := make(map[string]models.Transaction, len(items))
+	for _, item := range items {
+		result[*item.UploadIdentifier] = item
+	}
+
+	return result, nil
+}
+
+func (r *repositoryBase) GetTransactions(ctx context.Context, bankAccountId uint64, limit, offset int, filters TransactionFilters) ([]models.Transaction, error) {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
@@ -73,13 +110,15 @@ func (r *repositoryBase) GetTransactions(ctx context.Context, bankAccountId uint
 		"bankAccountId": bankAccountId,
 		"limit":         limit,
 		"offset":        offset,
+		"filters":       filters,
 	}
 
 	var items []models.Transaction
-	err := r.txn.ModelContext(span.Context(), &items).
+	query := r.txn.ModelContext(span.Context(), &items).
 		Where(`"transaction"."account_id" = ?`, r.AccountId()).
 		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
-		Where(`"transaction"."deleted_at" IS NULL`).
+		Where(`"transaction"."deleted_at" IS NULL`)
+	err := filters.apply(query).
 		Limit(limit).
 		Offset(offset).
 		Order(`date DESC`).
@@ -92,10 +131,16 @@ func (r *repositoryBase) GetTransactions(ctx context.Context, bankAccountId uint
 
 	span.Status = sentry.SpanStatusOK
 
+	if err = r.populateTransactionSplits(span.Context(), items); err != nil {
+		return nil, err
+	}
+
 	return items, nil
 }
 
-func (r *repositoryBase) GetTransactionsForSpending(ctx context.Context, bankAccountId, spendingId uint64, limit, offset int) ([]models.Transaction, error) {
+// GetTransactionsForSpending returns the transactions that were spent from the specified spending object, newest first.
+// If after is provided then only transactions after that cursor are returned and the offset should be zero.
+func (r *repositoryBase) GetTransactionsForSpending(ctx context.Context, bankAccountId, spendingId uint64, limit, offset int, after *Cursor) ([]models.Transaction, error) {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
@@ -105,14 +150,19 @@ func (r *repositoryBase) GetTransactionsForSpending(ctx context.Context, bankAcc
 		"spendingId":    spendingId,
 		"limit":         limit,
 		"offset":        offset,
+		"after":         after,
 	}
 
 	var items []models.Transaction
-	err := r.txn.ModelContext(span.Context(), &items).
+	query := r.txn.ModelContext(span.Context(), &items).
 		Where(`"transaction"."account_id" = ?`, r.AccountId()).
 		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
-		Where(`"transaction"."spending_id" = ?`, spendingId).
-		Where(`"transaction"."deleted_at" IS NULL`).
+		Where(`("transaction"."spending_id" = ? OR `+transactionSplitExists+`)`, spendingId, spendingId).
+		Where(`"transaction"."deleted_at" IS NULL`)
+	if after != nil {
+		query = query.Where(`("transaction"."date", "transaction"."transaction_id") < (?, ?)`, after.Date, after.Id)
+	}
+	err := query.
 		Limit(limit).
 		Offset(offset).
 		Order(`date DESC`).
@@ -125,6 +175,43 @@ func (r *repositoryBase) GetTransactionsForSpending(ctx context.Context, bankAcc
 
 	span.Status = sentry.SpanStatusOK
 
+	if err = r.populateTransactionSplits(span.Context(), items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetTransactionsByDateRange returns all of the non-deleted transactions for the specified bank account whose date is
+// within the provided range, inclusive of both the start and the end.
+func (r *repositoryBase) GetTransactionsByDateRange(ctx context.Context, bankAccountId uint64, start, end time.Time) ([]models.Transaction, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+		"start":         start,
+		"end":           end,
+	}
+
+	items := make([]models.Transaction, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction"."date" >= ?`, start).
+		Where(`"transaction"."date" <= ?`, end).
+		Where(`"transaction"."deleted_at" IS NULL`).
+		Order(`date DESC`).
+		Order(`transaction_id DESC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transactions by date range")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
 	return items, nil
 }
 
@@ -150,7 +237,12 @@ func (r *repositoryBase) GetTransaction(ctx context.Context, bankAccountId, tran
 
 	span.Status = sentry.SpanStatusOK
 
-	return &result, nil
+	items := []models.Transaction{result}
+	if err = r.populateTransactionSplits(span.Context(), items); err != nil {
+		return nil, err
+	}
+
+	return &items[0], nil
 }
 
 func (r *repositoryBase) CreateTransaction(ctx context.Context, bankAccountId uint64, transaction *models.Transaction) error {
@@ -238,8 +330,12 @@ func (r *repositoryBase) DeleteTransaction(ctx context.Context, bankAccountId, t
 		Where(`"transaction"."transaction_id" = ?`, transactionId).
 		Set(`"deleted_at" = ?`, time.Now().UTC()).
 		Update()
+	if err != nil {
+		return errors.Wrap(err, "failed to soft-delete transaction")
+	}
 
-	return errors.Wrap(err, "failed to soft-delete transaction")
+	// If the deleted transaction was one side of a transfer then the other side is no longer a transfer.
+	return r.unlinkDeletedTransfer(span.Context(), transactionId)
 }
 
 func (r *repositoryBase) GetTransactionsByPlaidTransactionId(ctx context.Context, linkId uint64, plaidTransactionIds []string) ([]models.Transaction, error) {
@@ -258,6 +354,10 @@ func (r *repositoryBase) GetTransactionsByPlaidTransactionId(ctx context.Context
 		return nil, errors.Wrap(err, "failed to retrieve transactions by plaid Id")
 	}
 
+	if err = r.populateTransactionSplits(span.Context(), result); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -289,6 +389,12 @@ func (r *repositoryBase) ProcessTransactionSpentFrom(ctx context.Context, bankAc
 		return nil, err
 	}
 
+	// Split transactions are handled separately, this also covers a transaction changing from a single spending object
+	// to splits or back again.
+	if len(input.Splits) > 0 || len(existing.Splits) > 0 {
+		return r.processTransactionSplits(span.Context(), account, bankAccountId, input, existing)
+	}
+
 	const (
 		AddExpense = iota
 		ChangeExpense
@@ -397,7 +503,13 @@ func (r *repositoryBase) ProcessTransactionSpentFrom(ctx context.Context, bankAc
 		expenseUpdates = append(expenseUpdates, *newExpense)
 	}
 
-	return expenseUpdates, r.UpdateSpending(span.Context(), bankAccountId, expenseUpdates)
+	return expenseUpdates, r.UpdateSpending(
+		span.Context(),
+		bankAccountId,
+		expenseUpdates,
+		models.SpendingLedgerReasonTransaction,
+		myownsanity.Uint64P(input.TransactionId),
+	)
 }
 
 func (r *repositoryBase) AddExpenseToTransaction(ctx context.Context, transaction *models.Transaction, spending *models.Spending) error {
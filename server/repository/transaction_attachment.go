@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// CreateTransactionAttachment attaches a file that has already been recorded with CreateFile to a transaction.
+func (r *repositoryBase) CreateTransactionAttachment(ctx context.Context, attachment *models.TransactionAttachment) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": attachment.BankAccountId,
+		"transactionId": attachment.TransactionId,
+		"fileId":        attachment.FileId,
+	}
+
+	attachment.AccountId = r.AccountId()
+	attachment.CreatedAt = time.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), attachment).Insert(attachment)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create transaction attachment")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// GetTransactionAttachments returns the files attached to the specified transaction, oldest first.
+func (r *repositoryBase) GetTransactionAttachments(ctx context.Context, bankAccountId, transactionId uint64) ([]models.File, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"transactionId": transactionId,
+	}
+
+	items := make([]models.File, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Join(`INNER JOIN "transaction_attachments" AS "transaction_attachment"`).
+		JoinOn(`"transaction_attachment"."account_id" = "file"."account_id" AND "transaction_attachment"."file_id" = "file"."file_id"`).
+		Where(`"transaction_attachment"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_attachment"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_attachment"."transaction_id" = ?`, transactionId).
+		Order(`file.created_at ASC`).
+		Order(`file.file_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction attachments")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// GetTransactionAttachment returns the specified file if it is attached to the specified transaction.
+func (r *repositoryBase) GetTransactionAttachment(ctx context.Context, bankAccountId, transactionId, fileId uint64) (*models.File, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"transactionId": transactionId,
+		"fileId":        fileId,
+	}
+
+	var result models.File
+	err := r.txn.ModelContext(span.Context(), &result).
+		Join(`INNER JOIN "transaction_attachments" AS "transaction_attachment"`).
+		JoinOn(`"transaction_attachment"."account_id" = "file"."account_id" AND "transaction_attachment"."file_id" = "file"."file_id"`).
+		Where(`"transaction_attachment"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_attachment"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_attachment"."transaction_id" = ?`, transactionId).
+		Where(`"transaction_attachment"."file_id" = ?`, fileId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction attachment")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+// DeleteTransactionAttachment removes the record of a file that is attached to the specified transaction. The file
+// itself is not removed from storage.
+func (r *repositoryBase) DeleteTransactionAttachment(ctx context.Context, bankAccountId, transactionId, fileId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"transactionId": transactionId,
+		"fileId":        fileId,
+	}
+
+	// The attachment record is removed along with the file by the foreign key.
+	result, err := r.txn.ModelContext(span.Context(), &models.File{}).
+		Where(`"file"."account_id" = ?`, r.AccountId()).
+		Where(`"file"."bank_account_id" = ?`, bankAccountId).
+		Where(`"file"."file_id" = ?`, fileId).
+		Where(`EXISTS (SELECT 1 FROM "transaction_attachments" AS "transaction_attachment" WHERE "transaction_attachment"."account_id" = "file"."account_id" AND "transaction_attachment"."file_id" = "file"."file_id" AND "transaction_attachment"."transaction_id" = ?)`, transactionId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove transaction attachment")
+	}
+
+	if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to remove transaction attachment")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// DeleteTransactionAttachments removes the records of every file attached to the provided transactions and returns
+// the files that were removed. The files themselves are not removed from storage, the caller is responsible for that
+// once the removal has been committed.
+func (r *repositoryBase) DeleteTransactionAttachments(ctx context.Context, transactionIds []uint64) ([]models.File, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"transactionIds": transactionIds,
+	}
+
+	if len(transactionIds) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil, nil
+	}
+
+	// The attachment records are removed along with the files by the foreign key.
+	items := make([]models.File, 0)
+	_, err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"file"."account_id" = ?`, r.AccountId()).
+		Where(`"file"."file_id" IN (SELECT "transaction_attachment"."file_id" FROM "transaction_attachments" AS "transaction_attachment" WHERE "transaction_attachment"."account_id" = ? AND "transaction_attachment"."transaction_id" IN (?))`, r.AccountId(), pg.In(transactionIds)).
+		Returning(`*`).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to remove transaction attachments")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
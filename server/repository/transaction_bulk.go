@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetTransactionsByIds returns the non-deleted transactions with the provided Ids for the specified bank account,
+// keyed by their transaction Id. Transactions that do not exist are not included.
+func (r *repositoryBase) GetTransactionsByIds(ctx context.Context, bankAccountId uint64, transactionIds []uint64) (map[uint64]models.Transaction, error) {
+	if len(transactionIds) == 0 {
+		return map[uint64]models.Transaction{}, nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":  bankAccountId,
+		"transactionIds": transactionIds,
+	}
+
+	items := make([]models.Transaction, 0, len(transactionIds))
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction"."deleted_at" IS NULL`).
+		WhereIn(`"transaction"."transaction_id" IN (?)`, transactionIds).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transactions by Id")
+	}
+
+	if err = r.populateTransactionSplits(span.Context(), items); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, err
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	result := make(map[uint64]models.Transaction, len(items))
+	for _, item := range items {
+		result[item.TransactionId] = item
+	}
+
+	return result, nil
+}
+
+// UpdateTransactionsBulk stores many transaction updates at once. Each update must be paired with the existing
+// transaction at the same index, and must contain all of the transaction's data. When a transaction is spent from
+// something different, everything that was deducted for the existing transaction is returned before anything is
+// deducted for the updates. Each affected spending object is only recalculated and stored once, and the updated
+// spending objects are returned. Every change to a spending object is recorded in its ledger against the transaction
+// that caused it. Changes to a transfer are applied to the other side of the transfer as well.
+func (r *repositoryBase) UpdateTransactionsBulk(ctx context.Context, bankAccountId uint64, updates []*models.Transaction, existing []models.Transaction) ([]models.Spending, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if len(updates) != len(existing) {
+		return nil, errors.New("every transaction update must have an existing transaction")
+	}
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"count":         len(updates),
+	}
+
+	if len(updates) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil, nil
+	}
+
+	account, err := r.GetAccount(span.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	spending := map[uint64]*models.Spending{}
+	getSpending := func(spendingId uint64) (*models.Spending, error) {
+		if item, ok := spending[spendingId]; ok {
+			return item, nil
+		}
+
+		item, err := r.GetSpendingById(span.Context(), bankAccountId, spendingId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve spending %d for transaction", spendingId)
+		}
+		spending[spendingId] = item
+
+		return item, nil
+	}
+
+	// Ledger entries are created for each transaction as it is processed, so that every entry references the
+	// transaction that caused it even though the spending objects are only stored once.
+	now := r.clock.Now()
+	entries := make([]models.SpendingLedgerEntry, 0)
+	record := func(transactionId uint64, before []models.Spending, after []*models.Spending) {
+		current := make([]models.Spending, len(after))
+		for i := range after {
+			current[i] = *after[i]
+		}
+		entries = append(entries, models.NewSpendingLedgerEntries(
+			before,
+			current,
+			models.SpendingLedgerReasonTransaction,
+			&transactionId,
+			now,
+		)...)
+	}
+
+	changed := make([]bool, len(updates))
+	for i := range updates {
+		changed[i] = !uint64PEqual(updates[i].SpendingId, existing[i].SpendingId) ||
+			!models.TransactionSplitsEqual(updates[i].Splits, existing[i].Splits)
+	}
+
+	// Return everything that was taken for the existing transactions first. That way a transaction moving to a spending
+	// object that another transaction is moving away from can use the returned funds.
+	for i := range existing {
+		if !changed[i] {
+			continue
+		}
+
+		before := make([]models.Spending, 0, len(existing[i].Splits)+1)
+		after := make([]*models.Spending, 0, len(existing[i].Splits)+1)
+		if existing[i].SpendingId != nil && existing[i].SpendingAmount != nil {
+			item, err := getSpending(*existing[i].SpendingId)
+			if err != nil {
+				return nil, err
+			}
+			before = append(before, *item)
+			after = append(after, item)
+			models.ReturnToSpending(item, *existing[i].SpendingAmount)
+		}
+
+		for _, split := range existing[i].Splits {
+			item, err := getSpending(split.SpendingId)
+			if err != nil {
+				return nil, err
+			}
+			before = append(before, *item)
+			after = append(after, item)
+			models.ReturnToSpending(item, split.SpendingAmount)
+		}
+		record(existing[i].TransactionId, before, after)
+	}
+
+	for i, update := range updates {
+		if !changed[i] {
+			continue
+		}
+
+		if len(update.Splits) > 0 {
+			return nil, errors.New("splits cannot be changed in a bulk update")
+		}
+
+		update.SpendingAmount = nil
+		if update.SpendingId != nil {
+			item, err := getSpending(*update.SpendingId)
+			if err != nil {
+				return nil, err
+			}
+			before := *item
+			deducted := models.DeductFromSpending(item, update.Amount)
+			update.SpendingAmount = &deducted
+			record(update.TransactionId, []models.Spending{before}, []*models.Spending{item})
+		}
+
+		if len(existing[i].Splits) > 0 {
+			if err := r.replaceTransactionSplits(span.Context(), bankAccountId, update.TransactionId, nil); err != nil {
+				return nil, err
+			}
+			update.Splits = nil
+		}
+	}
+
+	spendingIds := make([]uint64, 0, len(spending))
+	for spendingId := range spending {
+		spendingIds = append(spendingIds, spendingId)
+	}
+	sort.Slice(spendingIds, func(i, j int) bool {
+		return spendingIds[i] < spendingIds[j]
+	})
+
+	updatedSpending := make([]models.Spending, 0, len(spendingIds))
+	for _, spendingId := range spendingIds {
+		item := spending[spendingId]
+		if err := item.CalculateNextContribution(
+			span.Context(),
+			account.Timezone,
+			item.FundingSchedule,
+			now,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to calculate next contribution for transaction spending")
+		}
+		item.AccountId = r.AccountId()
+		item.BankAccountId = bankAccountId
+		updatedSpending = append(updatedSpending, *item)
+	}
+
+	if len(updatedSpending) > 0 {
+		if err = r.storeSpending(span.Context(), updatedSpending, entries); err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return nil, err
+		}
+	}
+
+	if err = r.UpdateTransactions(span.Context(), updates); err != nil {
+		return nil, err
+	}
+
+	// If a transfer was confirmed or unlinked then the other side of the transfer needs to be changed too. This is done
+	// after the transactions themselves are stored in case both sides are part of this update.
+	for i, update := range updates {
+		if existing[i].TransferTransactionId == nil ||
+			(uint64PEqual(update.TransferTransactionId, existing[i].TransferTransactionId) &&
+				transferStatusPEqual(update.TransferStatus, existing[i].TransferStatus)) {
+			continue
+		}
+
+		query := r.txn.ModelContext(span.Context(), &models.Transaction{}).
+			Where(`"transaction"."account_id" = ?`, r.AccountId()).
+			Where(`"transaction"."transaction_id" = ?`, *existing[i].TransferTransactionId).
+			Set(`"transfer_status" = ?`, update.TransferStatus)
+		if update.TransferTransactionId == nil {
+			query = query.Set(`"transfer_transaction_id" = NULL`)
+		}
+		if _, err = query.Update(); err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return nil, errors.Wrap(err, "failed to update the other side of a transfer")
+		}
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return updatedSpending, nil
+}
+
+func uint64PEqual(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func transferStatusPEqual(a, b *models.TransferStatus) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
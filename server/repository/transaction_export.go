@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// transactionExportItem is a transaction along with the names of the spending objects it is spent from, either directly
+// or through its splits.
+type transactionExportItem struct {
+	tableName string `pg:"transactions,alias:transaction"`
+
+	models.Transaction
+	SpendingNames []string `pg:"spending_names,array"`
+}
+
+// transactionExportSpendingNames selects the names of the spending objects a transaction is spent from, whether that is
+// directly or through any of its splits.
+const transactionExportSpendingNames = `ARRAY(
+	SELECT "spending"."name"
+	FROM "spending" AS "spending"
+	WHERE
+		"spending"."account_id" = "transaction"."account_id" AND
+		"spending"."bank_account_id" = "transaction"."bank_account_id" AND
+		(
+			"spending"."spending_id" = "transaction"."spending_id" OR
+			"spending"."spending_id" IN (
+				SELECT "transaction_split"."spending_id"
+				FROM "transaction_splits" AS "transaction_split"
+				WHERE
+					"transaction_split"."account_id" = "transaction"."account_id" AND
+					"transaction_split"."transaction_id" = "transaction"."transaction_id"
+			)
+		)
+	ORDER BY "spending"."name"
+) AS "spending_names"`
+
+// StreamTransactions calls the provided function for each non-deleted transaction in the bank account, oldest first,
+// along with the names of the spending objects the transaction is spent from. Transactions are read from the database
+// one at a time rather than all at once, so this can be used for bank accounts with any amount of history. If start or
+// end are provided then only transactions on or after start, and before end, are included. If the function returns an
+// error then no more transactions are read and that error is returned.
+func (r *repositoryBase) StreamTransactions(
+	ctx context.Context,
+	bankAccountId uint64,
+	start, end *time.Time,
+	fn func(transaction models.Transaction, spendingNames []string) error,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"start":         start,
+		"end":           end,
+	}
+
+	query := r.txn.ModelContext(span.Context(), (*transactionExportItem)(nil)).
+		ColumnExpr(`"transaction".*`).
+		ColumnExpr(transactionExportSpendingNames).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction"."deleted_at" IS NULL`)
+	if start != nil {
+		query = query.Where(`"transaction"."date" >= ?`, *start)
+	}
+	if end != nil {
+		query = query.Where(`"transaction"."date" < ?`, *end)
+	}
+
+	count := 0
+	err := query.
+		Order(`date ASC`).
+		Order(`transaction_id ASC`).
+		ForEach(func(item *transactionExportItem) error {
+			count++
+			return fn(item.Transaction, item.SpendingNames)
+		})
+	span.Data["count"] = count
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to stream transactions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// transactionSearchVector must match the expression used by the ix_transactions_search index exactly, otherwise
+// searches will not be able to use the index.
+const transactionSearchVector = `to_tsvector('simple', coalesce("transaction"."name", '') || ' ' || coalesce("transaction"."merchant_name", '') || ' ' || coalesce("transaction"."original_name", ''))`
+
+// transactionSplitExists is used to find transactions that have a split for a spending object. It takes the spending Id
+// as its only parameter.
+const transactionSplitExists = `EXISTS (SELECT 1 FROM "transaction_splits" AS "split" WHERE "split"."account_id" = "transaction"."account_id" AND "split"."bank_account_id" = "transaction"."bank_account_id" AND "split"."transaction_id" = "transaction"."transaction_id" AND "split"."spending_id" = ?)`
+
+// transactionCategoryTree is used to find transactions assigned to a category or to any of its descendants. It takes
+// the category Id as its only parameter.
+const transactionCategoryTree = `("transaction"."category_id", "transaction"."account_id") IN (WITH RECURSIVE "tree" AS (SELECT "category"."category_id", "category"."account_id" FROM "categories" AS "category" WHERE "category"."category_id" = ? UNION ALL SELECT "child"."category_id", "child"."account_id" FROM "categories" AS "child" INNER JOIN "tree" ON "child"."account_id" = "tree"."account_id" AND "child"."parent_category_id" = "tree"."category_id") SELECT "tree"."category_id", "tree"."account_id" FROM "tree")`
+
+// transactionSplitsNotExist is used to find transactions that do not have any splits.
+const transactionSplitsNotExist = `NOT EXISTS (SELECT 1 FROM "transaction_splits" AS "split" WHERE "split"."account_id" = "transaction"."account_id" AND "split"."bank_account_id" = "transaction"."bank_account_id" AND "split"."transaction_id" = "transaction"."transaction_id")`
+
+type TransactionDirection string
+
+const (
+	// TransactionDirectionDebit is money leaving the account, these transactions have a positive amount.
+	TransactionDirectionDebit TransactionDirection = "debit"
+	// TransactionDirectionCredit is money entering the account, these transactions have a negative amount.
+	TransactionDirectionCredit TransactionDirection = "credit"
+)
+
+// TransactionFilters narrows down the transactions returned when listing transactions. The zero value does not filter
+// anything.
+type TransactionFilters struct {
+	// StartDate is inclusive, only transactions on or after this time will be returned.
+	StartDate *time.Time
+	// EndDate is exclusive, only transactions before this time will be returned.
+	EndDate *time.Time
+	// MinimumAmount and MaximumAmount are compared against the absolute amount of the transaction in cents. Direction
+	// should be used to filter by whether the transaction is a debit or a credit. Both are inclusive.
+	MinimumAmount *int64
+	MaximumAmount *int64
+	Direction     TransactionDirection
+	IsPending     *bool
+	// SpendingId will only return transactions spent from the specified spending object, including transactions that
+	// have a split for it.
+	SpendingId *uint64
+	// Unassigned will only return transactions that are not spent from any spending object and are not split.
+	Unassigned bool
+	// CategoryId will only return transactions assigned to the specified category or to any of its child categories.
+	CategoryId *uint64
+	// Uncategorized will only return transactions that have not been assigned a category.
+	Uncategorized bool
+	// Search is matched against the beginning of each word in the name, merchant name and original name of the
+	// transaction. Every word in the search must match.
+	Search string
+	// After will only return transactions that come after the cursor when sorted by date and then by transaction Id,
+	// both descending. This should be used instead of an offset when paging through transactions.
+	After *Cursor
+}
+
+func (f TransactionFilters) apply(query *orm.Query) *orm.Query {
+	if f.StartDate != nil {
+		query = query.Where(`"transaction"."date" >= ?`, *f.StartDate)
+	}
+
+	if f.EndDate != nil {
+		query = query.Where(`"transaction"."date" < ?`, *f.EndDate)
+	}
+
+	if f.MinimumAmount != nil {
+		query = query.Where(`abs("transaction"."amount") >= ?`, *f.MinimumAmount)
+	}
+
+	if f.MaximumAmount != nil {
+		query = query.Where(`abs("transaction"."amount") <= ?`, *f.MaximumAmount)
+	}
+
+	switch f.Direction {
+	case TransactionDirectionDebit:
+		query = query.Where(`"transaction"."amount" > 0`)
+	case TransactionDirectionCredit:
+		query = query.Where(`"transaction"."amount" < 0`)
+	}
+
+	if f.IsPending != nil {
+		query = query.Where(`"transaction"."is_pending" = ?`, *f.IsPending)
+	}
+
+	if f.Unassigned {
+		query = query.Where(`"transaction"."spending_id" IS NULL`).Where(transactionSplitsNotExist)
+	} else if f.SpendingId != nil {
+		query = query.Where(`("transaction"."spending_id" = ? OR `+transactionSplitExists+`)`, *f.SpendingId, *f.SpendingId)
+	}
+
+	if f.Uncategorized {
+		query = query.Where(`"transaction"."category_id" IS NULL`)
+	} else if f.CategoryId != nil {
+		query = query.Where(transactionCategoryTree, *f.CategoryId)
+	}
+
+	if search := buildSearchQuery(f.Search); search != "" {
+		query = query.Where(transactionSearchVector+` @@ to_tsquery('simple', ?)`, search)
+	}
+
+	if f.After != nil {
+		query = query.Where(`("transaction"."date", "transaction"."transaction_id") < (?, ?)`, f.After.Date, f.After.Id)
+	}
+
+	return query
+}
+
+// buildSearchQuery converts user input into a tsquery that will match words starting with each of the words provided.
+// Anything that is not a letter or a number is treated as a word separator, so the result is always a valid tsquery.
+func buildSearchQuery(input string) string {
+	words := strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
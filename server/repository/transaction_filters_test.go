@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSearchQuery(t *testing.T) {
+	cases := map[string]string{
+		"":                    "",
+		"   ":                 "",
+		"Wendy's":             "wendy:* & s:*",
+		"starbucks coffee":    "starbucks:* & coffee:*",
+		"CHECKCARD #1234":     "checkcard:* & 1234:*",
+		"foo & bar | !baz:*":  "foo:* & bar:* & baz:*",
+		"café":                "café:*",
+		"'); DROP TABLE x;--": "drop:* & table:* & x:*",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, buildSearchQuery(input), "search query for %q", input)
+	}
+}
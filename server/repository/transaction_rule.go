@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetTransactionRules returns all of the transaction rules for the current account in the order they are evaluated.
+func (r *repositoryBase) GetTransactionRules(ctx context.Context) ([]models.TransactionRule, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	items := make([]models.TransactionRule, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"transaction_rule"."account_id" = ?`, r.AccountId()).
+		Order(`priority ASC`).
+		Order(`transaction_rule_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction rules")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+func (r *repositoryBase) GetTransactionRule(ctx context.Context, transactionRuleId uint64) (*models.TransactionRule, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"transactionRuleId": transactionRuleId,
+	}
+
+	var result models.TransactionRule
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"transaction_rule"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_rule"."transaction_rule_id" = ?`, transactionRuleId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction rule")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+func (r *repositoryBase) CreateTransactionRule(ctx context.Context, rule *models.TransactionRule) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := time.Now().UTC()
+	rule.TransactionRuleId = 0
+	rule.AccountId = r.AccountId()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	if _, err := r.txn.ModelContext(span.Context(), rule).Insert(rule); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create transaction rule")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) UpdateTransactionRule(ctx context.Context, rule *models.TransactionRule) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"transactionRuleId": rule.TransactionRuleId,
+	}
+
+	rule.AccountId = r.AccountId()
+	rule.UpdatedAt = time.Now().UTC()
+
+	result, err := r.txn.ModelContext(span.Context(), rule).
+		ExcludeColumn("created_at").
+		WherePK().
+		Returning(`*`).
+		Update(rule)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update transaction rule")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to update transaction rule")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) DeleteTransactionRule(ctx context.Context, transactionRuleId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"transactionRuleId": transactionRuleId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &models.TransactionRule{}).
+		Where(`"transaction_rule"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_rule"."transaction_rule_id" = ?`, transactionRuleId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to delete transaction rule")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to delete transaction rule")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// ApplyTransactionRules evaluates the account's enabled transaction rules against transactions that are about to be
+// created. The transactions are modified in place, including the amount they are spent from a spending object, but the
+// spending objects themselves are not updated. This must be called before the transactions are inserted, and
+// DeductTransactionSpending must be called once they have been inserted.
+func (r *repositoryBase) ApplyTransactionRules(ctx context.Context, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	rules, err := r.GetTransactionRules(span.Context())
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	// Spending objects are kept here as the transactions are evaluated so that the amount each transaction can take
+	// from one accounts for the transactions before it.
+	spending := map[transactionSpendingKey]*models.Spending{}
+	missing := map[transactionSpendingKey]struct{}{}
+	matched := 0
+	for i := range transactions {
+		transaction := &transactions[i]
+		spendingId := models.EvaluateTransactionRules(rules, transaction)
+		if spendingId == nil {
+			continue
+		}
+
+		key := transactionSpendingKey{
+			bankAccountId: transaction.BankAccountId,
+			spendingId:    *spendingId,
+		}
+		if _, ok := missing[key]; ok {
+			continue
+		}
+
+		item, ok := spending[key]
+		if !ok {
+			item, err = r.GetSpendingById(span.Context(), key.bankAccountId, key.spendingId)
+			if errors.Cause(err) == pg.ErrNoRows {
+				// The spending object might have been removed since the rule was created, the transaction is just left
+				// as is.
+				missing[key] = struct{}{}
+				continue
+			} else if err != nil {
+				return err
+			}
+			spending[key] = item
+		}
+
+		deducted := models.DeductFromSpending(item, transaction.Amount)
+		transaction.SpendingId = &item.SpendingId
+		transaction.SpendingAmount = &deducted
+		matched++
+	}
+
+	span.Data = map[string]interface{}{
+		"rules":   len(rules),
+		"matched": matched,
+	}
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// DeductTransactionSpending takes the spending amount of each new transaction that is spent from a spending object
+// away from that spending object, recording a ledger entry that references the transaction. This must be called after
+// the transactions from ApplyTransactionRules have been inserted, so that they have an Id.
+func (r *repositoryBase) DeductTransactionSpending(ctx context.Context, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	var account *models.Account
+	spending := map[transactionSpendingKey]*models.Spending{}
+	deducted := 0
+	for _, transaction := range transactions {
+		if transaction.SpendingId == nil || transaction.SpendingAmount == nil {
+			continue
+		}
+
+		if account == nil {
+			var err error
+			account, err = r.GetAccount(span.Context())
+			if err != nil {
+				return err
+			}
+		}
+
+		key := transactionSpendingKey{
+			bankAccountId: transaction.BankAccountId,
+			spendingId:    *transaction.SpendingId,
+		}
+		item, ok := spending[key]
+		if !ok {
+			var err error
+			item, err = r.GetSpendingById(span.Context(), key.bankAccountId, key.spendingId)
+			if err != nil {
+				return err
+			}
+			spending[key] = item
+		}
+
+		models.DeductFromSpending(item, *transaction.SpendingAmount)
+		if err := item.CalculateNextContribution(
+			span.Context(),
+			account.Timezone,
+			item.FundingSchedule,
+			r.clock.Now(),
+		); err != nil {
+			return errors.Wrap(err, "failed to calculate next contribution for spending of new transaction")
+		}
+
+		if err := r.UpdateSpending(
+			span.Context(),
+			transaction.BankAccountId,
+			[]models.Spending{*item},
+			models.SpendingLedgerReasonTransaction,
+			myownsanity.Uint64P(transaction.TransactionId),
+		); err != nil {
+			return err
+		}
+		deducted++
+	}
+
+	span.Data = map[string]interface{}{
+		"count":    len(transactions),
+		"deducted": deducted,
+	}
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+type transactionSpendingKey struct {
+	bankAccountId uint64
+	spendingId    uint64
+}
@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryBase_ApplyTransactionRules(t *testing.T) {
+	t.Run("spending ledger references the transactions", func(t *testing.T) {
+		clock := clock.NewMock()
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, clock, &bankAccount, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		expense := fixtures.GivenIHaveAnExpense(t, clock, fundingSchedule, 10000)
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, testutils.GetPgDatabase(t))
+
+		rule := models.TransactionRule{
+			Name:      "Coffee",
+			IsEnabled: true,
+			Conditions: models.TransactionRuleConditions{
+				Name:          myownsanity.StringP("coffee"),
+				BankAccountId: &bankAccount.BankAccountId,
+			},
+			Actions: models.TransactionRuleActions{
+				SpendingId: &expense.SpendingId,
+			},
+		}
+		require.NoError(t, repo.CreateTransactionRule(context.Background(), &rule), "must create rule")
+
+		transactions := make([]models.Transaction, 2)
+		for i, amount := range []int64{3000, 4000} {
+			transactions[i] = models.Transaction{
+				BankAccountId: bankAccount.BankAccountId,
+				Amount:        amount,
+				Date:          clock.Now(),
+				Name:          "Coffee Shop",
+				OriginalName:  "COFFEE SHOP",
+				CreatedAt:     clock.Now(),
+			}
+		}
+
+		require.NoError(t, repo.ApplyTransactionRules(context.Background(), transactions), "must apply rules")
+		for _, transaction := range transactions {
+			assert.EqualValues(t, &expense.SpendingId, transaction.SpendingId, "transaction should be spent from the expense")
+			assert.EqualValues(t, &transaction.Amount, transaction.SpendingAmount, "whole transaction should be spent")
+		}
+
+		{ // Nothing is taken from the expense until the transactions exist.
+			spending, err := repo.GetSpendingById(context.Background(), bankAccount.BankAccountId, expense.SpendingId)
+			require.NoError(t, err, "must retrieve expense")
+			assert.EqualValues(t, 10000, spending.CurrentAmount)
+		}
+
+		require.NoError(t, repo.InsertTransactions(context.Background(), transactions), "must insert transactions")
+		require.NoError(t, repo.DeductTransactionSpending(context.Background(), transactions), "must deduct spending")
+
+		spending, err := repo.GetSpendingById(context.Background(), bankAccount.BankAccountId, expense.SpendingId)
+		require.NoError(t, err, "must retrieve expense")
+		assert.EqualValues(t, 3000, spending.CurrentAmount)
+
+		entries, err := repo.GetSpendingLedger(context.Background(), bankAccount.BankAccountId, expense.SpendingId, 10, 0, nil)
+		require.NoError(t, err, "must retrieve ledger")
+		require.Len(t, entries, 2, "should have one ledger entry per transaction")
+		assert.Equal(t, models.SpendingLedgerReasonTransaction, entries[0].Reason)
+		assert.Equal(t, &transactions[1].TransactionId, entries[0].ReferenceId)
+		assert.EqualValues(t, -4000, entries[0].Amount)
+		assert.Equal(t, &transactions[0].TransactionId, entries[1].ReferenceId)
+		assert.EqualValues(t, -3000, entries[1].Amount)
+	})
+}
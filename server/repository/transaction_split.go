@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// populateTransactionSplits retrieves the splits for the provided transactions and stores them on each transaction.
+// Transactions that are not split are left with nil splits.
+func (r *repositoryBase) populateTransactionSplits(ctx context.Context, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	transactionIds := make([]uint64, len(transactions))
+	for i := range transactions {
+		transactionIds[i] = transactions[i].TransactionId
+	}
+
+	span.Data = map[string]interface{}{
+		"transactionIds": transactionIds,
+	}
+
+	splits := make([]models.TransactionSplit, 0)
+	err := r.txn.ModelContext(span.Context(), &splits).
+		Where(`"transaction_split"."account_id" = ?`, r.AccountId()).
+		WhereIn(`"transaction_split"."transaction_id" IN (?)`, transactionIds).
+		Order(`transaction_split_id ASC`).
+		Select(&splits)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to retrieve transaction splits")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	byTransaction := map[uint64][]models.TransactionSplit{}
+	for _, split := range splits {
+		byTransaction[split.TransactionId] = append(byTransaction[split.TransactionId], split)
+	}
+
+	for i := range transactions {
+		transactions[i].Splits = byTransaction[transactions[i].TransactionId]
+	}
+
+	return nil
+}
+
+// replaceTransactionSplits removes any existing splits for the specified transaction and stores the ones provided.
+func (r *repositoryBase) replaceTransactionSplits(ctx context.Context, bankAccountId, transactionId uint64, splits []models.TransactionSplit) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"transactionId": transactionId,
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &models.TransactionSplit{}).
+		Where(`"transaction_split"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_split"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_split"."transaction_id" = ?`, transactionId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove existing transaction splits")
+	}
+
+	if len(splits) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for i := range splits {
+		splits[i].TransactionSplitId = 0
+		splits[i].AccountId = r.AccountId()
+		splits[i].BankAccountId = bankAccountId
+		splits[i].TransactionId = transactionId
+		splits[i].CreatedAt = now
+	}
+
+	if _, err = r.txn.ModelContext(span.Context(), &splits).Insert(&splits); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create transaction splits")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// processTransactionSplits is used by ProcessTransactionSpentFrom when either the existing or the updated transaction is
+// split. Everything that was deducted for the existing transaction is returned to the spending objects it came from,
+// and then the amounts for the updated transaction are deducted. This way a spending object that is part of both is
+// only updated once with the net change. The updated splits are stored as well.
+func (r *repositoryBase) processTransactionSplits(ctx context.Context, account *models.Account, bankAccountId uint64, input, existing *models.Transaction) ([]models.Spending, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if input.SpendingId != nil && len(input.Splits) > 0 {
+		return nil, errors.New("a transaction cannot be spent from a single spending object and split")
+	}
+
+	if err := models.ValidateTransactionSplits(input.Amount, input.Splits); err != nil {
+		return nil, errors.Wrap(err, "invalid transaction splits")
+	}
+
+	// If nothing has changed then keep the existing splits, they have the amounts that were actually deducted.
+	if input.SpendingId == nil && existing.SpendingId == nil &&
+		input.Amount == existing.Amount &&
+		models.TransactionSplitsEqual(input.Splits, existing.Splits) {
+		input.Splits = existing.Splits
+		return nil, nil
+	}
+
+	spending := map[uint64]*models.Spending{}
+	getSpending := func(spendingId uint64) (*models.Spending, error) {
+		if item, ok := spending[spendingId]; ok {
+			return item, nil
+		}
+
+		item, err := r.GetSpendingById(span.Context(), bankAccountId, spendingId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve spending %d for transaction", spendingId)
+		}
+		spending[spendingId] = item
+
+		return item, nil
+	}
+
+	// Return everything that was taken for the existing transaction.
+	if existing.SpendingId != nil && existing.SpendingAmount != nil {
+		item, err := getSpending(*existing.SpendingId)
+		if err != nil {
+			return nil, err
+		}
+		models.ReturnToSpending(item, *existing.SpendingAmount)
+	}
+
+	for _, split := range existing.Splits {
+		item, err := getSpending(split.SpendingId)
+		if err != nil {
+			return nil, err
+		}
+		models.ReturnToSpending(item, split.SpendingAmount)
+	}
+
+	// Then deduct what is needed for the updated transaction.
+	input.SpendingAmount = nil
+	if input.SpendingId != nil {
+		item, err := getSpending(*input.SpendingId)
+		if err != nil {
+			return nil, err
+		}
+		deducted := models.DeductFromSpending(item, input.Amount)
+		input.SpendingAmount = &deducted
+	}
+
+	for i := range input.Splits {
+		item, err := getSpending(input.Splits[i].SpendingId)
+		if err != nil {
+			return nil, err
+		}
+		input.Splits[i].SpendingAmount = models.DeductFromSpending(item, input.Splits[i].Amount)
+	}
+
+	spendingIds := make([]uint64, 0, len(spending))
+	for spendingId := range spending {
+		spendingIds = append(spendingIds, spendingId)
+	}
+	sort.Slice(spendingIds, func(i, j int) bool {
+		return spendingIds[i] < spendingIds[j]
+	})
+
+	updates := make([]models.Spending, 0, len(spendingIds))
+	for _, spendingId := range spendingIds {
+		item := spending[spendingId]
+		if err := item.CalculateNextContribution(
+			span.Context(),
+			account.Timezone,
+			item.FundingSchedule,
+			time.Now(),
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to calculate next contribution for transaction spending")
+		}
+		updates = append(updates, *item)
+	}
+
+	if err := r.replaceTransactionSplits(span.Context(), bankAccountId, input.TransactionId, input.Splits); err != nil {
+		return nil, err
+	}
+
+	// An empty set of splits is used to remove them, but the transaction should not report any splits afterwards.
+	if len(input.Splits) == 0 {
+		input.Splits = nil
+	}
+
+	return updates, r.UpdateSpending(
+		span.Context(),
+		bankAccountId,
+		updates,
+		models.SpendingLedgerReasonTransaction,
+		myownsanity.Uint64P(input.TransactionId),
+	)
+}
+
+// removeSplitsForSpending is used before a spending object is deleted. Deleting the spending object would otherwise
+// remove only its own split from each transaction, leaving splits that no longer add up to the transaction amount. If a
+// transaction would be left with a single split then it is spent from that spending object directly instead. Otherwise
+// the remaining splits are removed and what they deducted is returned to their spending objects.
+func (r *repositoryBase) removeSplitsForSpending(ctx context.Context, bankAccountId, spendingId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"spendingId":    spendingId,
+	}
+
+	transactionIds := make([]uint64, 0)
+	err := r.txn.ModelContext(span.Context(), &models.TransactionSplit{}).
+		Column("transaction_id").
+		Where(`"transaction_split"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_split"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_split"."spending_id" = ?`, spendingId).
+		Order(`transaction_id ASC`).
+		Select(&transactionIds)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to retrieve transactions split with spending")
+	}
+
+	if len(transactionIds) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	splits := make([]models.TransactionSplit, 0)
+	err = r.txn.ModelContext(span.Context(), &splits).
+		Where(`"transaction_split"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_split"."bank_account_id" = ?`, bankAccountId).
+		WhereIn(`"transaction_split"."transaction_id" IN (?)`, transactionIds).
+		Where(`"transaction_split"."spending_id" != ?`, spendingId).
+		Order(`transaction_split_id ASC`).
+		Select(&splits)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to retrieve remaining transaction splits")
+	}
+
+	remaining := map[uint64][]models.TransactionSplit{}
+	for _, split := range splits {
+		remaining[split.TransactionId] = append(remaining[split.TransactionId], split)
+	}
+
+	account, err := r.GetAccount(span.Context())
+	if err != nil {
+		return err
+	}
+
+	spending := map[uint64]*models.Spending{}
+	for _, transactionId := range transactionIds {
+		if err = r.replaceTransactionSplits(span.Context(), bankAccountId, transactionId, nil); err != nil {
+			return err
+		}
+
+		if len(remaining[transactionId]) == 1 {
+			split := remaining[transactionId][0]
+			_, err = r.txn.ModelContext(span.Context(), &models.Transaction{}).
+				Set(`"spending_id" = ?`, split.SpendingId).
+				Set(`"spending_amount" = ?`, split.SpendingAmount).
+				Where(`"transaction"."account_id" = ?`, r.AccountId()).
+				Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+				Where(`"transaction"."transaction_id" = ?`, transactionId).
+				Update()
+			if err != nil {
+				span.Status = sentry.SpanStatusInternalError
+				return errors.Wrap(err, "failed to spend transaction from its remaining split")
+			}
+			continue
+		}
+
+		updates := make([]models.Spending, 0, len(remaining[transactionId]))
+		for _, split := range remaining[transactionId] {
+			item, ok := spending[split.SpendingId]
+			if !ok {
+				item, err = r.GetSpendingById(span.Context(), bankAccountId, split.SpendingId)
+				if err != nil {
+					return errors.Wrapf(err, "failed to retrieve spending %d for transaction", split.SpendingId)
+				}
+				spending[split.SpendingId] = item
+			}
+
+			models.ReturnToSpending(item, split.SpendingAmount)
+			if err = item.CalculateNextContribution(
+				span.Context(),
+				account.Timezone,
+				item.FundingSchedule,
+				r.clock.Now(),
+			); err != nil {
+				return errors.Wrap(err, "failed to calculate next contribution for transaction spending")
+			}
+			updates = append(updates, *item)
+		}
+
+		if err = r.UpdateSpending(
+			span.Context(),
+			bankAccountId,
+			updates,
+			models.SpendingLedgerReasonTransaction,
+			myownsanity.Uint64P(transactionId),
+		); err != nil {
+			return err
+		}
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
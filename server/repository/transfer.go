@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotATransfer = errors.New("transaction is not part of a transfer")
+)
+
+// DetectTransfers looks for transfers between the account's bank accounts that involve any of the provided
+// transactions. Any pairs found are linked to each other with a matched status. The provided transactions must have
+// already been inserted. The number of transfers that were linked is returned.
+func (r *repositoryBase) DetectTransfers(ctx context.Context, transactions []models.Transaction) (int, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if len(transactions) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return 0, nil
+	}
+
+	// Only look at candidates that could possibly be paired with one of the new transactions.
+	start, end := transactions[0].Date, transactions[0].Date
+	newTransactionIds := map[uint64]struct{}{}
+	for _, transaction := range transactions {
+		newTransactionIds[transaction.TransactionId] = struct{}{}
+		if transaction.Date.Before(start) {
+			start = transaction.Date
+		}
+		if transaction.Date.After(end) {
+			end = transaction.Date
+		}
+	}
+	start = start.Add(-models.TransferMatchWindow)
+	end = end.Add(models.TransferMatchWindow)
+
+	span.Data = map[string]interface{}{
+		"start": start,
+		"end":   end,
+	}
+
+	candidates := make([]models.Transaction, 0)
+	err := r.txn.ModelContext(span.Context(), &candidates).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."deleted_at" IS NULL`).
+		Where(`"transaction"."transfer_transaction_id" IS NULL`).
+		Where(`"transaction"."transfer_status" IS NULL`).
+		Where(`"transaction"."spending_id" IS NULL`).
+		Where(transactionSplitsNotExist).
+		Where(`"transaction"."date" >= ?`, start).
+		Where(`"transaction"."date" <= ?`, end).
+		Order(`transaction_id ASC`).
+		Select(&candidates)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, errors.Wrap(err, "failed to retrieve transfer candidates")
+	}
+
+	linked := 0
+	for _, pair := range models.FindTransferPairs(candidates) {
+		_, outflowIsNew := newTransactionIds[pair.Outflow.TransactionId]
+		_, inflowIsNew := newTransactionIds[pair.Inflow.TransactionId]
+		if !outflowIsNew && !inflowIsNew {
+			// Neither side of this pair is new, so it was not linked previously for a reason. Leave it alone.
+			continue
+		}
+
+		if err = r.linkTransfer(span.Context(), pair.Outflow, pair.Inflow, models.TransferStatusMatched); err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return linked, err
+		}
+		linked++
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return linked, nil
+}
+
+// ConfirmTransfer marks both sides of the transfer that the specified transaction belongs to as confirmed.
+func (r *repositoryBase) ConfirmTransfer(ctx context.Context, bankAccountId, transactionId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"transactionId": transactionId,
+	}
+
+	transaction, err := r.GetTransaction(span.Context(), bankAccountId, transactionId)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return err
+	}
+
+	if !transaction.IsTransfer() {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return errors.WithStack(ErrNotATransfer)
+	}
+
+	status := models.TransferStatusConfirmed
+	_, err = r.txn.ModelContext(span.Context(), &models.Transaction{}).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		WhereIn(`"transaction"."transaction_id" IN (?)`, []uint64{
+			transaction.TransactionId,
+			*transaction.TransferTransactionId,
+		}).
+		Set(`"transfer_status" = ?`, status).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to confirm transfer")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// UnlinkTransfer removes the link between the two sides of the transfer that the specified transaction belongs to.
+// Both transactions are marked as rejected so that they will not be matched as a transfer again.
+func (r *repositoryBase) UnlinkTransfer(ctx context.Context, bankAccountId, transactionId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"transactionId": transactionId,
+	}
+
+	transaction, err := r.GetTransaction(span.Context(), bankAccountId, transactionId)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return err
+	}
+
+	if !transaction.IsTransfer() {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return errors.WithStack(ErrNotATransfer)
+	}
+
+	status := models.TransferStatusRejected
+	_, err = r.txn.ModelContext(span.Context(), &models.Transaction{}).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		WhereIn(`"transaction"."transaction_id" IN (?)`, []uint64{
+			transaction.TransactionId,
+			*transaction.TransferTransactionId,
+		}).
+		Set(`"transfer_transaction_id" = NULL`).
+		Set(`"transfer_status" = ?`, status).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to unlink transfer")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) linkTransfer(ctx context.Context, outflow, inflow models.Transaction, status models.TransferStatus) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	for _, side := range []struct {
+		transaction models.Transaction
+		otherId     uint64
+	}{
+		{outflow, inflow.TransactionId},
+		{inflow, outflow.TransactionId},
+	} {
+		_, err := r.txn.ModelContext(span.Context(), &models.Transaction{}).
+			Where(`"transaction"."account_id" = ?`, r.AccountId()).
+			Where(`"transaction"."bank_account_id" = ?`, side.transaction.BankAccountId).
+			Where(`"transaction"."transaction_id" = ?`, side.transaction.TransactionId).
+			Set(`"transfer_transaction_id" = ?`, side.otherId).
+			Set(`"transfer_status" = ?`, status).
+			Update()
+		if err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return errors.Wrap(err, "failed to link transfer")
+		}
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// unlinkDeletedTransfer clears the transfer link from the other side of a transfer when one side is deleted.
+func (r *repositoryBase) unlinkDeletedTransfer(ctx context.Context, transactionId uint64) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	_, err := r.txn.ModelContext(span.Context(), &models.Transaction{}).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."transfer_transaction_id" = ?`, transactionId).
+		Set(`"transfer_transaction_id" = NULL`).
+		Set(`"transfer_status" = NULL`).
+		Update()
+	if err != nil && err != pg.ErrNoRows {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to unlink deleted transfer")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
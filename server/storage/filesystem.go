@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	_ Storage = &filesystemStorage{}
+)
+
+// filesystemStorage stores files in a directory on the local filesystem. The URIs returned are relative to the base
+// path, so `file:///chunk/name.csv` refers to `{basePath}/chunk/name.csv`. This way the base path can be moved without
+// invalidating files that have already been stored.
+type filesystemStorage struct {
+	log      *logrus.Entry
+	basePath string
+}
+
+// NewFilesystemStorage will create a storage backend that writes files to the provided directory. The directory will
+// be created if it does not already exist.
+func NewFilesystemStorage(log *logrus.Entry, basePath string) (Storage, error) {
+	if strings.TrimSpace(basePath) == "" {
+		return nil, errors.New("filesystem storage requires a base path")
+	}
+
+	basePath, err := filepath.Abs(basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve filesystem storage base path")
+	}
+
+	if err = os.MkdirAll(basePath, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create filesystem storage base path")
+	}
+
+	return &filesystemStorage{
+		log:      log,
+		basePath: basePath,
+	}, nil
+}
+
+func (f *filesystemStorage) Store(ctx context.Context, buf io.ReadSeekCloser, contentType string) (uri string, err error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	key := getStorePath(contentType)
+	uri = fmt.Sprintf("file:///%s", key)
+
+	destination, err := f.resolve(key)
+	if err != nil {
+		return "", err
+	}
+
+	log := f.log.
+		WithContext(span.Context()).
+		WithFields(logrus.Fields{
+			"destination": uri,
+		})
+
+	span.SetData("destination", uri)
+
+	log.Debug("writing file to filesystem")
+
+	directory := filepath.Dir(destination)
+	if err = os.MkdirAll(directory, 0700); err != nil {
+		return "", errors.Wrap(err, "failed to create directory for file")
+	}
+
+	// The file is written to a temporary file in the same directory first and then renamed. This way a partially
+	// written file can never be read using the returned URI.
+	temporary, err := os.CreateTemp(directory, ".upload-*")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temporary file")
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(temporary.Name())
+		}
+	}()
+
+	if _, err = io.Copy(temporary, buf); err != nil {
+		_ = temporary.Close()
+		return "", errors.Wrap(err, "failed to write file")
+	}
+
+	if err = temporary.Sync(); err != nil {
+		_ = temporary.Close()
+		return "", errors.Wrap(err, "failed to sync file")
+	}
+
+	if err = temporary.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to close file")
+	}
+
+	if err = os.Rename(temporary.Name(), destination); err != nil {
+		return "", errors.Wrap(err, "failed to move file into place")
+	}
+
+	// Sync the directory as well so that the rename itself is durable.
+	if err = syncDirectory(directory); err != nil {
+		return "", err
+	}
+
+	return uri, nil
+}
+
+func (f *filesystemStorage) Read(ctx context.Context, uri string) (buf io.ReadCloser, err error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	key, err := f.parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := f.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetData("source", uri)
+
+	f.log.
+		WithContext(span.Context()).
+		WithFields(logrus.Fields{
+			"source": uri,
+		}).
+		Debug("reading file from filesystem")
+
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file from filesystem")
+	}
+
+	return file, nil
+}
+
+func (f *filesystemStorage) Remove(ctx context.Context, uri string) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	key, err := f.parse(uri)
+	if err != nil {
+		return err
+	}
+
+	target, err := f.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	span.SetData("target", uri)
+
+	f.log.
+		WithContext(span.Context()).
+		WithFields(logrus.Fields{
+			"target": uri,
+		}).
+		Debug("removing file from filesystem")
+
+	if err = os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove file from filesystem")
+	}
+
+	return nil
+}
+
+// parse will make sure that the provided URI is a file URI and will return the key of the file relative to the base
+// path.
+func (f *filesystemStorage) parse(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse file uri")
+	}
+
+	if parsed.Scheme != "file" {
+		return "", errors.Errorf("file uri protocol mismatch, expected file but got %s", parsed.Scheme)
+	}
+
+	if parsed.Host != "" {
+		return "", errors.New("file uri must not specify a host")
+	}
+
+	return parsed.Path, nil
+}
+
+// resolve takes a key relative to the base path and returns the absolute path of that file. An error is returned if the
+// key would resolve to a path outside the base path.
+func (f *filesystemStorage) resolve(key string) (string, error) {
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", errors.New("file path must not traverse directories")
+		}
+	}
+
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", errors.New("file uri does not specify a file")
+	}
+
+	result := filepath.Join(f.basePath, filepath.FromSlash(cleaned))
+	relative, err := filepath.Rel(f.basePath, result)
+	if err != nil || relative == "." || strings.HasPrefix(relative, "..") {
+		return "", errors.New("file path must not traverse directories")
+	}
+
+	return result, nil
+}
+
+func syncDirectory(directory string) error {
+	handle, err := os.Open(directory)
+	if err != nil {
+		return errors.Wrap(err, "failed to open directory to sync")
+	}
+	defer handle.Close()
+
+	return errors.Wrap(handle.Sync(), "failed to sync directory")
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
+
+func TestFilesystemStorage(t *testing.T) {
+	t.Run("store and read", func(t *testing.T) {
+		basePath := t.TempDir()
+		store, err := NewFilesystemStorage(testutils.GetLog(t), basePath)
+		require.NoError(t, err, "must be able to create filesystem storage")
+
+		uri, err := store.Store(context.Background(), readSeekNopCloser{strings.NewReader("date,amount\n")}, "text/csv")
+		assert.NoError(t, err, "must be able to store file")
+		assert.True(t, strings.HasPrefix(uri, "file:///"), "uri should use the file protocol")
+		assert.True(t, strings.HasSuffix(uri, ".csv"), "file should have an extension based on its content type")
+
+		path := filepath.Join(basePath, filepath.FromSlash(strings.TrimPrefix(uri, "file:///")))
+		assert.FileExists(t, path, "file should be stored under the base path")
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary files should not be left behind")
+
+		reader, err := store.Read(context.Background(), uri)
+		require.NoError(t, err, "must be able to read file")
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "date,amount\n", string(data))
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		basePath := filepath.Join(t.TempDir(), "storage")
+		store, err := NewFilesystemStorage(testutils.GetLog(t), basePath)
+		require.NoError(t, err, "must be able to create filesystem storage")
+		require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(basePath), "secret.txt"), []byte("secret"), 0600))
+
+		for _, uri := range []string{
+			"file:///../secret.txt",
+			"file:///chunk/../../secret.txt",
+			"file:///chunk/%2e%2e/%2e%2e/secret.txt",
+		} {
+			reader, err := store.Read(context.Background(), uri)
+			assert.EqualError(t, err, "file path must not traverse directories", "uri: %s", uri)
+			assert.Nil(t, reader)
+		}
+	})
+
+	t.Run("protocol mismatch", func(t *testing.T) {
+		store, err := NewFilesystemStorage(testutils.GetLog(t), t.TempDir())
+		require.NoError(t, err, "must be able to create filesystem storage")
+
+		reader, err := store.Read(context.Background(), "s3://bucket/file.csv")
+		assert.EqualError(t, err, "file uri protocol mismatch, expected file but got s3")
+		assert.Nil(t, reader)
+
+		reader, err = store.Read(context.Background(), "file://host/file.csv")
+		assert.EqualError(t, err, "file uri must not specify a host")
+		assert.Nil(t, reader)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		store, err := NewFilesystemStorage(testutils.GetLog(t), t.TempDir())
+		require.NoError(t, err, "must be able to create filesystem storage")
+
+		reader, err := store.Read(context.Background(), "file:///chunk/missing.csv")
+		assert.Error(t, err)
+		assert.Nil(t, reader)
+	})
+	t.Run("remove", func(t *testing.T) {
+		store, err := NewFilesystemStorage(testutils.GetLog(t), t.TempDir())
+		require.NoError(t, err, "must be able to create filesystem storage")
+
+		uri, err := store.Store(context.Background(), readSeekNopCloser{strings.NewReader("receipt")}, "image/png")
+		require.NoError(t, err, "must be able to store file")
+
+		assert.NoError(t, store.Remove(context.Background(), uri), "must be able to remove file")
+
+		reader, err := store.Read(context.Background(), uri)
+		assert.Error(t, err, "file should no longer exist")
+		assert.Nil(t, reader)
+
+		assert.NoError(t, store.Remove(context.Background(), uri), "removing a missing file should not fail")
+		assert.EqualError(t, store.Remove(context.Background(), "file:///../secret.txt"), "file path must not traverse directories")
+	})
+}
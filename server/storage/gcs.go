@@ -11,17 +11,29 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	_ Storage = &gcsStorage{}
+)
+
 type gcsStorage struct {
 	log    *logrus.Entry
 	bucket string
 	client *storage.Client
 }
 
-func (s *gcsStorage) Store(ctx context.Context, buf io.ReadSeekCloser) (uri string, err error) {
+func NewGCSStorageBackend(log *logrus.Entry, bucket string, client *storage.Client) Storage {
+	return &gcsStorage{
+		log:    log,
+		bucket: bucket,
+		client: client,
+	}
+}
+
+func (s *gcsStorage) Store(ctx context.Context, buf io.ReadSeekCloser, contentType string) (uri string, err error) {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
-	key := getStorePath()
+	key := getStorePath(contentType)
 	uri = fmt.Sprintf("gcs://%s/%s", s.bucket, key)
 
 	log := s.log.
@@ -35,9 +47,62 @@ func (s *gcsStorage) Store(ctx context.Context, buf io.ReadSeekCloser) (uri stri
 	log.Debug("uploading file to Google Cloud Storage")
 
 	writer := s.client.Bucket(s.bucket).Object(key).NewWriter(span.Context())
+	writer.ContentType = contentType
 	if _, err := io.Copy(writer, buf); err != nil {
 		return "", errors.Wrap(err, "failed to write buffer to gcs writer")
 	}
 
 	return uri, errors.Wrap(writer.Close(), "failed to store file in gcs")
 }
+
+func (s *gcsStorage) Read(ctx context.Context, uri string) (buf io.ReadCloser, err error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	key, err := getObjectKey(uri, "gcs", s.bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetData("source", uri)
+
+	s.log.
+		WithContext(span.Context()).
+		WithFields(logrus.Fields{
+			"source": uri,
+		}).
+		Debug("reading file from Google Cloud Storage")
+
+	reader, err := s.client.Bucket(s.bucket).Object(key).NewReader(span.Context())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file from gcs")
+	}
+
+	return reader, nil
+}
+
+func (s *gcsStorage) Remove(ctx context.Context, uri string) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	key, err := getObjectKey(uri, "gcs", s.bucket)
+	if err != nil {
+		return err
+	}
+
+	span.SetData("target", uri)
+
+	s.log.
+		WithContext(span.Context()).
+		WithFields(logrus.Fields{
+			"target": uri,
+		}).
+		Debug("removing file from Google Cloud Storage")
+
+	err = s.client.Bucket(s.bucket).Object(key).Delete(span.Context())
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return errors.Wrap(err, "failed to remove file from gcs")
+	}
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/monetr/monetr/server/crumbs"
@@ -11,17 +13,29 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	_ Storage = &s3Storage{}
+)
+
 type s3Storage struct {
 	log     *logrus.Entry
 	bucket  string
 	session *s3.S3
 }
 
-func (s *s3Storage) Store(ctx context.Context, buf io.ReadSeekCloser) (uri string, err error) {
+func NewS3StorageBackend(log *logrus.Entry, bucket string, session *s3.S3) Storage {
+	return &s3Storage{
+		log:     log,
+		bucket:  bucket,
+		session: session,
+	}
+}
+
+func (s *s3Storage) Store(ctx context.Context, buf io.ReadSeekCloser, contentType string) (uri string, err error) {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
-	key := getStorePath()
+	key := getStorePath(contentType)
 	uri = fmt.Sprintf("s3://%s/%s", s.bucket, key)
 
 	log := s.log.
@@ -37,6 +51,7 @@ func (s *s3Storage) Store(ctx context.Context, buf io.ReadSeekCloser) (uri strin
 	_, err = s.session.PutObject(&s3.PutObjectInput{
 		Body:                    buf,
 		Bucket:                  &s.bucket,
+		ContentType:             &contentType,
 		Key:                     &key,
 		Metadata:                map[string]*string{},
 		SSEKMSEncryptionContext: nil,
@@ -55,5 +70,81 @@ func (s *s3Storage) Read(ctx context.Context, uri string) (buf io.ReadCloser, er
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
-	return nil, nil
+	key, err := getObjectKey(uri, "s3", s.bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetData("source", uri)
+
+	s.log.
+		WithContext(span.Context()).
+		WithFields(logrus.Fields{
+			"source": uri,
+		}).
+		Debug("reading file from S3")
+
+	result, err := s.session.GetObjectWithContext(span.Context(), &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file from s3")
+	}
+
+	return result.Body, nil
+}
+
+func (s *s3Storage) Remove(ctx context.Context, uri string) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	key, err := getObjectKey(uri, "s3", s.bucket)
+	if err != nil {
+		return err
+	}
+
+	span.SetData("target", uri)
+
+	s.log.
+		WithContext(span.Context()).
+		WithFields(logrus.Fields{
+			"target": uri,
+		}).
+		Debug("removing file from S3")
+
+	// S3 does not return an error when deleting an object that does not exist.
+	_, err = s.session.DeleteObjectWithContext(span.Context(), &s3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to remove file from s3")
+	}
+
+	return nil
+}
+
+// getObjectKey will parse the provided URI and make sure that it belongs to the scheme and bucket specified. It then
+// returns the key of the object within that bucket.
+func getObjectKey(uri, scheme, bucket string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse file uri")
+	}
+
+	if parsed.Scheme != scheme {
+		return "", errors.Errorf("file uri protocol mismatch, expected %s but got %s", scheme, parsed.Scheme)
+	}
+
+	if parsed.Host != bucket {
+		return "", errors.Errorf("file uri bucket mismatch, expected %s but got %s", bucket, parsed.Host)
+	}
+
+	key := strings.TrimPrefix(parsed.Path, "/")
+	if key == "" {
+		return "", errors.New("file uri does not specify an object")
+	}
+
+	return key, nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -19,17 +21,57 @@ type Storage interface {
 	// Depending on the implementation the file may still be present in whatever storage system even if the file was not
 	// successfully stored. This should be considered on a per-implementation basis as it will be unique to the
 	// implementation itself.
-	Store(ctx context.Context, buf io.ReadSeekCloser) (uri string, err error)
+	// The content type of the file is used to determine the extension of the stored file.
+	Store(ctx context.Context, buf io.ReadSeekCloser, contentType string) (uri string, err error)
 	// Read will take a file URI and will read it from the underlying storage system. If the URI provided is not for the
 	// storage interface under this then an error will be returned. For example; if this is backed by a file system but
 	// the provided URI is an S3 protocol, then this would return an error for protocol mismatch. If a file can be read
 	// then an buffer will be returned for that file.
 	Read(ctx context.Context, uri string) (buf io.ReadCloser, err error)
+	// Remove will take a file URI and delete that file from the underlying storage system. Like Read, an error is
+	// returned if the URI does not belong to this storage implementation. Removing a file that does not exist is not
+	// considered an error.
+	Remove(ctx context.Context, uri string) error
 }
 
-func getStorePath() string {
+func getStorePath(contentType string) string {
 	chunk := uuid.NewString()
 	name := uuid.NewString()
-	key := fmt.Sprintf("%s/%s.[FILETYPE]", chunk, name)
+	key := fmt.Sprintf("%s/%s.%s", chunk, name, getExtension(contentType))
 	return key
 }
+
+// getExtension returns the file extension (without the leading period) that should be used for a file of the provided
+// content type. Files with a content type that is not recognized are stored with a generic bin extension.
+func getExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "bin"
+	}
+
+	switch strings.ToLower(mediaType) {
+	case "text/csv", "application/csv":
+		return "csv"
+	case "application/x-ofx", "application/ofx":
+		return "ofx"
+	case "application/vnd.intu.qfx", "application/x-qfx":
+		return "qfx"
+	case "application/pdf":
+		return "pdf"
+	case "image/jpeg":
+		return "jpg"
+	case "image/png":
+		return "png"
+	case "text/plain":
+		return "txt"
+	}
+
+	// Fall back to whatever the system knows about, this might not be consistent between hosts so only known types are
+	// handled above.
+	extensions, err := mime.ExtensionsByType(mediaType)
+	if err == nil && len(extensions) > 0 {
+		return strings.TrimPrefix(extensions[0], ".")
+	}
+
+	return "bin"
+}
@@ -40,3 +40,46 @@ func TestParseURI(t *testing.T) {
 		assert.Equal(t, "/root/folder/file.txt", url.Path)
 	})
 }
+
+func TestGetObjectKey(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		key, err := getObjectKey("s3://bucket/folder/file.csv", "s3", "bucket")
+		assert.NoError(t, err, "must be able to get the key of a valid uri")
+		assert.Equal(t, "folder/file.csv", key)
+	})
+
+	t.Run("protocol mismatch", func(t *testing.T) {
+		key, err := getObjectKey("gcs://bucket/folder/file.csv", "s3", "bucket")
+		assert.EqualError(t, err, "file uri protocol mismatch, expected s3 but got gcs")
+		assert.Empty(t, key)
+	})
+
+	t.Run("bucket mismatch", func(t *testing.T) {
+		key, err := getObjectKey("s3://other/folder/file.csv", "s3", "bucket")
+		assert.EqualError(t, err, "file uri bucket mismatch, expected bucket but got other")
+		assert.Empty(t, key)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		key, err := getObjectKey("s3://bucket", "s3", "bucket")
+		assert.EqualError(t, err, "file uri does not specify an object")
+		assert.Empty(t, key)
+	})
+}
+
+func TestGetExtension(t *testing.T) {
+	cases := map[string]string{
+		"text/csv":                 "csv",
+		"text/csv; charset=utf-8":  "csv",
+		"application/x-ofx":        "ofx",
+		"application/vnd.intu.qfx": "qfx",
+		"image/jpeg":               "jpg",
+		"application/pdf":          "pdf",
+		"":                         "bin",
+		"not a content type":       "bin",
+		"application/x-unknown":    "bin",
+	}
+	for contentType, expected := range cases {
+		assert.Equal(t, expected, getExtension(contentType), "extension for %q", contentType)
+	}
+}